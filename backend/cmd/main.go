@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	_ "time/tzdata" // Embedded IANA database for company timezones
 
 	firebase "firebase.google.com/go/v4"
 	"firebase.google.com/go/v4/auth"
@@ -92,6 +93,11 @@ func main() {
 	aiHandler := handlers.NewAIHandler(serviceContainer.AIService())
 	reviewHandler := handlers.NewReviewHandler(serviceContainer.ReviewService())
 	employeeHandler := handlers.NewEmployeeHandler(serviceContainer.EmployeeService())
+	scheduleHandler := handlers.NewScheduleHandler(serviceContainer.ScheduleService())
+	boardingHandler := handlers.NewBoardingHandler(serviceContainer.BoardingService(), serviceContainer.BookingService())
+	courseHandler := handlers.NewCourseHandler(serviceContainer.CourseService())
+	packageHandler := handlers.NewPackageHandler(serviceContainer.PackageService())
+	waiverHandler := handlers.NewWaiverHandler(serviceContainer.WaiverService())
 	promptHandler := handlers.NewPromptHandler(serviceContainer.PromptService())
 	inventoryHandler := handlers.NewInventoryHandler(serviceContainer.InventoryService())
 	currencyHandler := handlers.NewCurrencyHandler(serviceContainer.CurrencyService())
@@ -198,6 +204,12 @@ func main() {
 			public.GET("/blog", contentHandler.GetPublicBlogPosts)
 			public.GET("/blog/:slug", contentHandler.GetBlogPostBySlug)
 
+			// iCalendar feeds, authenticated by the secret token in the URL
+			public.GET("/calendar/:token", bookingHandler.GetCalendarFeed)
+
+			// Course details for customers browsing classes
+			public.GET("/courses/:courseId", courseHandler.GetPublicCourse)
+
 			// Company endpoints
 			publicCompanies := public.Group("/companies")
 			{
@@ -205,7 +217,12 @@ func main() {
 				publicCompanies.GET("/cities", companyHandler.GetCompanyCities)
 				publicCompanies.GET("/:companyId", companyHandler.GetPublicCompany)
 				publicCompanies.GET("/:companyId/services", companyHandler.GetPublicServices)
+				publicCompanies.GET("/:companyId/services/:serviceId/options", serviceHandler.GetPublicServiceOptions)
+				publicCompanies.GET("/:companyId/services/:serviceId/intake-forms", serviceHandler.GetPublicServiceIntakeForms)
 				publicCompanies.GET("/:companyId/products", companyHandler.GetPublicProducts)
+				publicCompanies.GET("/:companyId/courses", courseHandler.GetPublicCourses)
+				publicCompanies.GET("/:companyId/packages", packageHandler.GetPublicPackages)
+				publicCompanies.GET("/:companyId/services/:serviceId/waiver", waiverHandler.GetServiceWaiver)
 			}
 		}
 
@@ -250,6 +267,8 @@ func main() {
 				users.PUT("/profile", userHandler.UpdateProfile)
 				users.DELETE("/profile", userHandler.DeleteProfile)
 				users.POST("/upload-avatar", uploadHandler.UploadAvatar)
+				users.GET("/calendar-feed", bookingHandler.GetUserCalendarFeed)
+				users.POST("/calendar-feed/regenerate", bookingHandler.RegenerateUserCalendarFeed)
 			}
 
 			// Pet management endpoints
@@ -291,6 +310,14 @@ func main() {
 				bookings.GET("/:id", bookingHandler.GetBooking)
 				bookings.PUT("/:id", bookingHandler.UpdateBooking)
 				bookings.DELETE("/:id", bookingHandler.CancelBooking)
+				bookings.GET("/:id/cancellation-preview", bookingHandler.PreviewCancellation)
+				bookings.PUT("/:id/reschedule", bookingHandler.RescheduleBooking)
+				bookings.POST("/:id/deposit/confirm", bookingHandler.ConfirmBookingDeposit)
+				bookings.GET("/:id/ics", bookingHandler.GetBookingICS)
+				bookings.GET("/:id/qr-code", bookingHandler.GetBookingQRCode)
+				bookings.GET("/:id/history", bookingHandler.GetBookingHistory)
+				bookings.GET("/:id/intake", bookingHandler.GetBookingIntake)
+				bookings.POST("/:id/intake", bookingHandler.SubmitBookingIntake)
 				bookings.GET("/availability", bookingHandler.CheckAvailability)
 
 				// AI-powered booking endpoints
@@ -300,12 +327,57 @@ func main() {
 				bookings.GET("/alternatives", bookingHandler.GetAlternativeSlots)
 				bookings.POST("/confirm-alternative", bookingHandler.ConfirmAlternativeBooking)
 
+				// Recurring booking series endpoints
+				bookings.POST("/series", bookingHandler.CreateBookingSeries)
+				bookings.GET("/series/:seriesId", bookingHandler.GetBookingSeries)
+				bookings.POST("/series/:seriesId/skip", bookingHandler.SkipSeriesOccurrence)
+				bookings.POST("/series/:seriesId/move", bookingHandler.MoveSeriesOccurrence)
+				bookings.POST("/series/:seriesId/cancel", bookingHandler.CancelBookingSeries)
+				bookings.PUT("/series/:seriesId/reschedule", bookingHandler.RescheduleBookingSeries)
+
+				// Waitlist endpoints
+				bookings.GET("/waitlist", bookingHandler.GetUserWaitlist)
+				bookings.POST("/waitlist", bookingHandler.JoinWaitlist)
+				bookings.DELETE("/waitlist/:entryId", bookingHandler.LeaveWaitlist)
+				bookings.POST("/waitlist/:entryId/accept", bookingHandler.AcceptWaitlistOffer)
+				bookings.POST("/waitlist/:entryId/decline", bookingHandler.DeclineWaitlistOffer)
+
+				// Boarding stay endpoints
+				bookings.GET("/stays/quote", boardingHandler.QuoteStay)
+				bookings.POST("/stays", boardingHandler.CreateStay)
+
 				// Customer pet medical data endpoints (for companies)
 				bookings.GET("/customer-pets/:petId/medical-data", bookingHandler.GetCustomerPetMedicalData)
 				bookings.GET("/customer-pets/:petId/vaccinations", bookingHandler.GetCustomerPetVaccinations)
 				bookings.GET("/customer-pets/:petId/medications", bookingHandler.GetCustomerPetMedications)
 			}
 
+			// Group classes and training courses
+			courses := protected.Group("/courses")
+			{
+				courses.POST("/:courseId/enroll", courseHandler.EnrollInCourse)
+				courses.GET("/enrollments", courseHandler.GetMyEnrollments)
+				courses.POST("/enrollments/:enrollmentId/confirm-payment", courseHandler.ConfirmEnrollmentPayment)
+				courses.GET("/enrollments/:enrollmentId/cancellation-preview", courseHandler.PreviewEnrollmentCancellation)
+				courses.POST("/enrollments/:enrollmentId/cancel", courseHandler.CancelEnrollment)
+			}
+
+			// Prepaid session packages
+			packages := protected.Group("/packages")
+			{
+				packages.POST("/:packageId/purchase", packageHandler.PurchasePackage)
+				packages.GET("/my", packageHandler.GetMyPackages)
+				packages.POST("/purchases/:purchaseId/confirm-payment", packageHandler.ConfirmPackagePayment)
+				packages.GET("/purchases/:purchaseId/ledger", packageHandler.GetMyPackageLedger)
+			}
+
+			// Liability waivers
+			waivers := protected.Group("/waivers")
+			{
+				waivers.POST("/:waiverId/sign", waiverHandler.SignWaiver)
+				waivers.GET("/my", waiverHandler.GetMySignatures)
+			}
+
 			// Review endpoints
 			reviews := protected.Group("/reviews")
 			{
@@ -339,6 +411,17 @@ func main() {
 					profileGroup.GET("/profile", employeeHandler.GetEmployeeProfile)
 					profileGroup.GET("/dashboard", employeeHandler.GetEmployeeDashboard)
 					profileGroup.GET("/check-permission/:permission", employeeHandler.CheckPermission)
+					profileGroup.GET("/schedule", scheduleHandler.GetMySchedule)
+					profileGroup.GET("/route", bookingHandler.GetEmployeeRoute)
+					profileGroup.GET("/calendar-feed", bookingHandler.GetEmployeeCalendarFeed)
+					profileGroup.POST("/calendar-feed/regenerate", bookingHandler.RegenerateEmployeeCalendarFeed)
+					profileGroup.GET("/external-calendars", scheduleHandler.GetExternalCalendars)
+					profileGroup.POST("/external-calendars", scheduleHandler.CreateExternalCalendar)
+					profileGroup.POST("/external-calendars/:calendarId/sync", scheduleHandler.SyncExternalCalendar)
+					profileGroup.DELETE("/external-calendars/:calendarId", scheduleHandler.DeleteExternalCalendar)
+					profileGroup.GET("/course-sessions", courseHandler.GetMyCourseSessions)
+					profileGroup.GET("/course-sessions/:sessionId/roster", courseHandler.GetSessionRoster)
+					profileGroup.PUT("/course-sessions/:sessionId/attendance", courseHandler.RecordAttendance)
 				}
 
 				// Employee management (requires manage_employees permission)
@@ -354,6 +437,35 @@ func main() {
 					management.DELETE("/:employeeId", employeeHandler.DeactivateEmployee)
 				}
 
+				// Front desk check-in and check-out (requires edit_bookings permission)
+				frontDesk := employees.Group("/bookings")
+				frontDesk.Use(middleware.EmployeeAuthMiddleware(serviceContainer.EmployeeService()))
+				frontDesk.Use(middleware.RequirePermission("edit_bookings"))
+				{
+					frontDesk.POST("/check-in", bookingHandler.CheckInBooking)
+					frontDesk.POST("/check-out", bookingHandler.CheckOutBooking)
+				}
+
+				// Employee schedules (requires manage_employees or manage_schedules permission)
+				scheduleManagement := employees.Group("/manage/:employeeId/schedule")
+				scheduleManagement.Use(middleware.EmployeeAuthMiddleware(serviceContainer.EmployeeService()))
+				scheduleManagement.Use(middleware.RequireAnyPermission("manage_employees", "manage_schedules"))
+				{
+					scheduleManagement.GET("", scheduleHandler.GetEmployeeSchedule)
+					scheduleManagement.GET("/working-hours", scheduleHandler.GetWorkingHours)
+					scheduleManagement.PUT("/shifts", scheduleHandler.SetWeeklyShifts)
+					scheduleManagement.POST("/overrides", scheduleHandler.CreateOverride)
+					scheduleManagement.DELETE("/overrides/:overrideId", scheduleHandler.DeleteOverride)
+					scheduleManagement.POST("/time-off", scheduleHandler.CreateTimeOff)
+					scheduleManagement.DELETE("/time-off/:timeOffId", scheduleHandler.DeleteTimeOff)
+					scheduleManagement.POST("/breaks", scheduleHandler.CreateBreak)
+					scheduleManagement.DELETE("/breaks/:breakId", scheduleHandler.DeleteBreak)
+					scheduleManagement.GET("/external-calendars", scheduleHandler.GetExternalCalendars)
+					scheduleManagement.POST("/external-calendars", scheduleHandler.CreateExternalCalendar)
+					scheduleManagement.POST("/external-calendars/:calendarId/sync", scheduleHandler.SyncExternalCalendar)
+					scheduleManagement.DELETE("/external-calendars/:calendarId", scheduleHandler.DeleteExternalCalendar)
+				}
+
 				// Reference data (employee auth required)
 				reference := employees.Group("/reference")
 				reference.Use(middleware.EmployeeAuthMiddleware(serviceContainer.EmployeeService()))
@@ -398,6 +510,9 @@ func main() {
 				payments.POST("/create-intent", paymentHandler.CreatePaymentIntent)
 				payments.POST("/confirm", paymentHandler.ConfirmPayment)
 				payments.GET("/history", paymentHandler.GetPaymentHistory)
+				payments.GET("/methods", paymentHandler.GetPaymentMethods)
+				payments.POST("/setup-intent", paymentHandler.CreateSetupIntent)
+				payments.POST("/subscriptions", paymentHandler.ProcessSubscriptionPayment)
 			}
 
 			// Upload endpoints
@@ -458,6 +573,107 @@ func main() {
 				companies.GET("/bookings", bookingHandler.GetCompanyBookings)
 				companies.POST("/bookings", bookingHandler.CreateCompanyBooking)
 				companies.PUT("/bookings/:id/status", bookingHandler.UpdateBookingStatus)
+				companies.POST("/bookings/:id/deposit-received", bookingHandler.MarkDepositReceived)
+				companies.POST("/payments/:paymentId/capture", paymentHandler.CaptureCompanyPayment)
+				companies.GET("/ledger", paymentHandler.GetCompanyLedger)
+				companies.GET("/payouts", paymentHandler.GetCompanyPayouts)
+				companies.GET("/payouts/settings", paymentHandler.GetPayoutSettings)
+				companies.PUT("/payouts/settings", paymentHandler.UpdatePayoutSettings)
+				companies.GET("/payouts/:payoutId", paymentHandler.GetCompanyPayout)
+				companies.GET("/payouts/:payoutId/statement", paymentHandler.DownloadPayoutStatement)
+				companies.POST("/bookings/:id/no-show", bookingHandler.MarkNoShow)
+				companies.GET("/bookings/:id/qr-code", bookingHandler.GetBookingQRCode)
+				companies.GET("/bookings/:id/history", bookingHandler.GetBookingHistory)
+				companies.GET("/bookings/:id/intake", bookingHandler.GetBookingIntake)
+				companies.POST("/bookings/:id/intake", bookingHandler.SubmitBookingIntake)
+				companies.POST("/bookings/check-in", bookingHandler.CheckInBooking)
+				companies.POST("/bookings/check-out", bookingHandler.CheckOutBooking)
+				companies.GET("/no-show-rules", bookingHandler.GetNoShowRules)
+				companies.PUT("/no-show-rules", bookingHandler.SetNoShowRules)
+				companies.GET("/booking-series/:seriesId", bookingHandler.GetBookingSeries)
+				companies.POST("/booking-series/:seriesId/cancel", bookingHandler.CancelBookingSeries)
+				companies.GET("/waitlist", bookingHandler.GetCompanyWaitlist)
+
+				// Travel between mobile appointments of walkers, sitters and pet taxis
+				companies.GET("/travel-settings", bookingHandler.GetTravelSettings)
+				companies.PUT("/travel-settings", bookingHandler.SetTravelSettings)
+				companies.GET("/employees/:employeeId/route", bookingHandler.GetEmployeeRoute)
+
+				// Cancellation policies
+				companies.GET("/cancellation-policies", bookingHandler.GetCancellationPolicies)
+				companies.POST("/cancellation-policies", bookingHandler.CreateCancellationPolicy)
+				companies.PUT("/cancellation-policies/:policyId", bookingHandler.UpdateCancellationPolicy)
+				companies.DELETE("/cancellation-policies/:policyId", bookingHandler.DeleteCancellationPolicy)
+				companies.PUT("/services/:serviceId/cancellation-policy", bookingHandler.SetServiceCancellationPolicy)
+
+				// Calendar feeds
+				companies.GET("/calendar-feed", bookingHandler.GetCompanyCalendarFeed)
+				companies.POST("/calendar-feed/regenerate", bookingHandler.RegenerateCompanyCalendarFeed)
+				companies.GET("/employees/:employeeId/calendar-feed", bookingHandler.GetEmployeeCalendarFeed)
+				companies.POST("/employees/:employeeId/calendar-feed/regenerate", bookingHandler.RegenerateEmployeeCalendarFeed)
+
+				// Booking deposits
+				companies.GET("/services/:serviceId/deposit", bookingHandler.GetServiceDeposit)
+				companies.PUT("/services/:serviceId/deposit", bookingHandler.SetServiceDeposit)
+
+				// Price and duration by pet type, breed size and weight
+				companies.GET("/services/:serviceId/price-rules", serviceHandler.GetServicePriceRules)
+				companies.PUT("/services/:serviceId/price-rules", serviceHandler.SetServicePriceRules)
+
+				// Service add-ons
+				companies.GET("/services/:serviceId/options", serviceHandler.GetServiceOptions)
+				companies.POST("/services/:serviceId/options", serviceHandler.CreateServiceOption)
+				companies.PUT("/service-options/:optionId", serviceHandler.UpdateServiceOption)
+				companies.DELETE("/service-options/:optionId", serviceHandler.DeleteServiceOption)
+
+				// Intake forms: questionnaires customers answer for a service
+				companies.GET("/intake-forms", serviceHandler.GetIntakeForms)
+				companies.POST("/intake-forms", serviceHandler.CreateIntakeForm)
+				companies.PUT("/intake-forms/:formId", serviceHandler.UpdateIntakeForm)
+				companies.DELETE("/intake-forms/:formId", serviceHandler.DeleteIntakeForm)
+
+				// Boarding: kennels, nightly rates, holidays and occupancy
+				companies.GET("/kennels", boardingHandler.GetKennels)
+				companies.POST("/kennels", boardingHandler.CreateKennel)
+				companies.PUT("/kennels/:kennelId", boardingHandler.UpdateKennel)
+				companies.DELETE("/kennels/:kennelId", boardingHandler.DeleteKennel)
+				companies.GET("/services/:serviceId/boarding-rates", boardingHandler.GetBoardingRate)
+				companies.PUT("/services/:serviceId/boarding-rates", boardingHandler.SetBoardingRate)
+				companies.GET("/holidays", boardingHandler.GetHolidays)
+				companies.POST("/holidays", boardingHandler.CreateHoliday)
+				companies.DELETE("/holidays/:holidayId", boardingHandler.DeleteHoliday)
+				companies.GET("/boarding/occupancy", boardingHandler.GetOccupancy)
+
+				// Group classes and training courses
+				companies.GET("/courses", courseHandler.GetCompanyCourses)
+				companies.POST("/courses", courseHandler.CreateCourse)
+				companies.GET("/courses/:courseId", courseHandler.GetCompanyCourse)
+				companies.PUT("/courses/:courseId", courseHandler.UpdateCourse)
+				companies.POST("/courses/:courseId/cancel", courseHandler.CancelCourse)
+				companies.POST("/courses/:courseId/sessions", courseHandler.AddCourseSession)
+				companies.DELETE("/courses/:courseId/sessions/:sessionId", courseHandler.CancelCourseSession)
+				companies.GET("/courses/:courseId/enrollments", courseHandler.GetCourseEnrollments)
+				companies.DELETE("/courses/:courseId/enrollments/:enrollmentId", courseHandler.CancelCourseEnrollment)
+				companies.GET("/course-sessions/:sessionId/roster", courseHandler.GetSessionRoster)
+				companies.PUT("/course-sessions/:sessionId/attendance", courseHandler.RecordAttendance)
+
+				// Prepaid session packages and customer balances
+				companies.GET("/packages", packageHandler.GetCompanyPackages)
+				companies.POST("/packages", packageHandler.CreatePackage)
+				companies.PUT("/packages/:packageId", packageHandler.UpdatePackage)
+				companies.GET("/package-purchases", packageHandler.GetCompanyPurchases)
+				companies.GET("/package-purchases/:purchaseId/ledger", packageHandler.GetCompanyPackageLedger)
+				companies.POST("/package-purchases/:purchaseId/adjustments", packageHandler.AdjustPackageBalance)
+
+				// Liability waivers and signatures
+				companies.GET("/waivers", waiverHandler.GetCompanyWaivers)
+				companies.POST("/waivers", waiverHandler.CreateWaiver)
+				companies.PUT("/waivers/:waiverId", waiverHandler.UpdateWaiver)
+				companies.PUT("/services/:serviceId/waiver", waiverHandler.SetServiceWaiver)
+				companies.GET("/waiver-signatures", waiverHandler.GetCompanySignatures)
+				companies.GET("/waiver-signatures/:signatureId", waiverHandler.GetSignedWaiver)
+				companies.GET("/waiver-signatures/:signatureId/document", waiverHandler.DownloadSignedWaiver)
+
 				companies.GET("/orders", orderHandler.GetCompanyOrders)
 				companies.PUT("/orders/:id/status", orderHandler.UpdateOrderStatus)
 
@@ -520,6 +736,27 @@ func main() {
 				companies.DELETE("/employees/:employeeId", employeeHandler.DeactivateEmployee)
 				companies.GET("/employees/reference/permissions", employeeHandler.GetAvailablePermissions)
 				companies.GET("/employees/reference/roles", employeeHandler.GetAvailableRoles)
+
+				// Employee schedules
+				companies.GET("/employees/:employeeId/schedule", scheduleHandler.GetEmployeeSchedule)
+				companies.GET("/employees/:employeeId/schedule/working-hours", scheduleHandler.GetWorkingHours)
+				companies.PUT("/employees/:employeeId/schedule/shifts", scheduleHandler.SetWeeklyShifts)
+				companies.POST("/employees/:employeeId/schedule/overrides", scheduleHandler.CreateOverride)
+				companies.DELETE("/employees/:employeeId/schedule/overrides/:overrideId", scheduleHandler.DeleteOverride)
+				companies.POST("/employees/:employeeId/schedule/time-off", scheduleHandler.CreateTimeOff)
+				companies.DELETE("/employees/:employeeId/schedule/time-off/:timeOffId", scheduleHandler.DeleteTimeOff)
+				companies.POST("/employees/:employeeId/schedule/breaks", scheduleHandler.CreateBreak)
+				companies.DELETE("/employees/:employeeId/schedule/breaks/:breakId", scheduleHandler.DeleteBreak)
+				companies.GET("/employees/:employeeId/schedule/external-calendars", scheduleHandler.GetExternalCalendars)
+				companies.POST("/employees/:employeeId/schedule/external-calendars", scheduleHandler.CreateExternalCalendar)
+				companies.POST("/employees/:employeeId/schedule/external-calendars/:calendarId/sync", scheduleHandler.SyncExternalCalendar)
+				companies.DELETE("/employees/:employeeId/schedule/external-calendars/:calendarId", scheduleHandler.DeleteExternalCalendar)
+
+				// Company-wide external calendars (block availability of the whole company)
+				companies.GET("/external-calendars", scheduleHandler.GetExternalCalendars)
+				companies.POST("/external-calendars", scheduleHandler.CreateExternalCalendar)
+				companies.POST("/external-calendars/:calendarId/sync", scheduleHandler.SyncExternalCalendar)
+				companies.DELETE("/external-calendars/:calendarId", scheduleHandler.DeleteExternalCalendar)
 			}
 
 			// AI endpoints
@@ -581,6 +818,10 @@ func main() {
 				admin.GET("/payment-settings", adminHandler.GetPaymentSettings)
 				admin.PUT("/payment-settings", adminHandler.UpdatePaymentSettings)
 
+				// Payment ledger
+				admin.GET("/ledger/reconciliation", paymentHandler.GetLedgerReconciliation)
+				admin.POST("/ledger/payments/:paymentId/processor-fee", paymentHandler.RecordProcessorFee)
+
 				// Service categories
 				admin.GET("/service-categories", adminHandler.GetServiceCategories)
 				admin.POST("/service-categories", adminHandler.CreateServiceCategory)
@@ -719,6 +960,18 @@ func main() {
 	// Start notification cron job
 	go serviceContainer.NotificationService().StartNotificationCron()
 
+	// Start booking cron jobs (recurring series, waitlist holds, unpaid deposits, external calendars)
+	go serviceContainer.BookingService().StartBookingCron()
+
+	// Start course cron jobs (unpaid enrollments)
+	go serviceContainer.CourseService().StartCourseCron()
+
+	// Start package cron jobs (unpaid purchases, expiring balances)
+	go serviceContainer.PackageService().StartPackageCron()
+
+	// Start payout cron job (scheduled company payouts)
+	go serviceContainer.PaymentService().StartPayoutCron()
+
 	// Get port from environment or default to 4000
 	port := os.Getenv("API_PORT")
 	if port == "" {
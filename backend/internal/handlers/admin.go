@@ -212,6 +212,7 @@ func (h *AdminHandler) GetPaymentSettings(c *gin.Context) {
 		"commission_enabled":    settings.CommissionEnabled,
 		"commission_percentage": settings.CommissionPercentage,
 		"has_stripe_keys":       settings.StripePublishableKey != "" && settings.StripeSecretKey != "",
+		"payment_provider":      settings.PaymentProvider,
 		"created_at":            settings.CreatedAt,
 		"updated_at":            settings.UpdatedAt,
 	}
@@ -242,6 +243,7 @@ func (h *AdminHandler) UpdatePaymentSettings(c *gin.Context) {
 		"commission_enabled":    settings.CommissionEnabled,
 		"commission_percentage": settings.CommissionPercentage,
 		"has_stripe_keys":       settings.StripePublishableKey != "" && settings.StripeSecretKey != "",
+		"payment_provider":      settings.PaymentProvider,
 		"updated_at":            settings.UpdatedAt,
 	}
 
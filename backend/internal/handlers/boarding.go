@@ -0,0 +1,252 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type BoardingHandler struct {
+	boardingService *services.BoardingService
+	bookingService  *services.BookingService
+}
+
+func NewBoardingHandler(boardingService *services.BoardingService, bookingService *services.BookingService) *BoardingHandler {
+	return &BoardingHandler{
+		boardingService: boardingService,
+		bookingService:  bookingService,
+	}
+}
+
+// GetKennels returns the kennel inventory of the company
+func (h *BoardingHandler) GetKennels(c *gin.Context) {
+	kennels, err := h.boardingService.GetKennels(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"kennels": kennels,
+	})
+}
+
+// CreateKennel adds a kennel to the company inventory
+func (h *BoardingHandler) CreateKennel(c *gin.Context) {
+	var req models.KennelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kennel, err := h.boardingService.CreateKennel(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"kennel":  kennel,
+	})
+}
+
+// UpdateKennel updates a kennel of the company
+func (h *BoardingHandler) UpdateKennel(c *gin.Context) {
+	var req models.KennelRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	kennel, err := h.boardingService.UpdateKennel(c.GetString("company_id"), c.Param("kennelId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"kennel":  kennel,
+	})
+}
+
+// DeleteKennel removes a kennel without upcoming stays
+func (h *BoardingHandler) DeleteKennel(c *gin.Context) {
+	if err := h.boardingService.DeleteKennel(c.GetString("company_id"), c.Param("kennelId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Kennel deleted successfully",
+	})
+}
+
+// GetBoardingRate returns the nightly rates of a boarding service
+func (h *BoardingHandler) GetBoardingRate(c *gin.Context) {
+	rate, err := h.boardingService.GetBoardingRate(c.Param("serviceId"))
+	if err != nil || rate.CompanyID != c.GetString("company_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Service not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"rates":   rate,
+	})
+}
+
+// SetBoardingRate sets the nightly, weekend and holiday rates of a boarding service
+func (h *BoardingHandler) SetBoardingRate(c *gin.Context) {
+	var req models.BoardingRateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rate, err := h.boardingService.SetBoardingRate(c.GetString("company_id"), c.Param("serviceId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"rates":   rate,
+	})
+}
+
+// GetHolidays returns the upcoming holidays of the company
+func (h *BoardingHandler) GetHolidays(c *gin.Context) {
+	holidays, err := h.boardingService.GetHolidays(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"holidays": holidays,
+	})
+}
+
+// CreateHoliday adds a date on which holiday rates apply
+func (h *BoardingHandler) CreateHoliday(c *gin.Context) {
+	var req models.CompanyHolidayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	holiday, err := h.boardingService.CreateHoliday(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"holiday": holiday,
+	})
+}
+
+// DeleteHoliday removes a company holiday
+func (h *BoardingHandler) DeleteHoliday(c *gin.Context) {
+	if err := h.boardingService.DeleteHoliday(c.GetString("company_id"), c.Param("holidayId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Holiday deleted successfully",
+	})
+}
+
+// GetOccupancy returns the per-night kennel occupancy calendar of the company
+func (h *BoardingHandler) GetOccupancy(c *gin.Context) {
+	from := c.DefaultQuery("from", time.Now().Format("2006-01-02"))
+	to := c.DefaultQuery("to", time.Now().AddDate(0, 0, 30).Format("2006-01-02"))
+
+	days, err := h.boardingService.GetOccupancy(c.GetString("company_id"), from, to)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"from":      from,
+		"to":        to,
+		"occupancy": days,
+	})
+}
+
+// QuoteStay prices a stay night by night and lists kennels available for the pet
+func (h *BoardingHandler) QuoteStay(c *gin.Context) {
+	serviceID := c.Query("service_id")
+	if serviceID == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "service_id is required"})
+		return
+	}
+
+	quote, err := h.boardingService.QuoteStay(serviceID, c.Query("pet_id"), c.Query("check_in_date"), c.Query("check_out_date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    quote,
+	})
+}
+
+// CreateStay books a multi-night boarding stay
+func (h *BoardingHandler) CreateStay(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.BoardingStayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, quote, err := h.bookingService.CreateBoardingStay(userID, &req)
+	if err != nil {
+		if errors.Is(err, services.ErrNoKennelAvailable) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"code":  "NO_KENNEL_AVAILABLE",
+			})
+			return
+		}
+		if errors.Is(err, services.ErrWaiverNotSigned) {
+			c.JSON(http.StatusPreconditionRequired, gin.H{
+				"error": err.Error(),
+				"code":  "WAIVER_REQUIRED",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Stay booked successfully",
+		"data":    booking,
+		"quote":   quote,
+	})
+}
@@ -1,9 +1,11 @@
 package handlers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
@@ -37,13 +39,18 @@ func (h *BookingHandler) CreateBooking(c *gin.Context) {
 
 	booking, err := h.bookingService.CreateBooking(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.respondBookingError(c, err)
 		return
 	}
 
+	message := "Booking created successfully"
+	if booking.Status == "pending_payment" {
+		message = "Booking held until the deposit is paid"
+	}
+
 	c.JSON(http.StatusCreated, gin.H{
 		"success": true,
-		"message": "Booking created successfully",
+		"message": message,
 		"data":    booking,
 	})
 }
@@ -59,6 +66,9 @@ func (h *BookingHandler) CreateCompanyBooking(c *gin.Context) {
 		Notes       string    `json:"notes"`
 		BookingDate time.Time `json:"booking_date" binding:"required"`
 		Duration    int       `json:"duration"` // in minutes
+		OptionIDs   []string  `json:"option_ids"`
+
+		Location *models.BookingLocation `json:"location"` // Client address for mobile services
 	}
 
 	var req CompanyBookingRequest
@@ -107,7 +117,14 @@ func (h *BookingHandler) CreateCompanyBooking(c *gin.Context) {
 		PetID:     "", // Will be set below if petID exists
 		DateTime:  req.BookingDate,
 		Notes:     req.Notes,
+		OptionIDs: req.OptionIDs,
+		Location:  req.Location,
+
+		// The company takes the booking for the client, any deposit is collected in person
+		SkipDeposit: true,
 	}
+	actor := bookingActor(c)
+	bookingReq.Actor = &actor
 
 	if petID != nil {
 		bookingReq.PetID = *petID
@@ -118,7 +135,7 @@ func (h *BookingHandler) CreateCompanyBooking(c *gin.Context) {
 	booking, err := h.bookingService.CreateBooking(&bookingReq)
 	if err != nil {
 		fmt.Printf("❌ CreateCompanyBooking: Failed to create booking: %v\n", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.respondBookingError(c, err)
 		return
 	}
 
@@ -281,7 +298,31 @@ func (h *BookingHandler) CheckAvailability(c *gin.Context) {
 		employeePtr = &employeeID
 	}
 
-	availability, err := h.bookingService.CheckAvailability(serviceID, date, employeePtr)
+	// Optional pet, for slots sized and priced for it
+	var petPtr *string
+	if petID := c.Query("pet_id"); petID != "" {
+		petPtr = &petID
+	}
+
+	// Optional comma-separated add-ons, lengthening the slots
+	var optionIDs []string
+	if options := c.Query("option_ids"); options != "" {
+		optionIDs = strings.Split(options, ",")
+	}
+
+	// Optional customer address, for slots a mobile employee can travel to
+	var location *models.BookingLocation
+	if lat, lng := c.Query("latitude"), c.Query("longitude"); lat != "" && lng != "" {
+		latitude, latErr := strconv.ParseFloat(lat, 64)
+		longitude, lngErr := strconv.ParseFloat(lng, 64)
+		if latErr != nil || lngErr != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid latitude or longitude"})
+			return
+		}
+		location = &models.BookingLocation{Address: c.Query("address"), Latitude: latitude, Longitude: longitude}
+	}
+
+	availability, err := h.bookingService.CheckAvailability(serviceID, date, employeePtr, petPtr, optionIDs, location)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -344,8 +385,14 @@ func (h *BookingHandler) UpdateBooking(c *gin.Context) {
 		return
 	}
 
+	// Only the company can change what the booking costs
+	if _, ok := updates["price"]; ok && booking.UserID == userID.(string) {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Only the company can change the booking price"})
+		return
+	}
+
 	// Update booking using the service
-	updatedBooking, err := h.bookingService.UpdateBooking(bookingID, updates)
+	updatedBooking, err := h.bookingService.UpdateBooking(bookingID, updates, bookingActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -377,7 +424,7 @@ func (h *BookingHandler) UpdateBookingStatus(c *gin.Context) {
 	}
 
 	// Validate status
-	validStatuses := []string{"pending", "confirmed", "in_progress", "completed", "cancelled", "rejected", "rescheduled"}
+	validStatuses := []string{"pending", "confirmed", "in_progress", "completed", "cancelled", "rejected", "rescheduled", "no_show"}
 	isValidStatus := false
 	for _, status := range validStatuses {
 		if req.Status == status {
@@ -391,7 +438,39 @@ func (h *BookingHandler) UpdateBookingStatus(c *gin.Context) {
 		return
 	}
 
-	err := h.bookingService.UpdateBookingStatus(bookingID, req.Status, req.Notes)
+	// Cancellations by the company refund the customer in full under the cancellation policy
+	if req.Status == "cancelled" {
+		outcome, err := h.bookingService.CancelBookingWithPolicy(bookingID, req.Notes, "company", nil, bookingActor(c))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"message":      "Booking status updated successfully",
+			"cancellation": outcome,
+		})
+		return
+	}
+
+	// No-shows are settled under the no-show fee of the cancellation policy
+	if req.Status == "no_show" {
+		outcome, err := h.bookingService.MarkNoShow(c.GetString("company_id"), bookingID, &models.MarkNoShowRequest{Notes: req.Notes}, bookingActor(c))
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+
+		c.JSON(http.StatusOK, gin.H{
+			"success":      true,
+			"message":      "Booking status updated successfully",
+			"cancellation": outcome,
+		})
+		return
+	}
+
+	err := h.bookingService.UpdateBookingStatus(bookingID, req.Status, req.Notes, bookingActor(c))
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -403,43 +482,45 @@ func (h *BookingHandler) UpdateBookingStatus(c *gin.Context) {
 	})
 }
 
-// CancelBooking cancels a booking
+// CancelBooking cancels a booking under its cancellation policy.
+// accepted_fee is the fee shown in the cancellation preview; a higher fee is not charged without a new confirmation.
 func (h *BookingHandler) CancelBooking(c *gin.Context) {
-	bookingID := c.Param("id")
-	if bookingID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking ID is required"})
+	booking, initiatedBy, ok := h.loadCancellableBooking(c)
+	if !ok {
 		return
 	}
 
 	var req struct {
-		Reason string `json:"reason"`
+		Reason      string   `json:"reason"`
+		AcceptedFee *float64 `json:"accepted_fee"`
 	}
 
 	c.ShouldBindJSON(&req) // Optional body
 
-	err := h.bookingService.UpdateBookingStatus(bookingID, "cancelled", req.Reason)
+	outcome, err := h.bookingService.CancelBookingWithPolicy(booking.ID, req.Reason, initiatedBy, req.AcceptedFee, bookingActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.respondCancellationError(c, outcome, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Booking cancelled successfully",
+		"data":    outcome,
 	})
 }
 
-// RescheduleBooking reschedules a booking to a new time
+// RescheduleBooking reschedules a booking to a new time, charging the reschedule fee of its policy
 func (h *BookingHandler) RescheduleBooking(c *gin.Context) {
-	bookingID := c.Param("id")
-	if bookingID == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Booking ID is required"})
+	booking, initiatedBy, ok := h.loadCancellableBooking(c)
+	if !ok {
 		return
 	}
 
 	var req struct {
 		NewDateTime time.Time `json:"new_date_time" binding:"required"`
 		Reason      string    `json:"reason"`
+		AcceptedFee *float64  `json:"accepted_fee"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -447,15 +528,16 @@ func (h *BookingHandler) RescheduleBooking(c *gin.Context) {
 		return
 	}
 
-	err := h.bookingService.RescheduleBooking(bookingID, req.NewDateTime, req.Reason)
+	outcome, err := h.bookingService.RescheduleBookingWithPolicy(booking.ID, req.NewDateTime, req.Reason, initiatedBy, req.AcceptedFee, bookingActor(c))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.respondCancellationError(c, outcome, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{
 		"success": true,
 		"message": "Booking rescheduled successfully",
+		"data":    outcome,
 	})
 }
 
@@ -786,7 +868,7 @@ func (h *BookingHandler) AutoAssignBooking(c *gin.Context) {
 	// Attempt auto-assignment
 	result, err := h.bookingService.AutoAssignBooking(&req)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.respondBookingError(c, err)
 		return
 	}
 
@@ -814,6 +896,8 @@ func (h *BookingHandler) ConfirmAlternativeBooking(c *gin.Context) {
 		DateTime   time.Time `json:"date_time" binding:"required"`
 		EmployeeID string    `json:"employee_id" binding:"required"`
 		Notes      string    `json:"notes"`
+
+		Location *models.BookingLocation `json:"location"`
 	}
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -843,12 +927,13 @@ func (h *BookingHandler) ConfirmAlternativeBooking(c *gin.Context) {
 		EmployeeID: &req.EmployeeID,
 		DateTime:   req.DateTime,
 		Notes:      req.Notes,
+		Location:   req.Location,
 	}
 
 	// Create the booking
 	booking, err := h.bookingService.CreateBooking(bookingReq)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		h.respondBookingError(c, err)
 		return
 	}
 
@@ -861,6 +946,40 @@ func (h *BookingHandler) ConfirmAlternativeBooking(c *gin.Context) {
 
 // Helper methods
 
+// respondBookingError maps booking errors to responses; a taken slot gets a stable code apps can handle
+func (h *BookingHandler) respondBookingError(c *gin.Context, err error) {
+	if errors.Is(err, services.ErrSlotTaken) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"code":  "SLOT_TAKEN",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrTravelTimeConflict) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"code":  "TRAVEL_TIME_CONFLICT",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrWaiverNotSigned) {
+		c.JSON(http.StatusPreconditionRequired, gin.H{
+			"error": err.Error(),
+			"code":  "WAIVER_REQUIRED",
+		})
+		return
+	}
+	if errors.Is(err, services.ErrBookingBlocked) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"error": err.Error(),
+			"code":  "BOOKING_BLOCKED",
+		})
+		return
+	}
+
+	c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+}
+
 func (h *BookingHandler) countBookingsByStatus(bookings []models.Booking, status string) int {
 	count := 0
 	for _, booking := range bookings {
@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCalendarFeed serves the iCalendar document of a feed token to calendar apps
+func (h *BookingHandler) GetCalendarFeed(c *gin.Context) {
+	token := strings.TrimSuffix(c.Param("token"), ".ics")
+
+	ics, err := h.bookingService.RenderCalendarFeed(token)
+	if err != nil {
+		if errors.Is(err, services.ErrCalendarFeedNotFound) {
+			c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", `inline; filename="bookings.ics"`)
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", ics)
+}
+
+// GetBookingICS downloads a single booking as an .ics file
+func (h *BookingHandler) GetBookingICS(c *gin.Context) {
+	booking, _, ok := h.loadCancellableBooking(c)
+	if !ok {
+		return
+	}
+
+	ics, err := h.bookingService.BookingICS(booking.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="booking-%s.ics"`, booking.ID))
+	c.Data(http.StatusOK, "text/calendar; charset=utf-8", ics)
+}
+
+// GetUserCalendarFeed returns the calendar feed URL of the customer's bookings
+func (h *BookingHandler) GetUserCalendarFeed(c *gin.Context) {
+	h.respondCalendarFeed(c, "user", c.GetString("user_id"), false)
+}
+
+// RegenerateUserCalendarFeed replaces the customer's calendar feed URL
+func (h *BookingHandler) RegenerateUserCalendarFeed(c *gin.Context) {
+	h.respondCalendarFeed(c, "user", c.GetString("user_id"), true)
+}
+
+// GetCompanyCalendarFeed returns the calendar feed URL of all company bookings
+func (h *BookingHandler) GetCompanyCalendarFeed(c *gin.Context) {
+	h.respondCalendarFeed(c, "company", c.GetString("company_id"), false)
+}
+
+// RegenerateCompanyCalendarFeed replaces the company calendar feed URL
+func (h *BookingHandler) RegenerateCompanyCalendarFeed(c *gin.Context) {
+	h.respondCalendarFeed(c, "company", c.GetString("company_id"), true)
+}
+
+// GetEmployeeCalendarFeed returns the calendar feed URL of an employee's assignments
+func (h *BookingHandler) GetEmployeeCalendarFeed(c *gin.Context) {
+	h.respondCalendarFeed(c, "employee", h.calendarEmployeeID(c), false)
+}
+
+// RegenerateEmployeeCalendarFeed replaces the calendar feed URL of an employee
+func (h *BookingHandler) RegenerateEmployeeCalendarFeed(c *gin.Context) {
+	h.respondCalendarFeed(c, "employee", h.calendarEmployeeID(c), true)
+}
+
+// calendarEmployeeID is the employee in the path for company routes, else the signed-in employee
+func (h *BookingHandler) calendarEmployeeID(c *gin.Context) string {
+	if employeeID := c.Param("employeeId"); employeeID != "" {
+		return employeeID
+	}
+	return c.GetString("employee_id")
+}
+
+func (h *BookingHandler) respondCalendarFeed(c *gin.Context, ownerType, ownerID string, regenerate bool) {
+	if ownerID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var feed *models.CalendarFeed
+	var err error
+	if regenerate {
+		feed, err = h.bookingService.RegenerateCalendarFeed(ownerType, ownerID, c.GetString("company_id"))
+	} else {
+		feed, err = h.bookingService.GetCalendarFeed(ownerType, ownerID, c.GetString("company_id"))
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	path := fmt.Sprintf("%s/api/v1/public/calendar/%s.ics", c.Request.Host, feed.Token)
+	feed.URL = scheme + "://" + path
+	feed.WebcalURL = "webcal://" + path
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"feed":    feed,
+	})
+}
@@ -0,0 +1,151 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetCancellationPolicies returns the cancellation policies of the company
+func (h *BookingHandler) GetCancellationPolicies(c *gin.Context) {
+	policies, err := h.bookingService.GetCancellationPolicies(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"policies": policies,
+	})
+}
+
+// CreateCancellationPolicy adds a cancellation policy to the company
+func (h *BookingHandler) CreateCancellationPolicy(c *gin.Context) {
+	var req models.CancellationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.bookingService.CreateCancellationPolicy(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"policy":  policy,
+	})
+}
+
+// UpdateCancellationPolicy replaces the rules of a company cancellation policy
+func (h *BookingHandler) UpdateCancellationPolicy(c *gin.Context) {
+	var req models.CancellationPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	policy, err := h.bookingService.UpdateCancellationPolicy(c.GetString("company_id"), c.Param("policyId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"policy":  policy,
+	})
+}
+
+// DeleteCancellationPolicy removes a company cancellation policy
+func (h *BookingHandler) DeleteCancellationPolicy(c *gin.Context) {
+	if err := h.bookingService.DeleteCancellationPolicy(c.GetString("company_id"), c.Param("policyId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Cancellation policy deleted successfully",
+	})
+}
+
+// SetServiceCancellationPolicy assigns a cancellation policy to a service
+func (h *BookingHandler) SetServiceCancellationPolicy(c *gin.Context) {
+	var req struct {
+		PolicyID *string `json:"policy_id"` // null falls back to the company default
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := h.bookingService.SetServiceCancellationPolicy(c.GetString("company_id"), c.Param("serviceId"), req.PolicyID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Service cancellation policy updated successfully",
+	})
+}
+
+// PreviewCancellation returns the fee and refund for cancelling or rescheduling a booking now
+func (h *BookingHandler) PreviewCancellation(c *gin.Context) {
+	booking, initiatedBy, ok := h.loadCancellableBooking(c)
+	if !ok {
+		return
+	}
+
+	outcome, err := h.bookingService.PreviewCancellation(booking.ID, c.DefaultQuery("action", "cancel"), initiatedBy)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    outcome,
+	})
+}
+
+// loadCancellableBooking loads the booking of the request and tells whether the customer or the company acts on it
+func (h *BookingHandler) loadCancellableBooking(c *gin.Context) (*models.Booking, string, bool) {
+	booking, err := h.bookingService.GetBookingByID(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return nil, "", false
+	}
+
+	switch {
+	case booking.UserID == c.GetString("user_id"):
+		return booking, "customer", true
+	case c.GetString("user_role") == "super_admin" || booking.CompanyID == c.GetString("company_id"):
+		return booking, "company", true
+	}
+
+	c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+	return nil, "", false
+}
+
+// respondCancellationError reports a fee change with the new outcome so the customer can confirm again
+func (h *BookingHandler) respondCancellationError(c *gin.Context, outcome *models.CancellationOutcome, err error) {
+	if errors.Is(err, services.ErrCancellationFeeChanged) {
+		c.JSON(http.StatusConflict, gin.H{
+			"error": err.Error(),
+			"code":  "CANCELLATION_FEE_CHANGED",
+			"data":  outcome,
+		})
+		return
+	}
+
+	h.respondBookingError(c, err)
+}
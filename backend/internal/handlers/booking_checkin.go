@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBookingQRCode returns the signed QR code shown at check-in and check-out
+func (h *BookingHandler) GetBookingQRCode(c *gin.Context) {
+	booking, _, ok := h.loadCancellableBooking(c)
+	if !ok {
+		return
+	}
+
+	code, err := h.bookingService.GetBookingQRCode(booking)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"qr_code": code,
+	})
+}
+
+// CheckInBooking scans a booking's QR code when the pet is dropped off
+func (h *BookingHandler) CheckInBooking(c *gin.Context) {
+	h.scanBooking(c, h.bookingService.CheckInBooking, "Pet checked in")
+}
+
+// CheckOutBooking scans a booking's QR code when the pet is picked up
+func (h *BookingHandler) CheckOutBooking(c *gin.Context) {
+	h.scanBooking(c, h.bookingService.CheckOutBooking, "Pet checked out")
+}
+
+func (h *BookingHandler) scanBooking(c *gin.Context, scan func(string, models.BookingActor, *models.BookingScanRequest) (*models.Booking, error), message string) {
+	var req models.BookingScanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	booking, err := scan(c.GetString("company_id"), bookingActor(c), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidBookingQR) {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"error": err.Error(),
+				"code":  "INVALID_QR_CODE",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": message,
+		"data":    booking,
+	})
+}
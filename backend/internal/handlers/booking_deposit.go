@@ -0,0 +1,87 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetServiceDeposit returns the deposit a service requires at booking time
+func (h *BookingHandler) GetServiceDeposit(c *gin.Context) {
+	deposit, err := h.bookingService.GetServiceDeposit(c.GetString("company_id"), c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"deposit": deposit,
+	})
+}
+
+// SetServiceDeposit configures the deposit a service requires at booking time
+func (h *BookingHandler) SetServiceDeposit(c *gin.Context) {
+	var req models.ServiceDepositRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	deposit, err := h.bookingService.SetServiceDeposit(c.GetString("company_id"), c.Param("serviceId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"deposit": deposit,
+	})
+}
+
+// ConfirmBookingDeposit confirms the customer's booking once the deposit payment has gone through
+func (h *BookingHandler) ConfirmBookingDeposit(c *gin.Context) {
+	booking, err := h.bookingService.GetBookingByID(c.Param("id"))
+	if err != nil || booking.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking not found"})
+		return
+	}
+
+	booking, err = h.bookingService.ConfirmBookingDeposit(booking.ID)
+	if err != nil {
+		if errors.Is(err, services.ErrDepositNotPaid) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error": err.Error(),
+				"code":  "DEPOSIT_NOT_PAID",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Deposit received, booking confirmed",
+		"data":    booking,
+	})
+}
+
+// MarkDepositReceived records a deposit paid to the company offline and confirms the booking
+func (h *BookingHandler) MarkDepositReceived(c *gin.Context) {
+	booking, err := h.bookingService.MarkDepositReceived(c.GetString("company_id"), c.Param("id"), bookingActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Deposit received, booking confirmed",
+		"data":    booking,
+	})
+}
@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBookingHistory returns the change history of a booking: who created, moved, reassigned or repriced it and when
+func (h *BookingHandler) GetBookingHistory(c *gin.Context) {
+	booking, _, ok := h.loadCancellableBooking(c)
+	if !ok {
+		return
+	}
+
+	events, err := h.bookingService.GetBookingHistory(booking.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"history": events,
+	})
+}
+
+// bookingActor attributes changes to the authenticated employee or user
+func bookingActor(c *gin.Context) models.BookingActor {
+	if employeeID := c.GetString("employee_id"); employeeID != "" {
+		return services.EmployeeActor(employeeID)
+	}
+	if userID := c.GetString("user_id"); userID != "" {
+		return services.UserActor(userID)
+	}
+	return services.SystemActor
+}
@@ -0,0 +1,52 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetBookingIntake returns the forms asked for a booking, answered or still outstanding
+func (h *BookingHandler) GetBookingIntake(c *gin.Context) {
+	booking, _, ok := h.loadCancellableBooking(c)
+	if !ok {
+		return
+	}
+
+	intake, err := h.bookingService.GetBookingIntake(booking.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"intake":  intake,
+	})
+}
+
+// SubmitBookingIntake answers one of a booking's forms, by the customer or by staff on their behalf
+func (h *BookingHandler) SubmitBookingIntake(c *gin.Context) {
+	booking, _, ok := h.loadCancellableBooking(c)
+	if !ok {
+		return
+	}
+
+	var req models.IntakeSubmission
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	intake, err := h.bookingService.SubmitBookingIntake(booking.ID, &req, bookingActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"intake":  intake,
+	})
+}
@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// MarkNoShow records that the customer missed a booking and charges the no-show fee unless waived
+func (h *BookingHandler) MarkNoShow(c *gin.Context) {
+	// The body is optional
+	var req models.MarkNoShowRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	outcome, err := h.bookingService.MarkNoShow(c.GetString("company_id"), c.Param("id"), &req, bookingActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"message":      "Booking marked as no-show",
+		"cancellation": outcome,
+	})
+}
+
+// GetNoShowRules returns the rules applied to customers with repeated no-shows
+func (h *BookingHandler) GetNoShowRules(c *gin.Context) {
+	rules, err := h.bookingService.GetNoShowRules(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"rules":   rules,
+	})
+}
+
+// SetNoShowRules configures the deposit and blocking thresholds for customers with repeated no-shows
+func (h *BookingHandler) SetNoShowRules(c *gin.Context) {
+	var req models.NoShowRules
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := h.bookingService.SetNoShowRules(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"rules":   rules,
+	})
+}
@@ -0,0 +1,74 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetEmployeeRoute returns the ordered stops of an employee's day with travel between them, ?date=YYYY-MM-DD (default today).
+// Company routes take the employee from the path, employee routes use the signed-in employee.
+func (h *BookingHandler) GetEmployeeRoute(c *gin.Context) {
+	employeeID := h.calendarEmployeeID(c)
+	if employeeID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee not authenticated"})
+		return
+	}
+
+	var date time.Time
+	if dateStr := c.Query("date"); dateStr != "" {
+		var err error
+		date, err = time.Parse("2006-01-02", dateStr)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+			return
+		}
+	}
+
+	route, err := h.bookingService.GetEmployeeRoute(c.GetString("company_id"), employeeID, date)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"route":   route,
+	})
+}
+
+// GetTravelSettings returns the speed and buffer used to plan travel between mobile appointments
+func (h *BookingHandler) GetTravelSettings(c *gin.Context) {
+	settings, err := h.bookingService.GetTravelSettings(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"settings": settings,
+	})
+}
+
+// SetTravelSettings configures the average travel speed and the allowance added to every trip
+func (h *BookingHandler) SetTravelSettings(c *gin.Context) {
+	var req models.TravelSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.bookingService.SetTravelSettings(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"settings": settings,
+	})
+}
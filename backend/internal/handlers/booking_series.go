@@ -0,0 +1,187 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+// CreateBookingSeries creates a recurring booking and generates its upcoming occurrences
+func (h *BookingHandler) CreateBookingSeries(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.CreateBookingSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.bookingService.CreateBookingSeries(userID, &req)
+	if errors.Is(err, services.ErrBookingBlocked) {
+		h.respondBookingError(c, err)
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Booking series created successfully",
+		"data":    result,
+	})
+}
+
+// GetBookingSeries returns a series with its bookings and exceptions
+func (h *BookingHandler) GetBookingSeries(c *gin.Context) {
+	series, ok := h.loadAccessibleSeries(c)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    series,
+	})
+}
+
+// SkipSeriesOccurrence skips a single occurrence of a series
+func (h *BookingHandler) SkipSeriesOccurrence(c *gin.Context) {
+	series, ok := h.loadAccessibleSeries(c)
+	if !ok {
+		return
+	}
+
+	var req models.SkipOccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.bookingService.SkipSeriesOccurrence(series.ID, &req, bookingActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Occurrence skipped successfully",
+		"data":    result,
+	})
+}
+
+// MoveSeriesOccurrence moves a single occurrence of a series to another time
+func (h *BookingHandler) MoveSeriesOccurrence(c *gin.Context) {
+	series, ok := h.loadAccessibleSeries(c)
+	if !ok {
+		return
+	}
+
+	var req models.MoveOccurrenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.bookingService.MoveSeriesOccurrence(series.ID, &req, bookingActor(c))
+	if err != nil {
+		if errors.Is(err, services.ErrSlotTaken) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"code":  "SLOT_TAKEN",
+				"data":  result,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Occurrence moved successfully",
+		"data":    result,
+	})
+}
+
+// CancelBookingSeries cancels a series and its upcoming bookings
+func (h *BookingHandler) CancelBookingSeries(c *gin.Context) {
+	series, ok := h.loadAccessibleSeries(c)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	c.ShouldBindJSON(&req) // Optional body
+
+	result, err := h.bookingService.CancelBookingSeries(series.ID, req.Reason, bookingActor(c))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Booking series cancelled successfully",
+		"data":    result,
+	})
+}
+
+// RescheduleBookingSeries moves all upcoming occurrences to a new start time and rule
+func (h *BookingHandler) RescheduleBookingSeries(c *gin.Context) {
+	series, ok := h.loadAccessibleSeries(c)
+	if !ok {
+		return
+	}
+
+	var req models.RescheduleSeriesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	result, err := h.bookingService.RescheduleBookingSeries(series.ID, &req, bookingActor(c))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Booking series rescheduled successfully",
+		"data":    result,
+	})
+}
+
+// loadAccessibleSeries loads the series from the route and checks the caller may manage it
+func (h *BookingHandler) loadAccessibleSeries(c *gin.Context) (*models.BookingSeries, bool) {
+	series, err := h.bookingService.GetBookingSeries(c.Param("seriesId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Booking series not found"})
+		return nil, false
+	}
+
+	userRole := c.GetString("user_role")
+	canAccess := userRole == "super_admin" ||
+		series.UserID == c.GetString("user_id") ||
+		(series.CompanyID != "" && series.CompanyID == c.GetString("company_id"))
+
+	if !canAccess {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Access denied"})
+		return nil, false
+	}
+
+	return series, true
+}
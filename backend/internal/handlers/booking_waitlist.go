@@ -0,0 +1,104 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// JoinWaitlist adds the authenticated customer to the waitlist of a service
+func (h *BookingHandler) JoinWaitlist(c *gin.Context) {
+	userID := c.GetString("user_id")
+	if userID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "User not authenticated"})
+		return
+	}
+
+	var req models.JoinWaitlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	entry, err := h.bookingService.JoinWaitlist(userID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Added to waitlist successfully",
+		"data":    entry,
+	})
+}
+
+// GetUserWaitlist returns the open waitlist entries and offers of the authenticated customer
+func (h *BookingHandler) GetUserWaitlist(c *gin.Context) {
+	entries, err := h.bookingService.GetUserWaitlist(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// GetCompanyWaitlist returns the open waitlist of the company
+func (h *BookingHandler) GetCompanyWaitlist(c *gin.Context) {
+	entries, err := h.bookingService.GetCompanyWaitlist(c.GetString("company_id"), c.Query("service_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    entries,
+	})
+}
+
+// AcceptWaitlistOffer books the slot held for the customer
+func (h *BookingHandler) AcceptWaitlistOffer(c *gin.Context) {
+	booking, err := h.bookingService.AcceptWaitlistOffer(c.GetString("user_id"), c.Param("entryId"))
+	if err != nil {
+		h.respondBookingError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Booking created successfully",
+		"data":    booking,
+	})
+}
+
+// DeclineWaitlistOffer passes the held slot to the next customer
+func (h *BookingHandler) DeclineWaitlistOffer(c *gin.Context) {
+	if err := h.bookingService.DeclineWaitlistOffer(c.GetString("user_id"), c.Param("entryId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Offer declined",
+	})
+}
+
+// LeaveWaitlist removes the customer from the waitlist
+func (h *BookingHandler) LeaveWaitlist(c *gin.Context) {
+	if err := h.bookingService.LeaveWaitlist(c.GetString("user_id"), c.Param("entryId")); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Removed from waitlist",
+	})
+}
@@ -605,6 +605,14 @@ func (h *CompanyHandler) UpdateCompanyProfile(c *gin.Context) {
 	// Set the company ID from context
 	updateData.ID = companyID
 
+	// Validate timezone (empty keeps the current one)
+	if updateData.Timezone != "" {
+		if _, err := time.LoadLocation(updateData.Timezone); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid timezone", "details": err.Error()})
+			return
+		}
+	}
+
 	updatedCompany, err := h.companyService.UpdateCompanyProfile(updateData)
 	if err != nil {
 		fmt.Printf("Error updating company profile: %v\n", err)
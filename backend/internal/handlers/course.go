@@ -0,0 +1,371 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type CourseHandler struct {
+	courseService *services.CourseService
+}
+
+func NewCourseHandler(courseService *services.CourseService) *CourseHandler {
+	return &CourseHandler{
+		courseService: courseService,
+	}
+}
+
+// GetPublicCourses returns the published courses of a company
+func (h *CourseHandler) GetPublicCourses(c *gin.Context) {
+	courses, err := h.courseService.GetPublishedCourses(c.Param("companyId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"courses": courses,
+	})
+}
+
+// GetPublicCourse returns a published course with its sessions and places left
+func (h *CourseHandler) GetPublicCourse(c *gin.Context) {
+	course, err := h.courseService.GetCourse(c.Param("courseId"))
+	if err != nil || course.Status != "published" {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"course":  course,
+	})
+}
+
+// GetCompanyCourses returns every course of the company
+func (h *CourseHandler) GetCompanyCourses(c *gin.Context) {
+	courses, err := h.courseService.GetCompanyCourses(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"courses": courses,
+	})
+}
+
+// GetCompanyCourse returns a course of the company
+func (h *CourseHandler) GetCompanyCourse(c *gin.Context) {
+	course, err := h.courseService.GetCourse(c.Param("courseId"))
+	if err != nil || course.CompanyID != c.GetString("company_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Course not found"})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"course":  course,
+	})
+}
+
+// CreateCourse creates a class or course, optionally with its sessions
+func (h *CourseHandler) CreateCourse(c *gin.Context) {
+	var req models.CourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	course, err := h.courseService.CreateCourse(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"course":  course,
+	})
+}
+
+// UpdateCourse changes the details of a course
+func (h *CourseHandler) UpdateCourse(c *gin.Context) {
+	var req models.CourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	course, err := h.courseService.UpdateCourse(c.GetString("company_id"), c.Param("courseId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"course":  course,
+	})
+}
+
+// CancelCourse cancels a course and refunds everyone enrolled
+func (h *CourseHandler) CancelCourse(c *gin.Context) {
+	var req struct {
+		Reason string `json:"reason"`
+	}
+
+	c.ShouldBindJSON(&req) // Optional body
+
+	course, err := h.courseService.CancelCourse(c.GetString("company_id"), c.Param("courseId"), req.Reason)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Course cancelled successfully",
+		"course":  course,
+	})
+}
+
+// AddCourseSession adds a session to a course
+func (h *CourseHandler) AddCourseSession(c *gin.Context) {
+	var req models.CourseSessionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	session, err := h.courseService.AddCourseSession(c.GetString("company_id"), c.Param("courseId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"session": session,
+	})
+}
+
+// CancelCourseSession cancels a single session of a course
+func (h *CourseHandler) CancelCourseSession(c *gin.Context) {
+	err := h.courseService.CancelCourseSession(c.GetString("company_id"), c.Param("courseId"), c.Param("sessionId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Session cancelled successfully",
+	})
+}
+
+// GetCourseEnrollments returns the enrollments of a company course
+func (h *CourseHandler) GetCourseEnrollments(c *gin.Context) {
+	enrollments, err := h.courseService.GetCourseEnrollments(c.GetString("company_id"), c.Param("courseId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"enrollments": enrollments,
+	})
+}
+
+// CancelCourseEnrollment removes a customer from a course with a full refund
+func (h *CourseHandler) CancelCourseEnrollment(c *gin.Context) {
+	outcome, err := h.courseService.CancelCourseEnrollment(c.GetString("company_id"), c.Param("courseId"), c.Param("enrollmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Enrollment cancelled successfully",
+		"data":    outcome,
+	})
+}
+
+// EnrollInCourse enrolls one of the customer's pets in a course
+func (h *CourseHandler) EnrollInCourse(c *gin.Context) {
+	var req models.EnrollCourseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	enrollment, err := h.courseService.EnrollInCourse(c.GetString("user_id"), c.Param("courseId"), &req)
+	if err != nil {
+		if errors.Is(err, services.ErrCourseFull) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"code":  "COURSE_FULL",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":    true,
+		"enrollment": enrollment,
+	})
+}
+
+// GetMyEnrollments returns the customer's course enrollments
+func (h *CourseHandler) GetMyEnrollments(c *gin.Context) {
+	enrollments, err := h.courseService.GetUserEnrollments(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":     true,
+		"enrollments": enrollments,
+	})
+}
+
+// ConfirmEnrollmentPayment completes an enrollment once the course payment has gone through
+func (h *CourseHandler) ConfirmEnrollmentPayment(c *gin.Context) {
+	enrollment, err := h.courseService.ConfirmEnrollmentPayment(c.GetString("user_id"), c.Param("enrollmentId"))
+	if err != nil {
+		if errors.Is(err, services.ErrDepositNotPaid) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error": err.Error(),
+				"code":  "PAYMENT_NOT_COMPLETED",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"message":    "Payment received, enrollment confirmed",
+		"enrollment": enrollment,
+	})
+}
+
+// PreviewEnrollmentCancellation returns the fee and refund for leaving a course now
+func (h *CourseHandler) PreviewEnrollmentCancellation(c *gin.Context) {
+	outcome, err := h.courseService.PreviewEnrollmentCancellation(c.GetString("user_id"), c.Param("enrollmentId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    outcome,
+	})
+}
+
+// CancelEnrollment leaves a course under its cancellation policy.
+// accepted_fee is the fee shown in the preview; a higher fee is not charged without a new confirmation.
+func (h *CourseHandler) CancelEnrollment(c *gin.Context) {
+	var req struct {
+		AcceptedFee *float64 `json:"accepted_fee"`
+	}
+
+	c.ShouldBindJSON(&req) // Optional body
+
+	outcome, err := h.courseService.CancelEnrollment(c.GetString("user_id"), c.Param("enrollmentId"), req.AcceptedFee)
+	if err != nil {
+		if errors.Is(err, services.ErrCancellationFeeChanged) {
+			c.JSON(http.StatusConflict, gin.H{
+				"error": err.Error(),
+				"code":  "CANCELLATION_FEE_CHANGED",
+				"data":  outcome,
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Enrollment cancelled successfully",
+		"data":    outcome,
+	})
+}
+
+// GetMyCourseSessions returns the upcoming sessions the authenticated employee teaches
+func (h *CourseHandler) GetMyCourseSessions(c *gin.Context) {
+	sessions, err := h.courseService.GetTrainerSessions(c.GetString("employee_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"sessions": sessions,
+	})
+}
+
+// GetSessionRoster returns the pets expected at a session with their attendance
+func (h *CourseHandler) GetSessionRoster(c *gin.Context) {
+	roster, err := h.courseService.GetSessionRoster(c.GetString("company_id"), c.Param("sessionId"), sessionTrainer(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"roster":  roster,
+	})
+}
+
+// RecordAttendance marks which enrolled pets attended a session
+func (h *CourseHandler) RecordAttendance(c *gin.Context) {
+	var req models.CourseAttendanceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	markedBy := sessionTrainer(c)
+	if markedBy == nil {
+		if userID := c.GetString("user_id"); userID != "" {
+			markedBy = &userID
+		}
+	}
+
+	roster, err := h.courseService.RecordAttendance(c.GetString("company_id"), c.Param("sessionId"), sessionTrainer(c), markedBy, req.Entries)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"roster":  roster,
+	})
+}
+
+// sessionTrainer returns the authenticated employee, who may only see the sessions they teach,
+// or nil for the company owner
+func sessionTrainer(c *gin.Context) *string {
+	if employeeID := c.GetString("employee_id"); employeeID != "" {
+		return &employeeID
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPublicServiceIntakeForms returns the forms customers fill in for a service
+func (h *ServiceHandler) GetPublicServiceIntakeForms(c *gin.Context) {
+	forms, err := h.serviceService.GetServiceIntakeForms(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"forms":   forms,
+	})
+}
+
+// GetIntakeForms returns every intake form of the company, including inactive ones
+func (h *ServiceHandler) GetIntakeForms(c *gin.Context) {
+	forms, err := h.serviceService.GetIntakeForms(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"forms":   forms,
+	})
+}
+
+// CreateIntakeForm defines a questionnaire and attaches it to services
+func (h *ServiceHandler) CreateIntakeForm(c *gin.Context) {
+	var req models.IntakeFormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := h.serviceService.CreateIntakeForm(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"form":    form,
+	})
+}
+
+// UpdateIntakeForm changes a form; answers already given keep the questions they were asked
+func (h *ServiceHandler) UpdateIntakeForm(c *gin.Context) {
+	var req models.IntakeFormRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	form, err := h.serviceService.UpdateIntakeForm(c.GetString("company_id"), c.Param("formId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"form":    form,
+	})
+}
+
+// DeleteIntakeForm removes a form from the company and its services
+func (h *ServiceHandler) DeleteIntakeForm(c *gin.Context) {
+	if err := h.serviceService.DeleteIntakeForm(c.GetString("company_id"), c.Param("formId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Intake form deleted",
+	})
+}
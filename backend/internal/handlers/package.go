@@ -0,0 +1,217 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type PackageHandler struct {
+	packageService *services.PackageService
+}
+
+func NewPackageHandler(packageService *services.PackageService) *PackageHandler {
+	return &PackageHandler{
+		packageService: packageService,
+	}
+}
+
+// GetPublicPackages returns the packages customers can buy from a company
+func (h *PackageHandler) GetPublicPackages(c *gin.Context) {
+	packages, err := h.packageService.GetActivePackages(c.Param("companyId"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"packages": packages,
+	})
+}
+
+// GetCompanyPackages returns every package of the company
+func (h *PackageHandler) GetCompanyPackages(c *gin.Context) {
+	packages, err := h.packageService.GetCompanyPackages(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"packages": packages,
+	})
+}
+
+// CreatePackage creates a prepaid bundle of sessions
+func (h *PackageHandler) CreatePackage(c *gin.Context) {
+	var req models.ServicePackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pkg, err := h.packageService.CreatePackage(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"package": pkg,
+	})
+}
+
+// UpdatePackage changes a package; set is_active to false to stop selling it
+func (h *PackageHandler) UpdatePackage(c *gin.Context) {
+	var req models.ServicePackageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	pkg, err := h.packageService.UpdatePackage(c.GetString("company_id"), c.Param("packageId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"package": pkg,
+	})
+}
+
+// PurchasePackage buys a package for the customer
+func (h *PackageHandler) PurchasePackage(c *gin.Context) {
+	// The body is optional
+	var req models.PurchasePackageRequest
+	c.ShouldBindJSON(&req)
+
+	purchase, err := h.packageService.PurchasePackage(c.GetString("user_id"), c.Param("packageId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"purchase": purchase,
+	})
+}
+
+// ConfirmPackagePayment credits the package once its payment has gone through
+func (h *PackageHandler) ConfirmPackagePayment(c *gin.Context) {
+	purchase, err := h.packageService.ConfirmPackagePayment(c.GetString("user_id"), c.Param("purchaseId"))
+	if err != nil {
+		if errors.Is(err, services.ErrDepositNotPaid) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error": err.Error(),
+				"code":  "PAYMENT_NOT_COMPLETED",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"message":  "Payment received, package credited",
+		"purchase": purchase,
+	})
+}
+
+// GetMyPackages returns the customer's package balances
+func (h *PackageHandler) GetMyPackages(c *gin.Context) {
+	purchases, err := h.packageService.GetUserPackages(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"purchases": purchases,
+	})
+}
+
+// GetMyPackageLedger returns the credits and debits of one of the customer's balances
+func (h *PackageHandler) GetMyPackageLedger(c *gin.Context) {
+	purchase, err := h.packageService.GetPurchase(c.Param("purchaseId"))
+	if err != nil || purchase.UserID != c.GetString("user_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Package purchase not found"})
+		return
+	}
+
+	h.respondLedger(c, purchase)
+}
+
+// GetCompanyPurchases returns the package balances sold by the company, filtered by ?user_id=
+func (h *PackageHandler) GetCompanyPurchases(c *gin.Context) {
+	purchases, err := h.packageService.GetCompanyPurchases(c.GetString("company_id"), c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"purchases": purchases,
+	})
+}
+
+// GetCompanyPackageLedger returns the credits and debits of a balance sold by the company
+func (h *PackageHandler) GetCompanyPackageLedger(c *gin.Context) {
+	purchase, err := h.packageService.GetPurchase(c.Param("purchaseId"))
+	if err != nil || purchase.CompanyID != c.GetString("company_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Package purchase not found"})
+		return
+	}
+
+	h.respondLedger(c, purchase)
+}
+
+// AdjustPackageBalance credits or debits sessions on a customer's balance by hand
+func (h *PackageHandler) AdjustPackageBalance(c *gin.Context) {
+	var req models.PackageAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var createdBy *string
+	if userID := c.GetString("user_id"); userID != "" {
+		createdBy = &userID
+	}
+
+	purchase, err := h.packageService.AdjustPackageBalance(c.GetString("company_id"), c.Param("purchaseId"), createdBy, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"purchase": purchase,
+	})
+}
+
+func (h *PackageHandler) respondLedger(c *gin.Context, purchase *models.PackagePurchase) {
+	entries, err := h.packageService.GetPurchaseLedger(purchase.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"purchase": purchase,
+		"ledger":   entries,
+	})
+}
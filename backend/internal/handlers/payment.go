@@ -1,10 +1,13 @@
 ﻿package handlers
 
 import (
+	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"strconv"
 
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
 	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
 	"github.com/gin-gonic/gin"
 )
@@ -61,7 +64,12 @@ func (h *PaymentHandler) HandleWebhook(c *gin.Context) {
 
 	err = h.paymentService.HandleWebhook(payload, sigHeader)
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		// Anything but a bad request is answered with an error so that Stripe redelivers the event
+		if errors.Is(err, services.ErrInvalidWebhook) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -206,7 +214,7 @@ func (h *PaymentHandler) UpdatePaymentStatus(c *gin.Context) {
 	}
 
 	// Validate status
-	validStatuses := []string{"pending", "processing", "succeeded", "failed", "canceled", "refunded", "partially_refunded"}
+	validStatuses := []string{"pending", "processing", "authorized", "succeeded", "failed", "canceled", "refunded", "partially_refunded", "disputed", "dispute_lost"}
 	isValidStatus := false
 	for _, status := range validStatuses {
 		if req.Status == status {
@@ -292,7 +300,7 @@ func (h *PaymentHandler) ProcessSubscriptionPayment(c *gin.Context) {
 	}
 
 	// Get subscription result from service
-	subscription, err := h.paymentService.CreateSubscription(userID.(string), req.PlanID, req.PaymentMethodID)
+	subscription, err := h.paymentService.CreateSubscription(userID.(string), req.CompanyID, req.PlanID, req.PaymentMethodID)
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -326,7 +334,14 @@ func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
 	// Confirm the payment using PaymentService
 	payment, err := h.paymentService.ConfirmPayment(req.PaymentIntentID, req.PaymentMethodID, userID.(string))
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		if errors.Is(err, services.ErrPaymentDeclined) {
+			c.JSON(http.StatusPaymentRequired, gin.H{
+				"error": err.Error(),
+				"code":  "PAYMENT_DECLINED",
+			})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
@@ -337,6 +352,183 @@ func (h *PaymentHandler) ConfirmPayment(c *gin.Context) {
 	})
 }
 
+// CaptureCompanyPayment collects a payment a customer of the company authorized to be captured later
+func (h *PaymentHandler) CaptureCompanyPayment(c *gin.Context) {
+	payment, err := h.paymentService.GetPaymentByID(c.Param("paymentId"))
+	if err != nil || payment.CompanyID == nil || *payment.CompanyID != c.GetString("company_id") {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Payment not found"})
+		return
+	}
+
+	payment, err = h.paymentService.CapturePayment(payment.ID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Payment captured successfully",
+		"data":    payment,
+	})
+}
+
+// GetCompanyLedger returns the company's balances with the platform and its latest ledger entries
+func (h *PaymentHandler) GetCompanyLedger(c *gin.Context) {
+	companyID := c.GetString("company_id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limit < 1 || limit > 500 {
+		limit = 50
+	}
+
+	balances, err := h.paymentService.GetCompanyLedgerBalances(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	entries, err := h.paymentService.GetCompanyLedger(companyID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"balances": balances,
+			"entries":  entries,
+		},
+	})
+}
+
+// GetLedgerReconciliation reports whether the ledger balances and agrees with the payments
+func (h *PaymentHandler) GetLedgerReconciliation(c *gin.Context) {
+	report, err := h.paymentService.GetLedgerReconciliation()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    report,
+	})
+}
+
+// RecordProcessorFee records the fee the payment provider kept on a payment
+func (h *PaymentHandler) RecordProcessorFee(c *gin.Context) {
+	var req struct {
+		Amount float64 `json:"amount" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.paymentService.RecordProcessorFee(c.Param("paymentId"), req.Amount); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Processor fee recorded successfully",
+	})
+}
+
+// GetCompanyPayouts returns the company's latest payouts and what its next payouts will hold
+func (h *PaymentHandler) GetCompanyPayouts(c *gin.Context) {
+	companyID := c.GetString("company_id")
+
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "20"))
+	if err != nil || limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	payouts, err := h.paymentService.GetCompanyPayouts(companyID, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	upcoming, err := h.paymentService.GetUpcomingPayouts(companyID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data": gin.H{
+			"payouts":  payouts,
+			"upcoming": upcoming,
+		},
+	})
+}
+
+// GetCompanyPayout returns a payout with every payment it includes
+func (h *PaymentHandler) GetCompanyPayout(c *gin.Context) {
+	payout, err := h.paymentService.GetCompanyPayout(c.GetString("company_id"), c.Param("payoutId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    payout,
+	})
+}
+
+// DownloadPayoutStatement returns a payout's statement as a CSV file
+func (h *PaymentHandler) DownloadPayoutStatement(c *gin.Context) {
+	statement, err := h.paymentService.GetPayoutStatement(c.GetString("company_id"), c.Param("payoutId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="payout-%s.csv"`, c.Param("payoutId")))
+	c.Data(http.StatusOK, "text/csv; charset=utf-8", statement)
+}
+
+// GetPayoutSettings returns how often and where the company is paid out
+func (h *PaymentHandler) GetPayoutSettings(c *gin.Context) {
+	settings, err := h.paymentService.GetPayoutSettings(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    settings,
+	})
+}
+
+// UpdatePayoutSettings changes the company's payout schedule, minimum and destination account
+func (h *PaymentHandler) UpdatePayoutSettings(c *gin.Context) {
+	var req models.CompanyPayoutSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	settings, err := h.paymentService.UpdatePayoutSettings(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Payout settings updated successfully",
+		"data":    settings,
+	})
+}
+
 // GetPaymentHistory retrieves payment history for a user
 func (h *PaymentHandler) GetPaymentHistory(c *gin.Context) {
 	// Get user ID from context
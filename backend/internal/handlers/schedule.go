@@ -0,0 +1,233 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type ScheduleHandler struct {
+	scheduleService *services.ScheduleService
+}
+
+func NewScheduleHandler(scheduleService *services.ScheduleService) *ScheduleHandler {
+	return &ScheduleHandler{scheduleService: scheduleService}
+}
+
+// GetEmployeeSchedule returns shifts, overrides, time-off and breaks of an employee
+func (h *ScheduleHandler) GetEmployeeSchedule(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	employeeID := c.Param("employeeId")
+
+	schedule, err := h.scheduleService.GetEmployeeSchedule(companyID, employeeID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"schedule": schedule,
+	})
+}
+
+// GetMySchedule returns the schedule of the authenticated employee
+func (h *ScheduleHandler) GetMySchedule(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	employeeID := c.GetString("employee_id")
+	if employeeID == "" {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Employee not authenticated"})
+		return
+	}
+
+	schedule, err := h.scheduleService.GetEmployeeSchedule(companyID, employeeID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"schedule": schedule,
+	})
+}
+
+// GetWorkingHours returns the effective working windows of an employee for a date
+func (h *ScheduleHandler) GetWorkingHours(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	employeeID := c.Param("employeeId")
+
+	date, err := time.Parse("2006-01-02", c.Query("date"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid date format. Use YYYY-MM-DD"})
+		return
+	}
+
+	// Reuse the ownership check of the schedule lookup
+	if _, err := h.scheduleService.GetEmployeeSchedule(companyID, employeeID); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	windows, err := h.scheduleService.GetWorkingWindows(employeeID, date)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"date":    date.Format("2006-01-02"),
+		"windows": windows,
+	})
+}
+
+// SetWeeklyShifts replaces the recurring weekly shifts of an employee
+func (h *ScheduleHandler) SetWeeklyShifts(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	employeeID := c.Param("employeeId")
+
+	var req models.SetEmployeeShiftsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	shifts, err := h.scheduleService.SetWeeklyShifts(companyID, employeeID, req.Shifts)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"shifts":  shifts,
+	})
+}
+
+// CreateOverride creates or replaces a schedule override for a single date
+func (h *ScheduleHandler) CreateOverride(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	employeeID := c.Param("employeeId")
+
+	var req models.EmployeeScheduleOverrideRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	override, err := h.scheduleService.CreateOverride(companyID, employeeID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"override": override,
+	})
+}
+
+// DeleteOverride removes a schedule override
+func (h *ScheduleHandler) DeleteOverride(c *gin.Context) {
+	err := h.scheduleService.DeleteOverride(c.GetString("company_id"), c.Param("employeeId"), c.Param("overrideId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Schedule override deleted successfully",
+	})
+}
+
+// CreateTimeOff records a vacation, sick day or other absence
+func (h *ScheduleHandler) CreateTimeOff(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	employeeID := c.Param("employeeId")
+
+	var req models.EmployeeTimeOffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	timeOff, err := h.scheduleService.CreateTimeOff(companyID, employeeID, h.actorID(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"time_off": timeOff,
+	})
+}
+
+// DeleteTimeOff removes a time-off entry
+func (h *ScheduleHandler) DeleteTimeOff(c *gin.Context) {
+	err := h.scheduleService.DeleteTimeOff(c.GetString("company_id"), c.Param("employeeId"), c.Param("timeOffId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Time-off deleted successfully",
+	})
+}
+
+// CreateBreak adds a recurring or one-off break
+func (h *ScheduleHandler) CreateBreak(c *gin.Context) {
+	companyID := c.GetString("company_id")
+	employeeID := c.Param("employeeId")
+
+	var req models.EmployeeBreakRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	employeeBreak, err := h.scheduleService.CreateBreak(companyID, employeeID, &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"break":   employeeBreak,
+	})
+}
+
+// DeleteBreak removes a break
+func (h *ScheduleHandler) DeleteBreak(c *gin.Context) {
+	err := h.scheduleService.DeleteBreak(c.GetString("company_id"), c.Param("employeeId"), c.Param("breakId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Break deleted successfully",
+	})
+}
+
+// Helper functions
+
+// actorID returns the ID of the owner or employee performing the request
+func (h *ScheduleHandler) actorID(c *gin.Context) *string {
+	if employeeID := c.GetString("employee_id"); employeeID != "" {
+		return &employeeID
+	}
+	if userID := c.GetString("user_id"); userID != "" {
+		return &userID
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetExternalCalendars returns the imported calendars of an employee, or the company-wide ones
+func (h *ScheduleHandler) GetExternalCalendars(c *gin.Context) {
+	calendars, err := h.scheduleService.GetExternalCalendars(c.GetString("company_id"), externalCalendarOwner(c))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"calendars": calendars,
+	})
+}
+
+// CreateExternalCalendar imports an ICS calendar from a URL (JSON or form field "url")
+// or from an uploaded .ics file (multipart field "file")
+func (h *ScheduleHandler) CreateExternalCalendar(c *gin.Context) {
+	var req models.ExternalCalendarRequest
+	if strings.HasPrefix(c.ContentType(), "multipart/form-data") {
+		req.Name = c.PostForm("name")
+		req.URL = c.PostForm("url")
+
+		if file, header, err := c.Request.FormFile("file"); err == nil {
+			defer file.Close()
+
+			// Validate file size (5MB limit)
+			if header.Size > 5*1024*1024 {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "File size must be less than 5MB"})
+				return
+			}
+
+			content, err := io.ReadAll(file)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+				return
+			}
+			req.ICSContent = string(content)
+			if req.Name == "" {
+				req.Name = header.Filename
+			}
+		}
+
+		if req.Name == "" {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "name is required"})
+			return
+		}
+	} else if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	calendar, err := h.scheduleService.CreateExternalCalendar(c.GetString("company_id"), externalCalendarOwner(c), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":  true,
+		"calendar": calendar,
+	})
+}
+
+// SyncExternalCalendar re-imports a calendar without waiting for the periodic refresh
+func (h *ScheduleHandler) SyncExternalCalendar(c *gin.Context) {
+	calendar, err := h.scheduleService.SyncExternalCalendar(c.GetString("company_id"), externalCalendarOwner(c), c.Param("calendarId"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":  true,
+		"calendar": calendar,
+	})
+}
+
+// DeleteExternalCalendar removes an imported calendar and frees the time it blocked
+func (h *ScheduleHandler) DeleteExternalCalendar(c *gin.Context) {
+	err := h.scheduleService.DeleteExternalCalendar(c.GetString("company_id"), externalCalendarOwner(c), c.Param("calendarId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "External calendar deleted successfully",
+	})
+}
+
+// externalCalendarOwner returns the employee whose calendars the request manages:
+// the employee of the route, the authenticated employee, or nil for company-wide calendars
+func externalCalendarOwner(c *gin.Context) *string {
+	if employeeID := c.Param("employeeId"); employeeID != "" {
+		return &employeeID
+	}
+	if employeeID := c.GetString("employee_id"); employeeID != "" {
+		return &employeeID
+	}
+	return nil
+}
@@ -149,7 +149,7 @@ func (h *ServiceHandler) CreateService(c *gin.Context) {
 			}
 			return request.PetTypes
 		}(),
-		Price:    request.Price,
+		Price:    models.MoneyFromDecimal(request.Price, ""),
 		Duration: request.Duration,
 		ImageID: func() string {
 			if request.ImageID != nil {
@@ -287,7 +287,7 @@ func (h *ServiceHandler) UpdateService(c *gin.Context) {
 		existingService.PetTypes = request.PetTypes
 	}
 	if request.Price > 0 {
-		existingService.Price = request.Price
+		existingService.Price = models.MoneyFromDecimal(request.Price, "")
 	}
 	if request.Duration > 0 {
 		existingService.Duration = request.Duration
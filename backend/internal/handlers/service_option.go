@@ -0,0 +1,89 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetPublicServiceOptions returns the add-ons customers can choose when booking a service
+func (h *ServiceHandler) GetPublicServiceOptions(c *gin.Context) {
+	options, err := h.serviceService.GetServiceOptions(c.Param("serviceId"), true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"options": options,
+	})
+}
+
+// GetServiceOptions returns every option of a company service, including inactive ones
+func (h *ServiceHandler) GetServiceOptions(c *gin.Context) {
+	options, err := h.serviceService.GetCompanyServiceOptions(c.GetString("company_id"), c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"options": options,
+	})
+}
+
+// CreateServiceOption adds an add-on with its own price and extra minutes to a service
+func (h *ServiceHandler) CreateServiceOption(c *gin.Context) {
+	var req models.ServiceOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	option, err := h.serviceService.CreateServiceOption(c.GetString("company_id"), c.Param("serviceId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"option":  option,
+	})
+}
+
+// UpdateServiceOption changes an option; existing bookings keep the price they were made with
+func (h *ServiceHandler) UpdateServiceOption(c *gin.Context) {
+	var req models.ServiceOptionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	option, err := h.serviceService.UpdateServiceOption(c.GetString("company_id"), c.Param("optionId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"option":  option,
+	})
+}
+
+// DeleteServiceOption removes an option from a service
+func (h *ServiceHandler) DeleteServiceOption(c *gin.Context) {
+	if err := h.serviceService.DeleteServiceOption(c.GetString("company_id"), c.Param("optionId")); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Service option deleted",
+	})
+}
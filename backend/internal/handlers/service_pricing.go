@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/gin-gonic/gin"
+)
+
+// GetServicePriceRules returns the price and duration matrix of a service
+func (h *ServiceHandler) GetServicePriceRules(c *gin.Context) {
+	rules, err := h.serviceService.GetServicePriceRules(c.GetString("company_id"), c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"rules":   rules,
+	})
+}
+
+// SetServicePriceRules replaces the price and duration matrix of a service; an empty list removes it
+func (h *ServiceHandler) SetServicePriceRules(c *gin.Context) {
+	var req models.ServicePriceMatrixRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	rules, err := h.serviceService.SetServicePriceRules(c.GetString("company_id"), c.Param("serviceId"), req.Rules)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"rules":   rules,
+	})
+}
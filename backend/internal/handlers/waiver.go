@@ -0,0 +1,181 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/services"
+	"github.com/gin-gonic/gin"
+)
+
+type WaiverHandler struct {
+	waiverService *services.WaiverService
+}
+
+func NewWaiverHandler(waiverService *services.WaiverService) *WaiverHandler {
+	return &WaiverHandler{
+		waiverService: waiverService,
+	}
+}
+
+// GetServiceWaiver returns the waiver customers sign before booking a service; waiver is null when none is required
+func (h *WaiverHandler) GetServiceWaiver(c *gin.Context) {
+	waiver, err := h.waiverService.GetServiceWaiver(c.Param("serviceId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"waiver":  waiver,
+	})
+}
+
+// SignWaiver records the customer's typed signature of the waiver version they were shown
+func (h *WaiverHandler) SignWaiver(c *gin.Context) {
+	var req models.WaiverSignRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	signature, err := h.waiverService.SignWaiver(c.GetString("user_id"), c.Param("waiverId"), &req, c.ClientIP(), c.Request.UserAgent())
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success":   true,
+		"signature": signature,
+	})
+}
+
+// GetMySignatures returns the waivers the customer has signed
+func (h *WaiverHandler) GetMySignatures(c *gin.Context) {
+	signatures, err := h.waiverService.GetUserSignatures(c.GetString("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"signatures": signatures,
+	})
+}
+
+// GetCompanyWaivers returns every waiver of the company with its current version
+func (h *WaiverHandler) GetCompanyWaivers(c *gin.Context) {
+	waivers, err := h.waiverService.GetCompanyWaivers(c.GetString("company_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"waivers": waivers,
+	})
+}
+
+// CreateWaiver publishes the first version of a waiver
+func (h *WaiverHandler) CreateWaiver(c *gin.Context) {
+	var req models.WaiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	waiver, err := h.waiverService.CreateWaiver(c.GetString("company_id"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"waiver":  waiver,
+	})
+}
+
+// UpdateWaiver changes a waiver; a new text is published as a new version customers sign again
+func (h *WaiverHandler) UpdateWaiver(c *gin.Context) {
+	var req models.WaiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	waiver, err := h.waiverService.UpdateWaiver(c.GetString("company_id"), c.Param("waiverId"), &req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"waiver":  waiver,
+	})
+}
+
+// SetServiceWaiver requires a signed waiver before a service can be booked; a null waiver_id removes the requirement
+func (h *WaiverHandler) SetServiceWaiver(c *gin.Context) {
+	var req models.ServiceWaiverRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := h.waiverService.SetServiceWaiver(c.GetString("company_id"), c.Param("serviceId"), req.WaiverID); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"waiver_id": req.WaiverID,
+	})
+}
+
+// GetCompanySignatures returns the signatures collected by the company, filtered by ?user_id=
+func (h *WaiverHandler) GetCompanySignatures(c *gin.Context) {
+	signatures, err := h.waiverService.GetCompanySignatures(c.GetString("company_id"), c.Query("user_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"signatures": signatures,
+	})
+}
+
+// GetSignedWaiver returns the full record of a signature with the text as signed
+func (h *WaiverHandler) GetSignedWaiver(c *gin.Context) {
+	record, err := h.waiverService.GetSignedWaiver(c.GetString("company_id"), c.Param("signatureId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"record":  record,
+	})
+}
+
+// DownloadSignedWaiver returns the printable signed waiver as a text file
+func (h *WaiverHandler) DownloadSignedWaiver(c *gin.Context) {
+	record, err := h.waiverService.GetSignedWaiver(c.GetString("company_id"), c.Param("signatureId"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="waiver-%s.txt"`, record.Signature.ID))
+	c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(record.Document))
+}
@@ -52,6 +52,8 @@ type CustomerData struct {
 	EmergencyContactName     string     `json:"emergency_contact_name"`
 	EmergencyContactPhone    string     `json:"emergency_contact_phone"`
 	EmergencyContactRelation string     `json:"emergency_contact_relation"`
+
+	Reliability CustomerReliability `json:"reliability"`
 }
 
 type PetData struct {
@@ -78,8 +80,11 @@ type PetData struct {
 
 type BookingWithCustomerData struct {
 	*Booking
-	Customer CustomerData `json:"customer"`
-	Pet      PetData      `json:"pet"`
+	Customer CustomerData   `json:"customer"`
+	Pet      PetData        `json:"pet"`
+	History  []BookingEvent `json:"history,omitempty"` // Set in the customer history view
+
+	Intake []BookingIntake `json:"intake,omitempty"` // Forms asked for the booking, answered or outstanding
 }
 
 // New Location Analytics Models
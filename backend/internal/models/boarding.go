@@ -0,0 +1,124 @@
+package models
+
+import (
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Note: Booking model is already defined in models.go
+
+// Kennel represents a kennel or room of a boarding company
+type Kennel struct {
+	ID              string         `json:"id" db:"id"`
+	CompanyID       string         `json:"company_id" db:"company_id"`
+	Name            string         `json:"name" db:"name"`
+	Description     *string        `json:"description" db:"description"`
+	Size            string         `json:"size" db:"size"` // small, medium, large, xlarge
+	MaxPetWeight    *float64       `json:"max_pet_weight" db:"max_pet_weight"`
+	AllowedPetTypes pq.StringArray `json:"allowed_pet_types" db:"allowed_pet_types"` // Pet type IDs, empty allows all
+	Capacity        int            `json:"capacity" db:"capacity"`                   // Pets per night
+	IsActive        bool           `json:"is_active" db:"is_active"`
+	CreatedAt       time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// BoardingRate holds the nightly pricing and check-in/out times of a boarding service
+type BoardingRate struct {
+	ID           string         `json:"id" db:"id"`
+	ServiceID    string         `json:"service_id" db:"service_id"`
+	CompanyID    string         `json:"company_id" db:"company_id"`
+	NightlyRate  float64        `json:"nightly_rate" db:"nightly_rate"`
+	WeekendRate  *float64       `json:"weekend_rate" db:"weekend_rate"`
+	HolidayRate  *float64       `json:"holiday_rate" db:"holiday_rate"`
+	WeekendDays  pq.StringArray `json:"weekend_days" db:"weekend_days"` // Nights starting on these days use the weekend rate
+	CheckInTime  string         `json:"check_in_time" db:"check_in_time"`
+	CheckOutTime string         `json:"check_out_time" db:"check_out_time"`
+	CreatedAt    time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// CompanyHoliday marks a date on which holiday rates apply
+type CompanyHoliday struct {
+	ID          string    `json:"id" db:"id"`
+	CompanyID   string    `json:"company_id" db:"company_id"`
+	HolidayDate time.Time `json:"holiday_date" db:"holiday_date"`
+	Name        string    `json:"name" db:"name"`
+	CreatedAt   time.Time `json:"created_at" db:"created_at"`
+}
+
+// StayNight is the price of a single night of a stay
+type StayNight struct {
+	Date     string  `json:"date"` // YYYY-MM-DD
+	Rate     float64 `json:"rate"`
+	RateType string  `json:"rate_type"` // regular, weekend, holiday
+}
+
+// StayQuote is the price and availability of a stay
+type StayQuote struct {
+	ServiceID        string      `json:"service_id"`
+	CheckInDate      string      `json:"check_in_date"`
+	CheckOutDate     string      `json:"check_out_date"`
+	CheckInTime      string      `json:"check_in_time"`
+	CheckOutTime     string      `json:"check_out_time"`
+	Nights           []StayNight `json:"nights"`
+	TotalPrice       float64     `json:"total_price"`
+	AvailableKennels []Kennel    `json:"available_kennels,omitempty"`
+}
+
+// KennelOccupancy is the occupancy of a kennel on a single night
+type KennelOccupancy struct {
+	KennelID   string   `json:"kennel_id"`
+	Name       string   `json:"name"`
+	Size       string   `json:"size"`
+	Capacity   int      `json:"capacity"`
+	Occupied   int      `json:"occupied"`
+	BookingIDs []string `json:"booking_ids"`
+}
+
+// OccupancyDay is the occupancy of all kennels on a single night
+type OccupancyDay struct {
+	Date      string            `json:"date"` // YYYY-MM-DD
+	Capacity  int               `json:"capacity"`
+	Occupied  int               `json:"occupied"`
+	Available int               `json:"available"`
+	Kennels   []KennelOccupancy `json:"kennels"`
+}
+
+// KennelRequest represents the request to create or update a kennel
+type KennelRequest struct {
+	Name            string   `json:"name" binding:"required"`
+	Description     *string  `json:"description"`
+	Size            string   `json:"size" binding:"required,oneof=small medium large xlarge"`
+	MaxPetWeight    *float64 `json:"max_pet_weight"`
+	AllowedPetTypes []string `json:"allowed_pet_types"`
+	Capacity        int      `json:"capacity"`
+	IsActive        *bool    `json:"is_active"`
+}
+
+// BoardingRateRequest represents the request to set the rates of a boarding service
+type BoardingRateRequest struct {
+	NightlyRate  float64  `json:"nightly_rate" binding:"required,gt=0"`
+	WeekendRate  *float64 `json:"weekend_rate"`
+	HolidayRate  *float64 `json:"holiday_rate"`
+	WeekendDays  []string `json:"weekend_days"`
+	CheckInTime  string   `json:"check_in_time"`
+	CheckOutTime string   `json:"check_out_time"`
+}
+
+// CompanyHolidayRequest represents the request to add a holiday
+type CompanyHolidayRequest struct {
+	Date string `json:"date" binding:"required"` // YYYY-MM-DD
+	Name string `json:"name" binding:"required"`
+}
+
+// BoardingStayRequest represents the request to book a multi-night stay
+type BoardingStayRequest struct {
+	CompanyID    string  `json:"company_id" binding:"required"`
+	ServiceID    string  `json:"service_id" binding:"required"`
+	PetID        string  `json:"pet_id" binding:"required"`
+	KennelID     *string `json:"kennel_id"`
+	CheckInDate  string  `json:"check_in_date" binding:"required"`  // YYYY-MM-DD
+	CheckOutDate string  `json:"check_out_date" binding:"required"` // YYYY-MM-DD
+	Notes        string  `json:"notes"`
+}
@@ -0,0 +1,32 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Booking model is already defined in models.go
+
+// BookingQRCode is the signed code a customer shows at the front desk
+type BookingQRCode struct {
+	BookingID string `json:"booking_id"`
+	Token     string `json:"token"` // Encoded in the QR code
+	Image     string `json:"image"` // PNG data URL of the QR code
+}
+
+// BookingHandover records what was handed over when a pet was checked in or out
+type BookingHandover struct {
+	ID         string    `json:"id" db:"id"`
+	BookingID  string    `json:"booking_id" db:"booking_id"`
+	Stage      string    `json:"stage" db:"stage"` // check_in, check_out
+	EmployeeID *string   `json:"employee_id" db:"employee_id"`
+	Belongings []string  `json:"belongings" db:"belongings"`
+	Notes      *string   `json:"notes" db:"notes"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+}
+
+// BookingScanRequest represents a scanned QR code with the handover details
+type BookingScanRequest struct {
+	Token      string   `json:"token" binding:"required"`
+	Belongings []string `json:"belongings"`
+	Notes      string   `json:"notes"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Booking model is already defined in models.go
+
+// BookingActor is who made a change to a booking
+type BookingActor struct {
+	Type string  `json:"type"` // user, employee, ai_agent, system
+	ID   *string `json:"id"`   // User or employee ID, or the AI agent key; nil for the system
+}
+
+// BookingEvent is an entry of a booking's append-only change history
+type BookingEvent struct {
+	ID        string                        `json:"id" db:"id"`
+	BookingID string                        `json:"booking_id" db:"booking_id"`
+	EventType string                        `json:"event_type" db:"event_type"` // created, rescheduled, reassigned, status_changed, price_changed, updated, checked_in, checked_out
+	Actor     BookingActor                  `json:"actor" db:"-"`
+	ActorName string                        `json:"actor_name,omitempty" db:"-"`
+	Changes   map[string]BookingFieldChange `json:"changes" db:"changes"`
+	Note      *string                       `json:"note" db:"note"`
+	CreatedAt time.Time                     `json:"created_at" db:"created_at"`
+}
+
+// BookingFieldChange is the old and new value of a changed booking field
+type BookingFieldChange struct {
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Booking and Company models are already defined in models.go
+
+// BookingLocation is the customer address a walker, sitter or pet taxi travels to
+type BookingLocation struct {
+	Address   string  `json:"address"`
+	Latitude  float64 `json:"latitude" binding:"required"`
+	Longitude float64 `json:"longitude" binding:"required"`
+}
+
+// TravelSettings controls the travel time added between consecutive mobile jobs
+type TravelSettings struct {
+	SpeedKmh      float64 `json:"travel_speed_kmh" binding:"required"` // Average speed over the straight-line distance
+	BufferMinutes int     `json:"travel_buffer_minutes"`               // Added to every trip, e.g. parking
+}
+
+// RouteStop is one appointment on an employee's daily route
+type RouteStop struct {
+	BookingID     string           `json:"booking_id"`
+	ServiceName   string           `json:"service_name"`
+	CustomerName  string           `json:"customer_name"`
+	PetName       string           `json:"pet_name"`
+	Status        string           `json:"status"`
+	StartsAt      time.Time        `json:"starts_at"`
+	EndsAt        time.Time        `json:"ends_at"`
+	Location      *BookingLocation `json:"location"`
+	DistanceKm    float64          `json:"distance_km"`    // From the previous stop, or the company for the first one
+	TravelMinutes int              `json:"travel_minutes"` // Including the travel buffer
+	LeaveBy       time.Time        `json:"leave_by"`       // Latest departure from the previous stop
+	Conflict      bool             `json:"conflict"`       // The previous job ends too late to arrive on time
+}
+
+// EmployeeRoute is an employee's mobile appointments of one day in visiting order
+type EmployeeRoute struct {
+	EmployeeID         string           `json:"employee_id"`
+	Date               string           `json:"date"` // YYYY-MM-DD in company time
+	Timezone           string           `json:"timezone"`
+	Start              *BookingLocation `json:"start"` // Company address the route starts from, if known
+	Stops              []RouteStop      `json:"stops"`
+	TotalDistanceKm    float64          `json:"total_distance_km"`
+	TotalTravelMinutes int              `json:"total_travel_minutes"`
+	Conflicts          int              `json:"conflicts"`
+}
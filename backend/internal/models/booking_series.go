@@ -0,0 +1,93 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Booking model is already defined in models.go
+
+// BookingSeries represents a recurring booking defined by an RRULE-style rule
+type BookingSeries struct {
+	ID             string     `json:"id" db:"id"`
+	UserID         string     `json:"user_id" db:"user_id"`
+	CompanyID      string     `json:"company_id" db:"company_id"`
+	ServiceID      string     `json:"service_id" db:"service_id"`
+	PetID          *string    `json:"pet_id" db:"pet_id"`
+	EmployeeID     *string    `json:"employee_id" db:"employee_id"`
+	RRule          string     `json:"rrule" db:"rrule"`                     // e.g. FREQ=WEEKLY;INTERVAL=2;BYDAY=TU
+	StartDateTime  time.Time  `json:"start_date_time" db:"start_date_time"` // First occurrence (DTSTART)
+	Timezone       string     `json:"timezone" db:"timezone"`               // Company timezone the rule is expanded in
+	Notes          *string    `json:"notes" db:"notes"`
+	Status         string     `json:"status" db:"status"` // active, cancelled, completed
+	GeneratedUntil *time.Time `json:"generated_until" db:"generated_until"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+
+	// Customer address every occurrence takes place at, for mobile businesses
+	ServiceLocation *BookingLocation `json:"service_location,omitempty" db:"-"`
+
+	// Extended information
+	Bookings   []Booking                `json:"bookings,omitempty"`
+	Exceptions []BookingSeriesException `json:"exceptions,omitempty"`
+}
+
+// BookingSeriesException skips or moves a single occurrence of a series
+type BookingSeriesException struct {
+	ID             string     `json:"id" db:"id"`
+	SeriesID       string     `json:"series_id" db:"series_id"`
+	OccurrenceDate time.Time  `json:"occurrence_date" db:"occurrence_date"`
+	Type           string     `json:"type" db:"type"` // skip, move
+	NewDateTime    *time.Time `json:"new_date_time" db:"new_date_time"`
+	Reason         *string    `json:"reason" db:"reason"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+}
+
+// SeriesOccurrenceResult reports what happened to a single occurrence
+type SeriesOccurrenceResult struct {
+	OccurrenceDate string    `json:"occurrence_date"` // YYYY-MM-DD in company time
+	DateTime       time.Time `json:"date_time"`
+	Status         string    `json:"status"` // created, exists, skipped, conflict, cancelled, moved, failed
+	BookingID      *string   `json:"booking_id,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	Code           string    `json:"code,omitempty"` // SLOT_TAKEN for availability conflicts
+}
+
+// BookingSeriesResult is returned by series operations
+type BookingSeriesResult struct {
+	Series      *BookingSeries           `json:"series"`
+	Occurrences []SeriesOccurrenceResult `json:"occurrences"`
+	Conflicts   int                      `json:"conflicts"`
+}
+
+// CreateBookingSeriesRequest represents the request to create a recurring booking
+type CreateBookingSeriesRequest struct {
+	CompanyID     string    `json:"company_id" binding:"required"`
+	ServiceID     string    `json:"service_id" binding:"required"`
+	PetID         string    `json:"pet_id"`
+	EmployeeID    *string   `json:"employee_id"`
+	StartDateTime time.Time `json:"start_date_time" binding:"required"`
+	RRule         string    `json:"rrule" binding:"required"`
+	Notes         string    `json:"notes"`
+
+	Location *BookingLocation `json:"location"` // Required by walking, sitting and pet taxi businesses
+}
+
+// SkipOccurrenceRequest represents the request to skip one occurrence
+type SkipOccurrenceRequest struct {
+	OccurrenceDate string `json:"occurrence_date" binding:"required"` // YYYY-MM-DD
+	Reason         string `json:"reason"`
+}
+
+// MoveOccurrenceRequest represents the request to move one occurrence
+type MoveOccurrenceRequest struct {
+	OccurrenceDate string    `json:"occurrence_date" binding:"required"` // YYYY-MM-DD
+	NewDateTime    time.Time `json:"new_date_time" binding:"required"`
+	Reason         string    `json:"reason"`
+}
+
+// RescheduleSeriesRequest changes the start time and optionally the rule of all future occurrences
+type RescheduleSeriesRequest struct {
+	StartDateTime time.Time `json:"start_date_time" binding:"required"`
+	RRule         *string   `json:"rrule"`
+	Reason        string    `json:"reason"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Booking model is already defined in models.go
+
+// CalendarFeed is a secret iCalendar subscription URL for the bookings of a company, employee or customer
+type CalendarFeed struct {
+	ID             string     `json:"id" db:"id"`
+	OwnerType      string     `json:"owner_type" db:"owner_type"` // company, employee, user
+	OwnerID        string     `json:"owner_id" db:"owner_id"`
+	Token          string     `json:"-" db:"token"`
+	URL            string     `json:"url" db:"-"`
+	WebcalURL      string     `json:"webcal_url" db:"-"` // Opens the subscription dialog of calendar apps
+	LastAccessedAt *time.Time `json:"last_accessed_at" db:"last_accessed_at"`
+	CreatedAt      time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at" db:"updated_at"`
+}
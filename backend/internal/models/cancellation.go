@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Booking and Payment models are already defined in models.go
+
+// CancellationPolicy defines the fees charged when a customer cancels, reschedules or misses a booking
+type CancellationPolicy struct {
+	ID                  string                `json:"id" db:"id"`
+	CompanyID           string                `json:"company_id" db:"company_id"`
+	Name                string                `json:"name" db:"name"`
+	Description         *string               `json:"description" db:"description"`
+	FreeCancelHours     int                   `json:"free_cancel_hours" db:"free_cancel_hours"` // No fee this many hours or more before the start
+	FeeTiers            []CancellationFeeTier `json:"fee_tiers" db:"fee_tiers"`
+	NoShowFeePercentage float64               `json:"no_show_fee_percentage" db:"no_show_fee_percentage"`
+	ApplyToReschedule   bool                  `json:"apply_to_reschedule" db:"apply_to_reschedule"` // Charge the same fees when moving a booking
+	IsDefault           bool                  `json:"is_default" db:"is_default"`                   // Used by services without their own policy
+	CreatedAt           time.Time             `json:"created_at" db:"created_at"`
+	UpdatedAt           time.Time             `json:"updated_at" db:"updated_at"`
+}
+
+// CancellationFeeTier charges a percentage of the booking price when cancelling at least HoursBefore hours ahead
+type CancellationFeeTier struct {
+	HoursBefore   int     `json:"hours_before"`
+	FeePercentage float64 `json:"fee_percentage"`
+}
+
+// CancellationPolicyRequest represents the request to create or update a cancellation policy
+type CancellationPolicyRequest struct {
+	Name                string                `json:"name" binding:"required"`
+	Description         *string               `json:"description"`
+	FreeCancelHours     int                   `json:"free_cancel_hours"`
+	FeeTiers            []CancellationFeeTier `json:"fee_tiers"`
+	NoShowFeePercentage *float64              `json:"no_show_fee_percentage"`
+	ApplyToReschedule   bool                  `json:"apply_to_reschedule"`
+	IsDefault           bool                  `json:"is_default"`
+}
+
+// CancellationOutcome is the fee and refund computed for cancelling, rescheduling or missing a booking
+type CancellationOutcome struct {
+	BookingID             string     `json:"booking_id"`
+	Action                string     `json:"action"`       // cancel, reschedule, no_show
+	InitiatedBy           string     `json:"initiated_by"` // customer, company
+	PolicyID              *string    `json:"policy_id"`
+	PolicyName            string     `json:"policy_name"`
+	HoursBefore           float64    `json:"hours_before"`
+	FreeUntil             *time.Time `json:"free_until,omitempty"` // Last moment to cancel without a fee
+	FeePercentage         float64    `json:"fee_percentage"`
+	FeeAmount             Money      `json:"fee_amount"`
+	AmountPaid            Money      `json:"amount_paid"`
+	RefundAmount          Money      `json:"refund_amount"`
+	ChargeAmount          Money      `json:"charge_amount"` // Part of the fee not covered by the payment
+	Currency              string     `json:"currency"`
+	PaymentID             *string    `json:"payment_id,omitempty"`
+	RefundID              *string    `json:"refund_id,omitempty"`
+	ChargePaymentIntentID *string    `json:"charge_payment_intent_id,omitempty"`
+	ChargeClientSecret    string     `json:"charge_client_secret,omitempty"`
+	SettlementError       string     `json:"settlement_error,omitempty"` // Refund or charge that must be settled manually
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Service and Booking models are already defined in models.go
+
+// Course is a group class or multi-session training course with a fixed number of places
+type Course struct {
+	ID                 string          `json:"id" db:"id"`
+	CompanyID          string          `json:"company_id" db:"company_id"`
+	ServiceID          *string         `json:"service_id" db:"service_id"`
+	EmployeeID         *string         `json:"employee_id" db:"employee_id"` // Trainer
+	Name               string          `json:"name" db:"name"`
+	Description        *string         `json:"description" db:"description"`
+	Capacity           int             `json:"capacity" db:"capacity"`
+	Price              float64         `json:"price" db:"price"`
+	Currency           string          `json:"currency" db:"currency"`
+	Status             string          `json:"status" db:"status"` // draft, published, completed, cancelled
+	EnrollmentClosesAt *time.Time      `json:"enrollment_closes_at" db:"enrollment_closes_at"`
+	EnrolledCount      int             `json:"enrolled_count" db:"-"`
+	SpotsLeft          int             `json:"spots_left" db:"-"`
+	Sessions           []CourseSession `json:"sessions" db:"-"`
+	CreatedAt          time.Time       `json:"created_at" db:"created_at"`
+	UpdatedAt          time.Time       `json:"updated_at" db:"updated_at"`
+}
+
+// CourseRequest represents the request to create or update a course
+type CourseRequest struct {
+	Name               string                 `json:"name" binding:"required"`
+	Description        *string                `json:"description"`
+	ServiceID          *string                `json:"service_id"`
+	EmployeeID         *string                `json:"employee_id"`
+	Capacity           int                    `json:"capacity" binding:"required"`
+	Price              *float64               `json:"price"` // Defaults to the service price
+	Currency           string                 `json:"currency"`
+	Status             string                 `json:"status"`
+	EnrollmentClosesAt *time.Time             `json:"enrollment_closes_at"`
+	Sessions           []CourseSessionRequest `json:"sessions"` // Only used on create
+}
+
+// CourseSession is one meeting of a course
+type CourseSession struct {
+	ID        string    `json:"id" db:"id"`
+	CourseID  string    `json:"course_id" db:"course_id"`
+	StartsAt  time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt    time.Time `json:"ends_at" db:"ends_at"`
+	Location  *string   `json:"location" db:"location"`
+	Status    string    `json:"status" db:"status"` // scheduled, cancelled
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CourseSessionRequest represents the request to add a session to a course
+type CourseSessionRequest struct {
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Location *string   `json:"location"`
+}
+
+// CourseEnrollment is a pet's place in a course, paid for once for all sessions
+type CourseEnrollment struct {
+	ID               string     `json:"id" db:"id"`
+	CourseID         string     `json:"course_id" db:"course_id"`
+	UserID           string     `json:"user_id" db:"user_id"`
+	PetID            string     `json:"pet_id" db:"pet_id"`
+	Status           string     `json:"status" db:"status"` // pending_payment, enrolled, completed, cancelled
+	Price            float64    `json:"price" db:"price"`
+	PaymentIntentID  *string    `json:"payment_intent_id" db:"payment_intent_id"`
+	PaymentID        *string    `json:"payment_id" db:"payment_id"`
+	PaymentExpiresAt *time.Time `json:"payment_expires_at" db:"payment_expires_at"`
+	ClientSecret     string     `json:"client_secret,omitempty" db:"-"`
+	FeeAmount        float64    `json:"fee_amount" db:"fee_amount"`
+	RefundAmount     float64    `json:"refund_amount" db:"refund_amount"`
+	CancelledBy      *string    `json:"cancelled_by" db:"cancelled_by"`
+	CancelledAt      *time.Time `json:"cancelled_at" db:"cancelled_at"`
+	CreatedAt        time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// EnrollCourseRequest represents the request to enroll a pet in a course
+type EnrollCourseRequest struct {
+	PetID string `json:"pet_id" binding:"required"`
+}
+
+// CourseEnrollmentCancellation is the fee and refund for leaving a course, under the policy of its service
+type CourseEnrollmentCancellation struct {
+	EnrollmentID  string  `json:"enrollment_id"`
+	PolicyName    string  `json:"policy_name"`
+	HoursBefore   float64 `json:"hours_before"` // Until the first session; negative once the course has started
+	FeePercentage float64 `json:"fee_percentage"`
+	FeeAmount     float64 `json:"fee_amount"`
+	AmountPaid    float64 `json:"amount_paid"`
+	RefundAmount  float64 `json:"refund_amount"`
+}
+
+// CourseRosterEntry is an enrolled pet on the roster of a session
+type CourseRosterEntry struct {
+	EnrollmentID    string  `json:"enrollment_id"`
+	UserID          string  `json:"user_id"`
+	CustomerName    string  `json:"customer_name"`
+	CustomerPhone   string  `json:"customer_phone"`
+	PetID           string  `json:"pet_id"`
+	PetName         string  `json:"pet_name"`
+	Attendance      *string `json:"attendance"` // present, absent, excused; nil until marked
+	AttendanceNotes *string `json:"attendance_notes"`
+}
+
+// CourseAttendanceRequest records attendance of a session
+type CourseAttendanceRequest struct {
+	Entries []CourseAttendanceEntry `json:"entries" binding:"required"`
+}
+
+// CourseAttendanceEntry is the attendance of one enrollment
+type CourseAttendanceEntry struct {
+	EnrollmentID string  `json:"enrollment_id" binding:"required"`
+	Status       string  `json:"status" binding:"required"`
+	Notes        *string `json:"notes"`
+}
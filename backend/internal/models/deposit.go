@@ -0,0 +1,18 @@
+package models
+
+// Note: Service and Booking models are already defined in models.go
+
+// ServiceDeposit is the prepayment a service requires before a booking is confirmed
+type ServiceDeposit struct {
+	ServiceID    string  `json:"service_id" db:"id"`
+	DepositType  string  `json:"deposit_type" db:"deposit_type"`                 // none, fixed, percentage
+	DepositValue float64 `json:"deposit_value" db:"deposit_value"`               // Amount, or percentage of the price
+	HoldMinutes  int     `json:"deposit_hold_minutes" db:"deposit_hold_minutes"` // Slot is held this long while unpaid
+}
+
+// ServiceDepositRequest represents the request to configure the deposit of a service
+type ServiceDepositRequest struct {
+	DepositType  string  `json:"deposit_type" binding:"required"`
+	DepositValue float64 `json:"deposit_value"`
+	HoldMinutes  int     `json:"deposit_hold_minutes"`
+}
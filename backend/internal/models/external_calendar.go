@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Employee model is already defined in models.go
+
+// ExternalCalendar is an ICS calendar imported from a URL or an uploaded file.
+// Its events are stored as busy blocks of the employee, or of the whole company when EmployeeID is nil.
+type ExternalCalendar struct {
+	ID           string     `json:"id" db:"id"`
+	CompanyID    string     `json:"company_id" db:"company_id"`
+	EmployeeID   *string    `json:"employee_id" db:"employee_id"`
+	Name         string     `json:"name" db:"name"`
+	SourceType   string     `json:"source_type" db:"source_type"` // url, upload
+	URL          *string    `json:"url" db:"url"`
+	EventCount   int        `json:"event_count" db:"event_count"`
+	LastSyncedAt *time.Time `json:"last_synced_at" db:"last_synced_at"`
+	LastError    *string    `json:"last_error" db:"last_error"`
+	CreatedAt    time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// ExternalCalendarRequest registers a calendar by URL or by the content of an uploaded .ics file
+type ExternalCalendarRequest struct {
+	Name       string `json:"name" binding:"required"`
+	URL        string `json:"url"`
+	ICSContent string `json:"ics_content"`
+}
+
+// ExternalBusyBlock is one imported event occurrence during which the owner cannot take bookings
+type ExternalBusyBlock struct {
+	ID         string    `json:"id" db:"id"`
+	CalendarID string    `json:"calendar_id" db:"calendar_id"`
+	EmployeeID *string   `json:"employee_id" db:"employee_id"`
+	StartsAt   time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time `json:"ends_at" db:"ends_at"`
+}
@@ -0,0 +1,61 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Service and Booking models are already defined in models.go
+
+// IntakeForm is a questionnaire a company asks before a visit, e.g. symptoms or feeding instructions
+type IntakeForm struct {
+	ID          string            `json:"id" db:"id"`
+	CompanyID   string            `json:"company_id" db:"company_id"`
+	Name        string            `json:"name" db:"name"`
+	Description *string           `json:"description" db:"description"`
+	Fields      []IntakeFormField `json:"fields" db:"fields"`
+	Completion  string            `json:"completion" db:"completion"` // at_booking, before_visit
+	IsActive    bool              `json:"is_active" db:"is_active"`
+	ServiceIDs  []string          `json:"service_ids" db:"-"`
+	CreatedAt   time.Time         `json:"created_at" db:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at" db:"updated_at"`
+}
+
+// IntakeFormField is a question of a form
+type IntakeFormField struct {
+	Key      string   `json:"key" binding:"required"` // Answers are keyed by it
+	Label    string   `json:"label" binding:"required"`
+	Type     string   `json:"type" binding:"required"` // text, choice, checkbox, file
+	Required bool     `json:"required"`
+	Options  []string `json:"options,omitempty"`  // Choices of a choice field
+	Multiple bool     `json:"multiple,omitempty"` // A choice field accepting several options
+}
+
+// IntakeFormRequest represents the request to create or update an intake form
+type IntakeFormRequest struct {
+	Name        string            `json:"name" binding:"required"`
+	Description *string           `json:"description"`
+	Fields      []IntakeFormField `json:"fields" binding:"required,dive"`
+	Completion  string            `json:"completion"`
+	ServiceIDs  []string          `json:"service_ids"` // Services the form is asked for
+	IsActive    *bool             `json:"is_active"`
+}
+
+// IntakeSubmission holds the answers to one form; file fields take the URL of an uploaded file
+type IntakeSubmission struct {
+	FormID  string                 `json:"form_id" binding:"required"`
+	Answers map[string]interface{} `json:"answers" binding:"required"`
+}
+
+// BookingIntake is a form asked for a booking with its answers, if given
+type BookingIntake struct {
+	ID          *string                `json:"id"` // Nil while the form is outstanding
+	BookingID   string                 `json:"booking_id"`
+	FormID      *string                `json:"form_id"`
+	FormName    string                 `json:"form_name"`
+	Completion  string                 `json:"completion,omitempty"`
+	Fields      []IntakeFormField      `json:"fields"`
+	Answers     map[string]interface{} `json:"answers,omitempty"`
+	Completed   bool                   `json:"completed"`
+	SubmittedBy *string                `json:"submitted_by,omitempty"`
+	SubmittedAt *time.Time             `json:"submitted_at,omitempty"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Service and Booking models are already defined in models.go
+
+// LedgerEntry is one line of a ledger transaction. Amount is signed by the account's normal balance:
+// positive increases what the account holds (money owed to a company, revenue, cash, expenses).
+type LedgerEntry struct {
+	TransactionID string    `json:"transaction_id" db:"transaction_id"`
+	Kind          string    `json:"kind" db:"kind"` // payment, release, refund, dispute, processor_fee, payout
+	PaymentID     *string   `json:"payment_id" db:"payment_id"`
+	Account       string    `json:"account" db:"account"`
+	Amount        Money     `json:"amount" db:"amount"`
+	Currency      string    `json:"currency" db:"currency"`
+	Description   string    `json:"description" db:"description"`
+	CreatedAt     time.Time `json:"created_at" db:"created_at"`
+}
+
+// CompanyLedgerBalance is what the platform holds for a company in one currency
+type CompanyLedgerBalance struct {
+	Currency  string `json:"currency"`
+	Escrow    Money  `json:"escrow"`    // Paid by customers for services not completed yet
+	Available Money  `json:"available"` // Released to the company and not paid out yet
+}
+
+// LedgerAccountBalance is the balance of an account, signed by its normal balance
+type LedgerAccountBalance struct {
+	Account     string `json:"account"`
+	AccountType string `json:"account_type"` // asset, liability, revenue, expense
+	Currency    string `json:"currency"`
+	Balance     Money  `json:"balance"`
+}
+
+// LedgerDiscrepancy is a payment whose ledger postings disagree with its status
+type LedgerDiscrepancy struct {
+	PaymentID string `json:"payment_id"`
+	Issue     string `json:"issue"`
+}
+
+// LedgerReconciliation checks the ledger's invariants and its agreement with the payments
+type LedgerReconciliation struct {
+	GeneratedAt            time.Time              `json:"generated_at"`
+	Balanced               bool                   `json:"balanced"` // Debits equal credits in every transaction and currency
+	TrialBalance           []LedgerAccountBalance `json:"trial_balance"`
+	UnbalancedTransactions []string               `json:"unbalanced_transactions"`
+	Discrepancies          []LedgerDiscrepancy    `json:"discrepancies"`
+}
@@ -193,6 +193,7 @@ type PaymentSettings struct {
 	StripePublishableKey string    `json:"stripe_publishable_key" db:"stripe_publishable_key"`
 	StripeSecretKey      string    `json:"stripe_secret_key" db:"stripe_secret_key"`
 	StripeWebhookSecret  string    `json:"stripe_webhook_secret" db:"stripe_webhook_secret"`
+	PaymentProvider      string    `json:"payment_provider" db:"payment_provider"` // stripe, fake
 	CreatedAt            time.Time `json:"created_at" db:"created_at"`
 	UpdatedAt            time.Time `json:"updated_at" db:"updated_at"`
 }
@@ -209,6 +210,7 @@ type Breed struct {
 	ID        string    `json:"id" db:"id"`
 	Name      string    `json:"name" db:"name"`
 	PetTypeID string    `json:"pet_type_id" db:"pet_type_id"`
+	SizeClass *string   `json:"size_class" db:"size_class"` // toy, small, medium, large, giant
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 }
 
@@ -381,6 +383,7 @@ type Company struct {
 	LogoURL                   string         `json:"logo_url" db:"logo_url"`
 	MediaGallery              pq.StringArray `json:"media_gallery" db:"media_gallery"`
 	BusinessHours             string         `json:"business_hours" db:"business_hours"`
+	Timezone                  string         `json:"timezone" db:"timezone"` // IANA name, e.g. Europe/Moscow
 	PlanID                    string         `json:"plan_id" db:"plan_id"`
 	TrialExpired              bool           `json:"trial_expired" db:"trial_expired"`
 	TrialEndsAt               *time.Time     `json:"trial_ends_at" db:"trial_ends_at"`
@@ -594,6 +597,7 @@ const (
 	PermissionViewEmployees   = "view_employees"
 	PermissionManageEmployees = "manage_employees"
 	PermissionViewSalaries    = "view_salaries"
+	PermissionManageSchedules = "manage_schedules" // Shifts, time-off and breaks
 
 	// Service management
 	PermissionViewServices   = "view_services"
@@ -700,8 +704,8 @@ type Service struct {
 	CategoryID         string         `json:"category_id" db:"category_id"`
 	Name               string         `json:"name" db:"name"`
 	Description        string         `json:"description" db:"description"`
-	Price              float64        `json:"price" db:"price"`
-	OriginalPrice      *float64       `json:"original_price" db:"original_price"`
+	Price              Money          `json:"price" db:"price"`
+	OriginalPrice      *Money         `json:"original_price" db:"original_price"`
 	DiscountPercentage *int           `json:"discount_percentage" db:"discount_percentage"`
 	IsOnSale           bool           `json:"is_on_sale" db:"is_on_sale"`
 	SaleStartDate      *time.Time     `json:"sale_start_date" db:"sale_start_date"`
@@ -722,6 +726,9 @@ type Service struct {
 	IsActive           bool           `json:"is_active" db:"is_active"`
 	CreatedAt          time.Time      `json:"created_at" db:"created_at"`
 	UpdatedAt          time.Time      `json:"updated_at" db:"updated_at"`
+
+	// Spread across pet sizes, set on public listings of services with a price matrix
+	PriceRange *ServicePriceRange `json:"price_range,omitempty" db:"-"`
 }
 
 // Product represents a company product
@@ -887,7 +894,7 @@ type Order struct {
 	UserID                string         `json:"user_id" db:"user_id"`
 	CompanyID             string         `json:"company_id" db:"company_id"`
 	Status                string         `json:"status" db:"status"`
-	TotalAmount           float64        `json:"total_amount" db:"total_amount"`
+	TotalAmount           Money          `json:"total_amount" db:"total_amount"`
 	PaymentID             *string        `json:"payment_id" db:"payment_id"`
 	PaymentStatus         string         `json:"payment_status" db:"payment_status"`
 	Items                 pq.StringArray `json:"items" db:"items"` // JSON array
@@ -914,12 +921,40 @@ type Booking struct {
 	EmployeeID *string   `json:"employee_id" db:"employee_id"`
 	DateTime   time.Time `json:"date_time" db:"date_time"`
 	Duration   int       `json:"duration" db:"duration"` // in minutes
-	Price      float64   `json:"price" db:"price"`
-	Status     string    `json:"status" db:"status"` // pending, confirmed, in_progress, completed, cancelled, rejected
+	Price      Money     `json:"price" db:"price"`
+	Status     string    `json:"status" db:"status"` // pending_payment, pending, confirmed, in_progress, completed, cancelled, rejected
 	Notes      *string   `json:"notes" db:"notes"`
 	PaymentID  *string   `json:"payment_id" db:"payment_id"`
-	CreatedAt  time.Time `json:"created_at" db:"created_at"`
-	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+	SeriesID   *string   `json:"series_id,omitempty" db:"series_id"` // Set for bookings generated from a recurring series
+
+	// Boarding stays cover a date range in an assigned kennel
+	CheckInDate  *time.Time `json:"check_in_date,omitempty" db:"check_in_date"`
+	CheckOutDate *time.Time `json:"check_out_date,omitempty" db:"check_out_date"`
+	KennelID     *string    `json:"kennel_id,omitempty" db:"kennel_id"`
+
+	// Deposit that must be paid before payment_expires_at, or the slot is released
+	DepositAmount          *Money     `json:"deposit_amount,omitempty" db:"deposit_amount"`
+	DepositPaymentIntentID *string    `json:"deposit_payment_intent_id,omitempty" db:"deposit_payment_intent_id"`
+	DepositClientSecret    string     `json:"deposit_client_secret,omitempty" db:"-"`
+	PaymentExpiresAt       *time.Time `json:"payment_expires_at,omitempty" db:"payment_expires_at"`
+
+	// Options chosen with the service; price and duration above already include them
+	OptionsPrice Money           `json:"options_price" db:"options_price"`
+	Options      []BookingOption `json:"options,omitempty" db:"-"`
+
+	// Set when front desk staff scan the booking's QR code
+	CheckedInAt  *time.Time        `json:"checked_in_at,omitempty" db:"checked_in_at"`
+	CheckedOutAt *time.Time        `json:"checked_out_at,omitempty" db:"checked_out_at"`
+	Handovers    []BookingHandover `json:"handovers,omitempty" db:"-"`
+
+	// Customer address of walking, sitting and pet taxi appointments
+	ServiceLocation *BookingLocation `json:"service_location,omitempty" db:"-"`
+
+	// Waiver signature the customer booked under, for services requiring one
+	WaiverSignatureID *string `json:"waiver_signature_id,omitempty" db:"waiver_signature_id"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
 
 	// Extended information for company views
 	CustomerInfo *CustomerInfo `json:"customer_info,omitempty"`
@@ -1112,8 +1147,8 @@ type CartItem struct {
 	ItemType            string    `json:"item_type" db:"item_type"`
 	ItemID              string    `json:"item_id" db:"item_id"`
 	Quantity            int       `json:"quantity" db:"quantity"`
-	UnitPrice           float64   `json:"unit_price" db:"unit_price"`
-	TotalPrice          float64   `json:"total_price" db:"total_price"`
+	UnitPrice           Money     `json:"unit_price" db:"unit_price"`
+	TotalPrice          Money     `json:"total_price" db:"total_price"`
 	SelectedOptions     string    `json:"selected_options" db:"selected_options"`
 	SpecialInstructions string    `json:"special_instructions" db:"special_instructions"`
 	CreatedAt           time.Time `json:"created_at" db:"created_at"`
@@ -1121,11 +1156,11 @@ type CartItem struct {
 }
 
 type CartTotal struct {
-	Subtotal       float64 `json:"subtotal"`
-	DiscountAmount float64 `json:"discount_amount"`
-	TaxAmount      float64 `json:"tax_amount"`
-	Total          float64 `json:"total"`
-	ItemCount      int     `json:"item_count"`
+	Subtotal       Money `json:"subtotal"`
+	DiscountAmount Money `json:"discount_amount"`
+	TaxAmount      Money `json:"tax_amount"`
+	Total          Money `json:"total"`
+	ItemCount      int   `json:"item_count"`
 }
 
 type SavedItem struct {
@@ -1177,18 +1212,26 @@ type Payment struct {
 	BookingID             *string    `json:"booking_id" db:"booking_id"`
 	OrderID               *string    `json:"order_id" db:"order_id"`
 	StripePaymentIntentID string     `json:"stripe_payment_intent_id" db:"stripe_payment_intent_id"`
-	Amount                float64    `json:"amount" db:"amount"`
+	Amount                Money      `json:"amount" db:"amount"`
 	Currency              string     `json:"currency" db:"currency"`
-	Status                string     `json:"status" db:"status"` // pending, succeeded, failed, canceled, refunded, partially_refunded
-	CommissionAmount      float64    `json:"commission_amount" db:"commission_amount"`
-	PlatformAmount        float64    `json:"platform_amount" db:"platform_amount"` // Amount held by platform (with commission)
-	CompanyAmount         float64    `json:"company_amount" db:"company_amount"`   // Amount to be transferred to company
+	Status                string     `json:"status" db:"status"` // pending, processing, authorized, succeeded, failed, canceled, refunded, partially_refunded, disputed, dispute_lost
+	CommissionAmount      Money      `json:"commission_amount" db:"commission_amount"`
+	PlatformAmount        Money      `json:"platform_amount" db:"platform_amount"` // Amount held by platform (with commission)
+	CompanyAmount         Money      `json:"company_amount" db:"company_amount"`   // Amount to be transferred to company
 	TransferredAt         *time.Time `json:"transferred_at" db:"transferred_at"`   // When money was transferred to company
 	PaymentMethodType     string     `json:"payment_method_type" db:"payment_method_type"`
 	CreatedAt             time.Time  `json:"created_at" db:"created_at"`
 	UpdatedAt             time.Time  `json:"updated_at" db:"updated_at"`
 }
 
+// SetCurrency reads the payment's amounts in its currency, once its row is scanned
+func (p *Payment) SetCurrency() {
+	p.Amount = p.Amount.WithCurrency(p.Currency)
+	p.CommissionAmount = p.CommissionAmount.WithCurrency(p.Currency)
+	p.PlatformAmount = p.PlatformAmount.WithCurrency(p.Currency)
+	p.CompanyAmount = p.CompanyAmount.WithCurrency(p.Currency)
+}
+
 // FileUpload represents uploaded files
 type FileUpload struct {
 	ID           string    `json:"id" db:"id"`
@@ -1242,7 +1285,7 @@ type Refund struct {
 	ID             string    `json:"id" db:"id"`
 	PaymentID      string    `json:"payment_id" db:"payment_id"`
 	StripeRefundID string    `json:"stripe_refund_id" db:"stripe_refund_id"`
-	Amount         float64   `json:"amount" db:"amount"`
+	Amount         Money     `json:"amount" db:"amount"`
 	Reason         string    `json:"reason" db:"reason"`
 	Status         string    `json:"status" db:"status"` // pending, succeeded, failed
 	CreatedAt      time.Time `json:"created_at" db:"created_at"`
@@ -1337,8 +1380,8 @@ type CurrencyConversionRequest struct {
 type CurrencyConversionResponse struct {
 	FromCurrency    string    `json:"from_currency"`
 	ToCurrency      string    `json:"to_currency"`
-	OriginalAmount  float64   `json:"original_amount"`
-	ConvertedAmount float64   `json:"converted_amount"`
+	OriginalAmount  Money     `json:"original_amount"`
+	ConvertedAmount Money     `json:"converted_amount"`
 	ExchangeRate    float64   `json:"exchange_rate"`
 	LastUpdated     time.Time `json:"last_updated"`
 }
@@ -0,0 +1,352 @@
+package models
+
+import (
+	"bytes"
+	"database/sql/driver"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DefaultCurrency is the currency of amounts stored without one
+const DefaultCurrency = "USD"
+
+// maxAmountShift bounds the exponent of amounts written in exponent form; larger ones cannot fit in int64
+const maxAmountShift = 64
+
+// currencyExponents lists the ISO 4217 currencies whose minor unit is not a hundredth
+var currencyExponents = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// Money is an amount in the minor unit of its currency (cents for USD), so that sums and splits are exact.
+// Rounding is explicit and always half away from zero, to the currency's minor unit:
+//   - decimals with more digits than the currency has, from JSON, SQL or float64 amounts
+//   - percentages, which are applied in hundredths of a percent
+//   - currency conversion
+//
+// In JSON and SQL it is a decimal number, the format prices and payments had as float64; its currency
+// travels in a field next to it. An empty currency is DefaultCurrency.
+type Money struct {
+	Amount   int64  // Minor units
+	Currency string // ISO 4217 code
+}
+
+// NewMoney returns an amount given in minor units
+func NewMoney(amount int64, currency string) Money {
+	return Money{Amount: amount, Currency: strings.ToUpper(currency)}
+}
+
+// MoneyFromDecimal returns a decimal amount, rounded to the currency's minor unit
+func MoneyFromDecimal(amount float64, currency string) Money {
+	scaled := amount * math.Pow10(CurrencyExponent(currency))
+	return NewMoney(int64(math.Round(scaled)), currency)
+}
+
+// ParseMoney reads a decimal such as "12.5" or "-0.125" exactly, rounding digits past the currency's minor unit
+func ParseMoney(value, currency string) (Money, error) {
+	amount, err := parseMinorUnits(strings.TrimSpace(value), CurrencyExponent(currency))
+	if err != nil {
+		return Money{}, err
+	}
+	return NewMoney(amount, currency), nil
+}
+
+// CurrencyExponent returns how many decimal digits a currency's minor unit has
+func CurrencyExponent(currency string) int {
+	if exponent, ok := currencyExponents[strings.ToUpper(currency)]; ok {
+		return exponent
+	}
+	return 2
+}
+
+// CurrencyExponents returns the currencies whose minor unit is not a hundredth, with their exponents
+func CurrencyExponents() map[string]int {
+	exponents := make(map[string]int, len(currencyExponents))
+	for currency, exponent := range currencyExponents {
+		exponents[currency] = exponent
+	}
+	return exponents
+}
+
+// Code returns the money's currency code
+func (m Money) Code() string {
+	if m.Currency == "" {
+		return DefaultCurrency
+	}
+	return m.Currency
+}
+
+// Decimal returns the amount in major units, for code that still works in float64
+func (m Money) Decimal() float64 {
+	return float64(m.Amount) / math.Pow10(CurrencyExponent(m.Currency))
+}
+
+// String formats the amount as a decimal with the currency's digits, e.g. "12.50"
+func (m Money) String() string {
+	exponent := CurrencyExponent(m.Currency)
+	amount := m.Amount
+	sign := ""
+	if amount < 0 {
+		sign = "-"
+		amount = -amount
+	}
+
+	digits := strconv.FormatInt(amount, 10)
+	if exponent == 0 {
+		return sign + digits
+	}
+	if len(digits) <= exponent {
+		digits = strings.Repeat("0", exponent-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-exponent] + "." + digits[len(digits)-exponent:]
+}
+
+func (m Money) IsZero() bool {
+	return m.Amount == 0
+}
+
+func (m Money) IsNegative() bool {
+	return m.Amount < 0
+}
+
+// Add returns the sum; both amounts must be in the same currency
+func (m Money) Add(other Money) Money {
+	return Money{Amount: m.Amount + other.Amount, Currency: m.Currency}
+}
+
+// Sub returns the difference; both amounts must be in the same currency
+func (m Money) Sub(other Money) Money {
+	return Money{Amount: m.Amount - other.Amount, Currency: m.Currency}
+}
+
+// Mul returns the amount times a quantity
+func (m Money) Mul(quantity int64) Money {
+	return Money{Amount: m.Amount * quantity, Currency: m.Currency}
+}
+
+// Percent returns a percentage of the amount. The percentage is taken to hundredths of a percent,
+// the precision percentages are stored with.
+func (m Money) Percent(percentage float64) Money {
+	basisPoints := int64(math.Round(percentage * 100))
+	return Money{Amount: divRound(m.Amount*basisPoints, 10000), Currency: m.Currency}
+}
+
+// Split takes a percentage off the amount and returns it with the rest; the two always add up to the amount
+func (m Money) Split(percentage float64) (part, rest Money) {
+	part = m.Percent(percentage)
+	return part, m.Sub(part)
+}
+
+// Allocate divides the amount in proportion to weights; the minor units rounding leaves over go to the first shares
+func (m Money) Allocate(weights ...int64) []Money {
+	shares := make([]Money, len(weights))
+	var total int64
+	for _, weight := range weights {
+		total += weight
+	}
+	if total <= 0 {
+		for i := range shares {
+			shares[i] = Money{Currency: m.Currency}
+		}
+		return shares
+	}
+
+	remainder := m.Amount
+	for i, weight := range weights {
+		shares[i] = Money{Amount: m.Amount * weight / total, Currency: m.Currency}
+		remainder -= shares[i].Amount
+	}
+
+	step := int64(1)
+	if remainder < 0 {
+		step = -1
+	}
+	for i := 0; remainder != 0; i = (i + 1) % len(shares) {
+		if weights[i] > 0 {
+			shares[i].Amount += step
+			remainder -= step
+		}
+	}
+	return shares
+}
+
+// Convert returns the amount in another currency at an exchange rate, rounded to that currency's minor unit
+func (m Money) Convert(rate float64, currency string) Money {
+	scale := math.Pow10(CurrencyExponent(currency) - CurrencyExponent(m.Currency))
+	return NewMoney(int64(math.Round(float64(m.Amount)*rate*scale)), currency)
+}
+
+// WithCurrency returns the same decimal amount in a currency, e.g. once the currency column of a row is read
+func (m Money) WithCurrency(currency string) Money {
+	from, to := CurrencyExponent(m.Currency), CurrencyExponent(currency)
+	amount := m.Amount
+	switch {
+	case to > from:
+		amount *= int64(math.Pow10(to - from))
+	case to < from:
+		amount = divRound(amount, int64(math.Pow10(from-to)))
+	}
+	return NewMoney(amount, currency)
+}
+
+// MarshalJSON writes the amount as a decimal number
+func (m Money) MarshalJSON() ([]byte, error) {
+	return []byte(m.String()), nil
+}
+
+// UnmarshalJSON reads a number, in decimal or exponent form, or a quoted decimal, keeping the currency already set
+func (m *Money) UnmarshalJSON(data []byte) error {
+	data = bytes.TrimSpace(data)
+	if string(data) == "null" {
+		m.Amount = 0
+		return nil
+	}
+
+	value := strings.Trim(string(data), `"`)
+	amount, err := parseMinorUnits(value, CurrencyExponent(m.Currency))
+	if err != nil {
+		return err
+	}
+	m.Amount = amount
+	return nil
+}
+
+// Value stores the amount as a decimal
+func (m Money) Value() (driver.Value, error) {
+	return m.String(), nil
+}
+
+// Scan reads a decimal column, keeping the currency already set
+func (m *Money) Scan(src interface{}) error {
+	exponent := CurrencyExponent(m.Currency)
+
+	switch value := src.(type) {
+	case nil:
+		m.Amount = 0
+		return nil
+	case []byte:
+		amount, err := parseMinorUnits(string(value), exponent)
+		if err != nil {
+			return err
+		}
+		m.Amount = amount
+	case string:
+		amount, err := parseMinorUnits(value, exponent)
+		if err != nil {
+			return err
+		}
+		m.Amount = amount
+	case int64:
+		m.Amount = value * int64(math.Pow10(exponent))
+	case float64:
+		m.Amount = int64(math.Round(value * math.Pow10(exponent)))
+	default:
+		return fmt.Errorf("cannot scan %T into Money", src)
+	}
+	return nil
+}
+
+// Helper functions
+
+// parseMinorUnits reads a decimal, possibly in exponent form such as "1e2", into units of 10^-exponent,
+// rounding half away from zero
+func parseMinorUnits(value string, exponent int) (int64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("invalid amount: empty")
+	}
+	original := value
+
+	negative := false
+	switch value[0] {
+	case '-':
+		negative = true
+		value = value[1:]
+	case '+':
+		value = value[1:]
+	}
+
+	shift := 0
+	if e := strings.IndexAny(value, "eE"); e >= 0 {
+		var err error
+		shift, err = strconv.Atoi(value[e+1:])
+		if err != nil || shift > maxAmountShift || shift < -maxAmountShift {
+			return 0, fmt.Errorf("invalid amount: %q", original)
+		}
+		value = value[:e]
+	}
+
+	whole, fraction := value, ""
+	if dot := strings.IndexByte(value, '.'); dot >= 0 {
+		whole, fraction = value[:dot], value[dot+1:]
+	}
+	if whole == "" && fraction == "" {
+		return 0, fmt.Errorf("invalid amount: %q", original)
+	}
+	for _, digit := range whole + fraction {
+		if digit < '0' || digit > '9' {
+			return 0, fmt.Errorf("invalid amount: %q", original)
+		}
+	}
+	whole, fraction = shiftDecimalPoint(whole, fraction, shift)
+
+	roundUp := false
+	if len(fraction) > exponent {
+		roundUp = fraction[exponent] >= '5'
+		fraction = fraction[:exponent]
+	} else {
+		fraction += strings.Repeat("0", exponent-len(fraction))
+	}
+
+	amount, err := strconv.ParseInt("0"+whole+fraction, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid amount: %q", original)
+	}
+	if roundUp {
+		amount++
+	}
+	if negative {
+		amount = -amount
+	}
+	return amount, nil
+}
+
+// shiftDecimalPoint moves the decimal point of whole.fraction by shift places, to the right when positive
+func shiftDecimalPoint(whole, fraction string, shift int) (string, string) {
+	digits := whole + fraction
+	point := len(whole) + shift
+	if point < 0 {
+		digits = strings.Repeat("0", -point) + digits
+		point = 0
+	}
+	if point > len(digits) {
+		digits += strings.Repeat("0", point-len(digits))
+	}
+	return digits[:point], digits[point:]
+}
+
+// divRound divides, rounding half away from zero
+func divRound(a, b int64) int64 {
+	quotient, remainder := a/b, a%b
+	if remainder < 0 {
+		remainder = -remainder
+	}
+	if 2*remainder >= abs64(b) {
+		if (a < 0) != (b < 0) {
+			quotient--
+		} else {
+			quotient++
+		}
+	}
+	return quotient
+}
+
+func abs64(value int64) int64 {
+	if value < 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,217 @@
+package models
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestCurrencyExponent(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     int
+	}{
+		{"USD", 2},
+		{"", 2},
+		{"eur", 2},
+		{"JPY", 0},
+		{"krw", 0},
+		{"KWD", 3},
+		{"BHD", 3},
+	}
+
+	for _, tt := range tests {
+		if got := CurrencyExponent(tt.currency); got != tt.want {
+			t.Errorf("CurrencyExponent(%q) = %d, want %d", tt.currency, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyFromDecimal(t *testing.T) {
+	tests := []struct {
+		amount   float64
+		currency string
+		want     int64
+	}{
+		{12.5, "USD", 1250},
+		{0.125, "USD", 13},
+		{-0.125, "USD", -13},
+		{0.1 + 0.2, "USD", 30},
+		{1500.5, "JPY", 1501},
+		{-1500.5, "JPY", -1501},
+		{1.5, "KWD", 1500},
+	}
+
+	for _, tt := range tests {
+		got := MoneyFromDecimal(tt.amount, tt.currency)
+		if got.Amount != tt.want {
+			t.Errorf("MoneyFromDecimal(%v, %q) = %d, want %d", tt.amount, tt.currency, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestParseMoney(t *testing.T) {
+	tests := []struct {
+		value    string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{"12.5", "USD", 1250, false},
+		{"0.125", "USD", 13, false},
+		{"-0.125", "USD", -13, false},
+		{"0.124", "USD", 12, false},
+		{"+3", "USD", 300, false},
+		{".5", "USD", 50, false},
+		{"1e2", "USD", 10000, false},
+		{"1.5E-1", "USD", 15, false},
+		{"-2.5e-3", "USD", 0, false},
+		{"5e-3", "USD", 1, false},
+		{"1.25e1", "JPY", 13, false},
+		{"1.2345", "KWD", 1235, false},
+		{"", "USD", 0, true},
+		{"abc", "USD", 0, true},
+		{"1e", "USD", 0, true},
+		{"e2", "USD", 0, true},
+		{"1e999", "USD", 0, true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseMoney(tt.value, tt.currency)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseMoney(%q, %q) error = %v, wantErr %v", tt.value, tt.currency, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got.Amount != tt.want {
+			t.Errorf("ParseMoney(%q, %q) = %d, want %d", tt.value, tt.currency, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneyPercent(t *testing.T) {
+	tests := []struct {
+		amount     int64
+		percentage float64
+		want       int64
+	}{
+		{1000, 10, 100},
+		{1005, 10, 101},   // 100.5 rounds up
+		{1004, 10, 100},   // 100.4 rounds down
+		{-1005, 10, -101}, // away from zero
+		{999, 33.33, 333},
+		{1, 50, 1},
+		{-1, 50, -1},
+		{1000, 0, 0},
+	}
+
+	for _, tt := range tests {
+		got := NewMoney(tt.amount, "USD").Percent(tt.percentage)
+		if got.Amount != tt.want {
+			t.Errorf("%d.Percent(%v) = %d, want %d", tt.amount, tt.percentage, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneySplitAndAllocate(t *testing.T) {
+	total := NewMoney(1001, "USD")
+
+	part, rest := total.Split(15)
+	if part.Amount != 150 || part.Add(rest) != total {
+		t.Errorf("Split(15) = %d + %d, want 150 adding up to %d", part.Amount, rest.Amount, total.Amount)
+	}
+
+	shares := total.Allocate(1, 1, 1)
+	want := []int64{334, 334, 333}
+	var sum int64
+	for i, share := range shares {
+		if share.Amount != want[i] {
+			t.Errorf("Allocate(1, 1, 1)[%d] = %d, want %d", i, share.Amount, want[i])
+		}
+		sum += share.Amount
+	}
+	if sum != total.Amount {
+		t.Errorf("Allocate(1, 1, 1) adds up to %d, want %d", sum, total.Amount)
+	}
+
+	shares = NewMoney(-100, "USD").Allocate(0, 3)
+	if shares[0].Amount != 0 || shares[1].Amount != -100 {
+		t.Errorf("Allocate(0, 3) = %d, %d; want 0, -100", shares[0].Amount, shares[1].Amount)
+	}
+}
+
+func TestMoneyString(t *testing.T) {
+	tests := []struct {
+		money Money
+		want  string
+	}{
+		{NewMoney(1250, "USD"), "12.50"},
+		{NewMoney(5, ""), "0.05"},
+		{NewMoney(-5, "USD"), "-0.05"},
+		{NewMoney(1500, "JPY"), "1500"},
+		{NewMoney(1235, "KWD"), "1.235"},
+		{NewMoney(0, "KWD"), "0.000"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.money.String(); got != tt.want {
+			t.Errorf("%+v.String() = %q, want %q", tt.money, got, tt.want)
+		}
+	}
+}
+
+func TestMoneyWithCurrency(t *testing.T) {
+	tests := []struct {
+		money    Money
+		currency string
+		want     int64
+	}{
+		{NewMoney(1250, "USD"), "EUR", 1250},
+		{NewMoney(1250, ""), "KWD", 12500},
+		{NewMoney(1250, ""), "JPY", 13},
+		{NewMoney(-1250, ""), "JPY", -13},
+		{NewMoney(1234, "KWD"), "USD", 123},
+	}
+
+	for _, tt := range tests {
+		got := tt.money.WithCurrency(tt.currency)
+		if got.Amount != tt.want {
+			t.Errorf("%+v.WithCurrency(%q) = %d, want %d", tt.money, tt.currency, got.Amount, tt.want)
+		}
+	}
+}
+
+func TestMoneyUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		data     string
+		currency string
+		want     int64
+		wantErr  bool
+	}{
+		{`12.5`, "", 1250, false},
+		{`"12.5"`, "", 1250, false},
+		{`1e2`, "", 10000, false},
+		{`1E2`, "JPY", 100, false},
+		{`2.5e-1`, "", 25, false},
+		{`1.0005e1`, "", 1001, false},
+		{`null`, "", 0, false},
+		{`true`, "", 0, true},
+	}
+
+	for _, tt := range tests {
+		money := Money{Currency: tt.currency}
+		err := json.Unmarshal([]byte(tt.data), &money)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("Unmarshal(%s) error = %v, wantErr %v", tt.data, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && money.Amount != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.data, money.Amount, tt.want)
+		}
+	}
+
+	var payload struct {
+		Price Money `json:"price"`
+	}
+	if err := json.Unmarshal([]byte(`{"price": 1.999e3}`), &payload); err != nil || payload.Price.Amount != 199900 {
+		t.Errorf("Unmarshal into a struct = %d, %v; want 199900", payload.Price.Amount, err)
+	}
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Service and Booking models are already defined in models.go
+
+// ServicePackage is a prepaid bundle of sessions of one service, e.g. "10 walks"
+type ServicePackage struct {
+	ID           string    `json:"id" db:"id"`
+	CompanyID    string    `json:"company_id" db:"company_id"`
+	ServiceID    string    `json:"service_id" db:"service_id"`
+	Name         string    `json:"name" db:"name"`
+	Description  *string   `json:"description" db:"description"`
+	SessionCount int       `json:"session_count" db:"session_count"`
+	Price        float64   `json:"price" db:"price"`
+	Currency     string    `json:"currency" db:"currency"`
+	ValidityDays *int      `json:"validity_days" db:"validity_days"` // nil means the sessions never expire
+	IsActive     bool      `json:"is_active" db:"is_active"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ServicePackageRequest represents the request to create or update a package
+type ServicePackageRequest struct {
+	ServiceID    string  `json:"service_id" binding:"required"`
+	Name         string  `json:"name" binding:"required"`
+	Description  *string `json:"description"`
+	SessionCount int     `json:"session_count" binding:"required"`
+	Price        float64 `json:"price"`
+	Currency     string  `json:"currency"`
+	ValidityDays *int    `json:"validity_days"`
+	IsActive     *bool   `json:"is_active"`
+}
+
+// PackagePurchase is a customer's balance of prepaid sessions
+type PackagePurchase struct {
+	ID                string     `json:"id" db:"id"`
+	PackageID         string     `json:"package_id" db:"package_id"`
+	PackageName       string     `json:"package_name" db:"-"`
+	CompanyID         string     `json:"company_id" db:"company_id"`
+	ServiceID         string     `json:"service_id" db:"service_id"`
+	UserID            string     `json:"user_id" db:"user_id"`
+	PetID             *string    `json:"pet_id" db:"pet_id"` // nil means any pet of the customer
+	SessionsTotal     int        `json:"sessions_total" db:"sessions_total"`
+	SessionsRemaining int        `json:"sessions_remaining" db:"sessions_remaining"`
+	Price             float64    `json:"price" db:"price"`
+	Status            string     `json:"status" db:"status"` // pending_payment, active, exhausted, expired, cancelled
+	PaymentIntentID   *string    `json:"payment_intent_id" db:"payment_intent_id"`
+	PaymentID         *string    `json:"payment_id" db:"payment_id"`
+	ClientSecret      string     `json:"client_secret,omitempty" db:"-"`
+	ExpiresAt         *time.Time `json:"expires_at" db:"expires_at"`
+	ActivatedAt       *time.Time `json:"activated_at" db:"activated_at"`
+	CreatedAt         time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// PurchasePackageRequest represents the request to buy a package
+type PurchasePackageRequest struct {
+	PetID *string `json:"pet_id"` // Restricts the sessions to one pet
+}
+
+// PackageLedgerEntry is a credit or debit of sessions on a package balance
+type PackageLedgerEntry struct {
+	ID           string    `json:"id" db:"id"`
+	PurchaseID   string    `json:"purchase_id" db:"purchase_id"`
+	BookingID    *string   `json:"booking_id" db:"booking_id"`
+	EntryType    string    `json:"entry_type" db:"entry_type"` // purchase, redemption, adjustment, expiry
+	Sessions     int       `json:"sessions" db:"sessions"`     // Positive for credits, negative for debits
+	BalanceAfter int       `json:"balance_after" db:"balance_after"`
+	Note         *string   `json:"note" db:"note"`
+	CreatedBy    *string   `json:"created_by" db:"created_by"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// PackageAdjustmentRequest credits or debits sessions by hand, e.g. a goodwill session
+type PackageAdjustmentRequest struct {
+	Sessions int    `json:"sessions" binding:"required"`
+	Note     string `json:"note"`
+}
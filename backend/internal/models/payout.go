@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+)
+
+// CompanyPayoutSettings is how often a company is paid what the platform holds for it
+type CompanyPayoutSettings struct {
+	CompanyID     string    `json:"company_id" db:"company_id"`
+	Schedule      string    `json:"schedule" db:"schedule"`             // daily, weekly, monthly
+	MinimumPayout Money     `json:"minimum_payout" db:"minimum_payout"` // Smaller balances wait for the next payout
+	Destination   *string   `json:"destination" db:"destination"`       // The company's account with the payment provider
+	UpdatedAt     time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// CompanyPayoutSettingsRequest updates a company's payout settings
+type CompanyPayoutSettingsRequest struct {
+	Schedule      *string  `json:"schedule"`
+	MinimumPayout *float64 `json:"minimum_payout"`
+	Destination   *string  `json:"destination"`
+}
+
+// CompanyPayout is money sent to a company for the payments released to it
+type CompanyPayout struct {
+	ID                 string              `json:"id" db:"id"`
+	CompanyID          string              `json:"company_id" db:"company_id"`
+	Provider           *string             `json:"provider" db:"provider"`
+	ProviderPayoutID   *string             `json:"provider_payout_id" db:"stripe_transfer_id"`
+	Amount             Money               `json:"amount" db:"amount"`
+	Currency           string              `json:"currency" db:"currency"`
+	Status             string              `json:"status" db:"status"` // pending, paid, failed
+	Description        *string             `json:"description" db:"description"`
+	FailureReason      *string             `json:"failure_reason" db:"failure_reason"`
+	PeriodStart        time.Time           `json:"period_start" db:"period_start"`
+	PeriodEnd          time.Time           `json:"period_end" db:"period_end"`
+	TotalSales         Money               `json:"total_sales" db:"total_sales"`
+	CommissionDeducted Money               `json:"commission_deducted" db:"commission_deducted"`
+	RefundsDeducted    Money               `json:"refunds_deducted" db:"refunds_deducted"`
+	HeldAmount         Money               `json:"held_amount" db:"held_amount"` // Released but held back for disputes and refunds
+	ProcessedAt        *time.Time          `json:"processed_at" db:"processed_at"`
+	CreatedAt          time.Time           `json:"created_at" db:"created_at"`
+	Items              []CompanyPayoutItem `json:"items,omitempty"`
+}
+
+// SetCurrency reads the payout's amounts in its currency, once its row is scanned
+func (p *CompanyPayout) SetCurrency() {
+	p.Amount = p.Amount.WithCurrency(p.Currency)
+	p.TotalSales = p.TotalSales.WithCurrency(p.Currency)
+	p.CommissionDeducted = p.CommissionDeducted.WithCurrency(p.Currency)
+	p.RefundsDeducted = p.RefundsDeducted.WithCurrency(p.Currency)
+	p.HeldAmount = p.HeldAmount.WithCurrency(p.Currency)
+}
+
+// CompanyPayoutItem is a payment a payout includes, by what changed on it since it was last paid out
+type CompanyPayoutItem struct {
+	PaymentID        string    `json:"payment_id" db:"payment_id"`
+	BookingID        *string   `json:"booking_id" db:"booking_id"`
+	OrderID          *string   `json:"order_id" db:"order_id"`
+	Description      string    `json:"description" db:"description"`
+	GrossAmount      Money     `json:"gross_amount" db:"gross_amount"`
+	CommissionAmount Money     `json:"commission_amount" db:"commission_amount"`
+	RefundedAmount   Money     `json:"refunded_amount" db:"refunded_amount"`
+	Amount           Money     `json:"amount" db:"amount"`
+	CreatedAt        time.Time `json:"created_at" db:"created_at"`
+}
+
+// UpcomingPayout is what a company's next payout in a currency would hold if it were made now
+type UpcomingPayout struct {
+	Currency      string    `json:"currency"`
+	ScheduledFor  time.Time `json:"scheduled_for"`
+	Amount        Money     `json:"amount"`         // Ready to be paid out
+	HeldAmount    Money     `json:"held_amount"`    // Waiting for disputes to close and refund holds to pass
+	MinimumPayout Money     `json:"minimum_payout"` // A smaller amount waits for the payout after
+}
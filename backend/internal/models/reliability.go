@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Company and Booking models are already defined in models.go
+
+// CustomerReliability summarizes how a customer has kept their bookings with a company
+type CustomerReliability struct {
+	TotalBookings     int        `json:"total_bookings"`
+	CompletedBookings int        `json:"completed_bookings"`
+	CancelledBookings int        `json:"cancelled_bookings"`
+	LateCancellations int        `json:"late_cancellations"` // Customer cancellations that carried a fee
+	NoShows           int        `json:"no_shows"`
+	LastNoShowAt      *time.Time `json:"last_no_show_at"`
+	ReliabilityScore  *float64   `json:"reliability_score"` // Percentage of attended bookings, nil without history
+}
+
+// NoShowRules are the company rules applied to online bookings of customers with repeated no-shows.
+// A threshold of 0 turns the rule off.
+type NoShowRules struct {
+	DepositAfter int     `json:"deposit_after" db:"no_show_deposit_after"` // Require a deposit from this many no-shows
+	DepositType  string  `json:"deposit_type" db:"no_show_deposit_type"`   // fixed, percentage
+	DepositValue float64 `json:"deposit_value" db:"no_show_deposit_value"`
+	BlockAfter   int     `json:"block_after" db:"no_show_block_after"` // Block online booking from this many no-shows
+	WindowDays   int     `json:"window_days" db:"no_show_window_days"` // Only count recent no-shows, 0 counts all
+}
+
+// MarkNoShowRequest represents the request to record that a customer missed a booking
+type MarkNoShowRequest struct {
+	Notes    string `json:"notes"`
+	WaiveFee bool   `json:"waive_fee"` // Skip the no-show fee of the cancellation policy
+}
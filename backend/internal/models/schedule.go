@@ -0,0 +1,117 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Employee model is already defined in models.go
+
+// EmployeeShift represents a recurring weekly working shift
+type EmployeeShift struct {
+	ID         string    `json:"id" db:"id"`
+	EmployeeID string    `json:"employee_id" db:"employee_id"`
+	CompanyID  string    `json:"company_id" db:"company_id"`
+	DayOfWeek  string    `json:"day_of_week" db:"day_of_week"` // monday..sunday
+	StartTime  string    `json:"start_time" db:"start_time"`   // HH:MM:SS
+	EndTime    string    `json:"end_time" db:"end_time"`       // HH:MM:SS
+	IsActive   bool      `json:"is_active" db:"is_active"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EmployeeScheduleOverride replaces the weekly shifts for a single date
+type EmployeeScheduleOverride struct {
+	ID           string    `json:"id" db:"id"`
+	EmployeeID   string    `json:"employee_id" db:"employee_id"`
+	CompanyID    string    `json:"company_id" db:"company_id"`
+	OverrideDate time.Time `json:"override_date" db:"override_date"`
+	IsDayOff     bool      `json:"is_day_off" db:"is_day_off"`
+	StartTime    *string   `json:"start_time" db:"start_time"`
+	EndTime      *string   `json:"end_time" db:"end_time"`
+	Reason       *string   `json:"reason" db:"reason"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EmployeeTimeOff represents a vacation, sick leave or other absence
+type EmployeeTimeOff struct {
+	ID         string    `json:"id" db:"id"`
+	EmployeeID string    `json:"employee_id" db:"employee_id"`
+	CompanyID  string    `json:"company_id" db:"company_id"`
+	Type       string    `json:"type" db:"type"` // vacation, sick, personal, other
+	StartsAt   time.Time `json:"starts_at" db:"starts_at"`
+	EndsAt     time.Time `json:"ends_at" db:"ends_at"`
+	Reason     *string   `json:"reason" db:"reason"`
+	CreatedBy  *string   `json:"created_by" db:"created_by"`
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// EmployeeBreak represents a break, recurring on a weekday or on a single date
+type EmployeeBreak struct {
+	ID         string     `json:"id" db:"id"`
+	EmployeeID string     `json:"employee_id" db:"employee_id"`
+	CompanyID  string     `json:"company_id" db:"company_id"`
+	DayOfWeek  *string    `json:"day_of_week" db:"day_of_week"`
+	BreakDate  *time.Time `json:"break_date" db:"break_date"`
+	StartTime  string     `json:"start_time" db:"start_time"`
+	EndTime    string     `json:"end_time" db:"end_time"`
+	Label      *string    `json:"label" db:"label"`
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`
+}
+
+// EmployeeSchedule is the full schedule configuration of an employee
+type EmployeeSchedule struct {
+	EmployeeID string                     `json:"employee_id"`
+	Shifts     []EmployeeShift            `json:"shifts"`
+	Overrides  []EmployeeScheduleOverride `json:"overrides"`
+	TimeOff    []EmployeeTimeOff          `json:"time_off"`
+	Breaks     []EmployeeBreak            `json:"breaks"`
+
+	// Upcoming events imported from the employee's and the company's external calendars
+	ExternalBusy []ExternalBusyBlock `json:"external_busy"`
+}
+
+// WorkingWindow is a continuous interval in which an employee can take bookings
+type WorkingWindow struct {
+	Start time.Time `json:"start"`
+	End   time.Time `json:"end"`
+}
+
+// EmployeeShiftRequest represents a single weekly shift in a request
+type EmployeeShiftRequest struct {
+	DayOfWeek string `json:"day_of_week" binding:"required,oneof=monday tuesday wednesday thursday friday saturday sunday"`
+	StartTime string `json:"start_time" binding:"required"`
+	EndTime   string `json:"end_time" binding:"required"`
+}
+
+// SetEmployeeShiftsRequest replaces the weekly shifts of an employee
+type SetEmployeeShiftsRequest struct {
+	Shifts []EmployeeShiftRequest `json:"shifts" binding:"dive"`
+}
+
+// EmployeeScheduleOverrideRequest represents the request to override a single date
+type EmployeeScheduleOverrideRequest struct {
+	Date      string  `json:"date" binding:"required"` // YYYY-MM-DD
+	IsDayOff  bool    `json:"is_day_off"`
+	StartTime *string `json:"start_time"`
+	EndTime   *string `json:"end_time"`
+	Reason    *string `json:"reason"`
+}
+
+// EmployeeTimeOffRequest represents the request to add time-off
+type EmployeeTimeOffRequest struct {
+	Type     string    `json:"type" binding:"required,oneof=vacation sick personal other"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+	EndsAt   time.Time `json:"ends_at" binding:"required"`
+	Reason   *string   `json:"reason"`
+}
+
+// EmployeeBreakRequest represents the request to add a break
+type EmployeeBreakRequest struct {
+	DayOfWeek *string `json:"day_of_week"`
+	Date      *string `json:"date"` // YYYY-MM-DD
+	StartTime string  `json:"start_time" binding:"required"`
+	EndTime   string  `json:"end_time" binding:"required"`
+	Label     *string `json:"label"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Service and Booking models are already defined in models.go
+
+// ServiceOption is an add-on customers can choose with a service, e.g. nail trim
+type ServiceOption struct {
+	ID           string    `json:"id" db:"id"`
+	CompanyID    string    `json:"company_id" db:"company_id"`
+	ServiceID    string    `json:"service_id" db:"service_id"`
+	Name         string    `json:"name" db:"name"`
+	Description  *string   `json:"description" db:"description"`
+	Price        Money     `json:"price" db:"price"`
+	ExtraMinutes int       `json:"extra_minutes" db:"extra_minutes"`
+	IsActive     bool      `json:"is_active" db:"is_active"`
+	SortOrder    int       `json:"sort_order" db:"sort_order"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ServiceOptionRequest represents the request to create or update a service option
+type ServiceOptionRequest struct {
+	Name         string  `json:"name" binding:"required"`
+	Description  *string `json:"description"`
+	Price        float64 `json:"price"`
+	ExtraMinutes int     `json:"extra_minutes"`
+	IsActive     *bool   `json:"is_active"`
+	SortOrder    int     `json:"sort_order"`
+}
+
+// BookingOption is an option as it was sold with a booking
+type BookingOption struct {
+	ID           string    `json:"id" db:"id"`
+	BookingID    string    `json:"booking_id" db:"booking_id"`
+	OptionID     *string   `json:"option_id" db:"option_id"`
+	Name         string    `json:"name" db:"name"`
+	Price        Money     `json:"price" db:"price"`
+	ExtraMinutes int       `json:"extra_minutes" db:"extra_minutes"`
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Service and Breed models are already defined in models.go
+
+// ServicePriceRule overrides the price and/or duration of a service for matching pets.
+// Empty criteria match any pet; when several rules match, the most specific one wins.
+type ServicePriceRule struct {
+	ID        string    `json:"id" db:"id"`
+	ServiceID string    `json:"service_id" db:"service_id"`
+	PetTypeID *string   `json:"pet_type_id" db:"pet_type_id"`
+	SizeClass *string   `json:"size_class" db:"size_class"` // toy, small, medium, large, giant
+	MinWeight *float64  `json:"min_weight" db:"min_weight"` // Inclusive, kg
+	MaxWeight *float64  `json:"max_weight" db:"max_weight"` // Exclusive, kg
+	Price     *Money    `json:"price" db:"price"`           // nil keeps the service price
+	Duration  *int      `json:"duration" db:"duration"`     // nil keeps the service duration
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+	UpdatedAt time.Time `json:"updated_at" db:"updated_at"`
+}
+
+// ServicePriceMatrixRequest replaces every price rule of a service
+type ServicePriceMatrixRequest struct {
+	Rules []ServicePriceRule `json:"rules"`
+}
+
+// ServicePriceRange is the spread of prices and durations of a service across pets
+type ServicePriceRange struct {
+	MinPrice    Money `json:"min_price"`
+	MaxPrice    Money `json:"max_price"`
+	MinDuration int   `json:"min_duration"`
+	MaxDuration int   `json:"max_duration"`
+}
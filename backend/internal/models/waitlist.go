@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Booking model is already defined in models.go
+
+// WaitlistEntry is a customer waiting for a slot of a service within a time window
+type WaitlistEntry struct {
+	ID            string     `json:"id" db:"id"`
+	UserID        string     `json:"user_id" db:"user_id"`
+	CompanyID     string     `json:"company_id" db:"company_id"`
+	ServiceID     string     `json:"service_id" db:"service_id"`
+	PetID         *string    `json:"pet_id" db:"pet_id"`
+	EmployeeID    *string    `json:"employee_id" db:"employee_id"`
+	WindowStart   time.Time  `json:"window_start" db:"window_start"`
+	WindowEnd     time.Time  `json:"window_end" db:"window_end"`
+	Notes         *string    `json:"notes" db:"notes"`
+	Status        string     `json:"status" db:"status"`                   // waiting, offered, booked, declined, expired, cancelled
+	HoldDateTime  *time.Time `json:"hold_date_time" db:"hold_date_time"`   // Slot held for the customer while offered
+	HoldExpiresAt *time.Time `json:"hold_expires_at" db:"hold_expires_at"` // Offer must be accepted before this time
+	BookingID     *string    `json:"booking_id" db:"booking_id"`
+	Position      int        `json:"position,omitempty"` // Place in the queue while waiting
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`
+}
+
+// JoinWaitlistRequest represents the request to join the waitlist of a service
+type JoinWaitlistRequest struct {
+	CompanyID   string    `json:"company_id" binding:"required"`
+	ServiceID   string    `json:"service_id" binding:"required"`
+	PetID       string    `json:"pet_id"`
+	EmployeeID  *string   `json:"employee_id"`
+	WindowStart time.Time `json:"window_start" binding:"required"`
+	WindowEnd   time.Time `json:"window_end" binding:"required"`
+	Notes       string    `json:"notes"`
+}
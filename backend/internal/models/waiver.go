@@ -0,0 +1,73 @@
+package models
+
+import (
+	"time"
+)
+
+// Note: Service and Booking models are already defined in models.go
+
+// Waiver is a liability waiver of a company; its text is versioned and customers sign a version
+type Waiver struct {
+	ID             string         `json:"id" db:"id"`
+	CompanyID      string         `json:"company_id" db:"company_id"`
+	Title          string         `json:"title" db:"title"`
+	IsActive       bool           `json:"is_active" db:"is_active"`
+	CurrentVersion *WaiverVersion `json:"current_version" db:"-"`
+	CreatedAt      time.Time      `json:"created_at" db:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at" db:"updated_at"`
+}
+
+// WaiverVersion is the text of a waiver as published; it never changes once created
+type WaiverVersion struct {
+	ID           string    `json:"id" db:"id"`
+	WaiverID     string    `json:"waiver_id" db:"waiver_id"`
+	Version      int       `json:"version" db:"version"`
+	Body         string    `json:"body" db:"body"`
+	DocumentHash string    `json:"document_hash" db:"document_hash"` // SHA-256 of the body, hex
+	CreatedAt    time.Time `json:"created_at" db:"created_at"`
+}
+
+// WaiverRequest represents the request to create or update a waiver; a changed body publishes a new version
+type WaiverRequest struct {
+	Title    string `json:"title" binding:"required"`
+	Body     string `json:"body" binding:"required"`
+	IsActive *bool  `json:"is_active"`
+}
+
+// ServiceWaiverRequest sets the waiver customers must sign before booking a service; null removes it
+type ServiceWaiverRequest struct {
+	WaiverID *string `json:"waiver_id"`
+}
+
+// WaiverSignRequest represents a customer signing the version of a waiver they were shown
+type WaiverSignRequest struct {
+	VersionID     string `json:"version_id" binding:"required"`
+	SignatureName string `json:"signature_name" binding:"required"` // Typed full name
+	Agreed        bool   `json:"agreed" binding:"required"`
+}
+
+// WaiverSignature is a customer's signature of a waiver version
+type WaiverSignature struct {
+	ID            string    `json:"id" db:"id"`
+	WaiverID      string    `json:"waiver_id" db:"waiver_id"`
+	WaiverTitle   string    `json:"waiver_title" db:"-"`
+	VersionID     string    `json:"version_id" db:"version_id"`
+	Version       int       `json:"version" db:"-"`
+	CompanyID     string    `json:"company_id" db:"company_id"`
+	UserID        string    `json:"user_id" db:"user_id"`
+	CustomerName  string    `json:"customer_name" db:"-"`
+	CustomerEmail string    `json:"customer_email" db:"-"`
+	SignatureName string    `json:"signature_name" db:"signature_name"`
+	DocumentHash  string    `json:"document_hash" db:"document_hash"`
+	IPAddress     *string   `json:"ip_address" db:"ip_address"`
+	UserAgent     *string   `json:"user_agent" db:"user_agent"`
+	SignedAt      time.Time `json:"signed_at" db:"signed_at"`
+}
+
+// SignedWaiverRecord is the full record of a signature: the text as signed and whether it still matches its hash
+type SignedWaiverRecord struct {
+	Signature WaiverSignature `json:"signature"`
+	Body      string          `json:"body"`
+	Verified  bool            `json:"verified"`
+	Document  string          `json:"document"` // Printable rendering of the signed waiver
+}
@@ -197,13 +197,13 @@ func (s *AdminService) GetPaymentSettings() (*models.PaymentSettings, error) {
 	err := s.db.QueryRow(`
 		SELECT id, stripe_enabled, commission_enabled, commission_percentage,
 			   stripe_publishable_key, stripe_secret_key, stripe_webhook_secret,
-			   created_at, updated_at
+			   payment_provider, created_at, updated_at
 		FROM payment_settings LIMIT 1
 	`).Scan(
 		&settings.ID, &settings.StripeEnabled, &settings.CommissionEnabled,
 		&settings.CommissionPercentage, &settings.StripePublishableKey,
 		&settings.StripeSecretKey, &settings.StripeWebhookSecret,
-		&settings.CreatedAt, &settings.UpdatedAt,
+		&settings.PaymentProvider, &settings.CreatedAt, &settings.UpdatedAt,
 	)
 
 	if err != nil {
@@ -224,6 +224,7 @@ func (s *AdminService) CreateDefaultPaymentSettings() (*models.PaymentSettings,
 		StripeEnabled:        false, // Disabled by default
 		CommissionEnabled:    true,  // Commission enabled by default
 		CommissionPercentage: 10.0,  // 10% default commission
+		PaymentProvider:      PaymentProviderStripe,
 		CreatedAt:            time.Now(),
 		UpdatedAt:            time.Now(),
 	}
@@ -231,12 +232,12 @@ func (s *AdminService) CreateDefaultPaymentSettings() (*models.PaymentSettings,
 	_, err := s.db.Exec(`
 		INSERT INTO payment_settings (id, stripe_enabled, commission_enabled, commission_percentage,
 									 stripe_publishable_key, stripe_secret_key, stripe_webhook_secret,
-									 created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+									 payment_provider, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 	`, settings.ID, settings.StripeEnabled, settings.CommissionEnabled,
 		settings.CommissionPercentage, settings.StripePublishableKey,
 		settings.StripeSecretKey, settings.StripeWebhookSecret,
-		settings.CreatedAt, settings.UpdatedAt)
+		settings.PaymentProvider, settings.CreatedAt, settings.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -276,6 +277,12 @@ func (s *AdminService) UpdatePaymentSettings(req *UpdatePaymentSettingsRequest)
 	if req.StripeWebhookSecret != nil {
 		currentSettings.StripeWebhookSecret = *req.StripeWebhookSecret
 	}
+	if req.PaymentProvider != nil {
+		if *req.PaymentProvider != PaymentProviderStripe && *req.PaymentProvider != PaymentProviderFake {
+			return nil, fmt.Errorf("payment provider must be stripe or fake")
+		}
+		currentSettings.PaymentProvider = *req.PaymentProvider
+	}
 
 	currentSettings.UpdatedAt = time.Now()
 
@@ -288,12 +295,13 @@ func (s *AdminService) UpdatePaymentSettings(req *UpdatePaymentSettingsRequest)
 			stripe_publishable_key = $5,
 			stripe_secret_key = $6,
 			stripe_webhook_secret = $7,
-			updated_at = $8
+			payment_provider = $8,
+			updated_at = $9
 		WHERE id = $1
 	`, currentSettings.ID, currentSettings.StripeEnabled, currentSettings.CommissionEnabled,
 		currentSettings.CommissionPercentage, currentSettings.StripePublishableKey,
 		currentSettings.StripeSecretKey, currentSettings.StripeWebhookSecret,
-		currentSettings.UpdatedAt)
+		currentSettings.PaymentProvider, currentSettings.UpdatedAt)
 
 	if err != nil {
 		return nil, err
@@ -430,6 +438,7 @@ type UpdatePaymentSettingsRequest struct {
 	StripePublishableKey *string  `json:"stripe_publishable_key"`
 	StripeSecretKey      *string  `json:"stripe_secret_key"`
 	StripeWebhookSecret  *string  `json:"stripe_webhook_secret"`
+	PaymentProvider      *string  `json:"payment_provider"`
 }
 
 // Service Categories Management
@@ -549,7 +558,7 @@ func (s *AdminService) DeletePetType(petTypeID string) error {
 
 // Breeds Management
 func (s *AdminService) GetBreeds() ([]models.Breed, error) {
-	query := `SELECT id, name, pet_type_id, created_at FROM breeds ORDER BY name`
+	query := `SELECT id, name, pet_type_id, size_class, created_at FROM breeds ORDER BY name`
 
 	rows, err := s.db.Query(query)
 	if err != nil {
@@ -560,7 +569,7 @@ func (s *AdminService) GetBreeds() ([]models.Breed, error) {
 	var breeds []models.Breed
 	for rows.Next() {
 		var breed models.Breed
-		err := rows.Scan(&breed.ID, &breed.Name, &breed.PetTypeID, &breed.CreatedAt)
+		err := rows.Scan(&breed.ID, &breed.Name, &breed.PetTypeID, &breed.SizeClass, &breed.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -571,7 +580,7 @@ func (s *AdminService) GetBreeds() ([]models.Breed, error) {
 }
 
 func (s *AdminService) GetBreedsByPetType(petTypeID string) ([]models.Breed, error) {
-	query := `SELECT id, name, pet_type_id, created_at FROM breeds WHERE pet_type_id = $1 ORDER BY name`
+	query := `SELECT id, name, pet_type_id, size_class, created_at FROM breeds WHERE pet_type_id = $1 ORDER BY name`
 
 	rows, err := s.db.Query(query, petTypeID)
 	if err != nil {
@@ -582,7 +591,7 @@ func (s *AdminService) GetBreedsByPetType(petTypeID string) ([]models.Breed, err
 	var breeds []models.Breed
 	for rows.Next() {
 		var breed models.Breed
-		err := rows.Scan(&breed.ID, &breed.Name, &breed.PetTypeID, &breed.CreatedAt)
+		err := rows.Scan(&breed.ID, &breed.Name, &breed.PetTypeID, &breed.SizeClass, &breed.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -593,17 +602,25 @@ func (s *AdminService) GetBreedsByPetType(petTypeID string) ([]models.Breed, err
 }
 
 func (s *AdminService) CreateBreed(breed *models.Breed) error {
+	if err := validateSizeClass(breed.SizeClass); err != nil {
+		return err
+	}
+
 	breed.ID = uuid.New().String()
 	breed.CreatedAt = time.Now()
 
-	query := `INSERT INTO breeds (id, name, pet_type_id, created_at) VALUES ($1, $2, $3, $4)`
-	_, err := s.db.Exec(query, breed.ID, breed.Name, breed.PetTypeID, breed.CreatedAt)
+	query := `INSERT INTO breeds (id, name, pet_type_id, size_class, created_at) VALUES ($1, $2, $3, $4, $5)`
+	_, err := s.db.Exec(query, breed.ID, breed.Name, breed.PetTypeID, breed.SizeClass, breed.CreatedAt)
 	return err
 }
 
 func (s *AdminService) UpdateBreed(breedID string, breed *models.Breed) error {
-	query := `UPDATE breeds SET name = $2, pet_type_id = $3 WHERE id = $1`
-	_, err := s.db.Exec(query, breedID, breed.Name, breed.PetTypeID)
+	if err := validateSizeClass(breed.SizeClass); err != nil {
+		return err
+	}
+
+	query := `UPDATE breeds SET name = $2, pet_type_id = $3, size_class = $4 WHERE id = $1`
+	_, err := s.db.Exec(query, breedID, breed.Name, breed.PetTypeID, breed.SizeClass)
 	return err
 }
 
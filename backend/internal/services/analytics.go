@@ -3044,7 +3044,7 @@ func (s *AnalyticsService) GetCompanyDashboard(companyID string, days int) (map[
 		fmt.Printf("❌ Revenue query error: %v\n", err)
 		return nil, err
 	}
-	fmt.Printf("✅ Revenue result: %d\n", totalRevenue)
+	fmt.Printf("✅ Revenue result: %.2f\n", totalRevenue)
 
 	// Total bookings
 	bookingsQuery := fmt.Sprintf(`
@@ -3134,11 +3134,19 @@ func (s *AnalyticsService) GetCompanyRevenue(companyID string, days int) (map[st
 		}
 	}
 
+	// Revenue from add-ons chosen with bookings
+	optionSales, optionsRevenue, err := s.getServiceOptionRevenue(companyID, days)
+	if err != nil {
+		return nil, err
+	}
+
 	return map[string]interface{}{
 		"daily_trends":   trends,
 		"period_days":    days,
 		"total_revenue":  totalRevenue,
 		"total_transactions": len(trends),
+		"options_revenue": optionsRevenue,
+		"option_sales":    optionSales,
 	}, nil
 }
 
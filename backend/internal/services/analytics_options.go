@@ -0,0 +1,45 @@
+package services
+
+import (
+	"fmt"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// getServiceOptionRevenue returns how often each service option was sold with confirmed or completed
+// bookings in the period, and the revenue options added on top of base service prices
+func (s *AnalyticsService) getServiceOptionRevenue(companyID string, days int) ([]map[string]interface{}, float64, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT bo.name, COUNT(*) as times_sold, COALESCE(SUM(bo.price), 0) as revenue
+		FROM booking_options bo
+		JOIN bookings b ON b.id = bo.booking_id
+		WHERE b.company_id = $1
+		AND b.created_at >= NOW() - INTERVAL '%d days'
+		AND b.status IN ('confirmed', 'completed')
+		GROUP BY bo.name
+		ORDER BY revenue DESC, times_sold DESC
+	`, days), companyID)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	options := []map[string]interface{}{}
+	var totalRevenue models.Money
+	for rows.Next() {
+		var name string
+		var timesSold int
+		var revenue models.Money
+		if err := rows.Scan(&name, &timesSold, &revenue); err != nil {
+			return nil, 0, err
+		}
+		totalRevenue = totalRevenue.Add(revenue)
+		options = append(options, map[string]interface{}{
+			"name":       name,
+			"times_sold": timesSold,
+			"revenue":    revenue,
+		})
+	}
+
+	return options, totalRevenue.Decimal(), nil
+}
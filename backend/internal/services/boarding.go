@@ -0,0 +1,640 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrNoKennelAvailable is returned when no suitable kennel has room for every night of a stay
+var ErrNoKennelAvailable = errors.New("no suitable kennel is available for the requested dates")
+
+// maxStayNights limits the length of a single boarding stay
+const maxStayNights = 90
+
+const (
+	defaultCheckInTime  = "14:00:00"
+	defaultCheckOutTime = "11:00:00"
+)
+
+type BoardingService struct {
+	db *sql.DB
+}
+
+func NewBoardingService(db *sql.DB) *BoardingService {
+	return &BoardingService{db: db}
+}
+
+// dbQuerier is implemented by both *sql.DB and *sql.Tx
+type dbQuerier interface {
+	rowQuerier
+	rowsQuerier
+}
+
+// stayPet is the part of a pet profile that decides which kennels fit it
+type stayPet struct {
+	PetTypeID string
+	Weight    *float64
+}
+
+const kennelColumns = `
+	id, company_id, name, description, size, max_pet_weight,
+	allowed_pet_types, capacity, is_active, created_at, updated_at`
+
+// GetKennels returns all kennels of a company
+func (s *BoardingService) GetKennels(companyID string) ([]models.Kennel, error) {
+	return s.queryKennels(s.db, `
+		SELECT`+kennelColumns+`
+		FROM kennels
+		WHERE company_id = $1
+		ORDER BY name
+	`, companyID)
+}
+
+// CreateKennel adds a kennel to the company inventory
+func (s *BoardingService) CreateKennel(companyID string, req *models.KennelRequest) (*models.Kennel, error) {
+	capacity := req.Capacity
+	if capacity <= 0 {
+		capacity = 1
+	}
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	kennels, err := s.queryKennels(s.db, `
+		INSERT INTO kennels (
+			id, company_id, name, description, size, max_pet_weight,
+			allowed_pet_types, capacity, is_active, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		RETURNING`+kennelColumns,
+		uuid.New().String(), companyID, req.Name, req.Description, req.Size, req.MaxPetWeight,
+		pq.Array(nonNilStrings(req.AllowedPetTypes)), capacity, isActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kennel: %w", err)
+	}
+
+	return &kennels[0], nil
+}
+
+// UpdateKennel updates a kennel of the company
+func (s *BoardingService) UpdateKennel(companyID, kennelID string, req *models.KennelRequest) (*models.Kennel, error) {
+	capacity := req.Capacity
+	if capacity <= 0 {
+		capacity = 1
+	}
+
+	kennels, err := s.queryKennels(s.db, `
+		UPDATE kennels SET
+			name = $3, description = $4, size = $5, max_pet_weight = $6,
+			allowed_pet_types = $7, capacity = $8,
+			is_active = COALESCE($9, is_active), updated_at = NOW()
+		WHERE id = $1 AND company_id = $2
+		RETURNING`+kennelColumns,
+		kennelID, companyID, req.Name, req.Description, req.Size, req.MaxPetWeight,
+		pq.Array(nonNilStrings(req.AllowedPetTypes)), capacity, req.IsActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update kennel: %w", err)
+	}
+	if len(kennels) == 0 {
+		return nil, fmt.Errorf("kennel not found")
+	}
+
+	return &kennels[0], nil
+}
+
+// DeleteKennel removes a kennel that has no upcoming stays
+func (s *BoardingService) DeleteKennel(companyID, kennelID string) error {
+	var upcoming int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM bookings
+		WHERE kennel_id = $1 AND company_id = $2 AND check_out_date >= CURRENT_DATE
+		  AND status NOT IN ('cancelled', 'rejected')
+	`, kennelID, companyID).Scan(&upcoming)
+	if err != nil {
+		return err
+	}
+	if upcoming > 0 {
+		return fmt.Errorf("kennel has %d upcoming stays, deactivate it instead", upcoming)
+	}
+
+	result, err := s.db.Exec("DELETE FROM kennels WHERE id = $1 AND company_id = $2", kennelID, companyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("kennel not found")
+	}
+
+	return nil
+}
+
+// GetBoardingRate returns the nightly rates of a boarding service.
+// Services without configured rates charge their regular price every night.
+func (s *BoardingService) GetBoardingRate(serviceID string) (*models.BoardingRate, error) {
+	return s.boardingRate(s.db, serviceID)
+}
+
+// SetBoardingRate creates or replaces the nightly rates of a boarding service
+func (s *BoardingService) SetBoardingRate(companyID, serviceID string, req *models.BoardingRateRequest) (*models.BoardingRate, error) {
+	var serviceCompanyID string
+	err := s.db.QueryRow("SELECT company_id FROM services WHERE id = $1", serviceID).Scan(&serviceCompanyID)
+	if err != nil || serviceCompanyID != companyID {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	weekendDays := []string{"friday", "saturday"}
+	if req.WeekendDays != nil {
+		weekendDays = []string{}
+		for _, day := range req.WeekendDays {
+			day = strings.ToLower(day)
+			if !validWeekdays[day] {
+				return nil, fmt.Errorf("invalid weekend day: %s", day)
+			}
+			weekendDays = append(weekendDays, day)
+		}
+	}
+
+	checkIn, checkOut := defaultCheckInTime, defaultCheckOutTime
+	if req.CheckInTime != "" {
+		t, err := parseClock(req.CheckInTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check_in_time: %s", req.CheckInTime)
+		}
+		checkIn = t.Format("15:04:05")
+	}
+	if req.CheckOutTime != "" {
+		t, err := parseClock(req.CheckOutTime)
+		if err != nil {
+			return nil, fmt.Errorf("invalid check_out_time: %s", req.CheckOutTime)
+		}
+		checkOut = t.Format("15:04:05")
+	}
+
+	var rate models.BoardingRate
+	err = s.db.QueryRow(`
+		INSERT INTO boarding_rates (
+			id, service_id, company_id, nightly_rate, weekend_rate, holiday_rate,
+			weekend_days, check_in_time, check_out_time, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+		ON CONFLICT (service_id) DO UPDATE SET
+			nightly_rate = EXCLUDED.nightly_rate,
+			weekend_rate = EXCLUDED.weekend_rate,
+			holiday_rate = EXCLUDED.holiday_rate,
+			weekend_days = EXCLUDED.weekend_days,
+			check_in_time = EXCLUDED.check_in_time,
+			check_out_time = EXCLUDED.check_out_time,
+			updated_at = NOW()
+		RETURNING id, service_id, company_id, nightly_rate, weekend_rate, holiday_rate,
+				  weekend_days, to_char(check_in_time, 'HH24:MI:SS'), to_char(check_out_time, 'HH24:MI:SS'), created_at, updated_at
+	`, uuid.New().String(), serviceID, companyID, req.NightlyRate, req.WeekendRate, req.HolidayRate,
+		pq.Array(weekendDays), checkIn, checkOut).Scan(
+		&rate.ID, &rate.ServiceID, &rate.CompanyID, &rate.NightlyRate, &rate.WeekendRate,
+		&rate.HolidayRate, &rate.WeekendDays, &rate.CheckInTime, &rate.CheckOutTime,
+		&rate.CreatedAt, &rate.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save boarding rates: %w", err)
+	}
+
+	return &rate, nil
+}
+
+// GetHolidays returns upcoming holidays of a company
+func (s *BoardingService) GetHolidays(companyID string) ([]models.CompanyHoliday, error) {
+	rows, err := s.db.Query(`
+		SELECT id, company_id, holiday_date, name, created_at
+		FROM company_holidays
+		WHERE company_id = $1 AND holiday_date >= CURRENT_DATE
+		ORDER BY holiday_date
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holidays: %w", err)
+	}
+	defer rows.Close()
+
+	holidays := []models.CompanyHoliday{}
+	for rows.Next() {
+		var h models.CompanyHoliday
+		if err := rows.Scan(&h.ID, &h.CompanyID, &h.HolidayDate, &h.Name, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		holidays = append(holidays, h)
+	}
+
+	return holidays, nil
+}
+
+// CreateHoliday adds or renames a company holiday
+func (s *BoardingService) CreateHoliday(companyID string, req *models.CompanyHolidayRequest) (*models.CompanyHoliday, error) {
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format, use YYYY-MM-DD")
+	}
+
+	var h models.CompanyHoliday
+	err = s.db.QueryRow(`
+		INSERT INTO company_holidays (id, company_id, holiday_date, name, created_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (company_id, holiday_date) DO UPDATE SET name = EXCLUDED.name
+		RETURNING id, company_id, holiday_date, name, created_at
+	`, uuid.New().String(), companyID, date.Format("2006-01-02"), req.Name).Scan(
+		&h.ID, &h.CompanyID, &h.HolidayDate, &h.Name, &h.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save holiday: %w", err)
+	}
+
+	return &h, nil
+}
+
+// DeleteHoliday removes a company holiday
+func (s *BoardingService) DeleteHoliday(companyID, holidayID string) error {
+	result, err := s.db.Exec("DELETE FROM company_holidays WHERE id = $1 AND company_id = $2", holidayID, companyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("holiday not found")
+	}
+
+	return nil
+}
+
+// QuoteStay prices a stay night by night and lists the kennels that fit the pet for every night
+func (s *BoardingService) QuoteStay(serviceID, petID, checkInDate, checkOutDate string) (*models.StayQuote, error) {
+	checkIn, checkOut, err := parseStayDates(checkInDate, checkOutDate)
+	if err != nil {
+		return nil, err
+	}
+
+	var companyID string
+	err = s.db.QueryRow("SELECT company_id FROM services WHERE id = $1 AND is_active = true", serviceID).Scan(&companyID)
+	if err != nil {
+		return nil, fmt.Errorf("service not found or inactive")
+	}
+
+	quote, err := s.quoteStay(s.db, companyID, serviceID, checkIn, checkOut)
+	if err != nil {
+		return nil, err
+	}
+
+	if petID != "" {
+		pet, err := s.getStayPet(s.db, petID)
+		if err != nil {
+			return nil, err
+		}
+
+		kennels, err := s.suitableKennels(s.db, companyID, nil, pet, false)
+		if err != nil {
+			return nil, err
+		}
+		for _, kennel := range kennels {
+			free, err := s.kennelHasRoom(s.db, kennel, checkIn, checkOut)
+			if err != nil {
+				return nil, err
+			}
+			if free {
+				quote.AvailableKennels = append(quote.AvailableKennels, kennel)
+			}
+		}
+	}
+
+	return quote, nil
+}
+
+// GetOccupancy returns the per-night kennel occupancy of a company for [from, to)
+func (s *BoardingService) GetOccupancy(companyID, fromDate, toDate string) ([]models.OccupancyDay, error) {
+	from, err := time.Parse("2006-01-02", fromDate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid from date format, use YYYY-MM-DD")
+	}
+	to, err := time.Parse("2006-01-02", toDate)
+	if err != nil || !to.After(from) {
+		return nil, fmt.Errorf("invalid to date, must be YYYY-MM-DD after from")
+	}
+	if to.Sub(from) > maxStayNights*24*time.Hour {
+		return nil, fmt.Errorf("occupancy range is limited to %d days", maxStayNights)
+	}
+
+	kennels, err := s.queryKennels(s.db, `
+		SELECT`+kennelColumns+`
+		FROM kennels
+		WHERE company_id = $1 AND is_active = true
+		ORDER BY name
+	`, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, kennel_id, check_in_date, check_out_date
+		FROM bookings
+		WHERE company_id = $1 AND kennel_id IS NOT NULL
+		  AND check_in_date < $3 AND check_out_date > $2
+		  AND status NOT IN ('cancelled', 'rejected')
+	`, companyID, from.Format("2006-01-02"), to.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stays: %w", err)
+	}
+	defer rows.Close()
+
+	type stay struct {
+		bookingID, kennelID string
+		checkIn, checkOut   string
+	}
+	var stays []stay
+	for rows.Next() {
+		var st stay
+		var checkIn, checkOut time.Time
+		if err := rows.Scan(&st.bookingID, &st.kennelID, &checkIn, &checkOut); err != nil {
+			return nil, err
+		}
+		st.checkIn, st.checkOut = checkIn.Format("2006-01-02"), checkOut.Format("2006-01-02")
+		stays = append(stays, st)
+	}
+
+	days := []models.OccupancyDay{}
+	for night := from; night.Before(to); night = night.AddDate(0, 0, 1) {
+		date := night.Format("2006-01-02")
+		day := models.OccupancyDay{Date: date, Kennels: []models.KennelOccupancy{}}
+
+		for _, kennel := range kennels {
+			occupancy := models.KennelOccupancy{
+				KennelID:   kennel.ID,
+				Name:       kennel.Name,
+				Size:       kennel.Size,
+				Capacity:   kennel.Capacity,
+				BookingIDs: []string{},
+			}
+			for _, st := range stays {
+				if st.kennelID == kennel.ID && st.checkIn <= date && date < st.checkOut {
+					occupancy.Occupied++
+					occupancy.BookingIDs = append(occupancy.BookingIDs, st.bookingID)
+				}
+			}
+
+			day.Capacity += kennel.Capacity
+			day.Occupied += occupancy.Occupied
+			day.Kennels = append(day.Kennels, occupancy)
+		}
+
+		day.Available = day.Capacity - day.Occupied
+		if day.Available < 0 {
+			day.Available = 0
+		}
+		days = append(days, day)
+	}
+
+	return days, nil
+}
+
+// Helper methods
+
+// quoteStay prices every night of a stay using weekend and holiday rates
+func (s *BoardingService) quoteStay(q dbQuerier, companyID, serviceID string, checkIn, checkOut time.Time) (*models.StayQuote, error) {
+	rate, err := s.boardingRate(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	holidays := make(map[string]bool)
+	rows, err := q.Query(`
+		SELECT holiday_date FROM company_holidays
+		WHERE company_id = $1 AND holiday_date >= $2 AND holiday_date < $3
+	`, companyID, checkIn.Format("2006-01-02"), checkOut.Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get holidays: %w", err)
+	}
+	for rows.Next() {
+		var date time.Time
+		if err := rows.Scan(&date); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		holidays[date.Format("2006-01-02")] = true
+	}
+	rows.Close()
+
+	weekendDays := make(map[string]bool)
+	for _, day := range rate.WeekendDays {
+		weekendDays[day] = true
+	}
+
+	quote := &models.StayQuote{
+		ServiceID:    serviceID,
+		CheckInDate:  checkIn.Format("2006-01-02"),
+		CheckOutDate: checkOut.Format("2006-01-02"),
+		CheckInTime:  rate.CheckInTime,
+		CheckOutTime: rate.CheckOutTime,
+		Nights:       []models.StayNight{},
+	}
+
+	for night := checkIn; night.Before(checkOut); night = night.AddDate(0, 0, 1) {
+		date := night.Format("2006-01-02")
+		n := models.StayNight{Date: date, Rate: rate.NightlyRate, RateType: "regular"}
+
+		switch {
+		case holidays[date] && rate.HolidayRate != nil:
+			n.Rate, n.RateType = *rate.HolidayRate, "holiday"
+		case weekendDays[strings.ToLower(night.Weekday().String())] && rate.WeekendRate != nil:
+			n.Rate, n.RateType = *rate.WeekendRate, "weekend"
+		}
+
+		quote.Nights = append(quote.Nights, n)
+		quote.TotalPrice += n.Rate
+	}
+
+	return quote, nil
+}
+
+// reserveKennel locks the company kennels and returns one that fits the pet and has room every night.
+// Must be called inside the transaction that inserts the stay.
+func (s *BoardingService) reserveKennel(tx *sql.Tx, companyID string, kennelID *string, pet *stayPet, checkIn, checkOut time.Time) (*models.Kennel, error) {
+	kennels, err := s.suitableKennels(tx, companyID, kennelID, pet, true)
+	if err != nil {
+		return nil, err
+	}
+	if len(kennels) == 0 {
+		if kennelID != nil {
+			return nil, fmt.Errorf("kennel not found or not suitable for this pet")
+		}
+		return nil, ErrNoKennelAvailable
+	}
+
+	for _, kennel := range kennels {
+		free, err := s.kennelHasRoom(tx, kennel, checkIn, checkOut)
+		if err != nil {
+			return nil, err
+		}
+		if free {
+			return &kennel, nil
+		}
+	}
+
+	return nil, ErrNoKennelAvailable
+}
+
+// suitableKennels returns active kennels that accept the pet's type and weight, smallest first
+func (s *BoardingService) suitableKennels(q rowsQuerier, companyID string, kennelID *string, pet *stayPet, lock bool) ([]models.Kennel, error) {
+	query := `
+		SELECT` + kennelColumns + `
+		FROM kennels
+		WHERE company_id = $1 AND is_active = true
+		  AND ($2::uuid IS NULL OR id = $2::uuid)
+		  AND (COALESCE(cardinality(allowed_pet_types), 0) = 0 OR $3 = ANY(allowed_pet_types))
+		  AND (max_pet_weight IS NULL OR $4::decimal IS NULL OR max_pet_weight >= $4::decimal)
+		ORDER BY CASE size WHEN 'small' THEN 1 WHEN 'medium' THEN 2 WHEN 'large' THEN 3 ELSE 4 END,
+				 max_pet_weight NULLS LAST, name`
+	if lock {
+		query += " FOR UPDATE"
+	}
+
+	return s.queryKennels(q, query, companyID, kennelID, pet.PetTypeID, pet.Weight)
+}
+
+// kennelHasRoom reports whether the kennel is below capacity on every night of [checkIn, checkOut)
+func (s *BoardingService) kennelHasRoom(q rowsQuerier, kennel models.Kennel, checkIn, checkOut time.Time) (bool, error) {
+	rows, err := q.Query(`
+		SELECT check_in_date, check_out_date
+		FROM bookings
+		WHERE kennel_id = $1 AND check_in_date < $3 AND check_out_date > $2
+		  AND status NOT IN ('cancelled', 'rejected')
+	`, kennel.ID, checkIn.Format("2006-01-02"), checkOut.Format("2006-01-02"))
+	if err != nil {
+		return false, fmt.Errorf("failed to get kennel stays: %w", err)
+	}
+	defer rows.Close()
+
+	var ranges [][2]string
+	for rows.Next() {
+		var in, out time.Time
+		if err := rows.Scan(&in, &out); err != nil {
+			return false, err
+		}
+		ranges = append(ranges, [2]string{in.Format("2006-01-02"), out.Format("2006-01-02")})
+	}
+
+	for night := checkIn; night.Before(checkOut); night = night.AddDate(0, 0, 1) {
+		date := night.Format("2006-01-02")
+		occupied := 0
+		for _, r := range ranges {
+			if r[0] <= date && date < r[1] {
+				occupied++
+			}
+		}
+		if occupied >= kennel.Capacity {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func (s *BoardingService) boardingRate(q rowQuerier, serviceID string) (*models.BoardingRate, error) {
+	var rate models.BoardingRate
+	err := q.QueryRow(`
+		SELECT id, service_id, company_id, nightly_rate, weekend_rate, holiday_rate,
+			   weekend_days, to_char(check_in_time, 'HH24:MI:SS'), to_char(check_out_time, 'HH24:MI:SS'), created_at, updated_at
+		FROM boarding_rates WHERE service_id = $1
+	`, serviceID).Scan(
+		&rate.ID, &rate.ServiceID, &rate.CompanyID, &rate.NightlyRate, &rate.WeekendRate,
+		&rate.HolidayRate, &rate.WeekendDays, &rate.CheckInTime, &rate.CheckOutTime,
+		&rate.CreatedAt, &rate.UpdatedAt,
+	)
+	if err == nil {
+		return &rate, nil
+	}
+	if err != sql.ErrNoRows {
+		return nil, fmt.Errorf("failed to get boarding rates: %w", err)
+	}
+
+	// No configured rates: the service price is charged per night
+	err = q.QueryRow("SELECT company_id, price FROM services WHERE id = $1", serviceID).Scan(
+		&rate.CompanyID, &rate.NightlyRate,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	rate.ServiceID = serviceID
+	rate.WeekendDays = pq.StringArray{}
+	rate.CheckInTime = defaultCheckInTime
+	rate.CheckOutTime = defaultCheckOutTime
+
+	return &rate, nil
+}
+
+func (s *BoardingService) getStayPet(q rowQuerier, petID string) (*stayPet, error) {
+	var pet stayPet
+	var petTypeID sql.NullString
+	var weight sql.NullFloat64
+	err := q.QueryRow("SELECT pet_type_id, weight FROM pets WHERE id = $1", petID).Scan(&petTypeID, &weight)
+	if err != nil {
+		return nil, fmt.Errorf("pet not found")
+	}
+
+	pet.PetTypeID = petTypeID.String
+	if weight.Valid && weight.Float64 > 0 {
+		pet.Weight = &weight.Float64
+	}
+
+	return &pet, nil
+}
+
+func (s *BoardingService) queryKennels(q rowsQuerier, query string, args ...interface{}) ([]models.Kennel, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	kennels := []models.Kennel{}
+	for rows.Next() {
+		var k models.Kennel
+		if err := rows.Scan(
+			&k.ID, &k.CompanyID, &k.Name, &k.Description, &k.Size, &k.MaxPetWeight,
+			&k.AllowedPetTypes, &k.Capacity, &k.IsActive, &k.CreatedAt, &k.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		kennels = append(kennels, k)
+	}
+
+	return kennels, nil
+}
+
+// parseStayDates parses a check-in/check-out date pair and validates the number of nights
+func parseStayDates(checkInDate, checkOutDate string) (time.Time, time.Time, error) {
+	checkIn, err := time.Parse("2006-01-02", checkInDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid check-in date format, use YYYY-MM-DD")
+	}
+	checkOut, err := time.Parse("2006-01-02", checkOutDate)
+	if err != nil {
+		return time.Time{}, time.Time{}, fmt.Errorf("invalid check-out date format, use YYYY-MM-DD")
+	}
+	if !checkOut.After(checkIn) {
+		return time.Time{}, time.Time{}, fmt.Errorf("check-out date must be after check-in date")
+	}
+	if checkOut.Sub(checkIn) > maxStayNights*24*time.Hour {
+		return time.Time{}, time.Time{}, fmt.Errorf("stays are limited to %d nights", maxStayNights)
+	}
+	return checkIn, checkOut, nil
+}
+
+func nonNilStrings(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
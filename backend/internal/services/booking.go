@@ -3,7 +3,9 @@ package services
 import (
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
@@ -12,13 +14,22 @@ import (
 	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
 	"github.com/google/uuid"
 	"github.com/lib/pq"
+	"github.com/robfig/cron/v3"
 )
 
+// ErrSlotTaken is returned when the requested interval has no capacity left for the service or employee
+var ErrSlotTaken = errors.New("requested time slot is not available")
+
 type BookingService struct {
 	db                  *sql.DB
 	notificationService *NotificationService
 	emailService        *EmailService
 	smsService          *SMSService
+	scheduleService     *ScheduleService
+	boardingService     *BoardingService
+	paymentService      *PaymentService
+	packageService      *PackageService
+	cronScheduler       *cron.Cron
 }
 
 func NewBookingService(db *sql.DB, notificationService *NotificationService, emailService *EmailService, smsService *SMSService) *BookingService {
@@ -27,9 +38,57 @@ func NewBookingService(db *sql.DB, notificationService *NotificationService, ema
 		notificationService: notificationService,
 		emailService:        emailService,
 		smsService:          smsService,
+		cronScheduler:       cron.New(),
 	}
 }
 
+// SetScheduleService injects the employee schedule service used by availability checks
+func (s *BookingService) SetScheduleService(scheduleService *ScheduleService) {
+	s.scheduleService = scheduleService
+}
+
+// StartBookingCron starts the background jobs of the booking service
+func (s *BookingService) StartBookingCron() {
+	// Extend recurring series once an hour
+	_, err := s.cronScheduler.AddFunc("0 * * * *", s.ExtendActiveSeries)
+	if err != nil {
+		log.Printf("Error adding booking series cron job: %v", err)
+		return
+	}
+
+	// Pass expired waitlist holds on every minute
+	_, err = s.cronScheduler.AddFunc("* * * * *", s.ExpireWaitlistHolds)
+	if err != nil {
+		log.Printf("Error adding waitlist cron job: %v", err)
+		return
+	}
+
+	// Confirm paid deposits and release unpaid slots every minute
+	_, err = s.cronScheduler.AddFunc("* * * * *", s.ReleaseUnpaidBookings)
+	if err != nil {
+		log.Printf("Error adding deposit cron job: %v", err)
+		return
+	}
+
+	// Re-import external calendars every 30 minutes
+	if s.scheduleService != nil {
+		_, err = s.cronScheduler.AddFunc("*/30 * * * *", s.scheduleService.RefreshExternalCalendars)
+		if err != nil {
+			log.Printf("Error adding external calendar cron job: %v", err)
+			return
+		}
+	}
+
+	s.cronScheduler.Start()
+	log.Println("Booking cron scheduler started")
+}
+
+// StopBookingCron stops the booking background jobs
+func (s *BookingService) StopBookingCron() {
+	s.cronScheduler.Stop()
+	log.Println("Booking cron scheduler stopped")
+}
+
 type BookingRequest struct {
 	UserID     string    `json:"user_id" binding:"required"`
 	CompanyID  string    `json:"company_id" binding:"required"`
@@ -38,10 +97,33 @@ type BookingRequest struct {
 	EmployeeID *string   `json:"employee_id"`
 	DateTime   time.Time `json:"date_time" binding:"required"`
 	Notes      string    `json:"notes"`
+	OptionIDs  []string  `json:"option_ids"` // Add-ons of the service, adding to price and duration
+
+	// Customer address for walking, sitting and pet taxi appointments; the employee travels there
+	Location *models.BookingLocation `json:"location"`
+
+	// Answers to the service's intake forms; forms due at booking must be included when the customer books
+	IntakeForms []models.IntakeSubmission `json:"intake_forms"`
+
+	// Set internally when the booking is generated from a recurring series
+	SeriesID       *string `json:"-"`
+	OccurrenceDate *string `json:"-"` // YYYY-MM-DD in company time
+
+	// Set internally when a waitlist hold is converted; the hold does not block its own booking
+	WaitlistEntryID *string `json:"-"`
+
+	// Set internally for bookings made without the customer present;
+	// no deposit is requested and the company's no-show rules do not apply
+	SkipDeposit bool `json:"-"`
+
+	// Set internally when someone other than the customer makes the booking; defaults to the customer
+	Actor *models.BookingActor `json:"-"`
 }
 
 type AvailabilitySlot struct {
-	DateTime     time.Time `json:"date_time"`
+	DateTime     time.Time `json:"date_time"`     // Company local time
+	DateTimeUTC  time.Time `json:"date_time_utc"` // Same instant in UTC
+	Timezone     string    `json:"timezone"`
 	Available    bool      `json:"available"`
 	EmployeeID   *string   `json:"employee_id"`
 	EmployeeName string    `json:"employee_name"`
@@ -92,6 +174,8 @@ type AIBookingRequest struct {
 	PetID     string    `json:"pet_id" binding:"required"`
 	DateTime  time.Time `json:"date_time" binding:"required"`
 	Notes     string    `json:"notes"`
+
+	Location *models.BookingLocation `json:"location"` // Customer address of mobile services
 }
 
 type AIBookingResponse struct {
@@ -119,38 +203,105 @@ func (s *BookingService) CreateBooking(req *BookingRequest) (*models.Booking, er
 
 	// 1. Validate service exists and is active
 	var service models.Service
+	var deposit models.ServiceDeposit
 	err = tx.QueryRow(`
 		SELECT id, company_id, name, price, duration, max_bookings_per_slot, 
-			   advance_booking_days, cancellation_policy, is_active
+			   advance_booking_days, cancellation_policy, is_active,
+			   deposit_type, deposit_value, deposit_hold_minutes
 		FROM services 
 		WHERE id = $1 AND company_id = $2 AND is_active = true
 	`, req.ServiceID, req.CompanyID).Scan(
 		&service.ID, &service.CompanyID, &service.Name, &service.Price,
 		&service.Duration, &service.MaxBookingsPerSlot, &service.AdvanceBookingDays,
 		&service.CancellationPolicy, &service.IsActive,
+		&deposit.DepositType, &deposit.DepositValue, &deposit.HoldMinutes,
 	)
 	if err != nil {
 		return nil, fmt.Errorf("service not found or inactive")
 	}
 
+	// Price and duration follow the service's price matrix for the pet's type, size and weight
+	service.Price, service.Duration, err = petServicePricing(tx, req.ServiceID, req.PetID, service.Price, service.Duration)
+	if err != nil {
+		return nil, err
+	}
+
+	// Chosen options add their price and extra minutes
+	options, err := selectedServiceOptions(tx, req.ServiceID, req.OptionIDs)
+	if err != nil {
+		return nil, err
+	}
+	optionsPrice, optionsMinutes := sumServiceOptions(options)
+	service.Price = service.Price.Add(optionsPrice)
+	service.Duration += optionsMinutes
+
+	// Bookings are stored in UTC and validated against the company's local calendar
+	loc := companyLocation(tx, req.CompanyID)
+	dateTime := req.DateTime.UTC()
+	localDateTime := dateTime.In(loc)
+	now := time.Now().In(loc)
+
 	// 2. Check advance booking limit
-	maxAdvanceDate := time.Now().AddDate(0, 0, service.AdvanceBookingDays)
-	if req.DateTime.After(maxAdvanceDate) {
+	maxAdvanceDate := inLocationDate(now, loc).AddDate(0, 0, service.AdvanceBookingDays+1)
+	if !localDateTime.Before(maxAdvanceDate) {
 		return nil, fmt.Errorf("booking date exceeds advance booking limit of %d days", service.AdvanceBookingDays)
 	}
 
 	// 3. Check if booking is in the past
-	if req.DateTime.Before(time.Now()) {
+	if localDateTime.Before(now) {
 		return nil, fmt.Errorf("cannot book appointments in the past")
 	}
 
-	// 4. Check availability
-	available, err := s.checkTimeSlotAvailability(tx, req.ServiceID, req.DateTime, req.EmployeeID)
+	// 4. Check availability (locks the service and employee until commit)
+	excludeID := ""
+	if req.WaitlistEntryID != nil {
+		excludeID = *req.WaitlistEntryID
+	}
+	available, err := s.checkTimeSlotAvailability(tx, req.ServiceID, dateTime, service.Duration, req.EmployeeID, excludeID)
 	if err != nil {
 		return nil, err
 	}
 	if !available {
-		return nil, fmt.Errorf("requested time slot is not available")
+		return nil, ErrSlotTaken
+	}
+
+	// Check the assigned employee is on shift and not on a break or time-off
+	if req.EmployeeID != nil && s.scheduleService != nil {
+		slotEnd := dateTime.Add(time.Duration(service.Duration) * time.Minute)
+		working, err := s.scheduleService.IsEmployeeWorking(*req.EmployeeID, dateTime, slotEnd)
+		if err != nil {
+			return nil, err
+		}
+		if !working {
+			return nil, fmt.Errorf("employee is not working at the requested time")
+		}
+	}
+
+	// Mobile employees need time to get to the address and on to their next job
+	if err := validateBookingLocation(req.Location); err != nil {
+		return nil, err
+	}
+	if err := s.checkEmployeeTravel(tx, req.CompanyID, req.EmployeeID, dateTime, service.Duration, req.Location, ""); err != nil {
+		return nil, err
+	}
+
+	// Check the company has not blocked the time in an imported calendar
+	if s.scheduleService != nil {
+		busy, err := s.scheduleService.IsCompanyBusy(req.CompanyID, dateTime, dateTime.Add(time.Duration(service.Duration)*time.Minute))
+		if err != nil {
+			return nil, err
+		}
+		if busy {
+			return nil, fmt.Errorf("company is not available at the requested time")
+		}
+	}
+
+	// Intake forms answered with the booking. Forms due at booking are only enforced when customers book
+	// themselves; bookings by staff, the assistant, a series or the waitlist leave them outstanding.
+	customerBooking := req.Actor == nil && req.SeriesID == nil && req.WaitlistEntryID == nil
+	intake, err := collectBookingIntake(tx, req.ServiceID, req.IntakeForms, customerBooking)
+	if err != nil {
+		return nil, err
 	}
 
 	// 5. Validate pet belongs to user
@@ -163,6 +314,21 @@ func (s *BookingService) CreateBooking(req *BookingRequest) (*models.Booking, er
 		return nil, fmt.Errorf("pet does not belong to user")
 	}
 
+	// Services requiring a waiver need the customer's signature of its current version
+	waiverSignatureID, err := serviceWaiverSignature(tx, req.ServiceID, req.UserID, customerBooking)
+	if err != nil {
+		return nil, err
+	}
+
+	// Customers with repeated no-shows may be blocked or asked for a deposit
+	var noShowRules *models.NoShowRules
+	if !req.SkipDeposit {
+		noShowRules, err = s.customerNoShowRules(tx, req.CompanyID, req.UserID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// 6. Create booking
 	booking := &models.Booking{
 		ID:         uuid.New().String(),
@@ -171,28 +337,94 @@ func (s *BookingService) CreateBooking(req *BookingRequest) (*models.Booking, er
 		ServiceID:  req.ServiceID,
 		PetID:      &req.PetID,
 		EmployeeID: req.EmployeeID,
-		DateTime:   req.DateTime,
+		DateTime:   dateTime,
 		Duration:   service.Duration,
 		Price:      service.Price,
 		Status:     "pending",
 		Notes:      &req.Notes,
+		SeriesID:   req.SeriesID,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
+
+		OptionsPrice: optionsPrice,
+
+		ServiceLocation:   req.Location,
+		WaiverSignatureID: waiverSignatureID,
+	}
+
+	// Services requiring prepayment hold the slot until the deposit is paid
+	if !req.SkipDeposit && s.paymentService != nil {
+		amount := depositAmount(deposit.DepositType, deposit.DepositValue, service.Price)
+		if noShowRules != nil {
+			if noShowDeposit := depositAmount(noShowRules.DepositType, noShowRules.DepositValue, service.Price); noShowDeposit.Amount > amount.Amount {
+				amount = noShowDeposit
+			}
+		}
+		if amount.Amount > 0 {
+			expiresAt := time.Now().Add(time.Duration(deposit.HoldMinutes) * time.Minute)
+			if expiresAt.After(dateTime) {
+				expiresAt = dateTime
+			}
+			booking.Status = "pending_payment"
+			booking.DepositAmount = &amount
+			booking.PaymentExpiresAt = &expiresAt
+		}
 	}
 
+	serviceAddress, serviceLatitude, serviceLongitude := locationColumns(booking.ServiceLocation)
 	_, err = tx.Exec(`
 		INSERT INTO bookings (
 			id, user_id, company_id, service_id, pet_id, employee_id,
-			date_time, duration, price, status, notes, created_at, updated_at
-		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+			date_time, duration, price, status, notes, created_at, updated_at,
+			series_id, occurrence_date, deposit_amount, payment_expires_at, options_price,
+			service_address, service_latitude, service_longitude, waiver_signature_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21, $22)
 	`, booking.ID, booking.UserID, booking.CompanyID, booking.ServiceID,
 		booking.PetID, booking.EmployeeID, booking.DateTime, booking.Duration,
-		booking.Price, booking.Status, booking.Notes, booking.CreatedAt, booking.UpdatedAt)
+		booking.Price, booking.Status, booking.Notes, booking.CreatedAt, booking.UpdatedAt,
+		booking.SeriesID, req.OccurrenceDate, booking.DepositAmount, booking.PaymentExpiresAt,
+		booking.OptionsPrice, serviceAddress, serviceLatitude, serviceLongitude, booking.WaiverSignatureID)
 
 	if err != nil {
 		return nil, err
 	}
 
+	if booking.Options, err = saveBookingOptions(tx, booking.ID, options); err != nil {
+		return nil, err
+	}
+
+	// A waitlist offer is used up together with the booking, so it can only be accepted once
+	if req.WaitlistEntryID != nil {
+		result, err := tx.Exec(`
+			UPDATE booking_waitlist SET status = 'booked', booking_id = $2, updated_at = NOW()
+			WHERE id = $1 AND status = 'offered' AND hold_expires_at > NOW()
+		`, *req.WaitlistEntryID, booking.ID)
+		if err != nil {
+			return nil, err
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return nil, fmt.Errorf("there is no active offer for this waitlist entry")
+		}
+	}
+
+	actor := UserActor(req.UserID)
+	if req.Actor != nil {
+		actor = *req.Actor
+	}
+	err = recordBookingEvent(tx, booking.ID, "created", actor, map[string]models.BookingFieldChange{
+		"status":      bookingFieldChange(nil, booking.Status),
+		"date_time":   bookingFieldChange(nil, booking.DateTime),
+		"price":       bookingFieldChange(nil, booking.Price),
+		"employee_id": bookingFieldChange(nil, booking.EmployeeID),
+	}, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err = saveBookingIntake(tx, booking.ID, intake, actor); err != nil {
+		return nil, err
+	}
+
 	// 7. Schedule notifications
 	err = s.scheduleBookingNotifications(tx, booking)
 	if err != nil {
@@ -203,20 +435,33 @@ func (s *BookingService) CreateBooking(req *BookingRequest) (*models.Booking, er
 		return nil, err
 	}
 
-	// 8. Send immediate notifications
+	// 8. Request the deposit; the payment row references the committed booking
+	if booking.Status == "pending_payment" {
+		if err = s.requestBookingDeposit(booking); err != nil {
+			s.releaseUnpaidBooking(booking)
+			return nil, err
+		}
+	}
+
+	// 9. Send immediate notifications
 	go s.sendBookingCreatedNotifications(booking)
 
 	return booking, nil
 }
 
-// CheckAvailability returns available time slots for a service
-func (s *BookingService) CheckAvailability(serviceID string, date time.Time, employeeID *string) ([]AvailabilitySlot, error) {
-	// Get service details and business hours
+// CheckAvailability returns available time slots for a service.
+// When petID is set, slot length and price follow the service's price matrix for that pet;
+// chosen options lengthen the slots and add to the price. For walking, sitting and pet taxi companies
+// a location keeps only the slots an employee can travel to from their other jobs.
+func (s *BookingService) CheckAvailability(serviceID string, date time.Time, employeeID, petID *string, optionIDs []string, location *models.BookingLocation) ([]AvailabilitySlot, error) {
+	// Get service details, business hours and company timezone
 	var service models.Service
+	var timezone sql.NullString
+	var serviceCompanyID string
 	err := s.db.QueryRow(`
 		SELECT s.id, s.name, s.price, s.duration, s.max_bookings_per_slot,
 			   s.available_days, s.start_time, s.end_time, s.buffer_time_before, s.buffer_time_after,
-			   c.business_hours
+			   s.assigned_employees, c.business_hours, c.timezone, c.id
 		FROM services s
 		JOIN companies c ON s.company_id = c.id
 		WHERE s.id = $1 AND s.is_active = true
@@ -224,12 +469,34 @@ func (s *BookingService) CheckAvailability(serviceID string, date time.Time, emp
 		&service.ID, &service.Name, &service.Price, &service.Duration,
 		&service.MaxBookingsPerSlot, &service.AvailableDays,
 		&service.StartTime, &service.EndTime, &service.BufferTimeBefore,
-		&service.BufferTimeAfter, &service.CompanyID, // using CompanyID field to store business_hours
+		&service.BufferTimeAfter, pq.Array(&service.AssignedEmployees),
+		&service.CompanyID, // using CompanyID field to store business_hours
+		&timezone, &serviceCompanyID,
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	if petID != nil {
+		service.Price, service.Duration, err = petServicePricing(s.db, serviceID, *petID, service.Price, service.Duration)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	options, err := selectedServiceOptions(s.db, serviceID, optionIDs)
+	if err != nil {
+		return nil, err
+	}
+	optionsPrice, optionsMinutes := sumServiceOptions(options)
+	service.Price = service.Price.Add(optionsPrice)
+	service.Duration += optionsMinutes
+
+	// Slots are generated on the requested calendar date in the company's timezone
+	loc := loadLocation(timezone.String)
+	date = inLocationDate(date, loc)
+	now := time.Now()
+
 	// Check if the requested date is available for this service
 	dayOfWeek := date.Weekday().String()
 	available := false
@@ -246,28 +513,109 @@ func (s *BookingService) CheckAvailability(serviceID string, date time.Time, emp
 	// Generate time slots for the day
 	slots := s.generateTimeSlots(service, date)
 
-	// Get existing bookings for the day
-	existingBookings, err := s.getExistingBookings(serviceID, date, employeeID)
+	// Bookings and waitlist holds around the day, counted the same way checkTimeSlotAvailability does
+	bufferBefore := time.Duration(service.BufferTimeBefore) * time.Minute
+	bufferAfter := time.Duration(service.BufferTimeAfter) * time.Minute
+	duration := time.Duration(service.Duration) * time.Minute
+	rangeStart := date.Add(-bufferBefore)
+	rangeEnd := date.AddDate(0, 0, 1).Add(duration + bufferAfter)
+	serviceIntervals, err := s.getOverlappingIntervals(s.db, "service_id", serviceID, rangeStart, rangeEnd, "")
+	if err != nil {
+		return nil, err
+	}
+	var employeeIntervals []bookingInterval
+	if employeeID != nil {
+		employeeIntervals, err = s.getOverlappingIntervals(s.db, "employee_id", *employeeID, rangeStart, rangeEnd, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	// Working windows of the requested employee, or of everyone assigned to the service
+	scheduledEmployees := []string(service.AssignedEmployees)
+	if employeeID != nil {
+		scheduledEmployees = []string{*employeeID}
+	}
+	employeeWindows, err := s.getEmployeeWindows(scheduledEmployees, date)
 	if err != nil {
 		return nil, err
 	}
 
+	// Appointments of mobile employees around the day, to fit travel to the customer's address
+	var travel *companyTravel
+	var routes map[string][]routeJob
+	if location != nil && len(scheduledEmployees) > 0 {
+		if err := validateBookingLocation(location); err != nil {
+			return nil, err
+		}
+		travel, err = loadCompanyTravel(s.db, serviceCompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if travel.Mobile {
+			routes, err = s.employeeRoutes(scheduledEmployees, date)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	// Company-wide busy time imported from external calendars
+	var companyBusy []models.ExternalBusyBlock
+	if s.scheduleService != nil {
+		companyBusy, err = s.scheduleService.GetCompanyBusyBlocks(serviceCompanyID, date, date.AddDate(0, 0, 1))
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	maxBookings := service.MaxBookingsPerSlot
+	if maxBookings < 1 {
+		maxBookings = 1
+	}
+
 	// Check availability for each slot
 	var availableSlots []AvailabilitySlot
 	for _, slot := range slots {
-		currentCount := 0
-		for _, booking := range existingBookings {
-			if s.timeSlotsOverlap(slot, booking.DateTime, time.Duration(service.Duration)*time.Minute) {
-				currentCount++
+		slotStart := slot.Add(-bufferBefore)
+		slotEnd := slot.Add(duration + bufferAfter)
+
+		currentCount := peakConcurrency(overlappingIntervals(serviceIntervals, slotStart, slotEnd), slotStart, slotEnd)
+		slotAvailable := currentCount < maxBookings && !slot.Before(now)
+
+		// Group sessions of the same service may share the employee, anything else means they are busy
+		if slotAvailable && employeeID != nil {
+			employeeOverlapping := overlappingIntervals(employeeIntervals, slotStart, slotEnd)
+			for _, interval := range employeeOverlapping {
+				if interval.ServiceID != serviceID {
+					slotAvailable = false
+				}
+			}
+			if peakConcurrency(employeeOverlapping, slotStart, slotEnd) >= maxBookings {
+				slotAvailable = false
+			}
+		}
+		if slotAvailable && employeeWindows != nil {
+			slotAvailable = anyEmployeeWorking(employeeWindows, slot, slot.Add(duration))
+		}
+		if slotAvailable && routes != nil {
+			slotAvailable = travel.anyEmployeeCanTravel(employeeWindows, routes, slot, slot.Add(duration), location)
+		}
+		for _, block := range companyBusy {
+			if intervalsOverlap(slot, slot.Add(duration), block.StartsAt, block.EndsAt) {
+				slotAvailable = false
 			}
 		}
 
 		availableSlots = append(availableSlots, AvailabilitySlot{
 			DateTime:     slot,
-			Available:    currentCount < service.MaxBookingsPerSlot,
+			DateTimeUTC:  slot.UTC(),
+			Timezone:     loc.String(),
+			Available:    slotAvailable,
+			EmployeeID:   employeeID,
 			ServiceID:    service.ID,
 			ServiceName:  service.Name,
-			Price:        service.Price,
+			Price:        service.Price.Decimal(),
 			Duration:     service.Duration,
 			MaxBookings:  service.MaxBookingsPerSlot,
 			CurrentCount: currentCount,
@@ -278,13 +626,27 @@ func (s *BookingService) CheckAvailability(serviceID string, date time.Time, emp
 }
 
 // UpdateBooking updates a booking with provided fields
-func (s *BookingService) UpdateBooking(bookingID string, updates map[string]interface{}) (*models.Booking, error) {
+func (s *BookingService) UpdateBooking(bookingID string, updates map[string]interface{}, actor models.BookingActor) (*models.Booking, error) {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return nil, err
 	}
 	defer tx.Rollback()
 
+	// Keep the current values for the change history
+	var before models.Booking
+	err = tx.QueryRow(`
+		SELECT id, employee_id, date_time, duration, price, status, notes
+		FROM bookings WHERE id = $1
+		FOR UPDATE
+	`, bookingID).Scan(
+		&before.ID, &before.EmployeeID, &before.DateTime, &before.Duration,
+		&before.Price, &before.Status, &before.Notes,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	// Build dynamic update query
 	setParts := []string{}
 	args := []interface{}{}
@@ -326,6 +688,12 @@ func (s *BookingService) UpdateBooking(bookingID string, updates map[string]inte
 		argIndex++
 	}
 
+	if price, ok := updates["price"]; ok {
+		setParts = append(setParts, fmt.Sprintf("price = $%d", argIndex))
+		args = append(args, price)
+		argIndex++
+	}
+
 	if len(setParts) == 1 { // Only updated_at
 		return nil, fmt.Errorf("no valid fields to update")
 	}
@@ -351,6 +719,10 @@ func (s *BookingService) UpdateBooking(bookingID string, updates map[string]inte
 		return nil, err
 	}
 
+	if err = recordBookingChanges(tx, &before, &booking, actor, ""); err != nil {
+		return nil, err
+	}
+
 	if err = tx.Commit(); err != nil {
 		return nil, err
 	}
@@ -359,7 +731,7 @@ func (s *BookingService) UpdateBooking(bookingID string, updates map[string]inte
 }
 
 // UpdateBookingStatus updates booking status and sends notifications
-func (s *BookingService) UpdateBookingStatus(bookingID, newStatus string, notes string) error {
+func (s *BookingService) UpdateBookingStatus(bookingID, newStatus string, notes string, actor models.BookingActor) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -396,17 +768,14 @@ func (s *BookingService) UpdateBookingStatus(bookingID, newStatus string, notes
 		return err
 	}
 
-	// Handle status-specific logic
-	switch newStatus {
-	case "confirmed":
-		err = s.scheduleReminderNotifications(tx, &booking)
-	case "cancelled":
-		err = s.cancelBookingNotifications(tx, bookingID)
-	case "completed":
-		err = s.scheduleFollowUpNotifications(tx, &booking)
+	err = recordBookingEvent(tx, bookingID, "status_changed", actor, map[string]models.BookingFieldChange{
+		"status": bookingFieldChange(booking.Status, newStatus),
+	}, notes)
+	if err != nil {
+		return err
 	}
 
-	if err != nil {
+	if err = s.applyStatusChange(tx, &booking, newStatus); err != nil {
 		return err
 	}
 
@@ -417,9 +786,37 @@ func (s *BookingService) UpdateBookingStatus(bookingID, newStatus string, notes
 	// Send status change notifications
 	go s.sendStatusChangeNotifications(&booking, newStatus)
 
+	// Offer the freed slot to the first waitlisted customer
+	if newStatus == "cancelled" {
+		go s.offerFreedSlot(booking.ServiceID, booking.DateTime, booking.EmployeeID)
+	}
+
 	return nil
 }
 
+// applyStatusChange runs the status-specific side effects of a booking moving to newStatus
+func (s *BookingService) applyStatusChange(tx *sql.Tx, booking *models.Booking, newStatus string) error {
+	var err error
+	switch newStatus {
+	case "confirmed":
+		err = s.scheduleReminderNotifications(tx, booking)
+	case "cancelled", "no_show":
+		err = s.cancelBookingNotifications(tx, booking.ID)
+	case "completed":
+		err = s.scheduleFollowUpNotifications(tx, booking)
+		if err == nil && s.packageService != nil {
+			// Pay for the visit from a prepaid package when the customer has one
+			err = s.packageService.redeemBookingSession(tx, booking)
+		}
+		if err == nil {
+			// The service is delivered, so what the customer paid for it is released to the company
+			_, err = releaseCompletedPayments(tx, &booking.ID, nil)
+		}
+	}
+
+	return err
+}
+
 // GetBookingsByUser returns bookings for a specific user
 func (s *BookingService) GetBookingsByUser(userID string, status string, limit int) ([]models.Booking, error) {
 	whereClause := "WHERE user_id = $1"
@@ -682,6 +1079,11 @@ func (s *BookingService) GetBookingsByCompany(companyID string, startDate ...int
 		bookings = append(bookings, bookingWithData)
 	}
 
+	// Include the intake forms asked for each booking
+	if err := s.attachBookingIntake(bookings); err != nil {
+		return nil, err
+	}
+
 	return bookings, nil
 }
 
@@ -715,6 +1117,11 @@ func (s *BookingService) GetCompanyCustomers(companyID string) ([]models.Custome
 	}
 	defer rows.Close()
 
+	reliability, err := s.customerReliability(companyID)
+	if err != nil {
+		return nil, err
+	}
+
 	var customers []models.CustomerData
 	for rows.Next() {
 		var customer models.CustomerData
@@ -768,6 +1175,7 @@ func (s *BookingService) GetCompanyCustomers(companyID string) ([]models.Custome
 		if emergencyContactRelation.Valid {
 			customer.EmergencyContactRelation = emergencyContactRelation.String
 		}
+		customer.Reliability = reliability[customer.UserID]
 		
 		customers = append(customers, customer)
 	}
@@ -833,40 +1241,78 @@ func (s *BookingService) GetCustomerBookingHistory(companyID, userID string) ([]
 		bookings = append(bookings, bookingWithData)
 	}
 
+	// Include who changed each booking and how
+	history, err := s.customerBookingEvents(companyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, booking := range bookings {
+		booking.History = history[booking.ID]
+	}
+	if err := s.attachBookingIntake(bookings); err != nil {
+		return nil, err
+	}
+
 	return bookings, nil
 }
 
 // Helper methods
 
-func (s *BookingService) checkTimeSlotAvailability(tx *sql.Tx, serviceID string, dateTime time.Time, employeeID *string) (bool, error) {
-	// Get service max bookings per slot
-	var maxBookings int
-	err := tx.QueryRow("SELECT max_bookings_per_slot FROM services WHERE id = $1", serviceID).Scan(&maxBookings)
+// checkTimeSlotAvailability checks service and employee capacity for [dateTime, dateTime+duration),
+// widened by the service buffers. The service row (and employee row) are locked FOR UPDATE so that
+// concurrent bookings for the same resources are serialized until the transaction ends.
+func (s *BookingService) checkTimeSlotAvailability(tx *sql.Tx, serviceID string, dateTime time.Time, duration int, employeeID *string, excludeBookingID string) (bool, error) {
+	var maxBookings, bufferBefore, bufferAfter int
+	err := tx.QueryRow(`
+		SELECT max_bookings_per_slot, COALESCE(buffer_time_before, 0), COALESCE(buffer_time_after, 0)
+		FROM services WHERE id = $1
+		FOR UPDATE
+	`, serviceID).Scan(&maxBookings, &bufferBefore, &bufferAfter)
 	if err != nil {
 		return false, err
 	}
-
-	// Count existing bookings for this time slot
-	whereClause := `
-		WHERE service_id = $1 
-		AND date_time = $2 
-		AND status NOT IN ('cancelled', 'rejected')
-	`
-	args := []interface{}{serviceID, dateTime}
+	if maxBookings < 1 {
+		maxBookings = 1
+	}
 
 	if employeeID != nil {
-		whereClause += " AND employee_id = $3"
-		args = append(args, *employeeID)
+		var lockedID string
+		err = tx.QueryRow("SELECT id FROM employees WHERE id = $1 AND is_active = true FOR UPDATE", *employeeID).Scan(&lockedID)
+		if err != nil {
+			return false, fmt.Errorf("employee not found or inactive")
+		}
 	}
 
-	var currentBookings int
-	query := fmt.Sprintf("SELECT COUNT(*) FROM bookings %s", whereClause)
-	err = tx.QueryRow(query, args...).Scan(&currentBookings)
+	start := dateTime.Add(-time.Duration(bufferBefore) * time.Minute)
+	end := dateTime.Add(time.Duration(duration+bufferAfter) * time.Minute)
+
+	// Service capacity
+	serviceIntervals, err := s.getOverlappingIntervals(tx, "service_id", serviceID, start, end, excludeBookingID)
 	if err != nil {
 		return false, err
 	}
+	if peakConcurrency(serviceIntervals, start, end) >= maxBookings {
+		return false, nil
+	}
+
+	// Employee capacity: group sessions of the same service may share an employee,
+	// anything else overlapping means the employee is busy
+	if employeeID != nil {
+		employeeIntervals, err := s.getOverlappingIntervals(tx, "employee_id", *employeeID, start, end, excludeBookingID)
+		if err != nil {
+			return false, err
+		}
+		for _, interval := range employeeIntervals {
+			if interval.ServiceID != serviceID {
+				return false, nil
+			}
+		}
+		if peakConcurrency(employeeIntervals, start, end) >= maxBookings {
+			return false, nil
+		}
+	}
 
-	return currentBookings < maxBookings, nil
+	return true, nil
 }
 
 func (s *BookingService) generateTimeSlots(service models.Service, date time.Time) []time.Time {
@@ -894,71 +1340,132 @@ func (s *BookingService) generateTimeSlots(service models.Service, date time.Tim
 	return slots
 }
 
-func (s *BookingService) getExistingBookings(serviceID string, date time.Time, employeeID *string) ([]models.Booking, error) {
-	startOfDay := time.Date(date.Year(), date.Month(), date.Day(), 0, 0, 0, 0, date.Location())
-	endOfDay := startOfDay.Add(24 * time.Hour)
-
-	whereClause := `
-		WHERE service_id = $1 
-		AND date_time >= $2 
-		AND date_time < $3 
-		AND status NOT IN ('cancelled', 'rejected')
-	`
-	args := []interface{}{serviceID, startOfDay, endOfDay}
+// bookingInterval is the time a booking occupies, including its service buffers
+type bookingInterval struct {
+	ServiceID string
+	Start     time.Time
+	End       time.Time
+}
 
-	if employeeID != nil {
-		whereClause += " AND employee_id = $4"
-		args = append(args, *employeeID)
-	}
+// rowsQuerier is implemented by both *sql.DB and *sql.Tx
+type rowsQuerier interface {
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+}
 
+// getOverlappingIntervals returns active bookings and waitlist holds whose buffered interval overlaps [start, end).
+// column must be a trusted column name present in both the bookings and booking_waitlist tables.
+func (s *BookingService) getOverlappingIntervals(q rowsQuerier, column, value string, start, end time.Time, excludeBookingID string) ([]bookingInterval, error) {
 	query := fmt.Sprintf(`
-		SELECT id, user_id, company_id, service_id, pet_id, employee_id,
-			   date_time, duration, price, status, notes, payment_id,
-			   created_at, updated_at
-		FROM bookings %s
-		ORDER BY date_time
-	`, whereClause)
-
-	rows, err := s.db.Query(query, args...)
+		SELECT b.service_id,
+			   b.date_time - (COALESCE(sv.buffer_time_before, 0) * INTERVAL '1 minute'),
+			   b.date_time + ((b.duration + COALESCE(sv.buffer_time_after, 0)) * INTERVAL '1 minute')
+		FROM bookings b
+		JOIN services sv ON b.service_id = sv.id
+		WHERE b.%[1]s = $1
+		AND b.status NOT IN ('cancelled', 'rejected')
+		AND NOT (b.status = 'pending_payment' AND b.payment_expires_at <= NOW())
+		AND b.id::text <> $4
+		AND b.date_time - (COALESCE(sv.buffer_time_before, 0) * INTERVAL '1 minute') < $3
+		AND b.date_time + ((b.duration + COALESCE(sv.buffer_time_after, 0)) * INTERVAL '1 minute') > $2
+		UNION ALL
+		SELECT w.service_id,
+			   w.hold_date_time - (COALESCE(sv.buffer_time_before, 0) * INTERVAL '1 minute'),
+			   w.hold_date_time + ((sv.duration + COALESCE(sv.buffer_time_after, 0)) * INTERVAL '1 minute')
+		FROM booking_waitlist w
+		JOIN services sv ON w.service_id = sv.id
+		WHERE w.%[1]s = $1
+		AND w.status = 'offered' AND w.hold_expires_at > NOW()
+		AND w.id::text <> $4
+		AND w.hold_date_time - (COALESCE(sv.buffer_time_before, 0) * INTERVAL '1 minute') < $3
+		AND w.hold_date_time + ((sv.duration + COALESCE(sv.buffer_time_after, 0)) * INTERVAL '1 minute') > $2
+	`, column)
+
+	rows, err := q.Query(query, value, start, end, excludeBookingID)
 	if err != nil {
-		return nil, err
+		return nil, fmt.Errorf("failed to get overlapping bookings: %w", err)
 	}
 	defer rows.Close()
 
-	var bookings []models.Booking
+	var intervals []bookingInterval
 	for rows.Next() {
-		var booking models.Booking
-		err := rows.Scan(
-			&booking.ID, &booking.UserID, &booking.CompanyID, &booking.ServiceID,
-			&booking.PetID, &booking.EmployeeID, &booking.DateTime, &booking.Duration,
-			&booking.Price, &booking.Status, &booking.Notes, &booking.PaymentID,
-			&booking.CreatedAt, &booking.UpdatedAt,
-		)
-		if err != nil {
+		var interval bookingInterval
+		if err := rows.Scan(&interval.ServiceID, &interval.Start, &interval.End); err != nil {
 			return nil, err
 		}
-		bookings = append(bookings, booking)
+		intervals = append(intervals, interval)
 	}
 
-	return bookings, nil
+	return intervals, nil
+}
+
+// overlappingIntervals returns the intervals that intersect [start, end)
+func overlappingIntervals(intervals []bookingInterval, start, end time.Time) []bookingInterval {
+	var overlapping []bookingInterval
+	for _, interval := range intervals {
+		if intervalsOverlap(start, end, interval.Start, interval.End) {
+			overlapping = append(overlapping, interval)
+		}
+	}
+	return overlapping
+}
+
+// intervalsOverlap reports whether [aStart, aEnd) and [bStart, bEnd) intersect
+func intervalsOverlap(aStart, aEnd, bStart, bEnd time.Time) bool {
+	return aStart.Before(bEnd) && bStart.Before(aEnd)
 }
 
-func (s *BookingService) timeSlotsOverlap(slot1 time.Time, slot2 time.Time, duration time.Duration) bool {
-	slot1End := slot1.Add(duration)
-	slot2End := slot2.Add(duration)
+// peakConcurrency returns the maximum number of intervals active at the same moment within [start, end)
+func peakConcurrency(intervals []bookingInterval, start, end time.Time) int {
+	type event struct {
+		at    time.Time
+		delta int
+	}
+
+	var events []event
+	for _, interval := range intervals {
+		from, to := interval.Start, interval.End
+		if from.Before(start) {
+			from = start
+		}
+		if to.After(end) {
+			to = end
+		}
+		if !from.Before(to) {
+			continue
+		}
+		events = append(events, event{at: from, delta: 1}, event{at: to, delta: -1})
+	}
+
+	// Ends sort before starts at the same instant so back-to-back bookings do not count as overlapping
+	sort.Slice(events, func(i, j int) bool {
+		if events[i].at.Equal(events[j].at) {
+			return events[i].delta < events[j].delta
+		}
+		return events[i].at.Before(events[j].at)
+	})
+
+	current, peak := 0, 0
+	for _, e := range events {
+		current += e.delta
+		if current > peak {
+			peak = current
+		}
+	}
 
-	return slot1.Before(slot2End) && slot2.Before(slot1End)
+	return peak
 }
 
 func (s *BookingService) isValidStatusTransition(currentStatus, newStatus string) bool {
 	validTransitions := map[string][]string{
-		"pending":     {"confirmed", "cancelled", "rejected"},
-		"confirmed":   {"in_progress", "cancelled", "rescheduled"},
-		"in_progress": {"completed", "cancelled"},
-		"completed":   {},
-		"cancelled":   {},
-		"rejected":    {},
-		"rescheduled": {"confirmed", "cancelled"},
+		"pending_payment": {"confirmed", "cancelled"},
+		"pending":         {"confirmed", "cancelled", "rejected"},
+		"confirmed":       {"in_progress", "cancelled", "rescheduled", "no_show"},
+		"in_progress":     {"completed", "cancelled"},
+		"completed":       {},
+		"cancelled":       {},
+		"rejected":        {},
+		"rescheduled":     {"confirmed", "cancelled"},
+		"no_show":         {"completed"}, // Correction when the customer turned up late
 	}
 
 	allowedStatuses, exists := validTransitions[currentStatus]
@@ -978,24 +1485,39 @@ func (s *BookingService) isValidStatusTransition(currentStatus, newStatus string
 // Notification methods
 
 func (s *BookingService) scheduleBookingNotifications(tx *sql.Tx, booking *models.Booking) error {
+	// Reminders are computed on the company's local clock so "the day before" survives DST changes
+	loc := companyLocation(tx, booking.CompanyID)
+	localDateTime := booking.DateTime.In(loc)
+	localData := map[string]interface{}{
+		"local_date_time": localDateTime.Format("2006-01-02 15:04"),
+		"timezone":        loc.String(),
+	}
+
 	notifications := []BookingNotification{
 		{
 			Type:        "booking_reminder_24h",
 			BookingID:   booking.ID,
 			UserID:      booking.UserID,
 			CompanyID:   booking.CompanyID,
-			ScheduledAt: booking.DateTime.Add(-24 * time.Hour),
+			ScheduledAt: localDateTime.AddDate(0, 0, -1).UTC(),
+			Data:        localData,
 		},
 		{
 			Type:        "booking_reminder_2h",
 			BookingID:   booking.ID,
 			UserID:      booking.UserID,
 			CompanyID:   booking.CompanyID,
-			ScheduledAt: booking.DateTime.Add(-2 * time.Hour),
+			ScheduledAt: localDateTime.Add(-2 * time.Hour).UTC(),
+			Data:        localData,
 		},
 	}
 
 	for _, notification := range notifications {
+		// Skip reminders whose time has already passed
+		if notification.ScheduledAt.Before(time.Now()) {
+			continue
+		}
+
 		notificationData, _ := json.Marshal(notification)
 		_, err := tx.Exec(`
 			INSERT INTO notification_schedule (id, user_id, type, title, message, scheduled_for, created_at)
@@ -1069,30 +1591,55 @@ func (s *BookingService) sendStatusChangeNotifications(booking *models.Booking,
 	// Send notifications for status changes
 	// TODO: Implement status change notifications
 	fmt.Printf("Booking status changed to %s for booking ID: %s\n", newStatus, booking.ID)
+
+	// Keep the customer's calendar in sync with the booking
+	switch newStatus {
+	case "confirmed":
+		s.sendBookingCalendarEmail(booking.ID, "Booking confirmed", "Your booking is confirmed. Add it to your calendar with the attached invitation.")
+	case "cancelled":
+		s.sendBookingCalendarEmail(booking.ID, "Booking cancelled", "Your booking has been cancelled. Open the attachment to remove it from your calendar.")
+	}
 }
 
 // GetBookingByID returns a booking by its ID
 func (s *BookingService) GetBookingByID(bookingID string) (*models.Booking, error) {
 	var booking models.Booking
+	var serviceAddress sql.NullString
+	var serviceLatitude, serviceLongitude sql.NullFloat64
 	err := s.db.QueryRow(`
 		SELECT id, user_id, company_id, service_id, pet_id, employee_id,
 			   date_time, duration, price, status, notes, payment_id,
-			   created_at, updated_at
+			   series_id, check_in_date, check_out_date, kennel_id,
+			   deposit_amount, deposit_payment_intent_id, payment_expires_at,
+			   options_price, checked_in_at, checked_out_at, created_at, updated_at,
+			   service_address, service_latitude, service_longitude, waiver_signature_id
 		FROM bookings WHERE id = $1
 	`, bookingID).Scan(
 		&booking.ID, &booking.UserID, &booking.CompanyID, &booking.ServiceID,
 		&booking.PetID, &booking.EmployeeID, &booking.DateTime, &booking.Duration,
 		&booking.Price, &booking.Status, &booking.Notes, &booking.PaymentID,
+		&booking.SeriesID, &booking.CheckInDate, &booking.CheckOutDate, &booking.KennelID,
+		&booking.DepositAmount, &booking.DepositPaymentIntentID, &booking.PaymentExpiresAt,
+		&booking.OptionsPrice, &booking.CheckedInAt, &booking.CheckedOutAt,
 		&booking.CreatedAt, &booking.UpdatedAt,
+		&serviceAddress, &serviceLatitude, &serviceLongitude, &booking.WaiverSignatureID,
 	)
 	if err != nil {
 		return nil, err
 	}
+	booking.ServiceLocation = scanBookingLocation(serviceAddress, serviceLatitude, serviceLongitude)
+
+	if booking.Options, err = getBookingOptions(s.db, bookingID); err != nil {
+		return nil, err
+	}
+	if booking.Handovers, err = getBookingHandovers(s.db, bookingID); err != nil {
+		return nil, err
+	}
 	return &booking, nil
 }
 
 // RescheduleBooking reschedules a booking to a new date/time
-func (s *BookingService) RescheduleBooking(bookingID string, newDateTime time.Time, reason string) error {
+func (s *BookingService) RescheduleBooking(bookingID string, newDateTime time.Time, reason string, actor models.BookingActor) error {
 	tx, err := s.db.Begin()
 	if err != nil {
 		return err
@@ -1114,13 +1661,41 @@ func (s *BookingService) RescheduleBooking(bookingID string, newDateTime time.Ti
 		return err
 	}
 
-	// Check availability for new time slot
-	available, err := s.checkTimeSlotAvailability(tx, booking.ServiceID, newDateTime, booking.EmployeeID)
+	var isStay bool
+	err = tx.QueryRow("SELECT check_in_date IS NOT NULL FROM bookings WHERE id = $1", bookingID).Scan(&isStay)
+	if err != nil {
+		return err
+	}
+	if isStay {
+		return fmt.Errorf("boarding stays cannot be moved to a single time slot, cancel and book new dates instead")
+	}
+
+	newDateTime = newDateTime.UTC()
+	if newDateTime.Before(time.Now()) {
+		return fmt.Errorf("cannot reschedule to a time in the past")
+	}
+
+	// Check availability for new time slot, ignoring the booking being moved
+	available, err := s.checkTimeSlotAvailability(tx, booking.ServiceID, newDateTime, booking.Duration, booking.EmployeeID, booking.ID)
 	if err != nil {
 		return err
 	}
 	if !available {
-		return fmt.Errorf("new time slot is not available")
+		return ErrSlotTaken
+	}
+
+	// Mobile appointments keep their address, so the travel around the new time is checked again
+	var serviceAddress sql.NullString
+	var serviceLatitude, serviceLongitude sql.NullFloat64
+	err = tx.QueryRow(`
+		SELECT service_address, service_latitude, service_longitude FROM bookings WHERE id = $1
+	`, bookingID).Scan(&serviceAddress, &serviceLatitude, &serviceLongitude)
+	if err != nil {
+		return err
+	}
+	location := scanBookingLocation(serviceAddress, serviceLatitude, serviceLongitude)
+	if err := s.checkEmployeeTravel(tx, booking.CompanyID, booking.EmployeeID, newDateTime, booking.Duration, location, booking.ID); err != nil {
+		return err
 	}
 
 	// Update booking
@@ -1133,6 +1708,14 @@ func (s *BookingService) RescheduleBooking(bookingID string, newDateTime time.Ti
 		return err
 	}
 
+	err = recordBookingEvent(tx, bookingID, "rescheduled", actor, map[string]models.BookingFieldChange{
+		"date_time": bookingFieldChange(booking.DateTime, newDateTime),
+		"status":    bookingFieldChange(booking.Status, "rescheduled"),
+	}, reason)
+	if err != nil {
+		return err
+	}
+
 	// Cancel old notifications and schedule new ones
 	err = s.cancelBookingNotifications(tx, bookingID)
 	if err != nil {
@@ -1363,8 +1946,9 @@ func (s *BookingService) FindAlternativeSlots(serviceID string, requestedDateTim
 		return nil, fmt.Errorf("failed to get service details: %w", err)
 	}
 
-	// Search for alternatives in the next 'daysToSearch' days
-	searchDate := requestedDateTime.Truncate(24 * time.Hour)
+	// Search for alternatives in the next 'daysToSearch' days of the company's calendar
+	loc := serviceLocation(s.db, serviceID)
+	searchDate := inLocationDate(requestedDateTime.In(loc), loc)
 	endDate := searchDate.AddDate(0, 0, daysToSearch)
 
 	for currentDate := searchDate; currentDate.Before(endDate); currentDate = currentDate.AddDate(0, 0, 1) {
@@ -1384,6 +1968,10 @@ func (s *BookingService) FindAlternativeSlots(serviceID string, requestedDateTim
 
 		// Generate time slots for this day
 		slots := s.generateTimeSlots(service, currentDate)
+		employeeWindows, err := s.getEmployeeWindows(service.AssignedEmployees, currentDate)
+		if err != nil {
+			return nil, err
+		}
 
 		for _, slot := range slots {
 			// Skip slots in the past
@@ -1391,6 +1979,11 @@ func (s *BookingService) FindAlternativeSlots(serviceID string, requestedDateTim
 				continue
 			}
 
+			// Skip slots where nobody assigned to the service is on shift
+			if employeeWindows != nil && !anyEmployeeWorking(employeeWindows, slot, slot.Add(time.Duration(service.Duration)*time.Minute)) {
+				continue
+			}
+
 			// Find available employee for this slot
 			employee, err := s.FindAvailableEmployee(serviceID, slot)
 			if err != nil {
@@ -1434,16 +2027,19 @@ func (s *BookingService) AutoAssignBooking(req *BookingRequest) (*BookingResult,
 		// Employee found, create booking
 		req.EmployeeID = &employee.EmployeeID
 		booking, err := s.CreateBooking(req)
-		if err != nil {
+		if err == nil {
+			return &BookingResult{
+				Success:          true,
+				Booking:          booking,
+				AssignedEmployee: employee,
+				Message:          fmt.Sprintf("Booking confirmed with %s", employee.EmployeeName),
+			}, nil
+		}
+		// Another request took the slot in the meantime, or the employee cannot travel there in time;
+		// fall through to alternatives
+		if !errors.Is(err, ErrSlotTaken) && !errors.Is(err, ErrTravelTimeConflict) {
 			return nil, err
 		}
-
-		return &BookingResult{
-			Success:          true,
-			Booking:          booking,
-			AssignedEmployee: employee,
-			Message:          fmt.Sprintf("Booking confirmed with %s", employee.EmployeeName),
-		}, nil
 	}
 
 	// No employee available, find alternatives
@@ -1466,6 +2062,7 @@ func (s *BookingService) AutoAssignBooking(req *BookingRequest) (*BookingResult,
 // ProcessAIBookingRequest handles booking request from AI assistant
 func (s *BookingService) ProcessAIBookingRequest(req *AIBookingRequest) (*AIBookingResponse, error) {
 	// Convert AI request to standard booking request
+	aiActor := AIAgentActor(bookingAIAgent)
 	bookingReq := &BookingRequest{
 		UserID:    req.UserID,
 		CompanyID: req.CompanyID,
@@ -1473,6 +2070,8 @@ func (s *BookingService) ProcessAIBookingRequest(req *AIBookingRequest) (*AIBook
 		PetID:     req.PetID,
 		DateTime:  req.DateTime,
 		Notes:     req.Notes,
+		Location:  req.Location,
+		Actor:     &aiActor,
 	}
 
 	// Try to auto-assign booking
@@ -1589,68 +2188,94 @@ type EmployeeInfoChat struct {
 
 // Helper function to check individual employee availability
 func (s *BookingService) checkEmployeeAvailability(employeeID, serviceID string, dateTime time.Time) (*EmployeeAvailability, error) {
-	// Get employee details and work schedule
+	// Get employee details
 	var employee EmployeeAvailability
-	var workSchedule string
 
 	err := s.db.QueryRow(`
-		SELECT id, name, work_schedule, is_active
+		SELECT id, first_name || ' ' || last_name, is_active
 		FROM employees 
 		WHERE id = $1 AND is_active = true
-	`, employeeID).Scan(&employee.EmployeeID, &employee.EmployeeName, &workSchedule, &employee.Available)
+	`, employeeID).Scan(&employee.EmployeeID, &employee.EmployeeName, &employee.Available)
 
 	if err != nil {
 		return nil, err
 	}
 
-	// Parse work schedule (simplified - assume JSON format)
-	var schedule map[string]interface{}
-	if err := json.Unmarshal([]byte(workSchedule), &schedule); err != nil {
-		// Default to available if schedule parsing fails
-		employee.Available = true
-	} else {
-		// Check if employee works on this day/time (simplified logic)
-		dayOfWeek := strings.ToLower(dateTime.Weekday().String())
-		if daySchedule, exists := schedule[dayOfWeek]; exists {
-			employee.Available = daySchedule != nil
-		}
-	}
-
-	// Count current bookings for this employee at this time
-	var bookingCount int
+	// Get service duration, buffers and capacity
+	var duration, maxBookings, bufferBefore, bufferAfter int
 	err = s.db.QueryRow(`
-		SELECT COUNT(*) 
-		FROM bookings 
-		WHERE employee_id = $1 
-		AND date_time = $2 
-		AND status NOT IN ('cancelled', 'rejected')
-	`, employeeID, dateTime).Scan(&bookingCount)
+		SELECT duration, max_bookings_per_slot,
+			   COALESCE(buffer_time_before, 0), COALESCE(buffer_time_after, 0)
+		FROM services 
+		WHERE id = $1
+	`, serviceID).Scan(&duration, &maxBookings, &bufferBefore, &bufferAfter)
 
 	if err != nil {
-		bookingCount = 0
+		duration = 60   // Default
+		maxBookings = 1 // Default
 	}
 
-	employee.CurrentBookings = bookingCount
-
-	// Check if employee is overbooked
-	var maxBookings int
-	err = s.db.QueryRow(`
-		SELECT max_bookings_per_slot 
-		FROM services 
-		WHERE id = $1
-	`, serviceID).Scan(&maxBookings)
+	// Check shifts, overrides, breaks and time-off
+	if s.scheduleService != nil {
+		working, err := s.scheduleService.IsEmployeeWorking(employeeID, dateTime, dateTime.Add(time.Duration(duration)*time.Minute))
+		if err != nil {
+			return nil, err
+		}
+		employee.Available = working
+	}
 
+	// Find this employee's bookings overlapping the buffered interval
+	start := dateTime.Add(-time.Duration(bufferBefore) * time.Minute)
+	end := dateTime.Add(time.Duration(duration+bufferAfter) * time.Minute)
+	intervals, err := s.getOverlappingIntervals(s.db, "employee_id", employeeID, start, end, "")
 	if err != nil {
-		maxBookings = 1 // Default
+		intervals = nil
 	}
 
-	if bookingCount >= maxBookings {
+	employee.CurrentBookings = len(intervals)
+
+	// Check if employee is busy with another service or overbooked
+	for _, interval := range intervals {
+		if interval.ServiceID != serviceID {
+			employee.Available = false
+		}
+	}
+	if peakConcurrency(intervals, start, end) >= maxBookings {
 		employee.Available = false
 	}
 
 	return &employee, nil
 }
 
+// getEmployeeWindows loads working windows of the given employees for a date.
+// Returns nil when there is nothing to check so callers keep the service-level result.
+func (s *BookingService) getEmployeeWindows(employeeIDs []string, date time.Time) (map[string][]models.WorkingWindow, error) {
+	if s.scheduleService == nil || len(employeeIDs) == 0 {
+		return nil, nil
+	}
+
+	windows := make(map[string][]models.WorkingWindow)
+	for _, employeeID := range employeeIDs {
+		employeeWindows, err := s.scheduleService.GetWorkingWindows(employeeID, date)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load schedule of employee %s: %w", employeeID, err)
+		}
+		windows[employeeID] = employeeWindows
+	}
+
+	return windows, nil
+}
+
+// anyEmployeeWorking reports whether at least one employee covers [start, end)
+func anyEmployeeWorking(windows map[string][]models.WorkingWindow, start, end time.Time) bool {
+	for _, employeeWindows := range windows {
+		if windowsContain(employeeWindows, start, end) {
+			return true
+		}
+	}
+	return false
+}
+
 // Helper function to calculate slot priority for sorting alternatives
 func (s *BookingService) calculateSlotPriority(slotTime, requestedTime time.Time, employeeLoad int) float64 {
 	// Lower time difference = higher priority
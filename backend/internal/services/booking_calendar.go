@@ -0,0 +1,377 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// ErrCalendarFeedNotFound is returned for unknown or regenerated feed tokens
+var ErrCalendarFeedNotFound = errors.New("calendar feed not found")
+
+// Feeds cover recent history and the bookable future
+const (
+	calendarFeedPastDays   = 30
+	calendarFeedFutureDays = 365
+)
+
+const calendarFeedColumns = `id, owner_type, owner_id, token, last_accessed_at, created_at, updated_at`
+
+// bookingCalendarDetails are the names shown on a booking's calendar event
+type bookingCalendarDetails struct {
+	ServiceName    string
+	PetName        string
+	CompanyName    string
+	CompanyAddress string
+	CompanyEmail   string
+	EmployeeName   string
+	ClientName     string
+}
+
+// GetCalendarFeed returns the feed of a company, employee or customer, creating it on first use.
+// companyID scopes company and employee feeds to the caller's company.
+func (s *BookingService) GetCalendarFeed(ownerType, ownerID, companyID string) (*models.CalendarFeed, error) {
+	if err := s.verifyCalendarFeedOwner(ownerType, ownerID, companyID); err != nil {
+		return nil, err
+	}
+
+	token, err := generateCalendarFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO calendar_feeds (owner_type, owner_id, token, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (owner_type, owner_id) DO NOTHING
+	`, ownerType, ownerID, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create calendar feed: %w", err)
+	}
+
+	return s.getCalendarFeed("owner_type = $1 AND owner_id = $2", ownerType, ownerID)
+}
+
+// RegenerateCalendarFeed replaces the feed token; subscriptions to the old URL stop working
+func (s *BookingService) RegenerateCalendarFeed(ownerType, ownerID, companyID string) (*models.CalendarFeed, error) {
+	if err := s.verifyCalendarFeedOwner(ownerType, ownerID, companyID); err != nil {
+		return nil, err
+	}
+
+	token, err := generateCalendarFeedToken()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = s.db.Exec(`
+		INSERT INTO calendar_feeds (owner_type, owner_id, token, created_at, updated_at)
+		VALUES ($1, $2, $3, NOW(), NOW())
+		ON CONFLICT (owner_type, owner_id) DO UPDATE SET
+			token = EXCLUDED.token, last_accessed_at = NULL, updated_at = NOW()
+	`, ownerType, ownerID, token)
+	if err != nil {
+		return nil, fmt.Errorf("failed to regenerate calendar feed: %w", err)
+	}
+
+	return s.getCalendarFeed("owner_type = $1 AND owner_id = $2", ownerType, ownerID)
+}
+
+// RenderCalendarFeed returns the iCalendar document behind a feed token.
+// Cancelled and rejected bookings are left out so they disappear from subscribed calendars.
+func (s *BookingService) RenderCalendarFeed(token string) ([]byte, error) {
+	feed, err := s.getCalendarFeed("token = $1", token)
+	if err != nil {
+		return nil, ErrCalendarFeedNotFound
+	}
+
+	s.db.Exec("UPDATE calendar_feeds SET last_accessed_at = NOW() WHERE id = $1", feed.ID)
+
+	from := time.Now().AddDate(0, 0, -calendarFeedPastDays)
+	to := time.Now().AddDate(0, 0, calendarFeedFutureDays)
+
+	var bookings []models.Booking
+	switch feed.OwnerType {
+	case "employee":
+		bookings, err = s.GetBookingsByEmployee(feed.OwnerID, from, to, "")
+	case "company":
+		bookings, err = s.queryCalendarBookings("company_id", feed.OwnerID, from, to)
+	default:
+		bookings, err = s.queryCalendarBookings("user_id", feed.OwnerID, from, to)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	active := []models.Booking{}
+	for _, booking := range bookings {
+		if booking.Status != "cancelled" && booking.Status != "rejected" {
+			active = append(active, booking)
+		}
+	}
+
+	details, err := s.bookingCalendarDetails(active)
+	if err != nil {
+		return nil, err
+	}
+
+	events := make([]icsEvent, 0, len(active))
+	for _, booking := range active {
+		events = append(events, bookingCalendarEvent(booking, details[booking.ID], feed.OwnerType))
+	}
+
+	return buildICS(s.calendarFeedName(feed), "PUBLISH", events), nil
+}
+
+// BookingICS returns a single booking as an iCalendar invitation, or as a cancellation once cancelled
+func (s *BookingService) BookingICS(bookingID string) ([]byte, error) {
+	ics, _, err := s.bookingICS(bookingID)
+	return ics, err
+}
+
+// Helper methods
+
+// bookingICS renders a booking invitation and returns it with its iCalendar method
+func (s *BookingService) bookingICS(bookingID string) ([]byte, string, error) {
+	booking, err := s.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, "", fmt.Errorf("booking not found")
+	}
+
+	details, err := s.bookingCalendarDetails([]models.Booking{*booking})
+	if err != nil {
+		return nil, "", err
+	}
+
+	event := bookingCalendarEvent(*booking, details[booking.ID], "user")
+	event.OrganizerName = details[booking.ID].CompanyName
+	event.OrganizerEmail = details[booking.ID].CompanyEmail
+
+	method := "REQUEST"
+	if event.Status == "CANCELLED" {
+		method = "CANCEL"
+	}
+
+	return buildICS("", method, []icsEvent{event}), method, nil
+}
+
+// sendBookingCalendarEmail emails the customer the booking's .ics so their calendar follows confirmations and cancellations
+func (s *BookingService) sendBookingCalendarEmail(bookingID, subject, message string) {
+	if s.emailService == nil {
+		return
+	}
+
+	var email string
+	err := s.db.QueryRow(`
+		SELECT COALESCE(u.email, '') FROM bookings b JOIN users u ON b.user_id = u.id WHERE b.id = $1
+	`, bookingID).Scan(&email)
+	if err != nil || email == "" {
+		return
+	}
+
+	ics, method, err := s.bookingICS(bookingID)
+	if err != nil {
+		log.Printf("Error building calendar invite for booking %s: %v", bookingID, err)
+		return
+	}
+
+	err = s.emailService.SendEmailWithAttachments(email, subject, message, []EmailAttachment{{
+		Filename:    "booking.ics",
+		ContentType: "text/calendar; charset=utf-8; method=" + method,
+		Content:     ics,
+	}})
+	if err != nil {
+		log.Printf("Error sending booking calendar email: %v", err)
+	}
+}
+
+func (s *BookingService) queryCalendarBookings(column, value string, from, to time.Time) ([]models.Booking, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`
+		SELECT id, user_id, company_id, service_id, pet_id, employee_id,
+			   date_time, duration, price, status, notes, payment_id,
+			   created_at, updated_at
+		FROM bookings
+		WHERE %s = $1 AND date_time >= $2 AND date_time < $3
+		ORDER BY date_time
+	`, column), value, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get calendar bookings: %w", err)
+	}
+	defer rows.Close()
+
+	var bookings []models.Booking
+	for rows.Next() {
+		var booking models.Booking
+		if err := rows.Scan(
+			&booking.ID, &booking.UserID, &booking.CompanyID, &booking.ServiceID,
+			&booking.PetID, &booking.EmployeeID, &booking.DateTime, &booking.Duration,
+			&booking.Price, &booking.Status, &booking.Notes, &booking.PaymentID,
+			&booking.CreatedAt, &booking.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		bookings = append(bookings, booking)
+	}
+
+	return bookings, nil
+}
+
+// bookingCalendarDetails loads the names shown on the calendar events of the given bookings in one query
+func (s *BookingService) bookingCalendarDetails(bookings []models.Booking) (map[string]bookingCalendarDetails, error) {
+	details := make(map[string]bookingCalendarDetails)
+	if len(bookings) == 0 {
+		return details, nil
+	}
+
+	ids := make([]string, 0, len(bookings))
+	for _, booking := range bookings {
+		ids = append(ids, booking.ID)
+	}
+
+	rows, err := s.db.Query(`
+		SELECT b.id, sv.name, COALESCE(p.name, ''), c.name,
+			   CONCAT_WS(', ', NULLIF(c.address, ''), NULLIF(c.city, '')), COALESCE(c.email, ''),
+			   TRIM(CONCAT(e.first_name, ' ', e.last_name)), TRIM(CONCAT(u.first_name, ' ', u.last_name))
+		FROM bookings b
+		JOIN services sv ON b.service_id = sv.id
+		JOIN companies c ON b.company_id = c.id
+		LEFT JOIN pets p ON b.pet_id = p.id
+		LEFT JOIN employees e ON b.employee_id = e.id
+		LEFT JOIN users u ON b.user_id = u.id
+		WHERE b.id = ANY($1)
+	`, pq.Array(ids))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking details: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		var d bookingCalendarDetails
+		if err := rows.Scan(
+			&id, &d.ServiceName, &d.PetName, &d.CompanyName, &d.CompanyAddress,
+			&d.CompanyEmail, &d.EmployeeName, &d.ClientName,
+		); err != nil {
+			return nil, err
+		}
+		details[id] = d
+	}
+
+	return details, nil
+}
+
+// bookingCalendarEvent builds the event of a booking as seen by a customer ("user") or by the company and its staff
+func bookingCalendarEvent(booking models.Booking, details bookingCalendarDetails, audience string) icsEvent {
+	event := icsEvent{
+		UID:      booking.ID + "@zootel",
+		Sequence: int(booking.UpdatedAt.Sub(booking.CreatedAt).Seconds()),
+		Start:    booking.DateTime,
+		End:      booking.DateTime.Add(time.Duration(booking.Duration) * time.Minute),
+		Stamp:    booking.UpdatedAt,
+		Location: details.CompanyAddress,
+		Status:   bookingEventStatus(booking.Status),
+	}
+	if event.Sequence < 0 {
+		event.Sequence = 0
+	}
+
+	var lines []string
+	if audience == "user" {
+		event.Summary = fmt.Sprintf("%s at %s", details.ServiceName, details.CompanyName)
+		if details.PetName != "" {
+			lines = append(lines, "Pet: "+details.PetName)
+		}
+	} else {
+		event.Summary = details.ServiceName
+		if details.PetName != "" {
+			event.Summary += " - " + details.PetName
+		}
+		if details.ClientName != "" {
+			lines = append(lines, "Client: "+details.ClientName)
+		}
+		if details.PetName != "" {
+			lines = append(lines, "Pet: "+details.PetName)
+		}
+		if details.EmployeeName != "" {
+			lines = append(lines, "Employee: "+details.EmployeeName)
+		}
+		if booking.Notes != nil && *booking.Notes != "" {
+			lines = append(lines, "Notes: "+*booking.Notes)
+		}
+	}
+	lines = append(lines, "Status: "+booking.Status)
+	event.Description = strings.Join(lines, "\n")
+
+	return event
+}
+
+// bookingEventStatus maps a booking status to an iCalendar event status
+func bookingEventStatus(status string) string {
+	switch status {
+	case "confirmed", "in_progress", "completed", "no_show":
+		return "CONFIRMED"
+	case "cancelled", "rejected":
+		return "CANCELLED"
+	}
+	return "TENTATIVE"
+}
+
+// verifyCalendarFeedOwner checks that company and employee feeds belong to the caller's company
+func (s *BookingService) verifyCalendarFeedOwner(ownerType, ownerID, companyID string) error {
+	switch ownerType {
+	case "user":
+		return nil
+	case "company":
+		if ownerID == "" || ownerID != companyID {
+			return fmt.Errorf("company not found")
+		}
+		return nil
+	case "employee":
+		var employeeCompanyID string
+		err := s.db.QueryRow("SELECT company_id FROM employees WHERE id = $1", ownerID).Scan(&employeeCompanyID)
+		if err != nil || employeeCompanyID != companyID {
+			return fmt.Errorf("employee not found")
+		}
+		return nil
+	}
+	return fmt.Errorf("invalid calendar feed owner: %s", ownerType)
+}
+
+func (s *BookingService) calendarFeedName(feed *models.CalendarFeed) string {
+	var name string
+	switch feed.OwnerType {
+	case "company":
+		s.db.QueryRow("SELECT name FROM companies WHERE id = $1", feed.OwnerID).Scan(&name)
+		return name + " bookings"
+	case "employee":
+		s.db.QueryRow("SELECT TRIM(CONCAT(first_name, ' ', last_name)) FROM employees WHERE id = $1", feed.OwnerID).Scan(&name)
+		return name + " appointments"
+	}
+	return "My Zootel bookings"
+}
+
+func (s *BookingService) getCalendarFeed(where string, args ...interface{}) (*models.CalendarFeed, error) {
+	var feed models.CalendarFeed
+	err := s.db.QueryRow("SELECT "+calendarFeedColumns+" FROM calendar_feeds WHERE "+where, args...).Scan(
+		&feed.ID, &feed.OwnerType, &feed.OwnerID, &feed.Token, &feed.LastAccessedAt,
+		&feed.CreatedAt, &feed.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &feed, nil
+}
+
+func generateCalendarFeedToken() (string, error) {
+	bytes := make([]byte, 32)
+	if _, err := rand.Read(bytes); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(bytes), nil
+}
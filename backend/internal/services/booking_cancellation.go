@@ -0,0 +1,540 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// ErrCancellationFeeChanged is returned when the fee due is higher than the one the customer confirmed
+var ErrCancellationFeeChanged = errors.New("cancellation fee has changed, review the new amount before confirming")
+
+const cancellationPolicyColumns = `
+	id, company_id, name, description, free_cancel_hours, fee_tiers,
+	no_show_fee_percentage, apply_to_reschedule, is_default, created_at, updated_at`
+
+// SetPaymentService injects the payment service used to refund payments and charge cancellation fees
+func (s *BookingService) SetPaymentService(paymentService *PaymentService) {
+	s.paymentService = paymentService
+}
+
+// GetCancellationPolicies returns the cancellation policies of a company
+func (s *BookingService) GetCancellationPolicies(companyID string) ([]models.CancellationPolicy, error) {
+	return s.queryCancellationPolicies(s.db, `
+		SELECT`+cancellationPolicyColumns+`
+		FROM cancellation_policies
+		WHERE company_id = $1
+		ORDER BY is_default DESC, name
+	`, companyID)
+}
+
+// CreateCancellationPolicy adds a cancellation policy to the company
+func (s *BookingService) CreateCancellationPolicy(companyID string, req *models.CancellationPolicyRequest) (*models.CancellationPolicy, error) {
+	return s.saveCancellationPolicy(companyID, "", req)
+}
+
+// UpdateCancellationPolicy replaces the rules of a company cancellation policy
+func (s *BookingService) UpdateCancellationPolicy(companyID, policyID string, req *models.CancellationPolicyRequest) (*models.CancellationPolicy, error) {
+	return s.saveCancellationPolicy(companyID, policyID, req)
+}
+
+// DeleteCancellationPolicy removes a policy; services using it fall back to the company default
+func (s *BookingService) DeleteCancellationPolicy(companyID, policyID string) error {
+	result, err := s.db.Exec("DELETE FROM cancellation_policies WHERE id = $1 AND company_id = $2", policyID, companyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("cancellation policy not found")
+	}
+
+	return nil
+}
+
+// SetServiceCancellationPolicy assigns a policy to a service, or clears it when policyID is nil
+func (s *BookingService) SetServiceCancellationPolicy(companyID, serviceID string, policyID *string) error {
+	if policyID != nil {
+		var exists bool
+		err := s.db.QueryRow(`
+			SELECT EXISTS(SELECT 1 FROM cancellation_policies WHERE id = $1 AND company_id = $2)
+		`, *policyID, companyID).Scan(&exists)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			return fmt.Errorf("cancellation policy not found")
+		}
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE services SET cancellation_policy_id = $3, updated_at = NOW()
+		WHERE id = $1 AND company_id = $2
+	`, serviceID, companyID, policyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("service not found")
+	}
+
+	return nil
+}
+
+// PreviewCancellation computes the fee and refund for cancelling or rescheduling a booking now,
+// so the customer can review them before confirming
+func (s *BookingService) PreviewCancellation(bookingID, action, initiatedBy string) (*models.CancellationOutcome, error) {
+	if action != "cancel" && action != "reschedule" {
+		return nil, fmt.Errorf("invalid action: %s", action)
+	}
+
+	booking, err := s.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+	if action == "cancel" && !s.isValidStatusTransition(booking.Status, "cancelled") {
+		return nil, fmt.Errorf("booking with status %s cannot be cancelled", booking.Status)
+	}
+
+	return s.evaluateCancellation(booking, action, initiatedBy, time.Now())
+}
+
+// CancelBookingWithPolicy cancels a booking and settles it under its cancellation policy:
+// the paid amount minus the fee is refunded and any fee not covered by the payment is charged.
+// When acceptedFee is set and the fee due is now higher, nothing is changed and ErrCancellationFeeChanged is returned.
+func (s *BookingService) CancelBookingWithPolicy(bookingID, reason, initiatedBy string, acceptedFee *float64, actor models.BookingActor) (*models.CancellationOutcome, error) {
+	booking, err := s.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+
+	outcome, err := s.evaluateCancellation(booking, "cancel", initiatedBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if acceptedFee != nil && outcome.FeeAmount.Amount > models.MoneyFromDecimal(*acceptedFee, outcome.Currency).Amount {
+		return outcome, ErrCancellationFeeChanged
+	}
+
+	// A deposit paid in the meantime keeps the booking, to be cancelled and refunded once it is confirmed
+	if booking.Status == "pending_payment" {
+		if err := s.cancelDepositPayment(booking); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.UpdateBookingStatus(bookingID, "cancelled", reason, actor); err != nil {
+		return nil, err
+	}
+	s.settleCancellation(booking, outcome)
+
+	return outcome, nil
+}
+
+// RescheduleBookingWithPolicy moves a booking and charges the reschedule fee of its policy, if any
+func (s *BookingService) RescheduleBookingWithPolicy(bookingID string, newDateTime time.Time, reason, initiatedBy string, acceptedFee *float64, actor models.BookingActor) (*models.CancellationOutcome, error) {
+	booking, err := s.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+
+	outcome, err := s.evaluateCancellation(booking, "reschedule", initiatedBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if acceptedFee != nil && outcome.FeeAmount.Amount > models.MoneyFromDecimal(*acceptedFee, outcome.Currency).Amount {
+		return outcome, ErrCancellationFeeChanged
+	}
+
+	if err := s.RescheduleBooking(bookingID, newDateTime, reason, actor); err != nil {
+		return nil, err
+	}
+
+	s.settleCancellation(booking, outcome)
+
+	return outcome, nil
+}
+
+// Helper methods
+
+// evaluateCancellation applies the booking's policy to an action taken at the given time.
+// Company-initiated actions never carry a fee.
+func (s *BookingService) evaluateCancellation(booking *models.Booking, action, initiatedBy string, at time.Time) (*models.CancellationOutcome, error) {
+	policy, err := s.bookingCancellationPolicy(booking.ServiceID, booking.CompanyID)
+	if err != nil {
+		return nil, err
+	}
+
+	payments, paid, currency, err := s.bookingPayments(booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome := &models.CancellationOutcome{
+		BookingID:   booking.ID,
+		Action:      action,
+		InitiatedBy: initiatedBy,
+		PolicyName:  "Free cancellation",
+		HoursBefore: math.Round(booking.DateTime.Sub(at).Hours()*100) / 100,
+		AmountPaid:  paid,
+		Currency:    currency,
+	}
+	if len(payments) > 0 {
+		outcome.PaymentID = &payments[0].ID
+	}
+
+	if policy != nil {
+		outcome.PolicyID = &policy.ID
+		outcome.PolicyName = policy.Name
+		if action != "no_show" && (action == "cancel" || policy.ApplyToReschedule) {
+			freeUntil := booking.DateTime.Add(-time.Duration(policy.FreeCancelHours) * time.Hour)
+			outcome.FreeUntil = &freeUntil
+		}
+		// Bookings still awaiting their deposit have nothing to settle
+		if initiatedBy != "company" && booking.Status != "pending_payment" {
+			outcome.FeePercentage = cancellationFeePercentage(policy, action, booking.DateTime.Sub(at).Hours())
+		}
+	}
+
+	outcome.FeeAmount = booking.Price.Percent(outcome.FeePercentage).WithCurrency(currency)
+	outcome.RefundAmount = models.NewMoney(0, currency)
+	outcome.ChargeAmount = models.NewMoney(0, currency)
+
+	switch {
+	case action == "no_show" && policy == nil:
+		// Without a policy a missed booking keeps what was paid and owes nothing more
+		outcome.PolicyName = "No-show"
+	case action == "reschedule":
+		// The booking stays paid for, so the fee is always charged separately
+		outcome.ChargeAmount = outcome.FeeAmount
+	default:
+		if difference := paid.Sub(outcome.FeeAmount); difference.IsNegative() {
+			outcome.ChargeAmount = outcome.FeeAmount.Sub(paid)
+		} else {
+			outcome.RefundAmount = difference
+		}
+	}
+
+	return outcome, nil
+}
+
+// cancellationFeePercentage returns the fee for acting hoursBefore hours ahead of the start.
+// Tiers are sorted by hours_before descending; cancelling inside the paid window below every tier costs the full price.
+func cancellationFeePercentage(policy *models.CancellationPolicy, action string, hoursBefore float64) float64 {
+	if action == "no_show" {
+		return policy.NoShowFeePercentage
+	}
+	if action == "reschedule" && !policy.ApplyToReschedule {
+		return 0
+	}
+	if hoursBefore >= float64(policy.FreeCancelHours) {
+		return 0
+	}
+
+	for _, tier := range policy.FeeTiers {
+		if hoursBefore >= float64(tier.HoursBefore) {
+			return tier.FeePercentage
+		}
+	}
+
+	return 100
+}
+
+// settleCancellation refunds and charges the amounts of an outcome and records it.
+// Payment failures do not undo the cancellation; they are reported on the outcome for manual follow-up.
+func (s *BookingService) settleCancellation(booking *models.Booking, outcome *models.CancellationOutcome) {
+	if (outcome.RefundAmount.Amount > 0 || outcome.ChargeAmount.Amount > 0) && s.paymentService == nil {
+		outcome.SettlementError = "payments are not available"
+	}
+
+	if outcome.RefundAmount.Amount > 0 && s.paymentService != nil {
+		s.refundBookingPayments(booking, outcome)
+	}
+
+	if outcome.ChargeAmount.Amount > 0 && s.paymentService != nil {
+		intent, err := s.paymentService.CreatePaymentIntent(&PaymentRequest{
+			UserID:      booking.UserID,
+			CompanyID:   booking.CompanyID,
+			BookingID:   &booking.ID,
+			Amount:      outcome.ChargeAmount.Decimal(),
+			Currency:    outcome.Currency,
+			Description: fmt.Sprintf("Booking %s fee (%s)", outcome.Action, outcome.PolicyName),
+			Metadata: map[string]interface{}{
+				"type":       "cancellation_fee",
+				"action":     outcome.Action,
+				"booking_id": booking.ID,
+			},
+		})
+		if err != nil {
+			log.Printf("Failed to charge fee for booking %s: %v", booking.ID, err)
+			outcome.SettlementError = "fee could not be charged"
+		} else {
+			outcome.ChargePaymentIntentID = &intent.PaymentIntentID
+			outcome.ChargeClientSecret = intent.ClientSecret
+		}
+	}
+
+	_, err := s.db.Exec(`
+		INSERT INTO booking_cancellations (
+			id, booking_id, policy_id, action, initiated_by, hours_before, fee_percentage,
+			fee_amount, amount_paid, refund_amount, charge_amount, currency,
+			payment_id, refund_id, charge_payment_intent_id, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, NOW())
+	`, uuid.New().String(), booking.ID, outcome.PolicyID, outcome.Action, outcome.InitiatedBy,
+		outcome.HoursBefore, outcome.FeePercentage, outcome.FeeAmount, outcome.AmountPaid,
+		outcome.RefundAmount, outcome.ChargeAmount, outcome.Currency,
+		outcome.PaymentID, outcome.RefundID, outcome.ChargePaymentIntentID)
+	if err != nil {
+		log.Printf("Failed to record cancellation of booking %s: %v", booking.ID, err)
+	}
+}
+
+// bookingCancellationPolicy returns the policy of the service, else the company default, else nil (free cancellation)
+func (s *BookingService) bookingCancellationPolicy(serviceID, companyID string) (*models.CancellationPolicy, error) {
+	policies, err := s.queryCancellationPolicies(s.db, `
+		SELECT`+cancellationPolicyColumns+`
+		FROM cancellation_policies
+		WHERE id = (SELECT cancellation_policy_id FROM services WHERE id = $1)
+		   OR (company_id = $2 AND is_default = true)
+		ORDER BY is_default ASC
+		LIMIT 1
+	`, serviceID, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get cancellation policy: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, nil
+	}
+
+	return &policies[0], nil
+}
+
+// refundBookingPayments refunds the outcome's refund amount across the booking's settled payments, latest
+// first, each up to what is left of it. RefundPayment records the payments' new status.
+func (s *BookingService) refundBookingPayments(booking *models.Booking, outcome *models.CancellationOutcome) {
+	payments, _, _, err := s.bookingPayments(booking.ID)
+	if err != nil {
+		log.Printf("Failed to refund booking %s: %v", booking.ID, err)
+		outcome.SettlementError = "refund could not be issued"
+		return
+	}
+
+	remaining := outcome.RefundAmount
+	for _, payment := range payments {
+		if remaining.Amount <= 0 {
+			break
+		}
+		amount := remaining
+		if payment.Refundable.Amount < amount.Amount {
+			amount = payment.Refundable
+		}
+
+		refund, err := s.paymentService.RefundPayment(&RefundRequest{
+			PaymentID: payment.ID,
+			Amount:    amount.Decimal(),
+			Reason:    fmt.Sprintf("Booking %s refund under policy %s", outcome.Action, outcome.PolicyName),
+		})
+		if err != nil {
+			log.Printf("Failed to refund payment %s of booking %s: %v", payment.ID, booking.ID, err)
+			outcome.SettlementError = "refund could not be issued"
+			return
+		}
+		if outcome.RefundID == nil {
+			outcome.RefundID = &refund.ID
+		}
+		remaining = remaining.Sub(amount)
+	}
+
+	if remaining.Amount > 0 {
+		outcome.SettlementError = "refund could not be issued"
+	}
+}
+
+// bookingPayment is a settled payment of a booking with what is left of it to refund
+type bookingPayment struct {
+	ID         string
+	Refundable models.Money
+}
+
+// bookingPayments returns the settled payments of a booking, latest first, and how much of them is not
+// yet refunded in total. Deposits, balances and paid fees of a booking are in the currency of its latest payment;
+// payments in another currency are left out.
+func (s *BookingService) bookingPayments(bookingID string) ([]bookingPayment, models.Money, string, error) {
+	rows, err := s.db.Query(`
+		SELECT p.id, p.amount - COALESCE((
+				   SELECT SUM(r.amount) FROM refunds r
+				   WHERE r.payment_id = p.id AND r.status <> 'failed'
+			   ), 0), LOWER(p.currency)
+		FROM payments p
+		WHERE p.booking_id = $1 AND p.status IN ('succeeded', 'partially_refunded')
+		ORDER BY p.created_at DESC
+	`, bookingID)
+	if err != nil {
+		return nil, models.Money{}, "", fmt.Errorf("failed to get booking payments: %w", err)
+	}
+	defer rows.Close()
+
+	currency := ""
+	payments := []bookingPayment{}
+	for rows.Next() {
+		var payment bookingPayment
+		var paymentCurrency string
+		if err := rows.Scan(&payment.ID, &payment.Refundable, &paymentCurrency); err != nil {
+			return nil, models.Money{}, "", err
+		}
+		if currency == "" {
+			currency = paymentCurrency
+		} else if paymentCurrency != currency {
+			continue
+		}
+
+		payment.Refundable = payment.Refundable.WithCurrency(currency)
+		if payment.Refundable.Amount <= 0 {
+			continue
+		}
+		payments = append(payments, payment)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, models.Money{}, "", fmt.Errorf("failed to get booking payments: %w", err)
+	}
+
+	if currency == "" {
+		currency = "usd"
+	}
+	paid := models.NewMoney(0, currency)
+	for _, payment := range payments {
+		paid = paid.Add(payment.Refundable)
+	}
+	return payments, paid, currency, nil
+}
+
+func (s *BookingService) saveCancellationPolicy(companyID, policyID string, req *models.CancellationPolicyRequest) (*models.CancellationPolicy, error) {
+	tiers, err := normalizeFeeTiers(req.FreeCancelHours, req.FeeTiers)
+	if err != nil {
+		return nil, err
+	}
+	tiersJSON, _ := json.Marshal(tiers)
+
+	noShowFee := 100.0
+	if req.NoShowFeePercentage != nil {
+		noShowFee = *req.NoShowFeePercentage
+	}
+	if noShowFee < 0 || noShowFee > 100 {
+		return nil, fmt.Errorf("no_show_fee_percentage must be between 0 and 100")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Only one default policy per company
+	if req.IsDefault {
+		_, err = tx.Exec(`
+			UPDATE cancellation_policies SET is_default = false, updated_at = NOW()
+			WHERE company_id = $1 AND is_default = true AND id::text <> $2
+		`, companyID, policyID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var policies []models.CancellationPolicy
+	if policyID == "" {
+		policies, err = s.queryCancellationPolicies(tx, `
+			INSERT INTO cancellation_policies (
+				id, company_id, name, description, free_cancel_hours, fee_tiers,
+				no_show_fee_percentage, apply_to_reschedule, is_default, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, NOW(), NOW())
+			RETURNING`+cancellationPolicyColumns,
+			uuid.New().String(), companyID, req.Name, req.Description, req.FreeCancelHours,
+			string(tiersJSON), noShowFee, req.ApplyToReschedule, req.IsDefault)
+	} else {
+		policies, err = s.queryCancellationPolicies(tx, `
+			UPDATE cancellation_policies SET
+				name = $3, description = $4, free_cancel_hours = $5, fee_tiers = $6,
+				no_show_fee_percentage = $7, apply_to_reschedule = $8, is_default = $9,
+				updated_at = NOW()
+			WHERE id = $1 AND company_id = $2
+			RETURNING`+cancellationPolicyColumns,
+			policyID, companyID, req.Name, req.Description, req.FreeCancelHours,
+			string(tiersJSON), noShowFee, req.ApplyToReschedule, req.IsDefault)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save cancellation policy: %w", err)
+	}
+	if len(policies) == 0 {
+		return nil, fmt.Errorf("cancellation policy not found")
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &policies[0], nil
+}
+
+// normalizeFeeTiers validates fee tiers and sorts them by hours_before descending
+func normalizeFeeTiers(freeCancelHours int, tiers []models.CancellationFeeTier) ([]models.CancellationFeeTier, error) {
+	if freeCancelHours < 0 {
+		return nil, fmt.Errorf("free_cancel_hours cannot be negative")
+	}
+
+	seen := make(map[int]bool)
+	normalized := []models.CancellationFeeTier{}
+	for _, tier := range tiers {
+		if tier.HoursBefore < 0 || tier.HoursBefore >= freeCancelHours {
+			return nil, fmt.Errorf("fee tier hours_before must be between 0 and free_cancel_hours")
+		}
+		if tier.FeePercentage < 0 || tier.FeePercentage > 100 {
+			return nil, fmt.Errorf("fee tier fee_percentage must be between 0 and 100")
+		}
+		if seen[tier.HoursBefore] {
+			return nil, fmt.Errorf("duplicate fee tier for %d hours before", tier.HoursBefore)
+		}
+		seen[tier.HoursBefore] = true
+		normalized = append(normalized, tier)
+	}
+
+	sort.Slice(normalized, func(i, j int) bool {
+		return normalized[i].HoursBefore > normalized[j].HoursBefore
+	})
+
+	return normalized, nil
+}
+
+func (s *BookingService) queryCancellationPolicies(q rowsQuerier, query string, args ...interface{}) ([]models.CancellationPolicy, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	policies := []models.CancellationPolicy{}
+	for rows.Next() {
+		var p models.CancellationPolicy
+		var tiersJSON []byte
+		if err := rows.Scan(
+			&p.ID, &p.CompanyID, &p.Name, &p.Description, &p.FreeCancelHours, &tiersJSON,
+			&p.NoShowFeePercentage, &p.ApplyToReschedule, &p.IsDefault, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		p.FeeTiers = []models.CancellationFeeTier{}
+		json.Unmarshal(tiersJSON, &p.FeeTiers)
+		policies = append(policies, p)
+	}
+
+	return policies, nil
+}
@@ -0,0 +1,263 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/lib/pq"
+	qrcode "github.com/skip2/go-qrcode"
+)
+
+// ErrInvalidBookingQR is returned when a scanned code was not issued for a booking
+var ErrInvalidBookingQR = errors.New("invalid booking QR code")
+
+// handoverStages maps a scan stage to the status it moves the booking to, the timestamp it sets
+// and the event it adds to the booking history
+var handoverStages = map[string]struct {
+	status string
+	column string
+	event  string
+	label  string
+}{
+	"check_in":  {status: "in_progress", column: "checked_in_at", event: "checked_in", label: "checked in"},
+	"check_out": {status: "completed", column: "checked_out_at", event: "checked_out", label: "checked out"},
+}
+
+// GetBookingQRCode returns the signed code the customer shows when dropping off and picking up their pet
+func (s *BookingService) GetBookingQRCode(booking *models.Booking) (*models.BookingQRCode, error) {
+	switch booking.Status {
+	case "cancelled", "rejected", "completed", "no_show":
+		return nil, fmt.Errorf("booking with status %s can no longer be checked in or out", booking.Status)
+	}
+
+	token, err := signBookingToken(booking.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	png, err := qrcode.Encode(token, qrcode.Medium, 256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate QR code: %w", err)
+	}
+
+	return &models.BookingQRCode{
+		BookingID: booking.ID,
+		Token:     token,
+		Image:     "data:image/png;base64," + base64.StdEncoding.EncodeToString(png),
+	}, nil
+}
+
+// CheckInBooking starts a booking from its scanned QR code, recording what the owner left with the pet
+func (s *BookingService) CheckInBooking(companyID string, actor models.BookingActor, req *models.BookingScanRequest) (*models.Booking, error) {
+	return s.scanBooking(companyID, actor, "check_in", req)
+}
+
+// CheckOutBooking completes a booking from its scanned QR code, recording what was returned to the owner
+func (s *BookingService) CheckOutBooking(companyID string, actor models.BookingActor, req *models.BookingScanRequest) (*models.Booking, error) {
+	return s.scanBooking(companyID, actor, "check_out", req)
+}
+
+// Helper methods
+
+func (s *BookingService) scanBooking(companyID string, actor models.BookingActor, stage string, req *models.BookingScanRequest) (*models.Booking, error) {
+	bookingID, err := verifyBookingToken(req.Token)
+	if err != nil {
+		return nil, err
+	}
+	transition := handoverStages[stage]
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var booking models.Booking
+	err = tx.QueryRow(`
+		SELECT id, user_id, company_id, service_id, pet_id, employee_id,
+			   date_time, duration, price, status
+		FROM bookings WHERE id = $1
+		FOR UPDATE
+	`, bookingID).Scan(
+		&booking.ID, &booking.UserID, &booking.CompanyID, &booking.ServiceID,
+		&booking.PetID, &booking.EmployeeID, &booking.DateTime, &booking.Duration,
+		&booking.Price, &booking.Status,
+	)
+	if err != nil || booking.CompanyID != companyID {
+		return nil, fmt.Errorf("booking not found")
+	}
+
+	if !s.isValidStatusTransition(booking.Status, transition.status) {
+		return nil, fmt.Errorf("booking with status %s cannot be %s", booking.Status, transition.label)
+	}
+
+	_, err = tx.Exec(fmt.Sprintf(`
+		UPDATE bookings SET status = $2, %s = NOW(), updated_at = NOW()
+		WHERE id = $1
+	`, transition.column), bookingID, transition.status)
+	if err != nil {
+		return nil, err
+	}
+
+	belongings := []string{}
+	for _, item := range req.Belongings {
+		if item = strings.TrimSpace(item); item != "" {
+			belongings = append(belongings, item)
+		}
+	}
+	var notes *string
+	if trimmed := strings.TrimSpace(req.Notes); trimmed != "" {
+		notes = &trimmed
+	}
+
+	// Company owners scan without an employee profile
+	var employeeID *string
+	if actor.Type == "employee" {
+		employeeID = actor.ID
+	}
+
+	handover := models.BookingHandover{
+		BookingID:  bookingID,
+		Stage:      stage,
+		EmployeeID: employeeID,
+		Belongings: belongings,
+		Notes:      notes,
+	}
+	err = tx.QueryRow(`
+		INSERT INTO booking_handovers (booking_id, stage, employee_id, belongings, notes)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING id, created_at
+	`, bookingID, stage, employeeID, pq.Array(belongings), notes).Scan(&handover.ID, &handover.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record handover: %w", err)
+	}
+
+	err = recordBookingEvent(tx, bookingID, transition.event, actor, map[string]models.BookingFieldChange{
+		"status": bookingFieldChange(booking.Status, transition.status),
+	}, req.Notes)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.applyStatusChange(tx, &booking, transition.status); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	go s.sendStatusChangeNotifications(&booking, transition.status)
+	go s.sendHandoverNotification(&booking, &handover)
+
+	return s.GetBookingByID(bookingID)
+}
+
+func getBookingHandovers(q rowsQuerier, bookingID string) ([]models.BookingHandover, error) {
+	rows, err := q.Query(`
+		SELECT id, booking_id, stage, employee_id, belongings, notes, created_at
+		FROM booking_handovers
+		WHERE booking_id = $1
+		ORDER BY created_at
+	`, bookingID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	handovers := []models.BookingHandover{}
+	for rows.Next() {
+		var handover models.BookingHandover
+		if err := rows.Scan(
+			&handover.ID, &handover.BookingID, &handover.Stage, &handover.EmployeeID,
+			pq.Array(&handover.Belongings), &handover.Notes, &handover.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		handovers = append(handovers, handover)
+	}
+
+	return handovers, nil
+}
+
+func (s *BookingService) sendHandoverNotification(booking *models.Booking, handover *models.BookingHandover) {
+	if s.notificationService == nil {
+		return
+	}
+
+	petName := "Your pet"
+	if booking.PetID != nil {
+		var name sql.NullString
+		if err := s.db.QueryRow("SELECT name FROM pets WHERE id = $1", *booking.PetID).Scan(&name); err == nil && name.Valid {
+			petName = name.String
+		}
+	}
+
+	loc := companyLocation(s.db, booking.CompanyID)
+	at := handover.CreatedAt.In(loc).Format("15:04")
+
+	payload := &NotificationPayload{
+		Type:      "booking_checked_in",
+		Title:     "Checked in",
+		Message:   fmt.Sprintf("%s was checked in at %s.", petName, at),
+		UserID:    booking.UserID,
+		CompanyID: booking.CompanyID,
+		BookingID: &booking.ID,
+		Data: map[string]interface{}{
+			"booking_id": booking.ID,
+			"stage":      handover.Stage,
+			"belongings": handover.Belongings,
+			"timezone":   loc.String(),
+		},
+	}
+	if handover.Stage == "check_out" {
+		payload.Type = "booking_checked_out"
+		payload.Title = "Ready to go home"
+		payload.Message = fmt.Sprintf("%s was checked out at %s.", petName, at)
+	}
+	if handover.Notes != nil {
+		payload.Message += " Note from the team: " + *handover.Notes
+		payload.Data["notes"] = *handover.Notes
+	}
+
+	if err := s.notificationService.SendImmediateNotification(payload, []string{"push", "email"}); err != nil {
+		log.Printf("Error sending %s notification: %v", handover.Stage, err)
+	}
+}
+
+// signBookingToken returns "<booking id>.<signature>", so staff devices can verify a code without a lookup table
+func signBookingToken(bookingID string) (string, error) {
+	secret := os.Getenv("BOOKING_QR_SECRET")
+	if secret == "" {
+		return "", fmt.Errorf("booking QR codes are not configured")
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(bookingID))
+	return bookingID + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), nil
+}
+
+func verifyBookingToken(token string) (string, error) {
+	separator := strings.LastIndex(token, ".")
+	if separator <= 0 {
+		return "", ErrInvalidBookingQR
+	}
+
+	expected, err := signBookingToken(token[:separator])
+	if err != nil {
+		return "", err
+	}
+	if !hmac.Equal([]byte(expected), []byte(token)) {
+		return "", ErrInvalidBookingQR
+	}
+
+	return token[:separator], nil
+}
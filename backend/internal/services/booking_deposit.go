@@ -0,0 +1,395 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// ErrDepositNotPaid is returned when a booking is confirmed before its deposit payment has succeeded
+var ErrDepositNotPaid = errors.New("deposit payment has not been received yet")
+
+// defaultDepositHoldMinutes is how long an unpaid booking holds its slot unless the service sets its own limit
+const defaultDepositHoldMinutes = 15
+
+// GetServiceDeposit returns the deposit a service requires at booking time
+func (s *BookingService) GetServiceDeposit(companyID, serviceID string) (*models.ServiceDeposit, error) {
+	var deposit models.ServiceDeposit
+	err := s.db.QueryRow(`
+		SELECT id, deposit_type, deposit_value, deposit_hold_minutes
+		FROM services WHERE id = $1 AND company_id = $2
+	`, serviceID, companyID).Scan(&deposit.ServiceID, &deposit.DepositType, &deposit.DepositValue, &deposit.HoldMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+
+	return &deposit, nil
+}
+
+// SetServiceDeposit configures a fixed or percentage deposit for a service, or turns it off with type "none"
+func (s *BookingService) SetServiceDeposit(companyID, serviceID string, req *models.ServiceDepositRequest) (*models.ServiceDeposit, error) {
+	switch req.DepositType {
+	case "none", "fixed":
+	case "percentage":
+		if req.DepositValue > 100 {
+			return nil, fmt.Errorf("percentage deposit cannot exceed 100")
+		}
+	default:
+		return nil, fmt.Errorf("invalid deposit type: %s", req.DepositType)
+	}
+	if req.DepositValue < 0 {
+		return nil, fmt.Errorf("deposit_value cannot be negative")
+	}
+
+	holdMinutes := req.HoldMinutes
+	if holdMinutes <= 0 {
+		holdMinutes = defaultDepositHoldMinutes
+	}
+
+	var deposit models.ServiceDeposit
+	err := s.db.QueryRow(`
+		UPDATE services SET deposit_type = $3, deposit_value = $4, deposit_hold_minutes = $5, updated_at = NOW()
+		WHERE id = $1 AND company_id = $2
+		RETURNING id, deposit_type, deposit_value, deposit_hold_minutes
+	`, serviceID, companyID, req.DepositType, req.DepositValue, holdMinutes).Scan(
+		&deposit.ServiceID, &deposit.DepositType, &deposit.DepositValue, &deposit.HoldMinutes,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("service not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to save deposit settings: %w", err)
+	}
+
+	return &deposit, nil
+}
+
+// ConfirmBookingDeposit confirms a booking held for payment once its deposit payment has succeeded
+func (s *BookingService) ConfirmBookingDeposit(bookingID string) (*models.Booking, error) {
+	booking, err := s.GetBookingByID(bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+	if booking.Status != "pending_payment" {
+		return nil, fmt.Errorf("booking is not awaiting payment")
+	}
+
+	paymentID, status, err := s.depositPayment(booking)
+	if err != nil {
+		return nil, err
+	}
+	if status != "succeeded" {
+		return nil, ErrDepositNotPaid
+	}
+
+	if err := s.confirmPaidBooking(booking.ID, paymentID, UserActor(booking.UserID), "Deposit paid"); err != nil {
+		return nil, err
+	}
+
+	return s.GetBookingByID(bookingID)
+}
+
+// MarkDepositReceived records a deposit the company collected offline and confirms the booking
+func (s *BookingService) MarkDepositReceived(companyID, bookingID string, actor models.BookingActor) (*models.Booking, error) {
+	booking, err := s.GetBookingByID(bookingID)
+	if err != nil || booking.CompanyID != companyID {
+		return nil, fmt.Errorf("booking not found")
+	}
+	if booking.Status != "pending_payment" {
+		return nil, fmt.Errorf("booking is not awaiting payment")
+	}
+
+	paymentID, status, err := s.depositPayment(booking)
+	if err != nil {
+		return nil, err
+	}
+	if status != "succeeded" {
+		if err := s.paymentService.UpdatePaymentStatus(paymentID, "succeeded"); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.confirmPaidBooking(booking.ID, paymentID, actor, "Deposit received by the company"); err != nil {
+		return nil, err
+	}
+
+	return s.GetBookingByID(bookingID)
+}
+
+// ReleaseUnpaidBookings confirms bookings whose deposit has arrived and cancels the ones past their payment deadline
+func (s *BookingService) ReleaseUnpaidBookings() {
+	rows, err := s.db.Query("SELECT id FROM bookings WHERE status = 'pending_payment'")
+	if err != nil {
+		log.Printf("Error getting unpaid bookings: %v", err)
+		return
+	}
+
+	var bookingIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			bookingIDs = append(bookingIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range bookingIDs {
+		booking, err := s.GetBookingByID(id)
+		if err != nil {
+			continue
+		}
+
+		if paymentID, status, err := s.depositPayment(booking); err == nil && status == "succeeded" {
+			if err := s.confirmPaidBooking(booking.ID, paymentID, SystemActor, "Deposit paid"); err != nil {
+				log.Printf("Error confirming paid booking %s: %v", booking.ID, err)
+			}
+			continue
+		}
+
+		if booking.PaymentExpiresAt != nil && booking.PaymentExpiresAt.After(time.Now()) {
+			continue
+		}
+
+		if s.releaseUnpaidBooking(booking) {
+			s.sendDepositExpiredNotification(booking)
+			s.offerFreedSlot(booking.ServiceID, booking.DateTime, booking.EmployeeID)
+		}
+	}
+}
+
+// Helper methods
+
+// depositAmount returns the deposit due for a booking of the given price
+func depositAmount(depositType string, value float64, price models.Money) models.Money {
+	switch depositType {
+	case "fixed":
+		if fixed := models.MoneyFromDecimal(value, price.Currency); fixed.Amount < price.Amount {
+			return fixed
+		}
+		return price
+	case "percentage":
+		return price.Percent(value)
+	}
+	return models.NewMoney(0, price.Currency)
+}
+
+// requestBookingDeposit creates the payment intent of a booking held for payment
+func (s *BookingService) requestBookingDeposit(booking *models.Booking) error {
+	intent, err := s.paymentService.CreatePaymentIntent(&PaymentRequest{
+		UserID:      booking.UserID,
+		CompanyID:   booking.CompanyID,
+		BookingID:   &booking.ID,
+		Amount:      booking.DepositAmount.Decimal(),
+		Currency:    booking.DepositAmount.Code(), // The deposit is in the currency of the service price
+		Description: "Booking deposit",
+		Metadata: map[string]interface{}{
+			"type":          "booking_deposit",
+			"booking_id":    booking.ID,
+			"booking_price": booking.Price,
+			"options_price": booking.OptionsPrice,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to request deposit: %w", err)
+	}
+
+	_, err = s.db.Exec("UPDATE bookings SET deposit_payment_intent_id = $2 WHERE id = $1", booking.ID, intent.PaymentIntentID)
+	if err != nil {
+		return err
+	}
+
+	booking.DepositPaymentIntentID = &intent.PaymentIntentID
+	booking.DepositClientSecret = intent.ClientSecret
+
+	return nil
+}
+
+// depositPayment returns the payment row and status behind the deposit of a booking
+func (s *BookingService) depositPayment(booking *models.Booking) (string, string, error) {
+	if booking.DepositPaymentIntentID == nil {
+		return "", "", fmt.Errorf("booking has no deposit payment")
+	}
+
+	var paymentID, status string
+	err := s.db.QueryRow(`
+		SELECT id, status FROM payments WHERE stripe_payment_intent_id = $1
+	`, *booking.DepositPaymentIntentID).Scan(&paymentID, &status)
+	if err != nil {
+		return "", "", fmt.Errorf("deposit payment not found")
+	}
+
+	return paymentID, status, nil
+}
+
+// confirmDepositPaid confirms, within the caller's transaction, the booking held for a deposit once the
+// provider reports the payment made. It returns the confirmed booking, nil when there was none to confirm;
+// the caller sends its notifications after committing.
+func (s *BookingService) confirmDepositPaid(tx *sql.Tx, intentID, paymentID string) (*models.Booking, error) {
+	var bookingID string
+	err := tx.QueryRow(`
+		SELECT id FROM bookings WHERE deposit_payment_intent_id = $1 AND status = 'pending_payment'
+	`, intentID).Scan(&bookingID)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return s.confirmPaidBookingTx(tx, bookingID, paymentID, SystemActor, "Deposit paid")
+}
+
+// confirmPaidBooking moves a booking out of pending_payment once its deposit is paid
+func (s *BookingService) confirmPaidBooking(bookingID, paymentID string, actor models.BookingActor, note string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	booking, err := s.confirmPaidBookingTx(tx, bookingID, paymentID, actor, note)
+	if err != nil || booking == nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	go s.sendStatusChangeNotifications(booking, "confirmed")
+
+	return nil
+}
+
+// confirmPaidBookingTx confirms the booking within tx; nil means it was confirmed or released concurrently
+func (s *BookingService) confirmPaidBookingTx(tx *sql.Tx, bookingID, paymentID string, actor models.BookingActor, note string) (*models.Booking, error) {
+	var booking models.Booking
+	err := tx.QueryRow(`
+		UPDATE bookings
+		SET status = 'confirmed', payment_id = $2, payment_expires_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending_payment'
+		RETURNING id, user_id, company_id, service_id, pet_id, employee_id,
+				  date_time, duration, price, status, notes, created_at, updated_at
+	`, bookingID, paymentID).Scan(
+		&booking.ID, &booking.UserID, &booking.CompanyID, &booking.ServiceID,
+		&booking.PetID, &booking.EmployeeID, &booking.DateTime, &booking.Duration,
+		&booking.Price, &booking.Status, &booking.Notes, &booking.CreatedAt, &booking.UpdatedAt,
+	)
+	if err == sql.ErrNoRows {
+		// Confirmed or released concurrently
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = recordBookingEvent(tx, booking.ID, "status_changed", actor, map[string]models.BookingFieldChange{
+		"status": bookingFieldChange("pending_payment", "confirmed"),
+	}, note)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.scheduleReminderNotifications(tx, &booking); err != nil {
+		return nil, err
+	}
+
+	return &booking, nil
+}
+
+// releaseUnpaidBooking voids the pending deposit payment of a booking still awaiting it, then cancels the booking.
+// Reports false when the booking was paid or released in the meantime, or the payment could not be voided.
+func (s *BookingService) releaseUnpaidBooking(booking *models.Booking) bool {
+	// A deposit the customer paid after all keeps the booking; the payment confirms it
+	if err := s.cancelDepositPayment(booking); err != nil {
+		if !errors.Is(err, ErrPaymentAlreadyMade) {
+			log.Printf("Error cancelling deposit of booking %s: %v", booking.ID, err)
+		}
+		return false
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		log.Printf("Error releasing unpaid booking %s: %v", booking.ID, err)
+		return false
+	}
+	defer tx.Rollback()
+
+	result, err := tx.Exec(`
+		UPDATE bookings SET status = 'cancelled', payment_expires_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending_payment'
+	`, booking.ID)
+	if err != nil {
+		log.Printf("Error releasing unpaid booking %s: %v", booking.ID, err)
+		return false
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return false
+	}
+
+	err = recordBookingEvent(tx, booking.ID, "status_changed", SystemActor, map[string]models.BookingFieldChange{
+		"status": bookingFieldChange("pending_payment", "cancelled"),
+	}, "Deposit not paid in time")
+	if err != nil {
+		log.Printf("Error releasing unpaid booking %s: %v", booking.ID, err)
+		return false
+	}
+
+	if err = s.cancelBookingNotifications(tx, booking.ID); err != nil {
+		log.Printf("Error releasing unpaid booking %s: %v", booking.ID, err)
+		return false
+	}
+
+	if err = tx.Commit(); err != nil {
+		log.Printf("Error releasing unpaid booking %s: %v", booking.ID, err)
+		return false
+	}
+
+	return true
+}
+
+// cancelDepositPayment voids the deposit payment of a booking that will not go ahead, at the provider too,
+// so that it can no longer be paid. ErrPaymentAlreadyMade means the customer paid it.
+func (s *BookingService) cancelDepositPayment(booking *models.Booking) error {
+	if s.paymentService == nil || booking.DepositPaymentIntentID == nil {
+		return nil
+	}
+
+	paymentID, _, err := s.depositPayment(booking)
+	if err != nil {
+		return nil
+	}
+
+	return s.paymentService.CancelPayment(paymentID)
+}
+
+func (s *BookingService) sendDepositExpiredNotification(booking *models.Booking) {
+	if s.notificationService == nil {
+		return
+	}
+
+	loc := companyLocation(s.db, booking.CompanyID)
+	payload := &NotificationPayload{
+		Type:  "booking_deposit_expired",
+		Title: "Booking released",
+		Message: fmt.Sprintf("Your booking on %s was released because the deposit was not paid in time.",
+			booking.DateTime.In(loc).Format("Jan 2 at 15:04")),
+		UserID:    booking.UserID,
+		CompanyID: booking.CompanyID,
+		BookingID: &booking.ID,
+		Data: map[string]interface{}{
+			"booking_id":      booking.ID,
+			"service_id":      booking.ServiceID,
+			"local_date_time": booking.DateTime.In(loc).Format("2006-01-02T15:04:05"),
+			"timezone":        loc.String(),
+		},
+	}
+
+	if err := s.notificationService.SendImmediateNotification(payload, []string{"push", "email"}); err != nil {
+		log.Printf("Error sending deposit expiry notification: %v", err)
+	}
+}
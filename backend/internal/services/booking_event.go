@@ -0,0 +1,196 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// sqlExecer is implemented by both *sql.DB and *sql.Tx
+type sqlExecer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+// SystemActor attributes changes made by background jobs and payment callbacks
+var SystemActor = models.BookingActor{Type: "system"}
+
+// bookingAIAgent is the AI agent key recorded for bookings made through the booking assistant
+const bookingAIAgent = "booking_assistant"
+
+// UserActor attributes a change to a customer or company owner
+func UserActor(userID string) models.BookingActor {
+	return models.BookingActor{Type: "user", ID: &userID}
+}
+
+// EmployeeActor attributes a change to a company employee
+func EmployeeActor(employeeID string) models.BookingActor {
+	return models.BookingActor{Type: "employee", ID: &employeeID}
+}
+
+// AIAgentActor attributes a change to an AI agent
+func AIAgentActor(agentKey string) models.BookingActor {
+	return models.BookingActor{Type: "ai_agent", ID: &agentKey}
+}
+
+const bookingEventColumns = `
+	e.id, e.booking_id, e.event_type, e.actor_type, e.actor_id, e.changes, e.note, e.created_at,
+	COALESCE(NULLIF(TRIM(CONCAT(u.first_name, ' ', u.last_name)), ''),
+			 NULLIF(TRIM(CONCAT(em.first_name, ' ', em.last_name)), ''), '')`
+
+const bookingEventJoins = `
+	LEFT JOIN users u ON e.actor_type = 'user' AND u.id::text = e.actor_id
+	LEFT JOIN employees em ON e.actor_type = 'employee' AND em.id::text = e.actor_id`
+
+// GetBookingHistory returns the change history of a booking, oldest first
+func (s *BookingService) GetBookingHistory(bookingID string) ([]models.BookingEvent, error) {
+	return s.queryBookingEvents(`
+		WHERE e.booking_id = $1
+		ORDER BY e.created_at, e.id
+	`, bookingID)
+}
+
+// Helper methods
+
+// customerBookingEvents returns the history of a customer's bookings with a company, keyed by booking
+func (s *BookingService) customerBookingEvents(companyID, userID string) (map[string][]models.BookingEvent, error) {
+	events, err := s.queryBookingEvents(`
+		JOIN bookings b ON b.id = e.booking_id
+		WHERE e.company_id = $1 AND b.user_id = $2
+		ORDER BY e.created_at, e.id
+	`, companyID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	byBooking := make(map[string][]models.BookingEvent)
+	for _, event := range events {
+		byBooking[event.BookingID] = append(byBooking[event.BookingID], event)
+	}
+
+	return byBooking, nil
+}
+
+func (s *BookingService) queryBookingEvents(where string, args ...interface{}) ([]models.BookingEvent, error) {
+	rows, err := s.db.Query("SELECT "+bookingEventColumns+" FROM booking_events e "+bookingEventJoins+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking history: %w", err)
+	}
+	defer rows.Close()
+
+	events := []models.BookingEvent{}
+	for rows.Next() {
+		var event models.BookingEvent
+		var changes []byte
+		if err := rows.Scan(
+			&event.ID, &event.BookingID, &event.EventType, &event.Actor.Type, &event.Actor.ID,
+			&changes, &event.Note, &event.CreatedAt, &event.ActorName,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(changes, &event.Changes); err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
+
+// recordBookingEvent appends an entry to a booking's history, in the caller's transaction when q is one
+func recordBookingEvent(q sqlExecer, bookingID, eventType string, actor models.BookingActor, changes map[string]models.BookingFieldChange, note string) error {
+	if changes == nil {
+		changes = map[string]models.BookingFieldChange{}
+	}
+	changesJSON, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+
+	var notePtr *string
+	if note = strings.TrimSpace(note); note != "" {
+		notePtr = &note
+	}
+
+	_, err = q.Exec(`
+		INSERT INTO booking_events (booking_id, company_id, event_type, actor_type, actor_id, changes, note)
+		SELECT id, company_id, $2, $3, $4, $5, $6 FROM bookings WHERE id = $1
+	`, bookingID, eventType, actor.Type, actor.ID, changesJSON, notePtr)
+	if err != nil {
+		return fmt.Errorf("failed to record booking event: %w", err)
+	}
+
+	return nil
+}
+
+// recordBookingChanges records the differences between two states of a booking, one event per kind of change
+func recordBookingChanges(q sqlExecer, before, after *models.Booking, actor models.BookingActor, note string) error {
+	events := []struct {
+		eventType string
+		changes   map[string]models.BookingFieldChange
+	}{
+		{"rescheduled", map[string]models.BookingFieldChange{}},
+		{"reassigned", map[string]models.BookingFieldChange{}},
+		{"status_changed", map[string]models.BookingFieldChange{}},
+		{"price_changed", map[string]models.BookingFieldChange{}},
+		{"updated", map[string]models.BookingFieldChange{}},
+	}
+
+	if !before.DateTime.Equal(after.DateTime) {
+		events[0].changes["date_time"] = bookingFieldChange(before.DateTime, after.DateTime)
+	}
+	if stringValue(before.EmployeeID) != stringValue(after.EmployeeID) {
+		events[1].changes["employee_id"] = bookingFieldChange(before.EmployeeID, after.EmployeeID)
+	}
+	if before.Status != after.Status {
+		events[2].changes["status"] = bookingFieldChange(before.Status, after.Status)
+	}
+	if before.Price != after.Price {
+		events[3].changes["price"] = bookingFieldChange(before.Price, after.Price)
+	}
+	if before.Duration != after.Duration {
+		events[4].changes["duration"] = bookingFieldChange(before.Duration, after.Duration)
+	}
+	if stringValue(before.Notes) != stringValue(after.Notes) {
+		events[4].changes["notes"] = bookingFieldChange(before.Notes, after.Notes)
+	}
+
+	for _, event := range events {
+		if len(event.changes) == 0 {
+			continue
+		}
+		if err := recordBookingEvent(q, after.ID, event.eventType, actor, event.changes, note); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// bookingFieldChange normalizes values so the history reads the same regardless of how a field is stored
+func bookingFieldChange(from, to interface{}) models.BookingFieldChange {
+	normalize := func(value interface{}) interface{} {
+		switch v := value.(type) {
+		case time.Time:
+			return v.UTC().Format(time.RFC3339)
+		case *string:
+			if v == nil {
+				return nil
+			}
+			return *v
+		}
+		return value
+	}
+
+	return models.BookingFieldChange{From: normalize(from), To: normalize(to)}
+}
+
+func stringValue(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
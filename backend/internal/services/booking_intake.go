@@ -0,0 +1,271 @@
+package services
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// bookingIntakeAnswers is a form answered for a booking, validated and ready to save
+type bookingIntakeAnswers struct {
+	Form    models.IntakeForm
+	Answers map[string]interface{}
+}
+
+// GetBookingIntake returns the forms asked for a booking, answered or still outstanding
+func (s *BookingService) GetBookingIntake(bookingID string) ([]models.BookingIntake, error) {
+	var serviceID string
+	if err := s.db.QueryRow("SELECT service_id FROM bookings WHERE id = $1", bookingID).Scan(&serviceID); err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+
+	forms, err := serviceIntakeForms(s.db, serviceID)
+	if err != nil {
+		return nil, err
+	}
+	responses, err := queryBookingIntakeResponses(s.db, `WHERE booking_id = $1`, bookingID)
+	if err != nil {
+		return nil, err
+	}
+
+	return mergeBookingIntake(bookingID, forms, responses), nil
+}
+
+// SubmitBookingIntake answers a form of a booking after it was made; answering again replaces the earlier answers
+func (s *BookingService) SubmitBookingIntake(bookingID string, req *models.IntakeSubmission, actor models.BookingActor) (*models.BookingIntake, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var serviceID, status string
+	err = tx.QueryRow(`
+		SELECT service_id, status FROM bookings WHERE id = $1 FOR UPDATE
+	`, bookingID).Scan(&serviceID, &status)
+	if err != nil {
+		return nil, fmt.Errorf("booking not found")
+	}
+	switch status {
+	case "cancelled", "rejected", "completed", "no_show":
+		return nil, fmt.Errorf("forms cannot be submitted for a %s booking", status)
+	}
+
+	answers, err := collectBookingIntake(tx, serviceID, []models.IntakeSubmission{*req}, false)
+	if err != nil {
+		return nil, err
+	}
+
+	intake, err := saveBookingIntake(tx, bookingID, answers, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &intake[0], nil
+}
+
+// Helper methods
+
+// collectBookingIntake validates the forms answered with a booking of a service. Each must be an active form
+// of the service; when enforce is set, every form due at booking has to be among them.
+func collectBookingIntake(q rowsQuerier, serviceID string, submissions []models.IntakeSubmission, enforce bool) ([]bookingIntakeAnswers, error) {
+	forms, err := serviceIntakeForms(q, serviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	byID := make(map[string]models.IntakeForm)
+	for _, form := range forms {
+		byID[form.ID] = form
+	}
+
+	var collected []bookingIntakeAnswers
+	submitted := make(map[string]bool)
+	for _, submission := range submissions {
+		form, ok := byID[submission.FormID]
+		if !ok {
+			return nil, fmt.Errorf("intake form %s is not asked for this service", submission.FormID)
+		}
+		if submitted[form.ID] {
+			return nil, fmt.Errorf("intake form %s is answered more than once", form.Name)
+		}
+		submitted[form.ID] = true
+
+		if err := validateIntakeAnswers(form.Fields, submission.Answers); err != nil {
+			return nil, fmt.Errorf("%s: %w", form.Name, err)
+		}
+		collected = append(collected, bookingIntakeAnswers{Form: form, Answers: submission.Answers})
+	}
+
+	if enforce {
+		for _, form := range forms {
+			if form.Completion == "at_booking" && !submitted[form.ID] {
+				return nil, fmt.Errorf("please complete the %s form to book this service", form.Name)
+			}
+		}
+	}
+
+	return collected, nil
+}
+
+// saveBookingIntake stores answers with a copy of their form, replacing earlier answers to the same form
+func saveBookingIntake(tx *sql.Tx, bookingID string, answers []bookingIntakeAnswers, actor models.BookingActor) ([]models.BookingIntake, error) {
+	var submittedBy *string
+	if actor.Type == "user" || actor.Type == "employee" {
+		submittedBy = actor.ID
+	}
+
+	saved := []models.BookingIntake{}
+	for _, answer := range answers {
+		fieldsJSON, err := json.Marshal(answer.Form.Fields)
+		if err != nil {
+			return nil, err
+		}
+		answersJSON, err := json.Marshal(answer.Answers)
+		if err != nil {
+			return nil, err
+		}
+
+		var id string
+		err = tx.QueryRow(`
+			INSERT INTO booking_intake_responses (booking_id, form_id, form_name, fields, answers, submitted_by)
+			VALUES ($1, $2, $3, $4, $5, $6)
+			ON CONFLICT (booking_id, form_id) WHERE form_id IS NOT NULL DO UPDATE
+			SET form_name = EXCLUDED.form_name, fields = EXCLUDED.fields, answers = EXCLUDED.answers,
+				submitted_by = EXCLUDED.submitted_by, updated_at = NOW()
+			RETURNING id
+		`, bookingID, answer.Form.ID, answer.Form.Name, fieldsJSON, answersJSON, submittedBy).Scan(&id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save intake form: %w", err)
+		}
+
+		if err := recordBookingEvent(tx, bookingID, "updated", actor, nil, "Intake form answered: "+answer.Form.Name); err != nil {
+			return nil, err
+		}
+
+		responses, err := queryBookingIntakeResponses(tx, `WHERE id = $1`, id)
+		if err != nil {
+			return nil, err
+		}
+		responses[0].Completion = answer.Form.Completion
+		saved = append(saved, responses[0])
+	}
+
+	return saved, nil
+}
+
+// attachBookingIntake sets the forms asked for each booking, answered or outstanding
+func (s *BookingService) attachBookingIntake(bookings []*models.BookingWithCustomerData) error {
+	if len(bookings) == 0 {
+		return nil
+	}
+
+	bookingIDs := make([]string, 0, len(bookings))
+	formsByService := make(map[string][]models.IntakeForm)
+	for _, booking := range bookings {
+		bookingIDs = append(bookingIDs, booking.ID)
+		if _, loaded := formsByService[booking.ServiceID]; loaded {
+			continue
+		}
+		forms, err := serviceIntakeForms(s.db, booking.ServiceID)
+		if err != nil {
+			return err
+		}
+		formsByService[booking.ServiceID] = forms
+	}
+
+	responses, err := queryBookingIntakeResponses(s.db, `WHERE booking_id::text = ANY($1)`, pq.Array(bookingIDs))
+	if err != nil {
+		return err
+	}
+	responsesByBooking := make(map[string][]models.BookingIntake)
+	for _, response := range responses {
+		responsesByBooking[response.BookingID] = append(responsesByBooking[response.BookingID], response)
+	}
+
+	for _, booking := range bookings {
+		booking.Intake = mergeBookingIntake(booking.ID, formsByService[booking.ServiceID], responsesByBooking[booking.ID])
+	}
+
+	return nil
+}
+
+// mergeBookingIntake lists the service's forms, outstanding ones without answers,
+// followed by answers to forms no longer asked for the service
+func mergeBookingIntake(bookingID string, forms []models.IntakeForm, responses []models.BookingIntake) []models.BookingIntake {
+	byForm := make(map[string]models.BookingIntake)
+	for _, response := range responses {
+		if response.FormID != nil {
+			byForm[*response.FormID] = response
+		}
+	}
+
+	intake := []models.BookingIntake{}
+	asked := make(map[string]bool)
+	for _, form := range forms {
+		asked[form.ID] = true
+		if response, ok := byForm[form.ID]; ok {
+			response.Completion = form.Completion
+			intake = append(intake, response)
+			continue
+		}
+
+		formID := form.ID
+		intake = append(intake, models.BookingIntake{
+			BookingID:  bookingID,
+			FormID:     &formID,
+			FormName:   form.Name,
+			Completion: form.Completion,
+			Fields:     form.Fields,
+		})
+	}
+	for _, response := range responses {
+		if response.FormID == nil || !asked[*response.FormID] {
+			intake = append(intake, response)
+		}
+	}
+
+	return intake
+}
+
+func queryBookingIntakeResponses(q rowsQuerier, where string, args ...interface{}) ([]models.BookingIntake, error) {
+	rows, err := q.Query(`
+		SELECT id, booking_id, form_id, form_name, fields, answers, submitted_by, submitted_at
+		FROM booking_intake_responses `+where+`
+		ORDER BY submitted_at`, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get intake forms: %w", err)
+	}
+	defer rows.Close()
+
+	responses := []models.BookingIntake{}
+	for rows.Next() {
+		var response models.BookingIntake
+		var id string
+		var fields, answers []byte
+		if err := rows.Scan(
+			&id, &response.BookingID, &response.FormID, &response.FormName,
+			&fields, &answers, &response.SubmittedBy, &response.SubmittedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fields, &response.Fields); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(answers, &response.Answers); err != nil {
+			return nil, err
+		}
+		response.ID = &id
+		response.Completed = true
+		responses = append(responses, response)
+	}
+
+	return responses, nil
+}
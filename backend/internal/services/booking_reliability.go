@@ -0,0 +1,194 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// ErrBookingBlocked is returned when a customer's no-shows reach the company's limit for online booking
+var ErrBookingBlocked = errors.New("online booking is not available after repeated no-shows, please contact the company")
+
+// GetNoShowRules returns the rules the company applies to customers with repeated no-shows
+func (s *BookingService) GetNoShowRules(companyID string) (*models.NoShowRules, error) {
+	rules, err := s.noShowRules(s.db, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("company not found")
+	}
+
+	return rules, nil
+}
+
+// SetNoShowRules saves the no-show rules of the company
+func (s *BookingService) SetNoShowRules(companyID string, req *models.NoShowRules) (*models.NoShowRules, error) {
+	if req.DepositAfter < 0 || req.BlockAfter < 0 || req.WindowDays < 0 {
+		return nil, fmt.Errorf("thresholds and window_days cannot be negative")
+	}
+	switch req.DepositType {
+	case "fixed":
+	case "percentage":
+		if req.DepositValue > 100 {
+			return nil, fmt.Errorf("percentage deposit cannot exceed 100")
+		}
+	default:
+		return nil, fmt.Errorf("invalid deposit type: %s", req.DepositType)
+	}
+	if req.DepositValue < 0 {
+		return nil, fmt.Errorf("deposit_value cannot be negative")
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE companies
+		SET no_show_deposit_after = $2, no_show_deposit_type = $3, no_show_deposit_value = $4,
+			no_show_block_after = $5, no_show_window_days = $6, updated_at = NOW()
+		WHERE id = $1
+	`, companyID, req.DepositAfter, req.DepositType, req.DepositValue, req.BlockAfter, req.WindowDays)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save no-show rules: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, fmt.Errorf("company not found")
+	}
+
+	return s.GetNoShowRules(companyID)
+}
+
+// MarkNoShow records that the customer did not turn up for a booking and settles the no-show fee
+// of its cancellation policy. With waiveFee the booking is settled as if the company cancelled it.
+func (s *BookingService) MarkNoShow(companyID, bookingID string, req *models.MarkNoShowRequest, actor models.BookingActor) (*models.CancellationOutcome, error) {
+	booking, err := s.GetBookingByID(bookingID)
+	if err != nil || booking.CompanyID != companyID {
+		return nil, fmt.Errorf("booking not found")
+	}
+	if !s.isValidStatusTransition(booking.Status, "no_show") {
+		return nil, fmt.Errorf("booking with status %s cannot be marked as no-show", booking.Status)
+	}
+	if booking.DateTime.After(time.Now()) {
+		return nil, fmt.Errorf("booking has not started yet")
+	}
+
+	initiatedBy := "customer"
+	if req.WaiveFee {
+		initiatedBy = "company"
+	}
+	outcome, err := s.evaluateCancellation(booking, "no_show", initiatedBy, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.UpdateBookingStatus(bookingID, "no_show", req.Notes, actor); err != nil {
+		return nil, err
+	}
+
+	s.settleCancellation(booking, outcome)
+
+	return outcome, nil
+}
+
+// Helper methods
+
+// noShowRules loads the no-show rules of a company
+func (s *BookingService) noShowRules(q rowQuerier, companyID string) (*models.NoShowRules, error) {
+	var rules models.NoShowRules
+	err := q.QueryRow(`
+		SELECT no_show_deposit_after, no_show_deposit_type, no_show_deposit_value,
+			   no_show_block_after, no_show_window_days
+		FROM companies WHERE id = $1
+	`, companyID).Scan(&rules.DepositAfter, &rules.DepositType, &rules.DepositValue, &rules.BlockAfter, &rules.WindowDays)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rules, nil
+}
+
+// customerNoShowRules applies the company's no-show rules to a customer about to book online.
+// Returns ErrBookingBlocked when the customer may not book, otherwise the rules when a deposit is required, or nil.
+func (s *BookingService) customerNoShowRules(q rowQuerier, companyID, userID string) (*models.NoShowRules, error) {
+	rules, err := s.noShowRules(q, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get no-show rules: %w", err)
+	}
+	if rules.DepositAfter == 0 && rules.BlockAfter == 0 {
+		return nil, nil
+	}
+
+	var since *time.Time
+	if rules.WindowDays > 0 {
+		windowStart := time.Now().AddDate(0, 0, -rules.WindowDays)
+		since = &windowStart
+	}
+
+	var noShows int
+	err = q.QueryRow(`
+		SELECT COUNT(*) FROM bookings
+		WHERE company_id = $1 AND user_id = $2 AND status = 'no_show'
+		  AND ($3::timestamptz IS NULL OR date_time >= $3)
+	`, companyID, userID, since).Scan(&noShows)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count no-shows: %w", err)
+	}
+
+	if rules.BlockAfter > 0 && noShows >= rules.BlockAfter {
+		return nil, ErrBookingBlocked
+	}
+	if rules.DepositAfter > 0 && noShows >= rules.DepositAfter {
+		return rules, nil
+	}
+
+	return nil, nil
+}
+
+// customerReliability returns the reliability counters of the company's customers keyed by user ID
+func (s *BookingService) customerReliability(companyID string) (map[string]models.CustomerReliability, error) {
+	rows, err := s.db.Query(`
+		SELECT b.user_id,
+			   COUNT(*),
+			   COUNT(*) FILTER (WHERE b.status = 'completed'),
+			   COUNT(*) FILTER (WHERE b.status = 'cancelled'),
+			   COUNT(*) FILTER (WHERE b.status = 'no_show'),
+			   MAX(b.date_time) FILTER (WHERE b.status = 'no_show'),
+			   COUNT(*) FILTER (WHERE EXISTS (
+				   SELECT 1 FROM booking_cancellations bc
+				   WHERE bc.booking_id = b.id AND bc.action = 'cancel'
+					 AND bc.initiated_by = 'customer' AND bc.fee_amount > 0
+			   ))
+		FROM bookings b
+		WHERE b.company_id = $1
+		GROUP BY b.user_id
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query customer reliability: %w", err)
+	}
+	defer rows.Close()
+
+	reliability := make(map[string]models.CustomerReliability)
+	for rows.Next() {
+		var userID string
+		var r models.CustomerReliability
+		var lastNoShowAt sql.NullTime
+		if err := rows.Scan(
+			&userID, &r.TotalBookings, &r.CompletedBookings, &r.CancelledBookings,
+			&r.NoShows, &lastNoShowAt, &r.LateCancellations,
+		); err != nil {
+			return nil, err
+		}
+		if lastNoShowAt.Valid {
+			r.LastNoShowAt = &lastNoShowAt.Time
+		}
+
+		// Late cancellations and no-shows both count as missed bookings
+		if finished := r.CompletedBookings + r.NoShows + r.LateCancellations; finished > 0 {
+			score := math.Round(float64(r.CompletedBookings)/float64(finished)*1000) / 10
+			r.ReliabilityScore = &score
+		}
+
+		reliability[userID] = r
+	}
+
+	return reliability, nil
+}
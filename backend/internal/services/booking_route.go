@@ -0,0 +1,350 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// ErrTravelTimeConflict is returned when the employee cannot get from the previous job to the address, or on to the next one, in time
+var ErrTravelTimeConflict = errors.New("employee cannot travel between appointments in time")
+
+// mobileBusinessTypes travel to the customer instead of receiving the pet at the company
+var mobileBusinessTypes = map[string]bool{"walking": true, "sitting": true, "pet_taxi": true}
+
+const earthRadiusKm = 6371.0
+
+// companyTravel is what route planning needs to know about a company
+type companyTravel struct {
+	Mobile   bool
+	Settings models.TravelSettings
+	Base     *models.BookingLocation // Company address, where routes start
+}
+
+// routeJob is an appointment of an employee with the address it takes place at
+type routeJob struct {
+	BookingID string
+	Start     time.Time
+	End       time.Time
+	Location  *models.BookingLocation
+}
+
+// GetTravelSettings returns how travel time between mobile jobs is computed for the company
+func (s *BookingService) GetTravelSettings(companyID string) (*models.TravelSettings, error) {
+	travel, err := loadCompanyTravel(s.db, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("company not found")
+	}
+
+	return &travel.Settings, nil
+}
+
+// SetTravelSettings saves the average travel speed and the allowance added to every trip
+func (s *BookingService) SetTravelSettings(companyID string, req *models.TravelSettings) (*models.TravelSettings, error) {
+	if req.SpeedKmh <= 0 || req.SpeedKmh > 200 {
+		return nil, fmt.Errorf("travel_speed_kmh must be between 0 and 200")
+	}
+	if req.BufferMinutes < 0 {
+		return nil, fmt.Errorf("travel_buffer_minutes cannot be negative")
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE companies SET travel_speed_kmh = $2, travel_buffer_minutes = $3, updated_at = NOW()
+		WHERE id = $1
+	`, companyID, req.SpeedKmh, req.BufferMinutes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save travel settings: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, fmt.Errorf("company not found")
+	}
+
+	return s.GetTravelSettings(companyID)
+}
+
+// GetEmployeeRoute returns an employee's appointments of a day in visiting order,
+// with the distance and travel time from the previous stop and the time to leave it.
+// A zero date is today in the company's timezone.
+func (s *BookingService) GetEmployeeRoute(companyID, employeeID string, date time.Time) (*models.EmployeeRoute, error) {
+	var employeeCompanyID string
+	err := s.db.QueryRow("SELECT company_id FROM employees WHERE id = $1", employeeID).Scan(&employeeCompanyID)
+	if err != nil || employeeCompanyID != companyID {
+		return nil, fmt.Errorf("employee not found")
+	}
+
+	travel, err := loadCompanyTravel(s.db, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := companyLocation(s.db, companyID)
+	if date.IsZero() {
+		date = time.Now().In(loc)
+	}
+	dayStart := inLocationDate(date, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+
+	rows, err := s.db.Query(`
+		SELECT b.id, b.status, b.date_time, b.duration,
+			   b.service_address, b.service_latitude, b.service_longitude,
+			   COALESCE(sv.name, ''), TRIM(CONCAT(u.first_name, ' ', u.last_name)), COALESCE(p.name, '')
+		FROM bookings b
+		LEFT JOIN services sv ON sv.id = b.service_id
+		LEFT JOIN users u ON u.id = b.user_id
+		LEFT JOIN pets p ON p.id = b.pet_id
+		WHERE b.employee_id = $1 AND b.date_time >= $2 AND b.date_time < $3
+		AND b.status NOT IN ('cancelled', 'rejected', 'no_show', 'pending_payment')
+		ORDER BY b.date_time
+	`, employeeID, dayStart, dayEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employee route: %w", err)
+	}
+	defer rows.Close()
+
+	route := &models.EmployeeRoute{
+		EmployeeID: employeeID,
+		Date:       dayStart.Format("2006-01-02"),
+		Timezone:   loc.String(),
+		Start:      travel.Base,
+		Stops:      []models.RouteStop{},
+	}
+
+	from := travel.Base
+	var previousEnd *time.Time
+	for rows.Next() {
+		var stop models.RouteStop
+		var duration int
+		var address sql.NullString
+		var latitude, longitude sql.NullFloat64
+		if err := rows.Scan(
+			&stop.BookingID, &stop.Status, &stop.StartsAt, &duration,
+			&address, &latitude, &longitude,
+			&stop.ServiceName, &stop.CustomerName, &stop.PetName,
+		); err != nil {
+			return nil, err
+		}
+		stop.StartsAt = stop.StartsAt.In(loc)
+		stop.EndsAt = stop.StartsAt.Add(time.Duration(duration) * time.Minute)
+		stop.Location = scanBookingLocation(address, latitude, longitude)
+
+		// Stops without an address are planned without travel and do not move the employee
+		if stop.Location != nil {
+			stop.DistanceKm, stop.TravelMinutes = travel.between(from, stop.Location)
+			from = stop.Location
+		}
+		stop.DistanceKm = math.Round(stop.DistanceKm*10) / 10
+		stop.LeaveBy = stop.StartsAt.Add(-time.Duration(stop.TravelMinutes) * time.Minute)
+		stop.Conflict = previousEnd != nil && previousEnd.After(stop.LeaveBy)
+
+		route.TotalDistanceKm += stop.DistanceKm
+		route.TotalTravelMinutes += stop.TravelMinutes
+		if stop.Conflict {
+			route.Conflicts++
+		}
+
+		end := stop.EndsAt
+		previousEnd = &end
+		route.Stops = append(route.Stops, stop)
+	}
+	route.TotalDistanceKm = math.Round(route.TotalDistanceKm*10) / 10
+
+	return route, nil
+}
+
+// Helper methods
+
+func loadCompanyTravel(q rowQuerier, companyID string) (*companyTravel, error) {
+	var businessType, address sql.NullString
+	var latitude, longitude sql.NullFloat64
+	travel := &companyTravel{}
+	err := q.QueryRow(`
+		SELECT business_type, travel_speed_kmh, travel_buffer_minutes, address, latitude, longitude
+		FROM companies WHERE id = $1
+	`, companyID).Scan(
+		&businessType, &travel.Settings.SpeedKmh, &travel.Settings.BufferMinutes,
+		&address, &latitude, &longitude,
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	travel.Mobile = mobileBusinessTypes[businessType.String]
+	travel.Base = scanBookingLocation(address, latitude, longitude)
+
+	return travel, nil
+}
+
+// checkEmployeeTravel makes sure the employee of a mobile appointment can arrive from their previous job
+// and reach their next one in time. Appointments without an employee or address are not checked.
+func (s *BookingService) checkEmployeeTravel(q dbQuerier, companyID string, employeeID *string, start time.Time, duration int, location *models.BookingLocation, excludeBookingID string) error {
+	if employeeID == nil || location == nil {
+		return nil
+	}
+
+	travel, err := loadCompanyTravel(q, companyID)
+	if err != nil {
+		return err
+	}
+	if !travel.Mobile {
+		return nil
+	}
+
+	end := start.Add(time.Duration(duration) * time.Minute)
+	jobs, err := employeeRouteJobs(q, *employeeID, start.AddDate(0, 0, -1), end.AddDate(0, 0, 1), excludeBookingID)
+	if err != nil {
+		return err
+	}
+	if !travel.fits(jobs, start, end, location) {
+		return ErrTravelTimeConflict
+	}
+
+	return nil
+}
+
+// employeeRouteJobs returns the employee's active appointments starting in [from, to)
+func employeeRouteJobs(q rowsQuerier, employeeID string, from, to time.Time, excludeBookingID string) ([]routeJob, error) {
+	rows, err := q.Query(`
+		SELECT id, date_time, duration, service_address, service_latitude, service_longitude
+		FROM bookings
+		WHERE employee_id = $1 AND date_time >= $2 AND date_time < $3
+		AND status NOT IN ('cancelled', 'rejected', 'no_show')
+		AND NOT (status = 'pending_payment' AND payment_expires_at <= NOW())
+		AND id::text <> $4
+		ORDER BY date_time
+	`, employeeID, from, to, excludeBookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get employee appointments: %w", err)
+	}
+	defer rows.Close()
+
+	var jobs []routeJob
+	for rows.Next() {
+		var job routeJob
+		var duration int
+		var address sql.NullString
+		var latitude, longitude sql.NullFloat64
+		if err := rows.Scan(&job.BookingID, &job.Start, &duration, &address, &latitude, &longitude); err != nil {
+			return nil, err
+		}
+		job.End = job.Start.Add(time.Duration(duration) * time.Minute)
+		job.Location = scanBookingLocation(address, latitude, longitude)
+		jobs = append(jobs, job)
+	}
+
+	return jobs, nil
+}
+
+// employeeRoutes returns the appointments of each employee around a day, for checking many slots at once
+func (s *BookingService) employeeRoutes(employeeIDs []string, date time.Time) (map[string][]routeJob, error) {
+	routes := make(map[string][]routeJob)
+	for _, employeeID := range employeeIDs {
+		jobs, err := employeeRouteJobs(s.db, employeeID, date.AddDate(0, 0, -1), date.AddDate(0, 0, 2), "")
+		if err != nil {
+			return nil, err
+		}
+		routes[employeeID] = jobs
+	}
+
+	return routes, nil
+}
+
+// anyEmployeeCanTravel reports whether at least one employee is working over [start, end)
+// and can fit the trip to and from the address around their other jobs
+func (t *companyTravel) anyEmployeeCanTravel(windows map[string][]models.WorkingWindow, routes map[string][]routeJob, start, end time.Time, location *models.BookingLocation) bool {
+	for employeeID, jobs := range routes {
+		if windows != nil && !windowsContain(windows[employeeID], start, end) {
+			continue
+		}
+		if t.fits(jobs, start, end, location) {
+			return true
+		}
+	}
+	return false
+}
+
+// fits reports whether an appointment at location over [start, end) leaves time to travel from the job before it
+// and to the job after it. Overlapping jobs are left to the capacity checks.
+func (t *companyTravel) fits(jobs []routeJob, start, end time.Time, location *models.BookingLocation) bool {
+	var previous, next *routeJob
+	for i := range jobs {
+		job := &jobs[i]
+		if !job.End.After(start) && (previous == nil || job.End.After(previous.End)) {
+			previous = job
+		}
+		if !job.Start.Before(end) && (next == nil || job.Start.Before(next.Start)) {
+			next = job
+		}
+	}
+
+	if previous != nil {
+		_, minutes := t.between(previous.Location, location)
+		if previous.End.Add(time.Duration(minutes) * time.Minute).After(start) {
+			return false
+		}
+	}
+	if next != nil {
+		_, minutes := t.between(location, next.Location)
+		if end.Add(time.Duration(minutes) * time.Minute).After(next.Start) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// between returns the straight-line distance and the travel time between two addresses;
+// unknown addresses and jobs at the same address need no travel
+func (t *companyTravel) between(from, to *models.BookingLocation) (float64, int) {
+	if from == nil || to == nil || t.Settings.SpeedKmh <= 0 {
+		return 0, 0
+	}
+
+	distance := haversineKm(from.Latitude, from.Longitude, to.Latitude, to.Longitude)
+	if distance < 0.05 {
+		return distance, 0
+	}
+
+	minutes := int(math.Ceil(distance / t.Settings.SpeedKmh * 60))
+	return distance, minutes + t.Settings.BufferMinutes
+}
+
+// haversineKm returns the great-circle distance between two coordinates
+func haversineKm(lat1, lng1, lat2, lng2 float64) float64 {
+	toRadians := func(degrees float64) float64 { return degrees * math.Pi / 180 }
+
+	dLat := toRadians(lat2 - lat1)
+	dLng := toRadians(lng2 - lng1)
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(toRadians(lat1))*math.Cos(toRadians(lat2))*math.Sin(dLng/2)*math.Sin(dLng/2)
+
+	return 2 * earthRadiusKm * math.Atan2(math.Sqrt(a), math.Sqrt(1-a))
+}
+
+func scanBookingLocation(address sql.NullString, latitude, longitude sql.NullFloat64) *models.BookingLocation {
+	if !latitude.Valid || !longitude.Valid {
+		return nil
+	}
+	return &models.BookingLocation{Address: address.String, Latitude: latitude.Float64, Longitude: longitude.Float64}
+}
+
+func validateBookingLocation(location *models.BookingLocation) error {
+	if location == nil {
+		return nil
+	}
+	if location.Latitude < -90 || location.Latitude > 90 || location.Longitude < -180 || location.Longitude > 180 {
+		return fmt.Errorf("invalid service location coordinates")
+	}
+	return nil
+}
+
+// locationColumns splits an optional location into the service_address, service_latitude and service_longitude columns
+func locationColumns(location *models.BookingLocation) (*string, *float64, *float64) {
+	if location == nil {
+		return nil, nil, nil
+	}
+	return &location.Address, &location.Latitude, &location.Longitude
+}
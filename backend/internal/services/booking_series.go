@@ -0,0 +1,625 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// seriesHorizonDays is how far ahead series occurrences are materialized as bookings
+// when the service allows booking further in advance
+const seriesHorizonDays = 60
+
+// CreateBookingSeries stores a recurring booking and generates its bookings up to the horizon.
+// Occurrences that cannot be booked are reported per occurrence instead of failing the series.
+func (s *BookingService) CreateBookingSeries(userID string, req *models.CreateBookingSeriesRequest) (*models.BookingSeriesResult, error) {
+	rule, err := ParseRecurrenceRule(req.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	var serviceCompanyID string
+	err = s.db.QueryRow(`
+		SELECT company_id FROM services WHERE id = $1 AND is_active = true
+	`, req.ServiceID).Scan(&serviceCompanyID)
+	if err != nil || serviceCompanyID != req.CompanyID {
+		return nil, fmt.Errorf("service not found or inactive")
+	}
+
+	var petID *string
+	if req.PetID != "" {
+		var petOwnerID string
+		err = s.db.QueryRow("SELECT user_id FROM pets WHERE id = $1", req.PetID).Scan(&petOwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("pet not found")
+		}
+		if petOwnerID != userID {
+			return nil, fmt.Errorf("pet does not belong to user")
+		}
+		petID = &req.PetID
+	}
+
+	// Generated bookings skip deposits, so customers held to the no-show rules book one at a time
+	noShowRules, err := s.customerNoShowRules(s.db, req.CompanyID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if noShowRules != nil {
+		return nil, fmt.Errorf("recurring bookings are not available, please book individually")
+	}
+
+	if err := validateBookingLocation(req.Location); err != nil {
+		return nil, err
+	}
+
+	loc := companyLocation(s.db, req.CompanyID)
+	if req.StartDateTime.Before(time.Now()) {
+		return nil, fmt.Errorf("series cannot start in the past")
+	}
+
+	series := &models.BookingSeries{
+		ID:            uuid.New().String(),
+		UserID:        userID,
+		CompanyID:     req.CompanyID,
+		ServiceID:     req.ServiceID,
+		PetID:         petID,
+		EmployeeID:    req.EmployeeID,
+		RRule:         rule.String(),
+		StartDateTime: req.StartDateTime.UTC(),
+		Timezone:      loc.String(),
+		Notes:         &req.Notes,
+		Status:        "active",
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+
+		ServiceLocation: req.Location,
+	}
+
+	serviceAddress, serviceLatitude, serviceLongitude := locationColumns(series.ServiceLocation)
+	_, err = s.db.Exec(`
+		INSERT INTO booking_series (
+			id, user_id, company_id, service_id, pet_id, employee_id,
+			rrule, start_date_time, timezone, notes, status, created_at, updated_at,
+			service_address, service_latitude, service_longitude
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, series.ID, series.UserID, series.CompanyID, series.ServiceID, series.PetID,
+		series.EmployeeID, series.RRule, series.StartDateTime, series.Timezone,
+		series.Notes, series.Status, series.CreatedAt, series.UpdatedAt,
+		serviceAddress, serviceLatitude, serviceLongitude)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create booking series: %w", err)
+	}
+
+	occurrences, err := s.generateSeriesOccurrences(series, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.seriesResult(series.ID, occurrences)
+}
+
+// GetBookingSeries returns a series with its generated bookings and exceptions
+func (s *BookingService) GetBookingSeries(seriesID string) (*models.BookingSeries, error) {
+	series, err := s.getSeries(seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, user_id, company_id, service_id, pet_id, employee_id,
+			   date_time, duration, price, status, notes, series_id,
+			   created_at, updated_at
+		FROM bookings
+		WHERE series_id = $1
+		ORDER BY date_time
+	`, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series bookings: %w", err)
+	}
+	defer rows.Close()
+
+	series.Bookings = []models.Booking{}
+	for rows.Next() {
+		var booking models.Booking
+		if err := rows.Scan(
+			&booking.ID, &booking.UserID, &booking.CompanyID, &booking.ServiceID,
+			&booking.PetID, &booking.EmployeeID, &booking.DateTime, &booking.Duration,
+			&booking.Price, &booking.Status, &booking.Notes, &booking.SeriesID,
+			&booking.CreatedAt, &booking.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		series.Bookings = append(series.Bookings, booking)
+	}
+
+	exceptions, err := s.getSeriesExceptions(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	series.Exceptions = []models.BookingSeriesException{}
+	for _, exception := range exceptions {
+		series.Exceptions = append(series.Exceptions, exception)
+	}
+	sort.Slice(series.Exceptions, func(i, j int) bool {
+		return series.Exceptions[i].OccurrenceDate.Before(series.Exceptions[j].OccurrenceDate)
+	})
+
+	return series, nil
+}
+
+// SkipSeriesOccurrence excludes a single occurrence, cancelling its booking if it was already generated
+func (s *BookingService) SkipSeriesOccurrence(seriesID string, req *models.SkipOccurrenceRequest, actor models.BookingActor) (*models.SeriesOccurrenceResult, error) {
+	series, err := s.getSeries(seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrence, err := s.resolveOccurrence(series, req.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.SeriesOccurrenceResult{
+		OccurrenceDate: req.OccurrenceDate,
+		DateTime:       occurrence,
+		Status:         "skipped",
+	}
+
+	if bookingID, err := s.liveOccurrenceBooking(seriesID, req.OccurrenceDate); err != nil {
+		return nil, err
+	} else if bookingID != "" {
+		if err := s.UpdateBookingStatus(bookingID, "cancelled", req.Reason, actor); err != nil {
+			return nil, fmt.Errorf("failed to cancel occurrence booking: %w", err)
+		}
+		result.BookingID = &bookingID
+	}
+
+	if err := s.saveSeriesException(seriesID, req.OccurrenceDate, "skip", nil, req.Reason); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// MoveSeriesOccurrence moves a single occurrence to another time.
+// Generated bookings are rescheduled through the regular availability check.
+func (s *BookingService) MoveSeriesOccurrence(seriesID string, req *models.MoveOccurrenceRequest, actor models.BookingActor) (*models.SeriesOccurrenceResult, error) {
+	series, err := s.getSeries(seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := s.resolveOccurrence(series, req.OccurrenceDate); err != nil {
+		return nil, err
+	}
+
+	newDateTime := req.NewDateTime.UTC()
+	if newDateTime.Before(time.Now()) {
+		return nil, fmt.Errorf("cannot move an occurrence to a time in the past")
+	}
+
+	result := &models.SeriesOccurrenceResult{
+		OccurrenceDate: req.OccurrenceDate,
+		DateTime:       newDateTime.In(loadLocation(series.Timezone)),
+		Status:         "moved",
+	}
+
+	bookingID, err := s.liveOccurrenceBooking(seriesID, req.OccurrenceDate)
+	if err != nil {
+		return nil, err
+	}
+	if bookingID != "" {
+		result.BookingID = &bookingID
+		if err := s.RescheduleBooking(bookingID, newDateTime, req.Reason, actor); err != nil {
+			if errors.Is(err, ErrSlotTaken) {
+				result.Status = "conflict"
+				result.Error = err.Error()
+				result.Code = "SLOT_TAKEN"
+				return result, err
+			}
+			return nil, err
+		}
+	}
+
+	if err := s.saveSeriesException(seriesID, req.OccurrenceDate, "move", &newDateTime, req.Reason); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// CancelBookingSeries stops the series and cancels all of its upcoming bookings
+func (s *BookingService) CancelBookingSeries(seriesID, reason string, actor models.BookingActor) (*models.BookingSeriesResult, error) {
+	if _, err := s.getSeries(seriesID); err != nil {
+		return nil, err
+	}
+
+	_, err := s.db.Exec(`
+		UPDATE booking_series SET status = 'cancelled', updated_at = NOW() WHERE id = $1
+	`, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel booking series: %w", err)
+	}
+
+	occurrences, err := s.cancelUpcomingSeriesBookings(seriesID, reason, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.seriesResult(seriesID, occurrences)
+}
+
+// RescheduleBookingSeries moves all upcoming occurrences to a new start time and optionally a new rule.
+// Upcoming bookings and exceptions are dropped and regenerated; COUNT is applied from the new start.
+func (s *BookingService) RescheduleBookingSeries(seriesID string, req *models.RescheduleSeriesRequest, actor models.BookingActor) (*models.BookingSeriesResult, error) {
+	series, err := s.getSeries(seriesID)
+	if err != nil {
+		return nil, err
+	}
+	if series.Status != "active" {
+		return nil, fmt.Errorf("only active series can be rescheduled")
+	}
+
+	ruleText := series.RRule
+	if req.RRule != nil && *req.RRule != "" {
+		ruleText = *req.RRule
+	}
+	rule, err := ParseRecurrenceRule(ruleText)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.StartDateTime.Before(time.Now()) {
+		return nil, fmt.Errorf("series cannot be rescheduled into the past")
+	}
+
+	// Free the current slots first so the new occurrences do not conflict with them
+	cancelled, err := s.cancelUpcomingSeriesBookings(seriesID, req.Reason, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := loadLocation(series.Timezone)
+	_, err = s.db.Exec(`
+		DELETE FROM booking_series_exceptions WHERE series_id = $1 AND occurrence_date >= $2
+	`, seriesID, inLocationDate(time.Now(), loc).Format("2006-01-02"))
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear series exceptions: %w", err)
+	}
+
+	series.RRule = rule.String()
+	series.StartDateTime = req.StartDateTime.UTC()
+	_, err = s.db.Exec(`
+		UPDATE booking_series
+		SET rrule = $2, start_date_time = $3, generated_until = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, seriesID, series.RRule, series.StartDateTime)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update booking series: %w", err)
+	}
+
+	generated, err := s.generateSeriesOccurrences(series, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.seriesResult(seriesID, append(cancelled, generated...))
+}
+
+// ExtendActiveSeries generates bookings for active series whose horizon has moved forward
+func (s *BookingService) ExtendActiveSeries() {
+	rows, err := s.db.Query(`
+		SELECT id FROM booking_series
+		WHERE status = 'active' AND (generated_until IS NULL OR generated_until < $1)
+	`, time.Now().AddDate(0, 0, seriesHorizonDays))
+	if err != nil {
+		log.Printf("Error getting active booking series: %v", err)
+		return
+	}
+
+	var seriesIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			seriesIDs = append(seriesIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range seriesIDs {
+		series, err := s.getSeries(id)
+		if err != nil {
+			log.Printf("Error loading booking series %s: %v", id, err)
+			continue
+		}
+
+		// Only expand occurrences past the previous horizon so earlier conflicts are not retried silently
+		from := time.Now()
+		if series.GeneratedUntil != nil && series.GeneratedUntil.After(from) {
+			from = series.GeneratedUntil.Add(time.Second)
+		}
+
+		if _, err := s.generateSeriesOccurrences(series, from); err != nil {
+			log.Printf("Error extending booking series %s: %v", id, err)
+		}
+	}
+}
+
+// Helper methods
+
+// generateSeriesOccurrences creates bookings for occurrences in [from, horizon] and records the new horizon
+func (s *BookingService) generateSeriesOccurrences(series *models.BookingSeries, from time.Time) ([]models.SeriesOccurrenceResult, error) {
+	rule, err := ParseRecurrenceRule(series.RRule)
+	if err != nil {
+		return nil, err
+	}
+
+	var advanceDays int
+	err = s.db.QueryRow("SELECT advance_booking_days FROM services WHERE id = $1", series.ServiceID).Scan(&advanceDays)
+	if err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	horizonDays := seriesHorizonDays
+	if advanceDays > 0 && advanceDays < horizonDays {
+		horizonDays = advanceDays
+	}
+
+	loc := loadLocation(series.Timezone)
+	horizon := inLocationDate(time.Now(), loc).AddDate(0, 0, horizonDays+1).Add(-time.Second)
+
+	exceptions, err := s.getSeriesExceptions(series.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	notes := ""
+	if series.Notes != nil {
+		notes = *series.Notes
+	}
+	petID := ""
+	if series.PetID != nil {
+		petID = *series.PetID
+	}
+
+	results := []models.SeriesOccurrenceResult{}
+	for _, occurrence := range rule.Occurrences(series.StartDateTime.In(loc), from, horizon) {
+		occurrenceDate := occurrence.Format("2006-01-02")
+		result := models.SeriesOccurrenceResult{OccurrenceDate: occurrenceDate, DateTime: occurrence}
+
+		if exception, ok := exceptions[occurrenceDate]; ok {
+			if exception.Type == "skip" {
+				result.Status = "skipped"
+				results = append(results, result)
+				continue
+			}
+			result.DateTime = exception.NewDateTime.In(loc)
+		}
+
+		if result.DateTime.Before(time.Now()) {
+			continue
+		}
+
+		existingID, err := s.liveOccurrenceBooking(series.ID, occurrenceDate)
+		if err != nil {
+			return nil, err
+		}
+		if existingID != "" {
+			result.Status = "exists"
+			result.BookingID = &existingID
+			results = append(results, result)
+			continue
+		}
+
+		booking, err := s.CreateBooking(&BookingRequest{
+			UserID:         series.UserID,
+			CompanyID:      series.CompanyID,
+			ServiceID:      series.ServiceID,
+			PetID:          petID,
+			EmployeeID:     series.EmployeeID,
+			DateTime:       result.DateTime,
+			Notes:          notes,
+			SeriesID:       &series.ID,
+			OccurrenceDate: &occurrenceDate,
+			Location:       series.ServiceLocation,
+			SkipDeposit:    true,
+		})
+		switch {
+		case err == nil:
+			result.Status = "created"
+			result.BookingID = &booking.ID
+		case errors.Is(err, ErrSlotTaken):
+			result.Status = "conflict"
+			result.Error = err.Error()
+			result.Code = "SLOT_TAKEN"
+		case errors.Is(err, ErrTravelTimeConflict):
+			result.Status = "conflict"
+			result.Error = err.Error()
+			result.Code = "TRAVEL_TIME_CONFLICT"
+		default:
+			result.Status = "failed"
+			result.Error = err.Error()
+		}
+		results = append(results, result)
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE booking_series SET generated_until = $2, updated_at = NOW() WHERE id = $1
+	`, series.ID, horizon)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update series horizon: %w", err)
+	}
+
+	return results, nil
+}
+
+// cancelUpcomingSeriesBookings cancels the series bookings that have not started yet
+func (s *BookingService) cancelUpcomingSeriesBookings(seriesID, reason string, actor models.BookingActor) ([]models.SeriesOccurrenceResult, error) {
+	rows, err := s.db.Query(`
+		SELECT id, date_time, occurrence_date
+		FROM bookings
+		WHERE series_id = $1 AND date_time > NOW()
+		  AND status IN ('pending', 'confirmed', 'rescheduled')
+		ORDER BY date_time
+	`, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series bookings: %w", err)
+	}
+
+	var results []models.SeriesOccurrenceResult
+	for rows.Next() {
+		var bookingID string
+		var dateTime time.Time
+		var occurrenceDate sql.NullTime
+		if err := rows.Scan(&bookingID, &dateTime, &occurrenceDate); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		result := models.SeriesOccurrenceResult{DateTime: dateTime, BookingID: &bookingID, Status: "cancelled"}
+		if occurrenceDate.Valid {
+			result.OccurrenceDate = occurrenceDate.Time.Format("2006-01-02")
+		}
+		results = append(results, result)
+	}
+	rows.Close()
+
+	for i := range results {
+		if err := s.UpdateBookingStatus(*results[i].BookingID, "cancelled", reason, actor); err != nil {
+			results[i].Status = "failed"
+			results[i].Error = err.Error()
+		}
+	}
+
+	return results, nil
+}
+
+// resolveOccurrence checks that the rule produces an occurrence on the given local date and returns it
+func (s *BookingService) resolveOccurrence(series *models.BookingSeries, occurrenceDate string) (time.Time, error) {
+	loc := loadLocation(series.Timezone)
+	day, err := time.ParseInLocation("2006-01-02", occurrenceDate, loc)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid occurrence_date format, use YYYY-MM-DD")
+	}
+
+	rule, err := ParseRecurrenceRule(series.RRule)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	occurrences := rule.Occurrences(series.StartDateTime.In(loc), day, day.AddDate(0, 0, 1).Add(-time.Second))
+	if len(occurrences) == 0 {
+		return time.Time{}, fmt.Errorf("series has no occurrence on %s", occurrenceDate)
+	}
+
+	return occurrences[0], nil
+}
+
+func (s *BookingService) liveOccurrenceBooking(seriesID, occurrenceDate string) (string, error) {
+	var bookingID string
+	err := s.db.QueryRow(`
+		SELECT id FROM bookings
+		WHERE series_id = $1 AND occurrence_date = $2
+		  AND status NOT IN ('cancelled', 'rejected')
+	`, seriesID, occurrenceDate).Scan(&bookingID)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get occurrence booking: %w", err)
+	}
+	return bookingID, nil
+}
+
+func (s *BookingService) saveSeriesException(seriesID, occurrenceDate, exceptionType string, newDateTime *time.Time, reason string) error {
+	_, err := s.db.Exec(`
+		INSERT INTO booking_series_exceptions (
+			id, series_id, occurrence_date, type, new_date_time, reason, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, NOW())
+		ON CONFLICT (series_id, occurrence_date) DO UPDATE SET
+			type = EXCLUDED.type,
+			new_date_time = EXCLUDED.new_date_time,
+			reason = EXCLUDED.reason
+	`, uuid.New().String(), seriesID, occurrenceDate, exceptionType, newDateTime, reason)
+	if err != nil {
+		return fmt.Errorf("failed to save series exception: %w", err)
+	}
+	return nil
+}
+
+func (s *BookingService) getSeries(seriesID string) (*models.BookingSeries, error) {
+	var series models.BookingSeries
+	var serviceAddress sql.NullString
+	var serviceLatitude, serviceLongitude sql.NullFloat64
+	err := s.db.QueryRow(`
+		SELECT id, user_id, company_id, service_id, pet_id, employee_id,
+			   rrule, start_date_time, timezone, notes, status, generated_until,
+			   created_at, updated_at, service_address, service_latitude, service_longitude
+		FROM booking_series WHERE id = $1
+	`, seriesID).Scan(
+		&series.ID, &series.UserID, &series.CompanyID, &series.ServiceID,
+		&series.PetID, &series.EmployeeID, &series.RRule, &series.StartDateTime,
+		&series.Timezone, &series.Notes, &series.Status, &series.GeneratedUntil,
+		&series.CreatedAt, &series.UpdatedAt,
+		&serviceAddress, &serviceLatitude, &serviceLongitude,
+	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("booking series not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	series.ServiceLocation = scanBookingLocation(serviceAddress, serviceLatitude, serviceLongitude)
+	return &series, nil
+}
+
+// getSeriesExceptions returns the exceptions of a series keyed by occurrence date
+func (s *BookingService) getSeriesExceptions(seriesID string) (map[string]models.BookingSeriesException, error) {
+	rows, err := s.db.Query(`
+		SELECT id, series_id, occurrence_date, type, new_date_time, reason, created_at
+		FROM booking_series_exceptions
+		WHERE series_id = $1
+		ORDER BY occurrence_date
+	`, seriesID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get series exceptions: %w", err)
+	}
+	defer rows.Close()
+
+	exceptions := make(map[string]models.BookingSeriesException)
+	for rows.Next() {
+		var e models.BookingSeriesException
+		if err := rows.Scan(
+			&e.ID, &e.SeriesID, &e.OccurrenceDate, &e.Type, &e.NewDateTime, &e.Reason, &e.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		exceptions[e.OccurrenceDate.Format("2006-01-02")] = e
+	}
+
+	return exceptions, nil
+}
+
+func (s *BookingService) seriesResult(seriesID string, occurrences []models.SeriesOccurrenceResult) (*models.BookingSeriesResult, error) {
+	series, err := s.getSeries(seriesID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.BookingSeriesResult{
+		Series:      series,
+		Occurrences: []models.SeriesOccurrenceResult{},
+	}
+	for _, occurrence := range occurrences {
+		if occurrence.Status == "conflict" {
+			result.Conflicts++
+		}
+		result.Occurrences = append(result.Occurrences, occurrence)
+	}
+
+	return result, nil
+}
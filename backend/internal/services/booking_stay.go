@@ -0,0 +1,144 @@
+package services
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// SetBoardingService injects the boarding service used for multi-night stays
+func (s *BookingService) SetBoardingService(boardingService *BoardingService) {
+	s.boardingService = boardingService
+}
+
+// CreateBoardingStay books a multi-night stay, priced per night, in a kennel that fits the pet.
+// The stay is stored as a booking from check-in time on the first day to check-out time on the last.
+func (s *BookingService) CreateBoardingStay(userID string, req *models.BoardingStayRequest) (*models.Booking, *models.StayQuote, error) {
+	if s.boardingService == nil {
+		return nil, nil, fmt.Errorf("boarding is not available")
+	}
+
+	checkIn, checkOut, err := parseStayDates(req.CheckInDate, req.CheckOutDate)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer tx.Rollback()
+
+	// 1. Validate service exists and is active
+	var advanceBookingDays int
+	err = tx.QueryRow(`
+		SELECT advance_booking_days FROM services
+		WHERE id = $1 AND company_id = $2 AND is_active = true
+	`, req.ServiceID, req.CompanyID).Scan(&advanceBookingDays)
+	if err != nil {
+		return nil, nil, fmt.Errorf("service not found or inactive")
+	}
+
+	// 2. Dates are calendar days of the company
+	loc := companyLocation(tx, req.CompanyID)
+	today := inLocationDate(time.Now().In(loc), time.UTC)
+	if checkIn.Before(today) {
+		return nil, nil, fmt.Errorf("cannot book stays in the past")
+	}
+	if checkIn.After(today.AddDate(0, 0, advanceBookingDays)) {
+		return nil, nil, fmt.Errorf("check-in date exceeds advance booking limit of %d days", advanceBookingDays)
+	}
+
+	// 3. Validate pet belongs to user
+	var petOwnerID string
+	err = tx.QueryRow("SELECT user_id FROM pets WHERE id = $1", req.PetID).Scan(&petOwnerID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("pet not found")
+	}
+	if petOwnerID != userID {
+		return nil, nil, fmt.Errorf("pet does not belong to user")
+	}
+	pet, err := s.boardingService.getStayPet(tx, req.PetID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Stays requiring a waiver need the customer's signature of its current version
+	waiverSignatureID, err := serviceWaiverSignature(tx, req.ServiceID, userID, true)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 4. Price every night and reserve a kennel (locks the company kennels until commit)
+	quote, err := s.boardingService.quoteStay(tx, req.CompanyID, req.ServiceID, checkIn, checkOut)
+	if err != nil {
+		return nil, nil, err
+	}
+	kennel, err := s.boardingService.reserveKennel(tx, req.CompanyID, req.KennelID, pet, checkIn, checkOut)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	start := clockOnDate(inLocationDate(checkIn, loc), quote.CheckInTime)
+	end := clockOnDate(inLocationDate(checkOut, loc), quote.CheckOutTime)
+
+	// 5. Create booking
+	booking := &models.Booking{
+		ID:           uuid.New().String(),
+		UserID:       userID,
+		CompanyID:    req.CompanyID,
+		ServiceID:    req.ServiceID,
+		PetID:        &req.PetID,
+		DateTime:     start.UTC(),
+		Duration:     int(end.Sub(start).Minutes()),
+		Price:        models.MoneyFromDecimal(quote.TotalPrice, ""),
+		Status:       "pending",
+		Notes:        &req.Notes,
+		CheckInDate:  &checkIn,
+		CheckOutDate: &checkOut,
+		KennelID:     &kennel.ID,
+		CreatedAt:    time.Now(),
+		UpdatedAt:    time.Now(),
+
+		WaiverSignatureID: waiverSignatureID,
+	}
+
+	_, err = tx.Exec(`
+		INSERT INTO bookings (
+			id, user_id, company_id, service_id, pet_id, date_time, duration,
+			price, status, notes, check_in_date, check_out_date, kennel_id,
+			created_at, updated_at, waiver_signature_id
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
+	`, booking.ID, booking.UserID, booking.CompanyID, booking.ServiceID, booking.PetID,
+		booking.DateTime, booking.Duration, booking.Price, booking.Status, booking.Notes,
+		checkIn.Format("2006-01-02"), checkOut.Format("2006-01-02"), booking.KennelID,
+		booking.CreatedAt, booking.UpdatedAt, booking.WaiverSignatureID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	err = recordBookingEvent(tx, booking.ID, "created", UserActor(booking.UserID), map[string]models.BookingFieldChange{
+		"status":         bookingFieldChange(nil, booking.Status),
+		"check_in_date":  bookingFieldChange(nil, booking.CheckInDate.Format("2006-01-02")),
+		"check_out_date": bookingFieldChange(nil, booking.CheckOutDate.Format("2006-01-02")),
+		"price":          bookingFieldChange(nil, booking.Price),
+	}, "")
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 6. Schedule notifications
+	if err = s.scheduleBookingNotifications(tx, booking); err != nil {
+		return nil, nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, nil, err
+	}
+
+	go s.sendBookingCreatedNotifications(booking)
+
+	return booking, quote, nil
+}
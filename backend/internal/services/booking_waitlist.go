@@ -0,0 +1,364 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+// waitlistHoldDuration is how long a freed slot is held for the waitlisted customer it is offered to
+const waitlistHoldDuration = 30 * time.Minute
+
+const waitlistColumns = `
+	id, user_id, company_id, service_id, pet_id, employee_id, window_start, window_end,
+	notes, status, hold_date_time, hold_expires_at, booking_id, created_at, updated_at`
+
+// JoinWaitlist adds the customer to the waitlist of a service for a time window
+func (s *BookingService) JoinWaitlist(userID string, req *models.JoinWaitlistRequest) (*models.WaitlistEntry, error) {
+	if !req.WindowEnd.After(req.WindowStart) {
+		return nil, fmt.Errorf("window_end must be after window_start")
+	}
+	if !req.WindowEnd.After(time.Now()) {
+		return nil, fmt.Errorf("waitlist window is in the past")
+	}
+
+	var serviceCompanyID string
+	err := s.db.QueryRow(`
+		SELECT company_id FROM services WHERE id = $1 AND is_active = true
+	`, req.ServiceID).Scan(&serviceCompanyID)
+	if err != nil || serviceCompanyID != req.CompanyID {
+		return nil, fmt.Errorf("service not found or inactive")
+	}
+
+	var petID *string
+	if req.PetID != "" {
+		var petOwnerID string
+		err = s.db.QueryRow("SELECT user_id FROM pets WHERE id = $1", req.PetID).Scan(&petOwnerID)
+		if err != nil {
+			return nil, fmt.Errorf("pet not found")
+		}
+		if petOwnerID != userID {
+			return nil, fmt.Errorf("pet does not belong to user")
+		}
+		petID = &req.PetID
+	}
+
+	entries, err := s.queryWaitlist(s.db, `
+		INSERT INTO booking_waitlist (
+			id, user_id, company_id, service_id, pet_id, employee_id,
+			window_start, window_end, notes, status, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 'waiting', NOW(), NOW())
+		RETURNING`+waitlistColumns,
+		uuid.New().String(), userID, req.CompanyID, req.ServiceID, petID, req.EmployeeID,
+		req.WindowStart.UTC(), req.WindowEnd.UTC(), req.Notes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to join waitlist: %w", err)
+	}
+
+	entry := &entries[0]
+	entry.Position, _ = s.waitlistPosition(entry)
+
+	return entry, nil
+}
+
+// GetUserWaitlist returns the open waitlist entries and offers of a customer
+func (s *BookingService) GetUserWaitlist(userID string) ([]models.WaitlistEntry, error) {
+	entries, err := s.queryWaitlist(s.db, `
+		SELECT`+waitlistColumns+`
+		FROM booking_waitlist
+		WHERE user_id = $1 AND status IN ('waiting', 'offered') AND window_end > NOW()
+		ORDER BY window_start
+	`, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range entries {
+		entries[i].Position, _ = s.waitlistPosition(&entries[i])
+	}
+
+	return entries, nil
+}
+
+// GetCompanyWaitlist returns the open waitlist of a company, optionally filtered by service
+func (s *BookingService) GetCompanyWaitlist(companyID, serviceID string) ([]models.WaitlistEntry, error) {
+	return s.queryWaitlist(s.db, `
+		SELECT`+waitlistColumns+`
+		FROM booking_waitlist
+		WHERE company_id = $1 AND ($2 = '' OR service_id::text = $2)
+		  AND status IN ('waiting', 'offered') AND window_end > NOW()
+		ORDER BY service_id, created_at
+	`, companyID, serviceID)
+}
+
+// AcceptWaitlistOffer converts an active hold into a booking
+func (s *BookingService) AcceptWaitlistOffer(userID, entryID string) (*models.Booking, error) {
+	entry, err := s.getWaitlistEntry(entryID)
+	if err != nil {
+		return nil, err
+	}
+	if entry.UserID != userID {
+		return nil, fmt.Errorf("waitlist entry not found")
+	}
+	if entry.Status != "offered" || entry.HoldDateTime == nil || entry.HoldExpiresAt == nil ||
+		!entry.HoldExpiresAt.After(time.Now()) {
+		return nil, fmt.Errorf("there is no active offer for this waitlist entry")
+	}
+
+	notes := ""
+	if entry.Notes != nil {
+		notes = *entry.Notes
+	}
+	petID := ""
+	if entry.PetID != nil {
+		petID = *entry.PetID
+	}
+
+	booking, err := s.CreateBooking(&BookingRequest{
+		UserID:          entry.UserID,
+		CompanyID:       entry.CompanyID,
+		ServiceID:       entry.ServiceID,
+		PetID:           petID,
+		EmployeeID:      entry.EmployeeID,
+		DateTime:        *entry.HoldDateTime,
+		Notes:           notes,
+		WaitlistEntryID: &entry.ID,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return booking, nil
+}
+
+// DeclineWaitlistOffer releases an active hold and passes the slot to the next customer
+func (s *BookingService) DeclineWaitlistOffer(userID, entryID string) error {
+	return s.releaseWaitlistEntry(userID, entryID, "declined", "offered")
+}
+
+// LeaveWaitlist removes the customer from the waitlist, passing on any active hold
+func (s *BookingService) LeaveWaitlist(userID, entryID string) error {
+	return s.releaseWaitlistEntry(userID, entryID, "cancelled", "waiting", "offered")
+}
+
+// ExpireWaitlistHolds passes expired holds on to the next customer in line
+func (s *BookingService) ExpireWaitlistHolds() {
+	entries, err := s.queryWaitlist(s.db, `
+		UPDATE booking_waitlist SET status = 'expired', updated_at = NOW()
+		WHERE status = 'offered' AND hold_expires_at <= NOW()
+		RETURNING`+waitlistColumns)
+	if err != nil {
+		log.Printf("Error expiring waitlist holds: %v", err)
+		return
+	}
+
+	for _, entry := range entries {
+		if entry.HoldDateTime != nil {
+			s.offerFreedSlot(entry.ServiceID, *entry.HoldDateTime, entry.EmployeeID)
+		}
+	}
+}
+
+// Helper methods
+
+// offerFreedSlot holds a freed slot for the first waitlisted customer whose window covers it
+func (s *BookingService) offerFreedSlot(serviceID string, dateTime time.Time, employeeID *string) {
+	if !dateTime.After(time.Now()) {
+		return
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		log.Printf("Error offering freed slot: %v", err)
+		return
+	}
+	defer tx.Rollback()
+
+	var duration int
+	if err := tx.QueryRow("SELECT duration FROM services WHERE id = $1", serviceID).Scan(&duration); err != nil {
+		return
+	}
+
+	// The slot may already have been taken again; this also locks the service until commit
+	available, err := s.checkTimeSlotAvailability(tx, serviceID, dateTime, duration, employeeID, "")
+	if err != nil || !available {
+		return
+	}
+
+	var entryID, userID, companyID string
+	err = tx.QueryRow(`
+		SELECT id, user_id, company_id
+		FROM booking_waitlist
+		WHERE service_id = $1 AND status = 'waiting'
+		  AND window_start <= $2 AND window_end >= $3
+		  AND (employee_id IS NULL OR employee_id::text = $4)
+		ORDER BY created_at
+		LIMIT 1
+		FOR UPDATE SKIP LOCKED
+	`, serviceID, dateTime, dateTime.Add(time.Duration(duration)*time.Minute), nullableString(employeeID)).Scan(
+		&entryID, &userID, &companyID,
+	)
+	if err != nil {
+		if err != sql.ErrNoRows {
+			log.Printf("Error finding waitlist entry: %v", err)
+		}
+		return
+	}
+
+	// Never hold a slot past its start time
+	expiresAt := time.Now().Add(waitlistHoldDuration)
+	if expiresAt.After(dateTime) {
+		expiresAt = dateTime
+	}
+
+	_, err = tx.Exec(`
+		UPDATE booking_waitlist
+		SET status = 'offered', hold_date_time = $2, hold_expires_at = $3,
+			employee_id = COALESCE(employee_id, $4), updated_at = NOW()
+		WHERE id = $1
+	`, entryID, dateTime, expiresAt, employeeID)
+	if err != nil {
+		log.Printf("Error creating waitlist hold: %v", err)
+		return
+	}
+
+	if err := tx.Commit(); err != nil {
+		log.Printf("Error creating waitlist hold: %v", err)
+		return
+	}
+
+	s.sendWaitlistOfferNotification(entryID, userID, companyID, serviceID, dateTime, expiresAt)
+}
+
+func (s *BookingService) sendWaitlistOfferNotification(entryID, userID, companyID, serviceID string, dateTime, expiresAt time.Time) {
+	if s.notificationService == nil {
+		return
+	}
+
+	loc := companyLocation(s.db, companyID)
+	payload := &NotificationPayload{
+		Type:  "waitlist_offer",
+		Title: "A slot opened up",
+		Message: fmt.Sprintf("A slot on %s is held for you until %s. Confirm it before it passes to the next person.",
+			dateTime.In(loc).Format("Jan 2 at 15:04"), expiresAt.In(loc).Format("15:04")),
+		UserID:    userID,
+		CompanyID: companyID,
+		Data: map[string]interface{}{
+			"waitlist_entry_id": entryID,
+			"service_id":        serviceID,
+			"date_time":         dateTime,
+			"local_date_time":   dateTime.In(loc).Format("2006-01-02T15:04:05"),
+			"timezone":          loc.String(),
+			"hold_expires_at":   expiresAt,
+		},
+	}
+
+	if err := s.notificationService.SendImmediateNotification(payload, []string{"push", "email"}); err != nil {
+		log.Printf("Error sending waitlist offer notification: %v", err)
+	}
+}
+
+// releaseWaitlistEntry moves an entry out of the queue and passes on its hold, if it had one
+func (s *BookingService) releaseWaitlistEntry(userID, entryID, newStatus string, fromStatuses ...string) error {
+	entry, err := s.getWaitlistEntry(entryID)
+	if err != nil {
+		return err
+	}
+	if entry.UserID != userID {
+		return fmt.Errorf("waitlist entry not found")
+	}
+
+	allowed := false
+	for _, status := range fromStatuses {
+		if entry.Status == status {
+			allowed = true
+		}
+	}
+	if !allowed {
+		return fmt.Errorf("waitlist entry is already %s", entry.Status)
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE booking_waitlist SET status = $3, updated_at = NOW()
+		WHERE id = $1 AND status = $2
+	`, entryID, entry.Status, newStatus)
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("waitlist entry changed, please retry")
+	}
+
+	if entry.Status == "offered" && entry.HoldDateTime != nil {
+		go s.offerFreedSlot(entry.ServiceID, *entry.HoldDateTime, entry.EmployeeID)
+	}
+
+	return nil
+}
+
+// waitlistPosition returns the 1-based place of a waiting entry in its service queue
+func (s *BookingService) waitlistPosition(entry *models.WaitlistEntry) (int, error) {
+	if entry.Status != "waiting" {
+		return 0, nil
+	}
+
+	var ahead int
+	err := s.db.QueryRow(`
+		SELECT COUNT(*) FROM booking_waitlist
+		WHERE service_id = $1 AND status = 'waiting' AND created_at < $2
+	`, entry.ServiceID, entry.CreatedAt).Scan(&ahead)
+	if err != nil {
+		return 0, err
+	}
+
+	return ahead + 1, nil
+}
+
+func (s *BookingService) getWaitlistEntry(entryID string) (*models.WaitlistEntry, error) {
+	entries, err := s.queryWaitlist(s.db, `
+		SELECT`+waitlistColumns+`
+		FROM booking_waitlist WHERE id = $1
+	`, entryID)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("waitlist entry not found")
+	}
+	return &entries[0], nil
+}
+
+func (s *BookingService) queryWaitlist(q rowsQuerier, query string, args ...interface{}) ([]models.WaitlistEntry, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	entries := []models.WaitlistEntry{}
+	for rows.Next() {
+		var e models.WaitlistEntry
+		if err := rows.Scan(
+			&e.ID, &e.UserID, &e.CompanyID, &e.ServiceID, &e.PetID, &e.EmployeeID,
+			&e.WindowStart, &e.WindowEnd, &e.Notes, &e.Status, &e.HoldDateTime,
+			&e.HoldExpiresAt, &e.BookingID, &e.CreatedAt, &e.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// nullableString returns the pointed-to value or an empty string
+func nullableString(value *string) string {
+	if value == nil {
+		return ""
+	}
+	return *value
+}
@@ -206,9 +206,9 @@ func (s *CartService) CalculateCartTotal(cartID string) (*models.CartTotal, erro
 
 	// Calculate discount if any
 	// This would be implemented based on discount codes logic
-	total.DiscountAmount = 0
-	total.TaxAmount = total.Subtotal * 0.1 // Example 10% tax
-	total.Total = total.Subtotal - total.DiscountAmount + total.TaxAmount
+	total.DiscountAmount = models.Money{}
+	total.TaxAmount = total.Subtotal.Percent(10) // Example 10% tax
+	total.Total = total.Subtotal.Sub(total.DiscountAmount).Add(total.TaxAmount)
 
 	return total, nil
 }
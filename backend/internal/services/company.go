@@ -599,7 +599,7 @@ func (s *CompanyService) GetCompanyByID(companyID string) (*models.Company, erro
 		       subscription_status, special_partner, manual_enabled_crm, 
 		       manual_enabled_ai_agents, is_demo, is_active, 
 		       website_integration_enabled, api_key, publish_to_marketplace,
-		       created_at, updated_at
+		       timezone, created_at, updated_at
 		FROM companies 
 		WHERE id = $1
 	`
@@ -625,7 +625,7 @@ func (s *CompanyService) GetCompanyByID(companyID string) (*models.Company, erro
 		&company.SpecialPartner, &company.ManualEnabledCRM,
 		&company.ManualEnabledAIAgents, &company.IsDemo, &company.IsActive,
 		&company.WebsiteIntegrationEnabled, &apiKey, &company.PublishToMarketplace,
-		&company.CreatedAt, &company.UpdatedAt,
+		&company.Timezone, &company.CreatedAt, &company.UpdatedAt,
 	)
 	
 	if err != nil {
@@ -715,7 +715,8 @@ func (s *CompanyService) UpdateCompanyProfile(company models.Company) (*models.C
 			latitude = $10, longitude = $11, phone = $12, email = $13, 
 			website = $14, logo_url = $15, media_gallery = $16, 
 			business_hours = $17, publish_to_marketplace = $18,
-			website_integration_enabled = $19, updated_at = CURRENT_TIMESTAMP
+			website_integration_enabled = $19,
+			timezone = COALESCE(NULLIF($20, ''), timezone), updated_at = CURRENT_TIMESTAMP
 		WHERE id = $1
 		RETURNING id, owner_id, name, description, categories, business_type, 
 		          country, state, city, address, latitude, longitude, 
@@ -724,7 +725,7 @@ func (s *CompanyService) UpdateCompanyProfile(company models.Company) (*models.C
 		          subscription_status, special_partner, manual_enabled_crm, 
 		          manual_enabled_ai_agents, is_demo, is_active, 
 		          website_integration_enabled, api_key, publish_to_marketplace,
-		          created_at, updated_at
+		          timezone, created_at, updated_at
 	`
 	
 	updatedCompany := &models.Company{}
@@ -741,6 +742,7 @@ func (s *CompanyService) UpdateCompanyProfile(company models.Company) (*models.C
 		company.Address, company.Latitude, company.Longitude, company.Phone,
 		company.Email, company.Website, company.LogoURL, pq.Array(company.MediaGallery),
 		company.BusinessHours, company.PublishToMarketplace, company.WebsiteIntegrationEnabled,
+		company.Timezone,
 	).Scan(
 		&updatedCompany.ID, &updatedCompany.OwnerID, &updatedCompany.Name, &updatedCompany.Description,
 		pq.Array(&updatedCompany.Categories), &updatedCompany.BusinessType,
@@ -753,7 +755,7 @@ func (s *CompanyService) UpdateCompanyProfile(company models.Company) (*models.C
 		&updatedCompany.SpecialPartner, &updatedCompany.ManualEnabledCRM,
 		&updatedCompany.ManualEnabledAIAgents, &updatedCompany.IsDemo, &updatedCompany.IsActive,
 		&updatedCompany.WebsiteIntegrationEnabled, &apiKey, &updatedCompany.PublishToMarketplace,
-		&updatedCompany.CreatedAt, &updatedCompany.UpdatedAt,
+		&updatedCompany.Timezone, &updatedCompany.CreatedAt, &updatedCompany.UpdatedAt,
 	)
 	
 	if err != nil {
@@ -38,6 +38,11 @@ type ServiceContainer struct {
 	uploadService       *UploadService
 	reviewService       *ReviewService
 	employeeService     *EmployeeService
+	scheduleService     *ScheduleService
+	boardingService     *BoardingService
+	courseService       *CourseService
+	packageService      *PackageService
+	waiverService       *WaiverService
 	promptService       *PromptService
 	inventoryService    *InventoryService
 	currencyService     *CurrencyService
@@ -66,8 +71,11 @@ func NewServiceContainer(db *sql.DB) *ServiceContainer {
 	orderService := NewOrderService(db)
 	reviewService := NewReviewService(db)
 	employeeService := NewEmployeeService(db)
+	scheduleService := NewScheduleService(db)
+	boardingService := NewBoardingService(db)
 	promptService := NewPromptService(db)
 	contentService := NewContentService(db)
+	waiverService := NewWaiverService(db)
 
 	// Initialize services with dependencies
 	emailService := NewEmailService(db)
@@ -79,6 +87,17 @@ func NewServiceContainer(db *sql.DB) *ServiceContainer {
 
 	// Booking service needs notification services
 	bookingService := NewBookingService(db, notificationService, emailService, smsService)
+	bookingService.SetScheduleService(scheduleService)
+	bookingService.SetBoardingService(boardingService)
+	bookingService.SetPaymentService(paymentService)
+	paymentService.SetBookingService(bookingService)
+
+	// Package service redeems prepaid sessions when bookings complete
+	packageService := NewPackageService(db, paymentService)
+	bookingService.SetPackageService(packageService)
+
+	// Course service reuses booking cancellation policies and payments
+	courseService := NewCourseService(db, bookingService, paymentService, notificationService)
 
 	// Addon service needs payment service
 	addonService := NewAddonService(db, paymentService)
@@ -123,6 +142,11 @@ func NewServiceContainer(db *sql.DB) *ServiceContainer {
 		chatService:         chatService,
 		reviewService:       reviewService,
 		employeeService:     employeeService,
+		scheduleService:     scheduleService,
+		boardingService:     boardingService,
+		courseService:       courseService,
+		packageService:      packageService,
+		waiverService:       waiverService,
 		promptService:       promptService,
 		inventoryService:    inventoryService,
 		currencyService:     currencyService,
@@ -183,10 +207,30 @@ func (c *ServiceContainer) InitializeServices() error {
 	c.smsService = NewSMSService(c.db)
 	c.initialized["sms"] = true
 
+	c.scheduleService = NewScheduleService(c.db)
+	c.initialized["schedule"] = true
+
+	c.boardingService = NewBoardingService(c.db)
+	c.initialized["boarding"] = true
+
 	// Initialize services that depend on notification/email/sms
 	c.bookingService = NewBookingService(c.db, c.notificationService, c.emailService, c.smsService)
+	c.bookingService.SetScheduleService(c.scheduleService)
+	c.bookingService.SetBoardingService(c.boardingService)
+	c.bookingService.SetPaymentService(c.paymentService)
+	c.paymentService.SetBookingService(c.bookingService)
 	c.initialized["booking"] = true
 
+	c.packageService = NewPackageService(c.db, c.paymentService)
+	c.bookingService.SetPackageService(c.packageService)
+	c.initialized["package"] = true
+
+	c.waiverService = NewWaiverService(c.db)
+	c.initialized["waiver"] = true
+
+	c.courseService = NewCourseService(c.db, c.bookingService, c.paymentService, c.notificationService)
+	c.initialized["course"] = true
+
 	c.chatService = NewChatService(c.db, c.aiService)
 	c.initialized["chat"] = true
 
@@ -332,6 +376,26 @@ func (c *ServiceContainer) EmployeeService() *EmployeeService {
 	return c.employeeService
 }
 
+func (c *ServiceContainer) ScheduleService() *ScheduleService {
+	return c.scheduleService
+}
+
+func (c *ServiceContainer) BoardingService() *BoardingService {
+	return c.boardingService
+}
+
+func (c *ServiceContainer) CourseService() *CourseService {
+	return c.courseService
+}
+
+func (c *ServiceContainer) PackageService() *PackageService {
+	return c.packageService
+}
+
+func (c *ServiceContainer) WaiverService() *WaiverService {
+	return c.waiverService
+}
+
 func (c *ServiceContainer) PromptService() *PromptService {
 	return c.promptService
 }
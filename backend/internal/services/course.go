@@ -0,0 +1,1009 @@
+package services
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/robfig/cron/v3"
+)
+
+// ErrCourseFull is returned when every place of a course is taken
+var ErrCourseFull = errors.New("course is fully booked")
+
+// courseEnrollmentHoldMinutes is how long an unpaid enrollment keeps its place
+const courseEnrollmentHoldMinutes = 15
+
+// activeEnrollmentCondition matches enrollments holding a place; unpaid ones only until their payment deadline
+const activeEnrollmentCondition = `(e.status = 'enrolled' OR (e.status = 'pending_payment' AND e.payment_expires_at > NOW()))`
+
+const courseColumns = `
+	c.id, c.company_id, c.service_id, c.employee_id, c.name, c.description, c.capacity,
+	c.price, c.currency, c.status, c.enrollment_closes_at, c.created_at, c.updated_at,
+	(SELECT COUNT(*) FROM course_enrollments e WHERE e.course_id = c.id AND ` + activeEnrollmentCondition + `)`
+
+const courseEnrollmentColumns = `
+	id, course_id, user_id, pet_id, status, price, payment_intent_id, payment_id,
+	payment_expires_at, fee_amount, refund_amount, cancelled_by, cancelled_at, created_at, updated_at`
+
+type CourseService struct {
+	db                  *sql.DB
+	bookingService      *BookingService
+	paymentService      *PaymentService
+	notificationService *NotificationService
+	cronScheduler       *cron.Cron
+}
+
+func NewCourseService(db *sql.DB, bookingService *BookingService, paymentService *PaymentService, notificationService *NotificationService) *CourseService {
+	return &CourseService{
+		db:                  db,
+		bookingService:      bookingService,
+		paymentService:      paymentService,
+		notificationService: notificationService,
+		cronScheduler:       cron.New(),
+	}
+}
+
+// StartCourseCron starts the course background jobs
+func (s *CourseService) StartCourseCron() {
+	// Free places of unpaid enrollments every minute
+	_, err := s.cronScheduler.AddFunc("* * * * *", s.ReleaseUnpaidEnrollments)
+	if err != nil {
+		log.Printf("Error adding course enrollment cron job: %v", err)
+		return
+	}
+
+	s.cronScheduler.Start()
+	log.Println("Course cron scheduler started")
+}
+
+// GetCompanyCourses returns every course of the company with its sessions
+func (s *CourseService) GetCompanyCourses(companyID string) ([]models.Course, error) {
+	return s.queryCourses(`WHERE c.company_id = $1 ORDER BY c.created_at DESC`, companyID)
+}
+
+// GetPublishedCourses returns the courses of a company customers can see
+func (s *CourseService) GetPublishedCourses(companyID string) ([]models.Course, error) {
+	return s.queryCourses(`WHERE c.company_id = $1 AND c.status = 'published' ORDER BY c.created_at DESC`, companyID)
+}
+
+// GetCourse returns a course with its sessions
+func (s *CourseService) GetCourse(courseID string) (*models.Course, error) {
+	courses, err := s.queryCourses(`WHERE c.id = $1`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(courses) == 0 {
+		return nil, fmt.Errorf("course not found")
+	}
+
+	return &courses[0], nil
+}
+
+// CreateCourse creates a course together with its initial sessions
+func (s *CourseService) CreateCourse(companyID string, req *models.CourseRequest) (*models.Course, error) {
+	if err := s.validateCourseRequest(companyID, req); err != nil {
+		return nil, err
+	}
+
+	price := 0.0
+	if req.Price != nil {
+		price = *req.Price
+	} else if req.ServiceID != nil {
+		s.db.QueryRow("SELECT price FROM services WHERE id = $1", *req.ServiceID).Scan(&price)
+	}
+
+	status := req.Status
+	if status == "" {
+		status = "draft"
+	}
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = "usd"
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var courseID string
+	err = tx.QueryRow(`
+		INSERT INTO courses (company_id, service_id, employee_id, name, description, capacity,
+							 price, currency, status, enrollment_closes_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		RETURNING id
+	`, companyID, req.ServiceID, req.EmployeeID, req.Name, req.Description, req.Capacity,
+		models.MoneyFromDecimal(price, currency), currency, status, req.EnrollmentClosesAt).Scan(&courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create course: %w", err)
+	}
+
+	for i := range req.Sessions {
+		if err := s.insertCourseSession(tx, courseID, req.EmployeeID, &req.Sessions[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetCourse(courseID)
+}
+
+// UpdateCourse changes the details of a course. Price changes only apply to new enrollments,
+// and capacity cannot drop below the places already taken. Completing a course completes its enrollments.
+func (s *CourseService) UpdateCourse(companyID, courseID string, req *models.CourseRequest) (*models.Course, error) {
+	course, err := s.companyCourse(companyID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status == "cancelled" {
+		return nil, fmt.Errorf("cancelled courses cannot be changed")
+	}
+	if req.Status == "cancelled" {
+		return nil, fmt.Errorf("use the cancel endpoint to cancel a course")
+	}
+	if err := s.validateCourseRequest(companyID, req); err != nil {
+		return nil, err
+	}
+	if req.Capacity < course.EnrolledCount {
+		return nil, fmt.Errorf("capacity cannot be lower than the %d places already taken", course.EnrolledCount)
+	}
+
+	price := course.Price
+	if req.Price != nil {
+		price = *req.Price
+	}
+	status := req.Status
+	if status == "" {
+		status = course.Status
+	}
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = course.Currency
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE courses
+		SET service_id = $2, employee_id = $3, name = $4, description = $5, capacity = $6,
+			price = $7, currency = $8, status = $9, enrollment_closes_at = $10, updated_at = NOW()
+		WHERE id = $1
+	`, courseID, req.ServiceID, req.EmployeeID, req.Name, req.Description, req.Capacity,
+		models.MoneyFromDecimal(price, currency), currency, status, req.EnrollmentClosesAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update course: %w", err)
+	}
+
+	if status == "completed" {
+		_, err = tx.Exec(`
+			UPDATE course_enrollments SET status = 'completed', updated_at = NOW()
+			WHERE course_id = $1 AND status = 'enrolled'
+		`, courseID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetCourse(courseID)
+}
+
+// CancelCourse cancels a course, refunds every enrolled customer in full and notifies them
+func (s *CourseService) CancelCourse(companyID, courseID, reason string) (*models.Course, error) {
+	course, err := s.companyCourse(companyID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status == "cancelled" || course.Status == "completed" {
+		return nil, fmt.Errorf("course is already %s", course.Status)
+	}
+
+	_, err = s.db.Exec("UPDATE courses SET status = 'cancelled', updated_at = NOW() WHERE id = $1", courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel course: %w", err)
+	}
+
+	enrollments, err := s.queryEnrollments(`WHERE course_id = $1 AND status IN ('pending_payment', 'enrolled')`, courseID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range enrollments {
+		if _, err := s.cancelEnrollment(course, &enrollments[i], "company"); err != nil {
+			log.Printf("Error cancelling course enrollment %s: %v", enrollments[i].ID, err)
+			continue
+		}
+
+		message := fmt.Sprintf("The course \"%s\" has been cancelled. Any payment will be refunded in full.", course.Name)
+		if reason != "" {
+			message += " Reason: " + reason
+		}
+		s.notifyEnrollment(course, &enrollments[i], "course_cancelled", "Course cancelled", message)
+	}
+
+	return s.GetCourse(courseID)
+}
+
+// AddCourseSession adds a session to a course; the trainer must be free at that time
+func (s *CourseService) AddCourseSession(companyID, courseID string, req *models.CourseSessionRequest) (*models.CourseSession, error) {
+	course, err := s.companyCourse(companyID, courseID)
+	if err != nil {
+		return nil, err
+	}
+	if course.Status == "cancelled" || course.Status == "completed" {
+		return nil, fmt.Errorf("sessions cannot be added to a %s course", course.Status)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.insertCourseSession(tx, courseID, course.EmployeeID, req); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	sessions, err := s.getCourseSessions(courseID)
+	if err != nil {
+		return nil, err
+	}
+	for i := range sessions {
+		if sessions[i].StartsAt.Equal(req.StartsAt) {
+			return &sessions[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("session not found")
+}
+
+// CancelCourseSession cancels a single session and tells everyone enrolled
+func (s *CourseService) CancelCourseSession(companyID, courseID, sessionID string) error {
+	course, err := s.companyCourse(companyID, courseID)
+	if err != nil {
+		return err
+	}
+
+	var startsAt time.Time
+	err = s.db.QueryRow(`
+		UPDATE course_sessions SET status = 'cancelled', updated_at = NOW()
+		WHERE id = $1 AND course_id = $2 AND status = 'scheduled'
+		RETURNING starts_at
+	`, sessionID, courseID).Scan(&startsAt)
+	if err != nil {
+		return fmt.Errorf("session not found")
+	}
+
+	enrollments, err := s.queryEnrollments(`WHERE course_id = $1 AND status = 'enrolled'`, courseID)
+	if err != nil {
+		return err
+	}
+
+	loc := companyLocation(s.db, companyID)
+	message := fmt.Sprintf("The session of \"%s\" on %s is cancelled.", course.Name, startsAt.In(loc).Format("Jan 2 at 15:04"))
+	for i := range enrollments {
+		s.notifyEnrollment(course, &enrollments[i], "course_session_cancelled", "Class cancelled", message)
+	}
+
+	return nil
+}
+
+// EnrollInCourse takes a place in a course for a pet. Paid courses hold the place
+// while the payment is completed; free ones enroll right away.
+func (s *CourseService) EnrollInCourse(userID, courseID string, req *models.EnrollCourseRequest) (*models.CourseEnrollment, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// Lock the course so concurrent enrollments cannot overfill it
+	var companyID, status, currency string
+	var capacity int
+	var price float64
+	var closesAt sql.NullTime
+	err = tx.QueryRow(`
+		SELECT company_id, status, capacity, price, currency,
+			   COALESCE(enrollment_closes_at, (
+				   SELECT MIN(starts_at) FROM course_sessions WHERE course_id = courses.id AND status = 'scheduled'
+			   ))
+		FROM courses WHERE id = $1
+		FOR UPDATE
+	`, courseID).Scan(&companyID, &status, &capacity, &price, &currency, &closesAt)
+	if err != nil || status != "published" {
+		return nil, fmt.Errorf("course not found")
+	}
+	if closesAt.Valid && !time.Now().Before(closesAt.Time) {
+		return nil, fmt.Errorf("enrollment for this course is closed")
+	}
+
+	var petOwnerID string
+	if err := tx.QueryRow("SELECT user_id FROM pets WHERE id = $1", req.PetID).Scan(&petOwnerID); err != nil {
+		return nil, fmt.Errorf("pet not found")
+	}
+	if petOwnerID != userID {
+		return nil, fmt.Errorf("pet does not belong to user")
+	}
+
+	var taken int
+	var petEnrolled bool
+	err = tx.QueryRow(`
+		SELECT COUNT(*), COALESCE(BOOL_OR(e.pet_id = $2), false)
+		FROM course_enrollments e
+		WHERE e.course_id = $1 AND `+activeEnrollmentCondition, courseID, req.PetID).Scan(&taken, &petEnrolled)
+	if err != nil {
+		return nil, err
+	}
+	if petEnrolled {
+		return nil, fmt.Errorf("pet is already enrolled in this course")
+	}
+	if taken >= capacity {
+		return nil, ErrCourseFull
+	}
+
+	// Clear a lapsed unpaid enrollment of the same pet so it does not block the new one
+	_, err = tx.Exec(`
+		UPDATE course_enrollments SET status = 'cancelled', cancelled_by = 'system', cancelled_at = NOW(), updated_at = NOW()
+		WHERE course_id = $1 AND pet_id = $2 AND status = 'pending_payment'
+	`, courseID, req.PetID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollmentStatus := "enrolled"
+	var expiresAt *time.Time
+	if price > 0 && s.paymentService != nil {
+		enrollmentStatus = "pending_payment"
+		deadline := time.Now().Add(courseEnrollmentHoldMinutes * time.Minute)
+		expiresAt = &deadline
+	}
+
+	var enrollmentID string
+	err = tx.QueryRow(`
+		INSERT INTO course_enrollments (course_id, user_id, pet_id, status, price, payment_expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, courseID, userID, req.PetID, enrollmentStatus, price, expiresAt).Scan(&enrollmentID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to enroll: %w", err)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.getEnrollment(enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request the course payment once the enrollment is committed
+	if enrollment.Status == "pending_payment" {
+		intent, err := s.paymentService.CreatePaymentIntent(&PaymentRequest{
+			UserID:      userID,
+			CompanyID:   companyID,
+			Amount:      price,
+			Currency:    currency,
+			Description: "Course enrollment",
+			Metadata: map[string]interface{}{
+				"type":          "course_enrollment",
+				"course_id":     courseID,
+				"enrollment_id": enrollmentID,
+			},
+		})
+		if err != nil {
+			s.db.Exec("UPDATE course_enrollments SET status = 'cancelled', cancelled_by = 'system', cancelled_at = NOW() WHERE id = $1", enrollmentID)
+			return nil, fmt.Errorf("failed to request payment: %w", err)
+		}
+
+		_, err = s.db.Exec("UPDATE course_enrollments SET payment_intent_id = $2 WHERE id = $1", enrollmentID, intent.PaymentIntentID)
+		if err != nil {
+			return nil, err
+		}
+		enrollment.PaymentIntentID = &intent.PaymentIntentID
+		enrollment.ClientSecret = intent.ClientSecret
+	}
+
+	return enrollment, nil
+}
+
+// ConfirmEnrollmentPayment completes an enrollment once its payment has succeeded
+func (s *CourseService) ConfirmEnrollmentPayment(userID, enrollmentID string) (*models.CourseEnrollment, error) {
+	enrollment, err := s.getEnrollment(enrollmentID)
+	if err != nil || enrollment.UserID != userID {
+		return nil, fmt.Errorf("enrollment not found")
+	}
+	if enrollment.Status != "pending_payment" {
+		return nil, fmt.Errorf("enrollment is not awaiting payment")
+	}
+
+	paymentID, status, err := s.enrollmentPayment(enrollment)
+	if err != nil {
+		return nil, err
+	}
+	if status != "succeeded" {
+		return nil, ErrDepositNotPaid
+	}
+
+	if err := s.confirmPaidEnrollment(enrollment.ID, paymentID); err != nil {
+		return nil, err
+	}
+
+	return s.getEnrollment(enrollmentID)
+}
+
+// ReleaseUnpaidEnrollments confirms paid enrollments and frees the places of those past their payment deadline
+func (s *CourseService) ReleaseUnpaidEnrollments() {
+	enrollments, err := s.queryEnrollments(`WHERE status = 'pending_payment'`)
+	if err != nil {
+		log.Printf("Error getting unpaid enrollments: %v", err)
+		return
+	}
+
+	for i := range enrollments {
+		enrollment := &enrollments[i]
+		if paymentID, status, err := s.enrollmentPayment(enrollment); err == nil && status == "succeeded" {
+			if err := s.confirmPaidEnrollment(enrollment.ID, paymentID); err != nil {
+				log.Printf("Error confirming course enrollment %s: %v", enrollment.ID, err)
+			}
+			continue
+		}
+
+		if enrollment.PaymentExpiresAt != nil && enrollment.PaymentExpiresAt.After(time.Now()) {
+			continue
+		}
+
+		_, err := s.db.Exec(`
+			UPDATE course_enrollments
+			SET status = 'cancelled', cancelled_by = 'system', cancelled_at = NOW(), updated_at = NOW()
+			WHERE id = $1 AND status = 'pending_payment'
+		`, enrollment.ID)
+		if err != nil {
+			log.Printf("Error releasing course enrollment %s: %v", enrollment.ID, err)
+			continue
+		}
+		s.voidEnrollmentPayment(enrollment)
+	}
+}
+
+// GetUserEnrollments returns the course enrollments of a customer
+func (s *CourseService) GetUserEnrollments(userID string) ([]models.CourseEnrollment, error) {
+	return s.queryEnrollments(`WHERE user_id = $1 ORDER BY created_at DESC`, userID)
+}
+
+// GetCourseEnrollments returns every enrollment of a company course
+func (s *CourseService) GetCourseEnrollments(companyID, courseID string) ([]models.CourseEnrollment, error) {
+	if _, err := s.companyCourse(companyID, courseID); err != nil {
+		return nil, err
+	}
+
+	return s.queryEnrollments(`WHERE course_id = $1 ORDER BY created_at`, courseID)
+}
+
+// PreviewEnrollmentCancellation returns the fee and refund for leaving a course now
+func (s *CourseService) PreviewEnrollmentCancellation(userID, enrollmentID string) (*models.CourseEnrollmentCancellation, error) {
+	enrollment, course, err := s.userEnrollment(userID, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.evaluateEnrollmentCancellation(course, enrollment, "customer")
+}
+
+// CancelEnrollment leaves a course under the cancellation policy of its service, counted from the first session.
+// When acceptedFee is set and the fee due is now higher, nothing is changed and ErrCancellationFeeChanged is returned.
+func (s *CourseService) CancelEnrollment(userID, enrollmentID string, acceptedFee *float64) (*models.CourseEnrollmentCancellation, error) {
+	enrollment, course, err := s.userEnrollment(userID, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+
+	outcome, err := s.evaluateEnrollmentCancellation(course, enrollment, "customer")
+	if err != nil {
+		return nil, err
+	}
+	if acceptedFee != nil && outcome.FeeAmount > *acceptedFee {
+		return outcome, ErrCancellationFeeChanged
+	}
+
+	return s.cancelEnrollment(course, enrollment, "customer")
+}
+
+// CancelCourseEnrollment removes a customer from a company course with a full refund
+func (s *CourseService) CancelCourseEnrollment(companyID, courseID, enrollmentID string) (*models.CourseEnrollmentCancellation, error) {
+	course, err := s.companyCourse(companyID, courseID)
+	if err != nil {
+		return nil, err
+	}
+
+	enrollment, err := s.getEnrollment(enrollmentID)
+	if err != nil || enrollment.CourseID != courseID {
+		return nil, fmt.Errorf("enrollment not found")
+	}
+
+	outcome, err := s.cancelEnrollment(course, enrollment, "company")
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifyEnrollment(course, enrollment, "course_enrollment_cancelled", "Course enrollment cancelled",
+		fmt.Sprintf("Your enrollment in \"%s\" was cancelled by the company. Any payment will be refunded in full.", course.Name))
+
+	return outcome, nil
+}
+
+// GetTrainerSessions returns the upcoming sessions of the courses an employee teaches
+func (s *CourseService) GetTrainerSessions(employeeID string) ([]models.CourseSession, error) {
+	rows, err := s.db.Query(`
+		SELECT cs.id, cs.course_id, cs.starts_at, cs.ends_at, cs.location, cs.status, cs.created_at, cs.updated_at
+		FROM course_sessions cs
+		JOIN courses c ON c.id = cs.course_id
+		WHERE c.employee_id = $1 AND c.status IN ('published', 'completed')
+		  AND cs.status = 'scheduled' AND cs.ends_at >= NOW()
+		ORDER BY cs.starts_at
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCourseSessions(rows)
+}
+
+// GetSessionRoster lists the pets enrolled in the course of a session with their attendance.
+// When trainerID is set the session must belong to a course that employee teaches.
+func (s *CourseService) GetSessionRoster(companyID, sessionID string, trainerID *string) ([]models.CourseRosterEntry, error) {
+	courseID, err := s.sessionCourse(companyID, sessionID, trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT e.id, e.user_id, COALESCE(u.first_name || ' ' || u.last_name, ''), COALESCE(u.phone, ''),
+			   e.pet_id, COALESCE(p.name, ''), a.status, a.notes
+		FROM course_enrollments e
+		JOIN users u ON u.id = e.user_id
+		LEFT JOIN pets p ON p.id = e.pet_id
+		LEFT JOIN course_attendance a ON a.enrollment_id = e.id AND a.session_id = $2
+		WHERE e.course_id = $1 AND e.status IN ('enrolled', 'completed')
+		ORDER BY p.name
+	`, courseID, sessionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roster: %w", err)
+	}
+	defer rows.Close()
+
+	roster := []models.CourseRosterEntry{}
+	for rows.Next() {
+		var entry models.CourseRosterEntry
+		if err := rows.Scan(
+			&entry.EnrollmentID, &entry.UserID, &entry.CustomerName, &entry.CustomerPhone,
+			&entry.PetID, &entry.PetName, &entry.Attendance, &entry.AttendanceNotes,
+		); err != nil {
+			return nil, err
+		}
+		roster = append(roster, entry)
+	}
+
+	return roster, nil
+}
+
+// RecordAttendance marks who attended a session, replacing earlier marks
+func (s *CourseService) RecordAttendance(companyID, sessionID string, trainerID, markedBy *string, entries []models.CourseAttendanceEntry) ([]models.CourseRosterEntry, error) {
+	courseID, err := s.sessionCourse(companyID, sessionID, trainerID)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, entry := range entries {
+		if entry.Status != "present" && entry.Status != "absent" && entry.Status != "excused" {
+			return nil, fmt.Errorf("invalid attendance status: %s", entry.Status)
+		}
+
+		result, err := tx.Exec(`
+			INSERT INTO course_attendance (session_id, enrollment_id, status, notes, marked_by, marked_at)
+			SELECT $1, e.id, $3, $4, $5, NOW()
+			FROM course_enrollments e
+			WHERE e.id = $2 AND e.course_id = $6 AND e.status IN ('enrolled', 'completed')
+			ON CONFLICT (session_id, enrollment_id)
+			DO UPDATE SET status = EXCLUDED.status, notes = EXCLUDED.notes,
+						  marked_by = EXCLUDED.marked_by, marked_at = NOW()
+		`, sessionID, entry.EnrollmentID, entry.Status, entry.Notes, markedBy, courseID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record attendance: %w", err)
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			return nil, fmt.Errorf("enrollment %s is not on the roster", entry.EnrollmentID)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.GetSessionRoster(companyID, sessionID, trainerID)
+}
+
+// Helper methods
+
+func (s *CourseService) queryCourses(where string, args ...interface{}) ([]models.Course, error) {
+	rows, err := s.db.Query(`SELECT `+courseColumns+` FROM courses c `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get courses: %w", err)
+	}
+	defer rows.Close()
+
+	courses := []models.Course{}
+	for rows.Next() {
+		var course models.Course
+		if err := rows.Scan(
+			&course.ID, &course.CompanyID, &course.ServiceID, &course.EmployeeID, &course.Name,
+			&course.Description, &course.Capacity, &course.Price, &course.Currency, &course.Status,
+			&course.EnrollmentClosesAt, &course.CreatedAt, &course.UpdatedAt, &course.EnrolledCount,
+		); err != nil {
+			return nil, err
+		}
+		course.SpotsLeft = int(math.Max(float64(course.Capacity-course.EnrolledCount), 0))
+		courses = append(courses, course)
+	}
+	rows.Close()
+
+	for i := range courses {
+		if courses[i].Sessions, err = s.getCourseSessions(courses[i].ID); err != nil {
+			return nil, err
+		}
+	}
+
+	return courses, nil
+}
+
+func (s *CourseService) companyCourse(companyID, courseID string) (*models.Course, error) {
+	course, err := s.GetCourse(courseID)
+	if err != nil || course.CompanyID != companyID {
+		return nil, fmt.Errorf("course not found")
+	}
+
+	return course, nil
+}
+
+func (s *CourseService) validateCourseRequest(companyID string, req *models.CourseRequest) error {
+	if req.Capacity <= 0 {
+		return fmt.Errorf("capacity must be positive")
+	}
+	if req.Price != nil && *req.Price < 0 {
+		return fmt.Errorf("price cannot be negative")
+	}
+	switch req.Status {
+	case "", "draft", "published", "completed":
+	default:
+		return fmt.Errorf("invalid status: %s", req.Status)
+	}
+
+	if req.ServiceID != nil {
+		var serviceCompanyID string
+		err := s.db.QueryRow("SELECT company_id FROM services WHERE id = $1", *req.ServiceID).Scan(&serviceCompanyID)
+		if err != nil || serviceCompanyID != companyID {
+			return fmt.Errorf("service not found")
+		}
+	}
+	if req.EmployeeID != nil {
+		var employeeCompanyID string
+		err := s.db.QueryRow("SELECT company_id FROM employees WHERE id = $1", *req.EmployeeID).Scan(&employeeCompanyID)
+		if err != nil || employeeCompanyID != companyID {
+			return fmt.Errorf("employee not found")
+		}
+	}
+
+	return nil
+}
+
+// insertCourseSession adds a session after checking the trainer has no bookings or other sessions at that time
+func (s *CourseService) insertCourseSession(tx *sql.Tx, courseID string, trainerID *string, req *models.CourseSessionRequest) error {
+	if !req.EndsAt.After(req.StartsAt) {
+		return fmt.Errorf("session must end after it starts")
+	}
+
+	if trainerID != nil {
+		var conflicts int
+		err := tx.QueryRow(`
+			SELECT
+				(SELECT COUNT(*) FROM bookings
+				 WHERE employee_id = $1 AND status NOT IN ('cancelled', 'rejected', 'no_show')
+				   AND date_time < $3 AND date_time + (duration * INTERVAL '1 minute') > $2)
+			  + (SELECT COUNT(*) FROM course_sessions cs JOIN courses c ON c.id = cs.course_id
+				 WHERE c.employee_id = $1 AND c.status <> 'cancelled' AND cs.status = 'scheduled'
+				   AND cs.starts_at < $3 AND cs.ends_at > $2)
+		`, *trainerID, req.StartsAt, req.EndsAt).Scan(&conflicts)
+		if err != nil {
+			return err
+		}
+		if conflicts > 0 {
+			return fmt.Errorf("trainer is not available at %s", req.StartsAt.Format(time.RFC3339))
+		}
+	}
+
+	_, err := tx.Exec(`
+		INSERT INTO course_sessions (course_id, starts_at, ends_at, location)
+		VALUES ($1, $2, $3, $4)
+	`, courseID, req.StartsAt.UTC(), req.EndsAt.UTC(), req.Location)
+	if err != nil {
+		return fmt.Errorf("failed to create session: %w", err)
+	}
+
+	return nil
+}
+
+func (s *CourseService) getCourseSessions(courseID string) ([]models.CourseSession, error) {
+	rows, err := s.db.Query(`
+		SELECT id, course_id, starts_at, ends_at, location, status, created_at, updated_at
+		FROM course_sessions WHERE course_id = $1
+		ORDER BY starts_at
+	`, courseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sessions: %w", err)
+	}
+	defer rows.Close()
+
+	return scanCourseSessions(rows)
+}
+
+// sessionCourse returns the course of a company session, optionally checking the trainer
+func (s *CourseService) sessionCourse(companyID, sessionID string, trainerID *string) (string, error) {
+	var courseID string
+	var employeeID *string
+	err := s.db.QueryRow(`
+		SELECT c.id, c.employee_id
+		FROM course_sessions cs
+		JOIN courses c ON c.id = cs.course_id
+		WHERE cs.id = $1 AND c.company_id = $2
+	`, sessionID, companyID).Scan(&courseID, &employeeID)
+	if err != nil {
+		return "", fmt.Errorf("session not found")
+	}
+	if trainerID != nil && (employeeID == nil || *employeeID != *trainerID) {
+		return "", fmt.Errorf("session not found")
+	}
+
+	return courseID, nil
+}
+
+func (s *CourseService) queryEnrollments(where string, args ...interface{}) ([]models.CourseEnrollment, error) {
+	rows, err := s.db.Query(`SELECT `+courseEnrollmentColumns+` FROM course_enrollments `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enrollments: %w", err)
+	}
+	defer rows.Close()
+
+	enrollments := []models.CourseEnrollment{}
+	for rows.Next() {
+		var e models.CourseEnrollment
+		if err := rows.Scan(
+			&e.ID, &e.CourseID, &e.UserID, &e.PetID, &e.Status, &e.Price, &e.PaymentIntentID, &e.PaymentID,
+			&e.PaymentExpiresAt, &e.FeeAmount, &e.RefundAmount, &e.CancelledBy, &e.CancelledAt,
+			&e.CreatedAt, &e.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		enrollments = append(enrollments, e)
+	}
+
+	return enrollments, nil
+}
+
+func (s *CourseService) getEnrollment(enrollmentID string) (*models.CourseEnrollment, error) {
+	enrollments, err := s.queryEnrollments(`WHERE id = $1`, enrollmentID)
+	if err != nil {
+		return nil, err
+	}
+	if len(enrollments) == 0 {
+		return nil, fmt.Errorf("enrollment not found")
+	}
+
+	return &enrollments[0], nil
+}
+
+func (s *CourseService) userEnrollment(userID, enrollmentID string) (*models.CourseEnrollment, *models.Course, error) {
+	enrollment, err := s.getEnrollment(enrollmentID)
+	if err != nil || enrollment.UserID != userID {
+		return nil, nil, fmt.Errorf("enrollment not found")
+	}
+
+	course, err := s.GetCourse(enrollment.CourseID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return enrollment, course, nil
+}
+
+// evaluateEnrollmentCancellation applies the cancellation policy of the course's service to the course price,
+// counting hours until the first session. Company cancellations and unpaid enrollments carry no fee.
+func (s *CourseService) evaluateEnrollmentCancellation(course *models.Course, enrollment *models.CourseEnrollment, initiatedBy string) (*models.CourseEnrollmentCancellation, error) {
+	if enrollment.Status != "pending_payment" && enrollment.Status != "enrolled" {
+		return nil, fmt.Errorf("enrollment with status %s cannot be cancelled", enrollment.Status)
+	}
+
+	outcome := &models.CourseEnrollmentCancellation{
+		EnrollmentID: enrollment.ID,
+		PolicyName:   "Free cancellation",
+	}
+
+	for _, session := range course.Sessions {
+		if session.Status == "scheduled" {
+			outcome.HoursBefore = math.Round(time.Until(session.StartsAt).Hours()*100) / 100
+			break
+		}
+	}
+
+	if enrollment.Status == "enrolled" && enrollment.PaymentID != nil {
+		outcome.AmountPaid = enrollment.Price
+	}
+
+	if course.ServiceID != nil && s.bookingService != nil {
+		policy, err := s.bookingService.bookingCancellationPolicy(*course.ServiceID, course.CompanyID)
+		if err != nil {
+			return nil, err
+		}
+		if policy != nil {
+			outcome.PolicyName = policy.Name
+			if initiatedBy != "company" && enrollment.Status == "enrolled" {
+				outcome.FeePercentage = cancellationFeePercentage(policy, "cancel", outcome.HoursBefore)
+			}
+		}
+	}
+
+	fee := models.MoneyFromDecimal(enrollment.Price, course.Currency).Percent(outcome.FeePercentage)
+	refund := models.MoneyFromDecimal(outcome.AmountPaid, course.Currency).Sub(fee)
+	if refund.IsNegative() {
+		refund = models.NewMoney(0, course.Currency)
+	}
+	outcome.FeeAmount = fee.Decimal()
+	outcome.RefundAmount = refund.Decimal()
+
+	return outcome, nil
+}
+
+// cancelEnrollment frees the place of an enrollment and refunds what the policy allows
+func (s *CourseService) cancelEnrollment(course *models.Course, enrollment *models.CourseEnrollment, initiatedBy string) (*models.CourseEnrollmentCancellation, error) {
+	outcome, err := s.evaluateEnrollmentCancellation(course, enrollment, initiatedBy)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE course_enrollments
+		SET status = 'cancelled', fee_amount = $2, refund_amount = $3, cancelled_by = $4,
+			cancelled_at = NOW(), updated_at = NOW()
+		WHERE id = $1 AND status IN ('pending_payment', 'enrolled')
+	`, enrollment.ID, outcome.FeeAmount, outcome.RefundAmount, initiatedBy)
+	if err != nil {
+		return nil, fmt.Errorf("failed to cancel enrollment: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return nil, fmt.Errorf("enrollment is already cancelled")
+	}
+
+	if enrollment.Status == "pending_payment" {
+		s.voidEnrollmentPayment(enrollment)
+		return outcome, nil
+	}
+
+	if outcome.RefundAmount > 0 && s.paymentService != nil && enrollment.PaymentID != nil {
+		_, err := s.paymentService.RefundPayment(&RefundRequest{
+			PaymentID: *enrollment.PaymentID,
+			Amount:    outcome.RefundAmount,
+			Reason:    fmt.Sprintf("Course enrollment cancelled under policy %s", outcome.PolicyName),
+		})
+		if err != nil {
+			log.Printf("Failed to refund course enrollment %s: %v", enrollment.ID, err)
+		}
+	}
+
+	return outcome, nil
+}
+
+// enrollmentPayment returns the payment row and status behind an enrollment
+func (s *CourseService) enrollmentPayment(enrollment *models.CourseEnrollment) (string, string, error) {
+	if enrollment.PaymentIntentID == nil {
+		return "", "", fmt.Errorf("enrollment has no payment")
+	}
+
+	var paymentID, status string
+	err := s.db.QueryRow(`
+		SELECT id, status FROM payments WHERE stripe_payment_intent_id = $1
+	`, *enrollment.PaymentIntentID).Scan(&paymentID, &status)
+	if err != nil {
+		return "", "", fmt.Errorf("enrollment payment not found")
+	}
+
+	return paymentID, status, nil
+}
+
+func (s *CourseService) confirmPaidEnrollment(enrollmentID, paymentID string) error {
+	_, err := s.db.Exec(`
+		UPDATE course_enrollments
+		SET status = 'enrolled', payment_id = $2, payment_expires_at = NULL, updated_at = NOW()
+		WHERE id = $1 AND status = 'pending_payment'
+	`, enrollmentID, paymentID)
+	return err
+}
+
+// voidEnrollmentPayment cancels the pending payment of an enrollment that will not go ahead
+func (s *CourseService) voidEnrollmentPayment(enrollment *models.CourseEnrollment) {
+	if s.paymentService == nil || enrollment.PaymentIntentID == nil {
+		return
+	}
+
+	paymentID, status, err := s.enrollmentPayment(enrollment)
+	if err != nil || status != "pending" {
+		return
+	}
+
+	if err := s.paymentService.UpdatePaymentStatus(paymentID, "canceled"); err != nil {
+		log.Printf("Error cancelling enrollment payment %s: %v", paymentID, err)
+	}
+}
+
+func (s *CourseService) notifyEnrollment(course *models.Course, enrollment *models.CourseEnrollment, notificationType, title, message string) {
+	if s.notificationService == nil {
+		return
+	}
+
+	payload := &NotificationPayload{
+		Type:      notificationType,
+		Title:     title,
+		Message:   message,
+		UserID:    enrollment.UserID,
+		CompanyID: course.CompanyID,
+		Data: map[string]interface{}{
+			"course_id":     course.ID,
+			"enrollment_id": enrollment.ID,
+		},
+	}
+
+	if err := s.notificationService.SendImmediateNotification(payload, []string{"push", "email"}); err != nil {
+		log.Printf("Error sending course notification: %v", err)
+	}
+}
+
+func scanCourseSessions(rows *sql.Rows) ([]models.CourseSession, error) {
+	sessions := []models.CourseSession{}
+	for rows.Next() {
+		var session models.CourseSession
+		if err := rows.Scan(
+			&session.ID, &session.CourseID, &session.StartsAt, &session.EndsAt,
+			&session.Location, &session.Status, &session.CreatedAt, &session.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+
+	return sessions, nil
+}
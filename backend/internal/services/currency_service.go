@@ -133,7 +133,7 @@ func (s *CurrencyService) GetBaseCurrency() (*models.Currency, error) {
 	return &currency, nil
 }
 
-// ConvertCurrency converts amount from one currency to another
+// ConvertCurrency converts amount from one currency to another, rounded to the target currency's minor unit
 func (s *CurrencyService) ConvertCurrency(fromCode, toCode string, amount float64) (*models.CurrencyConversionResponse, error) {
 	// Get both currencies
 	fromCurrency, err := s.GetCurrencyByCode(fromCode)
@@ -146,42 +146,39 @@ func (s *CurrencyService) ConvertCurrency(fromCode, toCode string, amount float6
 		return nil, fmt.Errorf("to currency not found: %v", err)
 	}
 
+	original := models.MoneyFromDecimal(amount, fromCode)
+
 	// If same currency, return as is
 	if fromCode == toCode {
 		return &models.CurrencyConversionResponse{
 			FromCurrency:    fromCode,
 			ToCurrency:      toCode,
-			OriginalAmount:  amount,
-			ConvertedAmount: amount,
+			OriginalAmount:  original,
+			ConvertedAmount: original,
 			ExchangeRate:    1.0,
 			LastUpdated:     fromCurrency.LastUpdated,
 		}, nil
 	}
 
-	// Convert to base currency first, then to target currency
-	var convertedAmount float64
+	// Rates are against the base currency; the cross rate converts in one step, rounding once
 	var exchangeRate float64
 
 	if fromCurrency.IsBase {
 		// From base to target
-		convertedAmount = amount * toCurrency.ExchangeRate
 		exchangeRate = toCurrency.ExchangeRate
 	} else if toCurrency.IsBase {
 		// From source to base
-		convertedAmount = amount / fromCurrency.ExchangeRate
 		exchangeRate = 1.0 / fromCurrency.ExchangeRate
 	} else {
 		// From source to base to target
-		baseAmount := amount / fromCurrency.ExchangeRate
-		convertedAmount = baseAmount * toCurrency.ExchangeRate
 		exchangeRate = toCurrency.ExchangeRate / fromCurrency.ExchangeRate
 	}
 
 	return &models.CurrencyConversionResponse{
 		FromCurrency:    fromCode,
 		ToCurrency:      toCode,
-		OriginalAmount:  amount,
-		ConvertedAmount: convertedAmount,
+		OriginalAmount:  original,
+		ConvertedAmount: original.Convert(exchangeRate, toCode),
 		ExchangeRate:    exchangeRate,
 		LastUpdated:     toCurrency.LastUpdated,
 	}, nil
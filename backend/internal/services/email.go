@@ -2,9 +2,18 @@ package services
 
 import (
 	"database/sql"
+	"fmt"
 	"log"
+	"strings"
 )
 
+// EmailAttachment is a file attached to an email, such as a booking .ics invitation
+type EmailAttachment struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+}
+
 type EmailService struct {
 	db *sql.DB
 }
@@ -27,3 +36,14 @@ func (s *EmailService) SendEmail(to, subject, body string) error {
 	log.Printf("EMAIL: To: %s, Subject: %s, Body: %s", to, subject, body)
 	return nil
 }
+
+// SendEmailWithAttachments sends an email with file attachments
+func (s *EmailService) SendEmailWithAttachments(to, subject, body string, attachments []EmailAttachment) error {
+	// TODO: Implement actual email sending
+	names := make([]string, 0, len(attachments))
+	for _, attachment := range attachments {
+		names = append(names, fmt.Sprintf("%s (%s, %d bytes)", attachment.Filename, attachment.ContentType, len(attachment.Content)))
+	}
+	log.Printf("EMAIL: To: %s, Subject: %s, Body: %s, Attachments: %s", to, subject, body, strings.Join(names, ", "))
+	return nil
+}
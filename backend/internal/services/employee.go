@@ -425,6 +425,7 @@ func (s *EmployeeService) GetAvailablePermissions() ([]models.EmployeePermission
 			{ID: "edit_customers", Name: "Edit Customers", Description: "Modify customer data", Category: "customers"},
 			{ID: "view_services", Name: "View Services", Description: "View company services", Category: "services"},
 			{ID: "manage_employees", Name: "Manage Employees", Description: "Manage employees", Category: "employees"},
+			{ID: "manage_schedules", Name: "Manage Schedules", Description: "Manage employee schedules", Category: "employees"},
 			{ID: "view_analytics", Name: "View Analytics", Description: "Access to analytics", Category: "analytics"},
 			{ID: "process_payments", Name: "Process Payments", Description: "Process payments", Category: "payments"},
 			{ID: "all", Name: "Full Access", Description: "Access to all functions", Category: "special"},
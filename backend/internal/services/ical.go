@@ -0,0 +1,402 @@
+package services
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// icsTimeFormat is the UTC date-time form of RFC 5545
+const icsTimeFormat = "20060102T150405Z"
+
+// icsMaxLineOctets is the longest content line allowed before folding
+const icsMaxLineOctets = 75
+
+// icsEvent is a single VEVENT of an iCalendar document.
+// UID must stay the same for the lifetime of the booking and Sequence must grow with every change,
+// so calendar clients replace the event instead of duplicating it.
+type icsEvent struct {
+	UID         string
+	Sequence    int
+	Start       time.Time
+	End         time.Time
+	Stamp       time.Time
+	Summary     string
+	Description string
+	Location    string
+	Status      string // TENTATIVE, CONFIRMED, CANCELLED
+
+	// Required by calendar clients for REQUEST and CANCEL messages
+	OrganizerName  string
+	OrganizerEmail string
+}
+
+// buildICS renders events as an iCalendar document. method is PUBLISH for feeds,
+// REQUEST for an invitation and CANCEL to remove a previously sent event.
+func buildICS(name, method string, events []icsEvent) []byte {
+	var b strings.Builder
+
+	writeICSLine(&b, "BEGIN:VCALENDAR")
+	writeICSLine(&b, "VERSION:2.0")
+	writeICSLine(&b, "PRODID:-//Zootel//Bookings//EN")
+	writeICSLine(&b, "CALSCALE:GREGORIAN")
+	writeICSLine(&b, "METHOD:"+method)
+	if name != "" {
+		writeICSLine(&b, "X-WR-CALNAME:"+icsEscape(name))
+	}
+
+	for _, event := range events {
+		writeICSLine(&b, "BEGIN:VEVENT")
+		writeICSLine(&b, "UID:"+event.UID)
+		writeICSLine(&b, "DTSTAMP:"+event.Stamp.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "DTSTART:"+event.Start.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "DTEND:"+event.End.UTC().Format(icsTimeFormat))
+		writeICSLine(&b, "SEQUENCE:"+strconv.Itoa(event.Sequence))
+		writeICSLine(&b, "STATUS:"+event.Status)
+		writeICSLine(&b, "SUMMARY:"+icsEscape(event.Summary))
+		if event.Description != "" {
+			writeICSLine(&b, "DESCRIPTION:"+icsEscape(event.Description))
+		}
+		if event.Location != "" {
+			writeICSLine(&b, "LOCATION:"+icsEscape(event.Location))
+		}
+		if event.OrganizerEmail != "" {
+			writeICSLine(&b, `ORGANIZER;CN="`+strings.ReplaceAll(event.OrganizerName, `"`, "'")+`":mailto:`+event.OrganizerEmail)
+		}
+		writeICSLine(&b, "END:VEVENT")
+	}
+
+	writeICSLine(&b, "END:VCALENDAR")
+
+	return []byte(b.String())
+}
+
+// icsEscape escapes a TEXT property value
+func icsEscape(value string) string {
+	value = strings.ReplaceAll(value, `\`, `\\`)
+	value = strings.ReplaceAll(value, ";", `\;`)
+	value = strings.ReplaceAll(value, ",", `\,`)
+	value = strings.ReplaceAll(value, "\r\n", `\n`)
+	value = strings.ReplaceAll(value, "\n", `\n`)
+	return value
+}
+
+// writeICSLine writes a content line folded at 75 octets without splitting UTF-8 characters
+func writeICSLine(b *strings.Builder, line string) {
+	limit := icsMaxLineOctets
+	for len(line) > limit {
+		cut := limit
+		for cut > 0 && !utf8.RuneStart(line[cut]) {
+			cut--
+		}
+		b.WriteString(line[:cut])
+		b.WriteString("\r\n ")
+		line = line[cut:]
+		// Continuation lines start with a space that counts towards the limit
+		limit = icsMaxLineOctets - 1
+	}
+	b.WriteString(line)
+	b.WriteString("\r\n")
+}
+
+// icsBusyTime is one occurrence of an imported event during which its owner is busy
+type icsBusyTime struct {
+	UID   string
+	Start time.Time
+	End   time.Time
+}
+
+// icsProperty is a parsed content line such as DTSTART;TZID=Europe/Berlin:20240105T090000
+type icsProperty struct {
+	Name   string
+	Params map[string]string
+	Value  string
+}
+
+// icsImportedEvent is a VEVENT read from an imported calendar
+type icsImportedEvent struct {
+	UID          string
+	Start        time.Time
+	End          time.Time
+	Duration     time.Duration
+	AllDay       bool
+	RRule        string
+	ExDates      []time.Time
+	RecurrenceID *time.Time
+	Skip         bool // Cancelled or marked as free time
+}
+
+// parseICSBusyTimes reads the VEVENTs of an iCalendar document and returns the busy occurrences overlapping [from, to).
+// Floating and all-day times are read in loc. Recurring events are expanded with the supported RRULE subset;
+// events with a rule outside of it only block their first occurrence.
+func parseICSBusyTimes(data []byte, loc *time.Location, from, to time.Time) ([]icsBusyTime, error) {
+	lines := unfoldICSLines(string(data))
+
+	isCalendar := false
+	for _, line := range lines {
+		if strings.EqualFold(strings.TrimSpace(line), "BEGIN:VCALENDAR") {
+			isCalendar = true
+			break
+		}
+	}
+	if !isCalendar {
+		return nil, fmt.Errorf("file is not an iCalendar document")
+	}
+
+	var events []icsImportedEvent
+	var current *icsImportedEvent
+	var currentErr error
+	nested := 0 // VALARM and other components inside an event
+
+	for _, line := range lines {
+		prop, ok := parseICSProperty(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case prop.Name == "BEGIN" && strings.EqualFold(prop.Value, "VEVENT"):
+			current = &icsImportedEvent{}
+			currentErr = nil
+			nested = 0
+			continue
+		case current == nil:
+			continue
+		case prop.Name == "BEGIN":
+			nested++
+			continue
+		case prop.Name == "END" && nested > 0:
+			nested--
+			continue
+		case prop.Name == "END" && strings.EqualFold(prop.Value, "VEVENT"):
+			// Without DTEND the event lasts its DURATION, or the whole day for all-day events
+			if current.End.IsZero() {
+				if current.Duration > 0 {
+					current.End = current.Start.Add(current.Duration)
+				} else if current.AllDay {
+					current.End = current.Start.AddDate(0, 0, 1)
+				}
+			}
+			if currentErr == nil && !current.Skip && !current.Start.IsZero() {
+				events = append(events, *current)
+			}
+			current = nil
+			continue
+		case nested > 0:
+			continue
+		}
+
+		var err error
+		switch prop.Name {
+		case "UID":
+			current.UID = prop.Value
+		case "DTSTART":
+			current.Start, current.AllDay, err = parseICSTime(prop, loc)
+		case "DTEND":
+			current.End, _, err = parseICSTime(prop, loc)
+		case "DURATION":
+			current.Duration, err = parseICSDuration(prop.Value)
+		case "RRULE":
+			current.RRule = prop.Value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.Value, ",") {
+				exdate, _, exErr := parseICSTime(icsProperty{Params: prop.Params, Value: value}, loc)
+				if exErr == nil {
+					current.ExDates = append(current.ExDates, exdate)
+				}
+			}
+		case "RECURRENCE-ID":
+			var recurrenceID time.Time
+			recurrenceID, _, err = parseICSTime(prop, loc)
+			current.RecurrenceID = &recurrenceID
+		case "STATUS":
+			current.Skip = current.Skip || strings.EqualFold(prop.Value, "CANCELLED")
+		case "TRANSP":
+			current.Skip = current.Skip || strings.EqualFold(prop.Value, "TRANSPARENT")
+		}
+		if err != nil {
+			currentErr = err
+		}
+	}
+
+	// Modified occurrences replace the instance of the master event they point at
+	overridden := make(map[string]bool)
+	for _, event := range events {
+		if event.RecurrenceID != nil {
+			overridden[event.UID+"|"+event.RecurrenceID.UTC().Format(icsTimeFormat)] = true
+		}
+	}
+
+	var busy []icsBusyTime
+	for _, event := range events {
+		duration := event.End.Sub(event.Start)
+		if duration <= 0 {
+			continue // Instant events do not block any time
+		}
+
+		starts := []time.Time{event.Start}
+		if event.RRule != "" && event.RecurrenceID == nil {
+			if rule, err := ParseRecurrenceRule(event.RRule); err == nil {
+				starts = rule.Occurrences(event.Start, from.Add(-duration), to)
+			}
+		}
+
+		for _, start := range starts {
+			end := start.Add(duration)
+			if !start.Before(to) || !end.After(from) {
+				continue
+			}
+			if event.RecurrenceID == nil && overridden[event.UID+"|"+start.UTC().Format(icsTimeFormat)] {
+				continue
+			}
+			excluded := false
+			for _, exdate := range event.ExDates {
+				if exdate.Equal(start) {
+					excluded = true
+					break
+				}
+			}
+			if excluded {
+				continue
+			}
+
+			busy = append(busy, icsBusyTime{UID: event.UID, Start: start, End: end})
+		}
+	}
+
+	return busy, nil
+}
+
+// unfoldICSLines joins folded continuation lines back into content lines
+func unfoldICSLines(content string) []string {
+	content = strings.ReplaceAll(content, "\r\n", "\n")
+
+	var lines []string
+	for _, line := range strings.Split(content, "\n") {
+		line = strings.TrimSuffix(line, "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		if line != "" {
+			lines = append(lines, line)
+		}
+	}
+
+	return lines
+}
+
+// parseICSProperty splits a content line into name, parameters and value, honoring quoted parameter values
+func parseICSProperty(line string) (icsProperty, bool) {
+	inQuotes := false
+	colon := -1
+	for i, r := range line {
+		if r == '"' {
+			inQuotes = !inQuotes
+		} else if r == ':' && !inQuotes {
+			colon = i
+			break
+		}
+	}
+	if colon <= 0 {
+		return icsProperty{}, false
+	}
+
+	parts := strings.Split(line[:colon], ";")
+	prop := icsProperty{
+		Name:   strings.ToUpper(strings.TrimSpace(parts[0])),
+		Params: make(map[string]string),
+		Value:  strings.TrimSpace(line[colon+1:]),
+	}
+	for _, param := range parts[1:] {
+		if kv := strings.SplitN(param, "=", 2); len(kv) == 2 {
+			prop.Params[strings.ToUpper(kv[0])] = strings.Trim(kv[1], `"`)
+		}
+	}
+
+	return prop, true
+}
+
+// parseICSTime parses a DATE or DATE-TIME value. UTC values end with Z, TZID selects the zone of
+// local values and floating values fall back to loc. Reports whether the value is an all-day date.
+func parseICSTime(prop icsProperty, loc *time.Location) (time.Time, bool, error) {
+	value := strings.TrimSpace(prop.Value)
+
+	if strings.EqualFold(prop.Params["VALUE"], "DATE") || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, loc)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date: %s", value)
+		}
+		return t, true, nil
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse(icsTimeFormat, value)
+		if err != nil {
+			return time.Time{}, false, fmt.Errorf("invalid date-time: %s", value)
+		}
+		return t, false, nil
+	}
+
+	zone := loc
+	if tzid := prop.Params["TZID"]; tzid != "" {
+		// Unknown zone names (e.g. Windows ones) are read in the calendar owner's timezone
+		if tzLoc, err := time.LoadLocation(tzid); err == nil {
+			zone = tzLoc
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, zone)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("invalid date-time: %s", value)
+	}
+
+	return t, false, nil
+}
+
+// parseICSDuration parses a positive duration such as PT1H30M, P1D or P2W
+func parseICSDuration(value string) (time.Duration, error) {
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "+")
+	if !strings.HasPrefix(value, "P") || len(value) < 3 {
+		return 0, fmt.Errorf("invalid duration: %s", value)
+	}
+
+	var total time.Duration
+	inTime := false
+	number := ""
+	for _, r := range value[1:] {
+		switch {
+		case r >= '0' && r <= '9':
+			number += string(r)
+		case r == 'T':
+			inTime = true
+		default:
+			n, err := strconv.Atoi(number)
+			if err != nil {
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+			number = ""
+
+			switch {
+			case r == 'W' && !inTime:
+				total += time.Duration(n) * 7 * 24 * time.Hour
+			case r == 'D' && !inTime:
+				total += time.Duration(n) * 24 * time.Hour
+			case r == 'H' && inTime:
+				total += time.Duration(n) * time.Hour
+			case r == 'M' && inTime:
+				total += time.Duration(n) * time.Minute
+			case r == 'S' && inTime:
+				total += time.Duration(n) * time.Second
+			default:
+				return 0, fmt.Errorf("invalid duration: %s", value)
+			}
+		}
+	}
+	if number != "" {
+		return 0, fmt.Errorf("invalid duration: %s", value)
+	}
+
+	return total, nil
+}
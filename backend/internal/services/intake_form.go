@@ -0,0 +1,332 @@
+package services
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+// intakeFieldTypes are the kinds of questions a form can ask
+var intakeFieldTypes = map[string]bool{"text": true, "choice": true, "checkbox": true, "file": true}
+
+// intakeFieldKey keeps answer keys stable and safe to use in exports
+var intakeFieldKey = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+const intakeFormColumns = `
+	f.id, f.company_id, f.name, f.description, f.fields, f.completion, f.is_active,
+	ARRAY(SELECT sif.service_id::text FROM service_intake_forms sif WHERE sif.form_id = f.id),
+	f.created_at, f.updated_at`
+
+// GetIntakeForms returns every intake form of the company
+func (s *ServiceService) GetIntakeForms(companyID string) ([]models.IntakeForm, error) {
+	return queryIntakeForms(s.db, `
+		WHERE f.company_id = $1
+		ORDER BY f.name
+	`, companyID)
+}
+
+// GetServiceIntakeForms returns the active forms customers fill in for a service
+func (s *ServiceService) GetServiceIntakeForms(serviceID string) ([]models.IntakeForm, error) {
+	return serviceIntakeForms(s.db, serviceID)
+}
+
+// CreateIntakeForm defines a questionnaire and attaches it to services of the company
+func (s *ServiceService) CreateIntakeForm(companyID string, req *models.IntakeFormRequest) (*models.IntakeForm, error) {
+	if err := validateIntakeForm(req); err != nil {
+		return nil, err
+	}
+	fieldsJSON, err := json.Marshal(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var formID string
+	err = tx.QueryRow(`
+		INSERT INTO intake_forms (company_id, name, description, fields, completion, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, companyID, req.Name, req.Description, fieldsJSON, req.Completion, isActive).Scan(&formID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create intake form: %w", err)
+	}
+
+	if err := setIntakeFormServices(tx, companyID, formID, req.ServiceIDs); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getIntakeForm(companyID, formID)
+}
+
+// UpdateIntakeForm changes a form and the services it is attached to; answers already given keep the questions they were asked
+func (s *ServiceService) UpdateIntakeForm(companyID, formID string, req *models.IntakeFormRequest) (*models.IntakeForm, error) {
+	existing, err := s.getIntakeForm(companyID, formID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateIntakeForm(req); err != nil {
+		return nil, err
+	}
+	fieldsJSON, err := json.Marshal(req.Fields)
+	if err != nil {
+		return nil, err
+	}
+
+	isActive := existing.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE intake_forms
+		SET name = $2, description = $3, fields = $4, completion = $5, is_active = $6, updated_at = NOW()
+		WHERE id = $1
+	`, formID, req.Name, req.Description, fieldsJSON, req.Completion, isActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update intake form: %w", err)
+	}
+
+	if err := setIntakeFormServices(tx, companyID, formID, req.ServiceIDs); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getIntakeForm(companyID, formID)
+}
+
+// DeleteIntakeForm removes a form; bookings keep their copy of the answers
+func (s *ServiceService) DeleteIntakeForm(companyID, formID string) error {
+	result, err := s.db.Exec("DELETE FROM intake_forms WHERE id = $1 AND company_id = $2", formID, companyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("intake form not found")
+	}
+
+	return nil
+}
+
+// Helper methods
+
+func (s *ServiceService) getIntakeForm(companyID, formID string) (*models.IntakeForm, error) {
+	forms, err := queryIntakeForms(s.db, `WHERE f.id = $1 AND f.company_id = $2`, formID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(forms) == 0 {
+		return nil, fmt.Errorf("intake form not found")
+	}
+
+	return &forms[0], nil
+}
+
+// setIntakeFormServices replaces the services a form is attached to; each must belong to the company
+func setIntakeFormServices(q sqlExecer, companyID, formID string, serviceIDs []string) error {
+	if _, err := q.Exec("DELETE FROM service_intake_forms WHERE form_id = $1", formID); err != nil {
+		return fmt.Errorf("failed to clear form services: %w", err)
+	}
+	if len(serviceIDs) == 0 {
+		return nil
+	}
+
+	result, err := q.Exec(`
+		INSERT INTO service_intake_forms (service_id, form_id)
+		SELECT id, $2 FROM services WHERE company_id = $1 AND id::text = ANY($3)
+		ON CONFLICT DO NOTHING
+	`, companyID, formID, pq.Array(serviceIDs))
+	if err != nil {
+		return fmt.Errorf("failed to attach form to services: %w", err)
+	}
+	unique := make(map[string]bool)
+	for _, id := range serviceIDs {
+		unique[id] = true
+	}
+	if rowsAffected, _ := result.RowsAffected(); int(rowsAffected) != len(unique) {
+		return fmt.Errorf("one or more services not found")
+	}
+
+	return nil
+}
+
+func validateIntakeForm(req *models.IntakeFormRequest) error {
+	if req.Completion == "" {
+		req.Completion = "before_visit"
+	}
+	if req.Completion != "at_booking" && req.Completion != "before_visit" {
+		return fmt.Errorf("completion must be at_booking or before_visit")
+	}
+	if len(req.Fields) == 0 {
+		return fmt.Errorf("a form needs at least one field")
+	}
+
+	keys := make(map[string]bool)
+	for i, field := range req.Fields {
+		if !intakeFieldKey.MatchString(field.Key) {
+			return fmt.Errorf("field %d: key must be lowercase letters, digits and underscores", i+1)
+		}
+		if keys[field.Key] {
+			return fmt.Errorf("field %d: key %s is used more than once", i+1, field.Key)
+		}
+		keys[field.Key] = true
+
+		if !intakeFieldTypes[field.Type] {
+			return fmt.Errorf("field %d: invalid type %s", i+1, field.Type)
+		}
+		if field.Type == "choice" && len(field.Options) == 0 {
+			return fmt.Errorf("field %d: a choice field needs options", i+1)
+		}
+		if field.Type != "choice" && (len(field.Options) > 0 || field.Multiple) {
+			return fmt.Errorf("field %d: only choice fields take options", i+1)
+		}
+	}
+
+	return nil
+}
+
+// validateIntakeAnswers checks answers against the form's fields: no unknown keys, required fields
+// answered, and each answer of its field's type. Required checkboxes must be ticked.
+func validateIntakeAnswers(fields []models.IntakeFormField, answers map[string]interface{}) error {
+	byKey := make(map[string]models.IntakeFormField)
+	for _, field := range fields {
+		byKey[field.Key] = field
+	}
+	for key := range answers {
+		if _, ok := byKey[key]; !ok {
+			return fmt.Errorf("unknown field %s", key)
+		}
+	}
+
+	for _, field := range fields {
+		answer, answered := answers[field.Key]
+		if answer == nil {
+			answered = false
+		}
+
+		switch field.Type {
+		case "text", "file":
+			value, ok := answer.(string)
+			if answered && !ok {
+				return fmt.Errorf("%s must be text", field.Label)
+			}
+			answered = answered && value != ""
+		case "checkbox":
+			value, ok := answer.(bool)
+			if answered && !ok {
+				return fmt.Errorf("%s must be true or false", field.Label)
+			}
+			answered = answered && value
+		case "choice":
+			if answered {
+				chosen, err := intakeChoices(field, answer)
+				if err != nil {
+					return err
+				}
+				answered = len(chosen) > 0
+			}
+		}
+
+		if field.Required && !answered {
+			return fmt.Errorf("%s is required", field.Label)
+		}
+	}
+
+	return nil
+}
+
+// intakeChoices returns the options chosen in a choice answer, a string or, for multiple choice, a list
+func intakeChoices(field models.IntakeFormField, answer interface{}) ([]string, error) {
+	var chosen []string
+	switch value := answer.(type) {
+	case string:
+		chosen = []string{value}
+	case []interface{}:
+		if !field.Multiple {
+			return nil, fmt.Errorf("%s takes a single option", field.Label)
+		}
+		for _, item := range value {
+			option, ok := item.(string)
+			if !ok {
+				return nil, fmt.Errorf("%s must be a list of options", field.Label)
+			}
+			chosen = append(chosen, option)
+		}
+	default:
+		return nil, fmt.Errorf("%s must be one of its options", field.Label)
+	}
+
+	valid := make(map[string]bool)
+	for _, option := range field.Options {
+		valid[option] = true
+	}
+	for _, option := range chosen {
+		if !valid[option] {
+			return nil, fmt.Errorf("%s has no option %s", field.Label, option)
+		}
+	}
+
+	return chosen, nil
+}
+
+func queryIntakeForms(q rowsQuerier, where string, args ...interface{}) ([]models.IntakeForm, error) {
+	rows, err := q.Query(`SELECT `+intakeFormColumns+` FROM intake_forms f `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get intake forms: %w", err)
+	}
+	defer rows.Close()
+
+	forms := []models.IntakeForm{}
+	for rows.Next() {
+		var form models.IntakeForm
+		var fields []byte
+		if err := rows.Scan(
+			&form.ID, &form.CompanyID, &form.Name, &form.Description, &fields, &form.Completion,
+			&form.IsActive, pq.Array(&form.ServiceIDs), &form.CreatedAt, &form.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(fields, &form.Fields); err != nil {
+			return nil, err
+		}
+		forms = append(forms, form)
+	}
+
+	return forms, nil
+}
+
+// serviceIntakeForms returns the active forms attached to a service
+func serviceIntakeForms(q rowsQuerier, serviceID string) ([]models.IntakeForm, error) {
+	return queryIntakeForms(q, `
+		JOIN service_intake_forms s ON s.form_id = f.id
+		WHERE s.service_id = $1 AND f.is_active = true
+		ORDER BY f.name
+	`, serviceID)
+}
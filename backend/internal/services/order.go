@@ -167,7 +167,7 @@ func (s *OrderService) CreateOrderFromTemplate(userID, templateID string) (strin
 			continue // Skip items that no longer exist
 		}
 
-		cartItem.TotalPrice = cartItem.UnitPrice * float64(cartItem.Quantity)
+		cartItem.TotalPrice = cartItem.UnitPrice.Mul(int64(cartItem.Quantity))
 
 		// Add to cart
 		err = cartService.AddItemToCart(cart.ID, cartItem)
@@ -0,0 +1,653 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/robfig/cron/v3"
+)
+
+// packagePaymentHoldHours is how long an unpaid package purchase waits for its payment
+const packagePaymentHoldHours = 24
+
+const servicePackageColumns = `
+	id, company_id, service_id, name, description, session_count, price, currency,
+	validity_days, is_active, created_at, updated_at`
+
+const packagePurchaseColumns = `
+	pp.id, pp.package_id, sp.name, pp.company_id, pp.service_id, pp.user_id, pp.pet_id,
+	pp.sessions_total, pp.sessions_remaining, pp.price, pp.status, pp.payment_intent_id,
+	pp.payment_id, pp.expires_at, pp.activated_at, pp.created_at, pp.updated_at`
+
+type PackageService struct {
+	db             *sql.DB
+	paymentService *PaymentService
+	cronScheduler  *cron.Cron
+}
+
+func NewPackageService(db *sql.DB, paymentService *PaymentService) *PackageService {
+	return &PackageService{
+		db:             db,
+		paymentService: paymentService,
+		cronScheduler:  cron.New(),
+	}
+}
+
+// SetPackageService injects the package service that redeems prepaid sessions when bookings complete
+func (s *BookingService) SetPackageService(packageService *PackageService) {
+	s.packageService = packageService
+}
+
+// StartPackageCron starts the package background jobs
+func (s *PackageService) StartPackageCron() {
+	// Settle unpaid purchases every minute
+	_, err := s.cronScheduler.AddFunc("* * * * *", s.ReleaseUnpaidPurchases)
+	if err != nil {
+		log.Printf("Error adding package payment cron job: %v", err)
+		return
+	}
+
+	// Expire balances once an hour
+	_, err = s.cronScheduler.AddFunc("0 * * * *", s.ExpirePackages)
+	if err != nil {
+		log.Printf("Error adding package expiry cron job: %v", err)
+		return
+	}
+
+	s.cronScheduler.Start()
+	log.Println("Package cron scheduler started")
+}
+
+// GetCompanyPackages returns every package of the company
+func (s *PackageService) GetCompanyPackages(companyID string) ([]models.ServicePackage, error) {
+	return s.queryPackages(`WHERE company_id = $1 ORDER BY created_at DESC`, companyID)
+}
+
+// GetActivePackages returns the packages customers can buy from a company
+func (s *PackageService) GetActivePackages(companyID string) ([]models.ServicePackage, error) {
+	return s.queryPackages(`WHERE company_id = $1 AND is_active = true ORDER BY price`, companyID)
+}
+
+// CreatePackage creates a prepaid bundle of sessions of one of the company's services
+func (s *PackageService) CreatePackage(companyID string, req *models.ServicePackageRequest) (*models.ServicePackage, error) {
+	if err := s.validatePackageRequest(companyID, req); err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = "usd"
+	}
+
+	var packageID string
+	err := s.db.QueryRow(`
+		INSERT INTO service_packages (company_id, service_id, name, description, session_count,
+									  price, currency, validity_days, is_active)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`, companyID, req.ServiceID, req.Name, req.Description, req.SessionCount,
+		models.MoneyFromDecimal(req.Price, currency), currency, req.ValidityDays, isActive).Scan(&packageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create package: %w", err)
+	}
+
+	return s.getPackage(packageID)
+}
+
+// UpdatePackage changes a package. Balances already sold keep the terms they were bought with.
+func (s *PackageService) UpdatePackage(companyID, packageID string, req *models.ServicePackageRequest) (*models.ServicePackage, error) {
+	existing, err := s.getPackage(packageID)
+	if err != nil || existing.CompanyID != companyID {
+		return nil, fmt.Errorf("package not found")
+	}
+	if err := s.validatePackageRequest(companyID, req); err != nil {
+		return nil, err
+	}
+
+	isActive := existing.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+	currency := strings.ToLower(req.Currency)
+	if currency == "" {
+		currency = existing.Currency
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE service_packages
+		SET service_id = $2, name = $3, description = $4, session_count = $5, price = $6,
+			currency = $7, validity_days = $8, is_active = $9, updated_at = NOW()
+		WHERE id = $1
+	`, packageID, req.ServiceID, req.Name, req.Description, req.SessionCount,
+		models.MoneyFromDecimal(req.Price, currency), currency, req.ValidityDays, isActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update package: %w", err)
+	}
+
+	return s.getPackage(packageID)
+}
+
+// PurchasePackage buys a package for the customer. Paid packages are credited once the payment
+// succeeds; free ones right away.
+func (s *PackageService) PurchasePackage(userID, packageID string, req *models.PurchasePackageRequest) (*models.PackagePurchase, error) {
+	pkg, err := s.getPackage(packageID)
+	if err != nil || !pkg.IsActive {
+		return nil, fmt.Errorf("package not found")
+	}
+
+	if req.PetID != nil {
+		var petOwnerID string
+		if err := s.db.QueryRow("SELECT user_id FROM pets WHERE id = $1", *req.PetID).Scan(&petOwnerID); err != nil {
+			return nil, fmt.Errorf("pet not found")
+		}
+		if petOwnerID != userID {
+			return nil, fmt.Errorf("pet does not belong to user")
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var purchaseID string
+	err = tx.QueryRow(`
+		INSERT INTO package_purchases (package_id, company_id, service_id, user_id, pet_id,
+									   sessions_total, sessions_remaining, price, status)
+		VALUES ($1, $2, $3, $4, $5, $6, 0, $7, 'pending_payment')
+		RETURNING id
+	`, pkg.ID, pkg.CompanyID, pkg.ServiceID, userID, req.PetID, pkg.SessionCount, pkg.Price).Scan(&purchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to purchase package: %w", err)
+	}
+
+	paid := pkg.Price > 0 && s.paymentService != nil
+	if !paid {
+		if err := s.activatePurchase(tx, purchaseID, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	purchase, err := s.getPurchase(purchaseID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Request the package payment once the purchase is committed
+	if paid {
+		intent, err := s.paymentService.CreatePaymentIntent(&PaymentRequest{
+			UserID:      userID,
+			CompanyID:   pkg.CompanyID,
+			Amount:      pkg.Price,
+			Currency:    pkg.Currency,
+			Description: fmt.Sprintf("Package: %s", pkg.Name),
+			Metadata: map[string]interface{}{
+				"type":        "service_package",
+				"package_id":  pkg.ID,
+				"purchase_id": purchaseID,
+			},
+		})
+		if err != nil {
+			s.db.Exec("UPDATE package_purchases SET status = 'cancelled', updated_at = NOW() WHERE id = $1", purchaseID)
+			return nil, fmt.Errorf("failed to request payment: %w", err)
+		}
+
+		_, err = s.db.Exec("UPDATE package_purchases SET payment_intent_id = $2 WHERE id = $1", purchaseID, intent.PaymentIntentID)
+		if err != nil {
+			return nil, err
+		}
+		purchase.PaymentIntentID = &intent.PaymentIntentID
+		purchase.ClientSecret = intent.ClientSecret
+	}
+
+	return purchase, nil
+}
+
+// ConfirmPackagePayment credits the sessions of a purchase once its payment has succeeded
+func (s *PackageService) ConfirmPackagePayment(userID, purchaseID string) (*models.PackagePurchase, error) {
+	purchase, err := s.getPurchase(purchaseID)
+	if err != nil || purchase.UserID != userID {
+		return nil, fmt.Errorf("package purchase not found")
+	}
+	if purchase.Status != "pending_payment" {
+		return nil, fmt.Errorf("package purchase is not awaiting payment")
+	}
+
+	paymentID, status, err := s.purchasePayment(purchase)
+	if err != nil {
+		return nil, err
+	}
+	if status != "succeeded" {
+		return nil, ErrDepositNotPaid
+	}
+
+	if err := s.confirmPaidPurchase(purchase.ID, paymentID); err != nil {
+		return nil, err
+	}
+
+	return s.getPurchase(purchaseID)
+}
+
+// ReleaseUnpaidPurchases credits paid purchases and cancels those that were never paid
+func (s *PackageService) ReleaseUnpaidPurchases() {
+	purchases, err := s.queryPurchases(`WHERE pp.status = 'pending_payment'`)
+	if err != nil {
+		log.Printf("Error getting unpaid package purchases: %v", err)
+		return
+	}
+
+	for i := range purchases {
+		purchase := &purchases[i]
+		paymentID, status, err := s.purchasePayment(purchase)
+		if err == nil && status == "succeeded" {
+			if err := s.confirmPaidPurchase(purchase.ID, paymentID); err != nil {
+				log.Printf("Error crediting package purchase %s: %v", purchase.ID, err)
+			}
+			continue
+		}
+
+		if time.Since(purchase.CreatedAt) < packagePaymentHoldHours*time.Hour {
+			continue
+		}
+
+		_, err = s.db.Exec(`
+			UPDATE package_purchases SET status = 'cancelled', updated_at = NOW()
+			WHERE id = $1 AND status = 'pending_payment'
+		`, purchase.ID)
+		if err != nil {
+			log.Printf("Error cancelling package purchase %s: %v", purchase.ID, err)
+			continue
+		}
+		if s.paymentService != nil && paymentID != "" && status == "pending" {
+			if err := s.paymentService.UpdatePaymentStatus(paymentID, "canceled"); err != nil {
+				log.Printf("Error cancelling package payment %s: %v", paymentID, err)
+			}
+		}
+	}
+}
+
+// ExpirePackages writes off the sessions left on balances past their expiry date
+func (s *PackageService) ExpirePackages() {
+	rows, err := s.db.Query(`
+		SELECT id FROM package_purchases
+		WHERE status = 'active' AND expires_at IS NOT NULL AND expires_at <= NOW()
+	`)
+	if err != nil {
+		log.Printf("Error getting expired packages: %v", err)
+		return
+	}
+
+	var purchaseIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			purchaseIDs = append(purchaseIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range purchaseIDs {
+		if err := s.expirePurchase(id); err != nil {
+			log.Printf("Error expiring package purchase %s: %v", id, err)
+		}
+	}
+}
+
+// GetUserPackages returns the customer's package balances
+func (s *PackageService) GetUserPackages(userID string) ([]models.PackagePurchase, error) {
+	return s.queryPurchases(`WHERE pp.user_id = $1 AND pp.status <> 'cancelled' ORDER BY pp.created_at DESC`, userID)
+}
+
+// GetCompanyPurchases returns the package balances sold by a company, optionally for one customer
+func (s *PackageService) GetCompanyPurchases(companyID, userID string) ([]models.PackagePurchase, error) {
+	return s.queryPurchases(`
+		WHERE pp.company_id = $1 AND ($2 = '' OR pp.user_id::text = $2) AND pp.status <> 'cancelled'
+		ORDER BY pp.created_at DESC
+	`, companyID, userID)
+}
+
+// GetPurchase returns a package balance
+func (s *PackageService) GetPurchase(purchaseID string) (*models.PackagePurchase, error) {
+	return s.getPurchase(purchaseID)
+}
+
+// GetPurchaseLedger returns the credits and debits of a package balance, oldest first
+func (s *PackageService) GetPurchaseLedger(purchaseID string) ([]models.PackageLedgerEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT id, purchase_id, booking_id, entry_type, sessions, balance_after, note, created_by, created_at
+		FROM package_ledger_entries
+		WHERE purchase_id = $1
+		ORDER BY created_at, id
+	`, purchaseID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package ledger: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.PackageLedgerEntry{}
+	for rows.Next() {
+		var entry models.PackageLedgerEntry
+		if err := rows.Scan(
+			&entry.ID, &entry.PurchaseID, &entry.BookingID, &entry.EntryType, &entry.Sessions,
+			&entry.BalanceAfter, &entry.Note, &entry.CreatedBy, &entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// AdjustPackageBalance credits or debits sessions on a balance by hand, e.g. a goodwill session
+func (s *PackageService) AdjustPackageBalance(companyID, purchaseID string, createdBy *string, req *models.PackageAdjustmentRequest) (*models.PackagePurchase, error) {
+	if req.Sessions == 0 {
+		return nil, fmt.Errorf("sessions cannot be zero")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var status string
+	var remaining int
+	err = tx.QueryRow(`
+		SELECT status, sessions_remaining FROM package_purchases
+		WHERE id = $1 AND company_id = $2
+		FOR UPDATE
+	`, purchaseID, companyID).Scan(&status, &remaining)
+	if err != nil {
+		return nil, fmt.Errorf("package purchase not found")
+	}
+	if status != "active" && status != "exhausted" {
+		return nil, fmt.Errorf("package with status %s cannot be adjusted", status)
+	}
+
+	balance := remaining + req.Sessions
+	if balance < 0 {
+		return nil, fmt.Errorf("only %d sessions are left", remaining)
+	}
+
+	if err := s.setBalance(tx, purchaseID, balance); err != nil {
+		return nil, err
+	}
+
+	var note *string
+	if req.Note != "" {
+		note = &req.Note
+	}
+	if err := addPackageLedgerEntry(tx, purchaseID, nil, "adjustment", req.Sessions, balance, note, createdBy); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getPurchase(purchaseID)
+}
+
+// Helper methods
+
+// redeemBookingSession debits one session for a completed booking from the customer's matching balance,
+// the one expiring first. Bookings without a matching balance are left alone.
+func (s *PackageService) redeemBookingSession(tx *sql.Tx, booking *models.Booking) error {
+	var redeemed bool
+	err := tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM package_ledger_entries WHERE booking_id = $1 AND entry_type = 'redemption')
+	`, booking.ID).Scan(&redeemed)
+	if err != nil || redeemed {
+		return err
+	}
+
+	var purchaseID string
+	var remaining int
+	err = tx.QueryRow(`
+		SELECT id, sessions_remaining FROM package_purchases
+		WHERE user_id = $1 AND company_id = $2 AND service_id = $3
+		  AND (pet_id IS NULL OR pet_id = $4)
+		  AND status = 'active' AND sessions_remaining > 0
+		  AND (expires_at IS NULL OR expires_at > $5)
+		ORDER BY pet_id NULLS LAST, expires_at NULLS LAST, activated_at
+		LIMIT 1
+		FOR UPDATE
+	`, booking.UserID, booking.CompanyID, booking.ServiceID, booking.PetID, booking.DateTime).Scan(&purchaseID, &remaining)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to find package balance: %w", err)
+	}
+
+	balance := remaining - 1
+	if err := s.setBalance(tx, purchaseID, balance); err != nil {
+		return err
+	}
+
+	return addPackageLedgerEntry(tx, purchaseID, &booking.ID, "redemption", -1, balance, nil, nil)
+}
+
+func (s *PackageService) queryPackages(where string, args ...interface{}) ([]models.ServicePackage, error) {
+	rows, err := s.db.Query(`SELECT `+servicePackageColumns+` FROM service_packages `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get packages: %w", err)
+	}
+	defer rows.Close()
+
+	packages := []models.ServicePackage{}
+	for rows.Next() {
+		var pkg models.ServicePackage
+		if err := rows.Scan(
+			&pkg.ID, &pkg.CompanyID, &pkg.ServiceID, &pkg.Name, &pkg.Description, &pkg.SessionCount,
+			&pkg.Price, &pkg.Currency, &pkg.ValidityDays, &pkg.IsActive, &pkg.CreatedAt, &pkg.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		packages = append(packages, pkg)
+	}
+
+	return packages, nil
+}
+
+func (s *PackageService) getPackage(packageID string) (*models.ServicePackage, error) {
+	packages, err := s.queryPackages(`WHERE id = $1`, packageID)
+	if err != nil {
+		return nil, err
+	}
+	if len(packages) == 0 {
+		return nil, fmt.Errorf("package not found")
+	}
+
+	return &packages[0], nil
+}
+
+func (s *PackageService) validatePackageRequest(companyID string, req *models.ServicePackageRequest) error {
+	if req.SessionCount <= 0 {
+		return fmt.Errorf("session_count must be positive")
+	}
+	if req.Price < 0 {
+		return fmt.Errorf("price cannot be negative")
+	}
+	if req.ValidityDays != nil && *req.ValidityDays <= 0 {
+		return fmt.Errorf("validity_days must be positive")
+	}
+
+	var serviceCompanyID string
+	err := s.db.QueryRow("SELECT company_id FROM services WHERE id = $1", req.ServiceID).Scan(&serviceCompanyID)
+	if err != nil || serviceCompanyID != companyID {
+		return fmt.Errorf("service not found")
+	}
+
+	return nil
+}
+
+func (s *PackageService) queryPurchases(where string, args ...interface{}) ([]models.PackagePurchase, error) {
+	rows, err := s.db.Query(`
+		SELECT `+packagePurchaseColumns+`
+		FROM package_purchases pp
+		JOIN service_packages sp ON sp.id = pp.package_id
+		`+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get package purchases: %w", err)
+	}
+	defer rows.Close()
+
+	purchases := []models.PackagePurchase{}
+	for rows.Next() {
+		var p models.PackagePurchase
+		if err := rows.Scan(
+			&p.ID, &p.PackageID, &p.PackageName, &p.CompanyID, &p.ServiceID, &p.UserID, &p.PetID,
+			&p.SessionsTotal, &p.SessionsRemaining, &p.Price, &p.Status, &p.PaymentIntentID,
+			&p.PaymentID, &p.ExpiresAt, &p.ActivatedAt, &p.CreatedAt, &p.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		purchases = append(purchases, p)
+	}
+
+	return purchases, nil
+}
+
+func (s *PackageService) getPurchase(purchaseID string) (*models.PackagePurchase, error) {
+	purchases, err := s.queryPurchases(`WHERE pp.id = $1`, purchaseID)
+	if err != nil {
+		return nil, err
+	}
+	if len(purchases) == 0 {
+		return nil, fmt.Errorf("package purchase not found")
+	}
+
+	return &purchases[0], nil
+}
+
+// activatePurchase credits the sessions of a purchase and starts its validity period
+func (s *PackageService) activatePurchase(tx *sql.Tx, purchaseID string, paymentID *string) error {
+	var sessions int
+	err := tx.QueryRow(`
+		UPDATE package_purchases pp
+		SET status = 'active', sessions_remaining = pp.sessions_total, payment_id = $2,
+			activated_at = NOW(),
+			expires_at = CASE WHEN sp.validity_days IS NULL THEN NULL
+							  ELSE NOW() + (sp.validity_days * INTERVAL '1 day') END,
+			updated_at = NOW()
+		FROM service_packages sp
+		WHERE pp.id = $1 AND sp.id = pp.package_id AND pp.status = 'pending_payment'
+		RETURNING pp.sessions_total
+	`, purchaseID, paymentID).Scan(&sessions)
+	if err == sql.ErrNoRows {
+		return fmt.Errorf("package purchase is not awaiting payment")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to activate package: %w", err)
+	}
+
+	return addPackageLedgerEntry(tx, purchaseID, nil, "purchase", sessions, sessions, nil, nil)
+}
+
+func (s *PackageService) confirmPaidPurchase(purchaseID, paymentID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := s.activatePurchase(tx, purchaseID, &paymentID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// purchasePayment returns the payment row and status behind a purchase
+func (s *PackageService) purchasePayment(purchase *models.PackagePurchase) (string, string, error) {
+	if purchase.PaymentIntentID == nil {
+		return "", "", fmt.Errorf("package purchase has no payment")
+	}
+
+	var paymentID, status string
+	err := s.db.QueryRow(`
+		SELECT id, status FROM payments WHERE stripe_payment_intent_id = $1
+	`, *purchase.PaymentIntentID).Scan(&paymentID, &status)
+	if err != nil {
+		return "", "", fmt.Errorf("package payment not found")
+	}
+
+	return paymentID, status, nil
+}
+
+func (s *PackageService) expirePurchase(purchaseID string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var remaining int
+	err = tx.QueryRow(`
+		SELECT sessions_remaining FROM package_purchases
+		WHERE id = $1 AND status = 'active'
+		FOR UPDATE
+	`, purchaseID).Scan(&remaining)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(`
+		UPDATE package_purchases SET status = 'expired', sessions_remaining = 0, updated_at = NOW()
+		WHERE id = $1
+	`, purchaseID)
+	if err != nil {
+		return err
+	}
+
+	if remaining > 0 {
+		if err := addPackageLedgerEntry(tx, purchaseID, nil, "expiry", -remaining, 0, nil, nil); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+// setBalance stores a new session balance, moving the purchase between active and exhausted
+func (s *PackageService) setBalance(tx *sql.Tx, purchaseID string, balance int) error {
+	_, err := tx.Exec(`
+		UPDATE package_purchases
+		SET sessions_remaining = $2,
+			status = CASE WHEN $2 = 0 THEN 'exhausted' ELSE 'active' END,
+			updated_at = NOW()
+		WHERE id = $1
+	`, purchaseID, balance)
+	if err != nil {
+		return fmt.Errorf("failed to update package balance: %w", err)
+	}
+
+	return nil
+}
+
+func addPackageLedgerEntry(tx *sql.Tx, purchaseID string, bookingID *string, entryType string, sessions, balanceAfter int, note, createdBy *string) error {
+	_, err := tx.Exec(`
+		INSERT INTO package_ledger_entries (purchase_id, booking_id, entry_type, sessions, balance_after, note, created_by)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, purchaseID, bookingID, entryType, sessions, balanceAfter, note, createdBy)
+	if err != nil {
+		return fmt.Errorf("failed to record package ledger entry: %w", err)
+	}
+
+	return nil
+}
@@ -2,23 +2,45 @@ package services
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
 	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
 )
 
+// ErrPaymentAlreadyMade is returned when a payment to cancel was made in the meantime
+var ErrPaymentAlreadyMade = errors.New("payment was already made")
+
 type PaymentService struct {
-	db              *sql.DB
+	db *sql.DB
+
+	// Settings are reloaded by concurrent requests
+	settingsMu      sync.RWMutex
 	paymentSettings *models.PaymentSettings
 	stripeSecretKey string
 	webhookSecret   string
+
+	// provider takes card payments; nil while payments are taken offline
+	providerMu       sync.Mutex
+	provider         PaymentProvider
+	providerKey      string
+	providerOverride PaymentProvider
+
+	// bookingService confirms bookings whose deposit a webhook reports paid
+	bookingService *BookingService
+
+	cronScheduler *cron.Cron
 }
 
 func NewPaymentService(db *sql.DB) *PaymentService {
 	service := &PaymentService{
-		db: db,
+		db:            db,
+		cronScheduler: cron.New(),
 	}
 	// Load payment settings on initialization
 	service.loadPaymentSettings()
@@ -31,13 +53,13 @@ func (s *PaymentService) loadPaymentSettings() error {
 	err := s.db.QueryRow(`
 		SELECT id, stripe_enabled, commission_enabled, commission_percentage, 
 			   stripe_publishable_key, stripe_secret_key, stripe_webhook_secret, 
-			   created_at, updated_at
+			   payment_provider, created_at, updated_at
 		FROM payment_settings LIMIT 1
 	`).Scan(
 		&settings.ID, &settings.StripeEnabled, &settings.CommissionEnabled,
 		&settings.CommissionPercentage, &settings.StripePublishableKey,
 		&settings.StripeSecretKey, &settings.StripeWebhookSecret,
-		&settings.CreatedAt, &settings.UpdatedAt,
+		&settings.PaymentProvider, &settings.CreatedAt, &settings.UpdatedAt,
 	)
 
 	if err != nil && err != sql.ErrNoRows {
@@ -51,6 +73,7 @@ func (s *PaymentService) loadPaymentSettings() error {
 			StripeEnabled:        false, // Disabled by default until keys are set
 			CommissionEnabled:    true,  // Commission enabled by default
 			CommissionPercentage: 10.0,  // 10% default commission
+			PaymentProvider:      PaymentProviderStripe,
 			CreatedAt:            time.Now(),
 			UpdatedAt:            time.Now(),
 		}
@@ -58,12 +81,12 @@ func (s *PaymentService) loadPaymentSettings() error {
 		_, err = s.db.Exec(`
 			INSERT INTO payment_settings (id, stripe_enabled, commission_enabled, commission_percentage, 
 										 stripe_publishable_key, stripe_secret_key, stripe_webhook_secret, 
-										 created_at, updated_at)
-			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+										 payment_provider, created_at, updated_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
 		`, defaultSettings.ID, defaultSettings.StripeEnabled, defaultSettings.CommissionEnabled,
 			defaultSettings.CommissionPercentage, defaultSettings.StripePublishableKey,
 			defaultSettings.StripeSecretKey, defaultSettings.StripeWebhookSecret,
-			defaultSettings.CreatedAt, defaultSettings.UpdatedAt)
+			defaultSettings.PaymentProvider, defaultSettings.CreatedAt, defaultSettings.UpdatedAt)
 
 		if err != nil {
 			return err
@@ -71,13 +94,82 @@ func (s *PaymentService) loadPaymentSettings() error {
 		settings = defaultSettings
 	}
 
+	s.settingsMu.Lock()
 	s.paymentSettings = settings
 	s.stripeSecretKey = settings.StripeSecretKey
 	s.webhookSecret = settings.StripeWebhookSecret
+	s.settingsMu.Unlock()
+
+	return s.selectProvider(settings)
+}
+
+// currentSettings returns the settings loaded last
+func (s *PaymentService) currentSettings() *models.PaymentSettings {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+
+	return s.paymentSettings
+}
+
+// currentWebhookSecret returns the webhook signing secret loaded last
+func (s *PaymentService) currentWebhookSecret() string {
+	s.settingsMu.RLock()
+	defer s.settingsMu.RUnlock()
+
+	return s.webhookSecret
+}
+
+// SetBookingService sets the booking service that webhooks confirm paid deposits through
+func (s *PaymentService) SetBookingService(bookingService *BookingService) {
+	s.bookingService = bookingService
+}
+
+// SetPaymentProvider takes payments through the given provider whatever the settings select,
+// e.g. a FakePaymentProvider to run the payment flow in process
+func (s *PaymentService) SetPaymentProvider(provider PaymentProvider) {
+	s.providerMu.Lock()
+	defer s.providerMu.Unlock()
+
+	s.providerOverride = provider
+	s.provider = provider
+}
+
+// selectProvider switches to the provider the settings select. An unchanged selection keeps the
+// current provider, so the fake provider's payments survive settings reloads.
+func (s *PaymentService) selectProvider(settings *models.PaymentSettings) error {
+	s.providerMu.Lock()
+	defer s.providerMu.Unlock()
+
+	if s.providerOverride != nil {
+		return nil
+	}
+
+	key := settings.PaymentProvider
+	if key != PaymentProviderFake {
+		key = fmt.Sprintf("%s:%t:%s", settings.PaymentProvider, settings.StripeEnabled, settings.StripeSecretKey)
+	}
+	if key == s.providerKey {
+		return nil
+	}
+
+	provider, err := newPaymentProvider(settings.PaymentProvider, settings.StripeEnabled, settings.StripeSecretKey)
+	if err != nil {
+		return err
+	}
+	s.provider = provider
+	s.providerKey = key
 
 	return nil
 }
 
+// paymentProvider returns the active provider, nil while payments are taken offline
+func (s *PaymentService) paymentProvider() PaymentProvider {
+	s.providerMu.Lock()
+	defer s.providerMu.Unlock()
+
+	return s.provider
+}
+
 type PaymentRequest struct {
 	UserID      string                 `json:"user_id" binding:"required"`
 	CompanyID   string                 `json:"company_id" binding:"required"`
@@ -87,6 +179,9 @@ type PaymentRequest struct {
 	Currency    string                 `json:"currency" binding:"required"`
 	Description string                 `json:"description"`
 	Metadata    map[string]interface{} `json:"metadata"`
+
+	// CaptureLater only authorizes the card; the company captures the payment afterwards
+	CaptureLater bool `json:"capture_later"`
 }
 
 type PaymentIntentResponse struct {
@@ -105,6 +200,20 @@ type RefundRequest struct {
 	Reason    string  `json:"reason"`
 }
 
+type SetupIntentResponse struct {
+	SetupIntentID string `json:"setup_intent_id"`
+	ClientSecret  string `json:"client_secret"`
+	Status        string `json:"status"`
+	OfflineMode   bool   `json:"offline_mode"`
+}
+
+type SubscriptionResponse struct {
+	SubscriptionID   string     `json:"subscription_id"`
+	Status           string     `json:"status"`
+	CurrentPeriodEnd *time.Time `json:"current_period_end"`
+	OfflineMode      bool       `json:"offline_mode"`
+}
+
 // CreatePaymentIntent creates a payment intent with commission and escrow logic
 func (s *PaymentService) CreatePaymentIntent(req *PaymentRequest) (*PaymentIntentResponse, error) {
 	// Reload settings to get latest configuration
@@ -112,17 +221,13 @@ func (s *PaymentService) CreatePaymentIntent(req *PaymentRequest) (*PaymentInten
 		return nil, err
 	}
 
-	// Calculate commission and amounts
-	var commissionAmount, platformAmount, companyAmount float64
-	if s.paymentSettings.CommissionEnabled {
-		commissionAmount = req.Amount * (s.paymentSettings.CommissionPercentage / 100.0)
-		platformAmount = req.Amount                   // Full amount goes to platform initially
-		companyAmount = req.Amount - commissionAmount // Amount to transfer to company later
-	} else {
-		commissionAmount = 0
-		platformAmount = req.Amount
-		companyAmount = req.Amount
+	// Calculate commission and amounts in minor units, so that commission and company amount add up exactly
+	amount := models.MoneyFromDecimal(req.Amount, req.Currency)
+	commissionAmount, companyAmount := models.NewMoney(0, req.Currency), amount
+	if settings := s.currentSettings(); settings.CommissionEnabled {
+		commissionAmount, companyAmount = amount.Split(settings.CommissionPercentage)
 	}
+	platformAmount := amount // Full amount goes to platform initially; the company amount is transferred later
 
 	// Create payment record
 	payment := &models.Payment{
@@ -131,8 +236,8 @@ func (s *PaymentService) CreatePaymentIntent(req *PaymentRequest) (*PaymentInten
 		CompanyID:             &req.CompanyID,
 		BookingID:             req.BookingID,
 		OrderID:               req.OrderID,
-		StripePaymentIntentID: uuid.New().String(), // Placeholder for now
-		Amount:                req.Amount,
+		StripePaymentIntentID: uuid.New().String(), // Reference of offline payments
+		Amount:                amount,
 		Currency:              req.Currency,
 		Status:                "pending",
 		CommissionAmount:      commissionAmount,
@@ -143,41 +248,63 @@ func (s *PaymentService) CreatePaymentIntent(req *PaymentRequest) (*PaymentInten
 		UpdatedAt:             time.Now(),
 	}
 
+	response := &PaymentIntentResponse{
+		Status:   payment.Status,
+		Amount:   payment.Amount.Amount,
+		Currency: payment.Currency,
+	}
+
+	var providerName *string
+	if provider := s.paymentProvider(); provider != nil {
+		customerID, err := s.providerCustomer(provider, req.UserID, true)
+		if err != nil {
+			return nil, err
+		}
+
+		intent, err := provider.CreatePaymentIntent(ProviderIntentParams{
+			Amount:        payment.Amount.Amount,
+			Currency:      payment.Currency,
+			CustomerID:    customerID,
+			Description:   req.Description,
+			Metadata:      intentMetadata(payment.ID, req.Metadata),
+			ManualCapture: req.CaptureLater,
+			// The payment ID is new for every payment, so only a repeat of this request is deduplicated
+			IdempotencyKey: "payment:" + payment.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create payment: %w", err)
+		}
+
+		name := provider.Name()
+		providerName = &name
+		payment.StripePaymentIntentID = intent.ID
+		response.ClientSecret = intent.ClientSecret
+		response.Status = intent.Status
+		response.OfflineMode = false
+	} else {
+		// Offline mode
+		response.ClientSecret = ""
+		response.OfflineMode = true
+		response.OfflineMessage = "Онлайн-оплата временно недоступна. Пожалуйста, произведите оплату наличными или переводом. После оплаты свяжитесь с нами для подтверждения."
+	}
+	response.PaymentIntentID = payment.StripePaymentIntentID
+
 	// Store payment in database
 	_, err := s.db.Exec(`
 		INSERT INTO payments (id, user_id, company_id, booking_id, order_id, stripe_payment_intent_id,
 							 amount, currency, status, commission_amount, platform_amount, company_amount,
-							 payment_method_type, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15)
+							 payment_method_type, provider, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16)
 	`, payment.ID, payment.UserID, payment.CompanyID, payment.BookingID,
 		payment.OrderID, payment.StripePaymentIntentID, payment.Amount,
 		payment.Currency, payment.Status, payment.CommissionAmount,
 		payment.PlatformAmount, payment.CompanyAmount, payment.PaymentMethodType,
-		payment.CreatedAt, payment.UpdatedAt)
+		providerName, payment.CreatedAt, payment.UpdatedAt)
 
 	if err != nil {
 		return nil, err
 	}
 
-	response := &PaymentIntentResponse{
-		PaymentIntentID: payment.StripePaymentIntentID,
-		Status:          payment.Status,
-		Amount:          int64(payment.Amount * 100),
-		Currency:        payment.Currency,
-	}
-
-	// Check if Stripe is enabled
-	if s.paymentSettings.StripeEnabled && s.stripeSecretKey != "" {
-		// TODO: Create actual Stripe payment intent when SDK is available
-		response.ClientSecret = "placeholder_client_secret"
-		response.OfflineMode = false
-	} else {
-		// Offline mode
-		response.ClientSecret = ""
-		response.OfflineMode = true
-		response.OfflineMessage = "Онлайн-оплата временно недоступна. Пожалуйста, произведите оплату наличными или переводом. После оплаты свяжитесь с нами для подтверждения."
-	}
-
 	return response, nil
 }
 
@@ -206,25 +333,22 @@ func (s *PaymentService) TransferToCompany(paymentID string, reason string) erro
 		return fmt.Errorf("payment already transferred to company")
 	}
 
-	// Check if payment is succeeded
-	if payment.Status != "succeeded" {
+	// Check if payment is succeeded; what was refunded before stays out of the transfer
+	if payment.Status != "succeeded" && payment.Status != "partially_refunded" {
 		return fmt.Errorf("cannot transfer payment with status: %s", payment.Status)
 	}
 
-	// For now, just mark as transferred (TODO: implement actual Stripe transfer)
-	now := time.Now()
-	_, err = s.db.Exec(`
-		UPDATE payments SET transferred_at = $2, updated_at = $3 WHERE id = $1
-	`, paymentID, now, now)
-
+	tx, err := s.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	// TODO: When Stripe is enabled, implement actual transfer to company's connected account
-	// Example: stripe.Transfer.New(&stripe.TransferParams{...})
+	if err := releasePayment(tx, paymentID); err != nil {
+		return err
+	}
 
-	return nil
+	return tx.Commit()
 }
 
 // GetPaymentSettings returns current payment settings
@@ -232,7 +356,7 @@ func (s *PaymentService) GetPaymentSettings() (*models.PaymentSettings, error) {
 	if err := s.loadPaymentSettings(); err != nil {
 		return nil, err
 	}
-	return s.paymentSettings, nil
+	return s.currentSettings(), nil
 }
 
 // UpdatePaymentSettings updates payment settings (admin only)
@@ -263,72 +387,161 @@ func (s *PaymentService) UpdatePaymentSettings(settings *models.PaymentSettings)
 
 // MarkServiceCompleted marks a booking/order as completed and triggers payment transfer
 func (s *PaymentService) MarkServiceCompleted(bookingID, orderID *string, reason string) error {
-	var paymentID string
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	released, err := releaseCompletedPayments(tx, bookingID, orderID)
+	if err != nil {
+		return err
+	}
+	if released == 0 {
+		return fmt.Errorf("no transferable payment found")
+	}
+
+	return tx.Commit()
+}
+
+// releaseCompletedPayments releases, within tx, the payments of a completed booking or order that are
+// still held for it, and returns how many it released. What was refunded before stays out of the transfer.
+func releaseCompletedPayments(tx *sql.Tx, bookingID, orderID *string) (int, error) {
 	var query string
 	var args []interface{}
 
 	if bookingID != nil {
-		query = "SELECT id FROM payments WHERE booking_id = $1 AND status = 'succeeded' AND transferred_at IS NULL"
+		query = "SELECT id FROM payments WHERE booking_id = $1 AND status IN ('succeeded', 'partially_refunded') AND transferred_at IS NULL FOR UPDATE"
 		args = []interface{}{*bookingID}
 	} else if orderID != nil {
-		query = "SELECT id FROM payments WHERE order_id = $1 AND status = 'succeeded' AND transferred_at IS NULL"
+		query = "SELECT id FROM payments WHERE order_id = $1 AND status IN ('succeeded', 'partially_refunded') AND transferred_at IS NULL FOR UPDATE"
 		args = []interface{}{*orderID}
 	} else {
-		return fmt.Errorf("either booking_id or order_id must be provided")
+		return 0, fmt.Errorf("either booking_id or order_id must be provided")
 	}
 
-	err := s.db.QueryRow(query, args...).Scan(&paymentID)
+	rows, err := tx.Query(query, args...)
 	if err != nil {
-		if err == sql.ErrNoRows {
-			return fmt.Errorf("no transferable payment found")
+		return 0, err
+	}
+	var paymentIDs []string
+	for rows.Next() {
+		var paymentID string
+		if err := rows.Scan(&paymentID); err != nil {
+			rows.Close()
+			return 0, err
 		}
-		return err
+		paymentIDs = append(paymentIDs, paymentID)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, err
 	}
 
-	return s.TransferToCompany(paymentID, reason)
+	for _, paymentID := range paymentIDs {
+		if err := releasePayment(tx, paymentID); err != nil {
+			return 0, err
+		}
+	}
+
+	return len(paymentIDs), nil
 }
 
-// HandleWebhook processes payment webhooks (placeholder implementation)
-func (s *PaymentService) HandleWebhook(payload []byte, sigHeader string) error {
-	// TODO: Implement Stripe webhook handling when SDK is available
-	return fmt.Errorf("Stripe webhook functionality is currently disabled")
+// releasePayment sets a payment transferred and moves it out of escrow in the ledger
+func releasePayment(tx *sql.Tx, paymentID string) error {
+	// The company is owed the payment from now on; it reaches the company with its next payout
+	now := time.Now()
+	_, err := tx.Exec(`
+		UPDATE payments SET transferred_at = $2, updated_at = $3 WHERE id = $1
+	`, paymentID, now, now)
+	if err != nil {
+		return err
+	}
+
+	return postPaymentReleased(tx, paymentID)
 }
 
-// RefundPayment processes a refund (placeholder implementation)
+// RefundPayment sends money back through the provider that took the payment. Refunds of
+// payments taken offline stay pending until the company pays them back. A payment is refunded
+// at most what was paid less its earlier refunds; the payment row stays locked meanwhile, so
+// that concurrent refunds cannot add up to more.
 func (s *PaymentService) RefundPayment(req *RefundRequest) (*models.Refund, error) {
-	// TODO: Implement Stripe refund when SDK is available
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
 
 	// Get payment
 	var payment models.Payment
-	err := s.db.QueryRow(`
-		SELECT id, user_id, company_id, booking_id, order_id, stripe_payment_intent_id,
-			   amount, currency, status, commission_amount, platform_amount, company_amount,
-			   transferred_at, payment_method_type, created_at, updated_at
-		FROM payments WHERE id = $1
+	var orderID *string
+	err = tx.QueryRow(`
+		SELECT id, order_id, stripe_payment_intent_id, amount, currency, status
+		FROM payments WHERE id = $1 FOR UPDATE
 	`, req.PaymentID).Scan(
-		&payment.ID, &payment.UserID, &payment.CompanyID, &payment.BookingID,
-		&payment.OrderID, &payment.StripePaymentIntentID, &payment.Amount,
-		&payment.Currency, &payment.Status, &payment.CommissionAmount,
-		&payment.PlatformAmount, &payment.CompanyAmount, &payment.TransferredAt,
-		&payment.PaymentMethodType, &payment.CreatedAt, &payment.UpdatedAt,
+		&payment.ID, &orderID, &payment.StripePaymentIntentID, &payment.Amount, &payment.Currency, &payment.Status,
 	)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("payment not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	payment.SetCurrency()
+
+	if payment.Status != "succeeded" && payment.Status != "partially_refunded" {
+		return nil, fmt.Errorf("payment is %s and cannot be refunded", payment.Status)
+	}
+
+	refunded := models.NewMoney(0, payment.Currency)
+	err = tx.QueryRow(`
+		SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1 AND status <> 'failed'
+	`, payment.ID).Scan(&refunded)
 	if err != nil {
 		return nil, err
 	}
+	refundable := payment.Amount.Sub(refunded)
+
+	amount := models.MoneyFromDecimal(req.Amount, payment.Currency)
+	if amount.Amount <= 0 || amount.Amount > refundable.Amount {
+		return nil, fmt.Errorf("refund amount must be positive and at most %s, what is left of the payment", refundable)
+	}
 
 	// Create refund record
 	refundRecord := &models.Refund{
 		ID:             uuid.New().String(),
 		PaymentID:      payment.ID,
-		StripeRefundID: uuid.New().String(), // Placeholder
-		Amount:         req.Amount,
+		StripeRefundID: uuid.New().String(), // Reference of offline refunds
+		Amount:         amount,
 		Reason:         req.Reason,
 		Status:         "pending",
 		CreatedAt:      time.Now(),
 	}
 
+	online, err := s.takenOnline(payment.ID)
+	if err != nil {
+		return nil, err
+	}
+	if online {
+		provider, err := s.paymentProviderFor(payment.ID)
+		if err != nil {
+			return nil, err
+		}
+		refund, err := provider.Refund(ProviderRefundParams{
+			IntentID:       payment.StripePaymentIntentID,
+			Amount:         amount.Amount,
+			Reason:         req.Reason,
+			IdempotencyKey: "refund:" + refundRecord.ID,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to refund payment: %w", err)
+		}
+		refundRecord.StripeRefundID = refund.ID
+		refundRecord.Status = refund.Status
+	}
+
 	// Store refund record
-	_, err = s.db.Exec(`
+	_, err = tx.Exec(`
 		INSERT INTO refunds (id, payment_id, stripe_refund_id, amount, reason, status, created_at)
 		VALUES ($1, $2, $3, $4, $5, $6, $7)
 	`, refundRecord.ID, refundRecord.PaymentID, refundRecord.StripeRefundID,
@@ -338,6 +551,33 @@ func (s *PaymentService) RefundPayment(req *RefundRequest) (*models.Refund, erro
 		return nil, err
 	}
 
+	if refundRecord.Status != "failed" {
+		if online {
+			err = postPaymentReturned(tx, payment.ID, "refund:"+refundRecord.StripeRefundID, "refund",
+				amount.Amount, "Refund: "+req.Reason)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		status := "partially_refunded"
+		if amount.Amount >= refundable.Amount {
+			status = "refunded"
+		}
+		if _, err := tx.Exec("UPDATE payments SET status = $2, updated_at = NOW() WHERE id = $1", payment.ID, status); err != nil {
+			return nil, err
+		}
+		if status == "refunded" && orderID != nil {
+			if _, err := tx.Exec("UPDATE orders SET status = 'refunded', updated_at = NOW() WHERE id = $1", *orderID); err != nil {
+				return nil, fmt.Errorf("failed to mark order refunded: %w", err)
+			}
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
 	return refundRecord, nil
 }
 
@@ -371,6 +611,7 @@ func (s *PaymentService) GetPaymentsByUser(userID string, days int) ([]models.Pa
 		if err != nil {
 			return nil, err
 		}
+		payment.SetCurrency()
 		payments = append(payments, payment)
 	}
 
@@ -407,6 +648,7 @@ func (s *PaymentService) GetPaymentsByCompany(companyID string, days int) ([]mod
 		if err != nil {
 			return nil, err
 		}
+		payment.SetCurrency()
 		payments = append(payments, payment)
 	}
 
@@ -451,9 +693,34 @@ func (s *PaymentService) GetPaymentByID(paymentID string) (*models.Payment, erro
 	if err != nil {
 		return nil, err
 	}
+	payment.SetCurrency()
 	return &payment, nil
 }
 
+// settlePaymentStatus records the status a provider reports for a payment, posting it to the ledger once captured
+func (s *PaymentService) settlePaymentStatus(paymentID, status string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE payments SET status = $2, updated_at = $3 WHERE id = $1
+	`, paymentID, status, time.Now())
+	if err != nil {
+		return err
+	}
+
+	if status == "succeeded" {
+		if err := postPaymentCaptured(tx, paymentID); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
 func (s *PaymentService) UpdatePaymentStatus(paymentID, status string) error {
 	_, err := s.db.Exec(`
 		UPDATE payments SET status = $2, updated_at = $3 WHERE id = $1
@@ -461,32 +728,213 @@ func (s *PaymentService) UpdatePaymentStatus(paymentID, status string) error {
 	return err
 }
 
-func (s *PaymentService) GetUserPaymentMethods(userID string) ([]map[string]interface{}, error) {
-	// TODO: Implement when Stripe is available
-	return []map[string]interface{}{}, nil
+// GetUserPaymentMethods returns the cards the user saved with the active provider
+func (s *PaymentService) GetUserPaymentMethods(userID string) ([]SavedPaymentMethod, error) {
+	provider := s.paymentProvider()
+	if provider == nil {
+		return []SavedPaymentMethod{}, nil
+	}
+
+	customerID, err := s.providerCustomer(provider, userID, false)
+	if err != nil || customerID == "" {
+		return []SavedPaymentMethod{}, err
+	}
+
+	return provider.ListPaymentMethods(customerID)
 }
 
-func (s *PaymentService) CreateSetupIntent(userID string) (map[string]interface{}, error) {
-	// TODO: Implement when Stripe is available
-	return map[string]interface{}{
-		"setup_intent_id": "placeholder",
-		"client_secret":   "placeholder",
-		"offline_mode":    !s.paymentSettings.StripeEnabled,
+// CreateSetupIntent starts saving a card of the user for later payments
+func (s *PaymentService) CreateSetupIntent(userID string) (*SetupIntentResponse, error) {
+	provider := s.paymentProvider()
+	if provider == nil {
+		return &SetupIntentResponse{OfflineMode: true}, nil
+	}
+
+	customerID, err := s.providerCustomer(provider, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := provider.CreateSetupIntent(customerID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create setup intent: %w", err)
+	}
+
+	return &SetupIntentResponse{
+		SetupIntentID: intent.ID,
+		ClientSecret:  intent.ClientSecret,
+		Status:        intent.Status,
 	}, nil
 }
 
-func (s *PaymentService) CreateSubscription(userID, priceID, paymentMethodID string) (map[string]interface{}, error) {
-	// TODO: Implement when Stripe is available
-	return map[string]interface{}{
-		"subscription_id": "placeholder",
-		"status":          "offline",
-		"offline_mode":    !s.paymentSettings.StripeEnabled,
+// CreateSubscription subscribes a company of the user to a recurring price of the provider;
+// webhooks then follow the subscription through its lifecycle
+func (s *PaymentService) CreateSubscription(userID, companyID, priceID, paymentMethodID string) (*SubscriptionResponse, error) {
+	var ownerID sql.NullString
+	err := s.db.QueryRow("SELECT owner_id FROM companies WHERE id = $1", companyID).Scan(&ownerID)
+	if err != nil || ownerID.String != userID {
+		return nil, fmt.Errorf("company not found")
+	}
+
+	provider := s.paymentProvider()
+	if provider == nil {
+		return &SubscriptionResponse{Status: "offline", OfflineMode: true}, nil
+	}
+
+	customerID, err := s.providerCustomer(provider, userID, true)
+	if err != nil {
+		return nil, err
+	}
+
+	subscription, err := provider.CreateSubscription(customerID, priceID, paymentMethodID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create subscription: %w", err)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO payment_subscriptions (company_id, user_id, provider, subscription_id, price_id,
+										   status, current_period_end)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+	`, companyID, userID, provider.Name(), subscription.ID, priceID, subscription.Status, subscription.CurrentPeriodEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save subscription: %w", err)
+	}
+	if err := applyCompanySubscription(tx, companyID, subscription.Status, &subscription.CurrentPeriodEnd); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return &SubscriptionResponse{
+		SubscriptionID:   subscription.ID,
+		Status:           subscription.Status,
+		CurrentPeriodEnd: &subscription.CurrentPeriodEnd,
 	}, nil
 }
 
+// ConfirmPayment pays a pending payment of the user with a payment method. A declined card
+// marks the payment failed; the customer can confirm it again with another card.
 func (s *PaymentService) ConfirmPayment(paymentIntentID, paymentMethodID, userID string) (*models.Payment, error) {
-	// TODO: Implement Stripe payment confirmation when SDK is available
-	return nil, fmt.Errorf("Stripe functionality is currently disabled")
+	var paymentID, status string
+	var ownerID sql.NullString
+	err := s.db.QueryRow(`
+		SELECT id, user_id, status FROM payments WHERE stripe_payment_intent_id = $1
+	`, paymentIntentID).Scan(&paymentID, &ownerID, &status)
+	if err != nil || ownerID.String != userID {
+		return nil, fmt.Errorf("payment not found")
+	}
+
+	switch status {
+	case "succeeded", "authorized", "processing":
+		return s.GetPaymentByID(paymentID)
+	case "pending", "failed":
+	default:
+		return nil, fmt.Errorf("payment is %s and cannot be confirmed", status)
+	}
+
+	provider, err := s.paymentProviderFor(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := provider.ConfirmPaymentIntent(paymentIntentID, paymentMethodID)
+	if err != nil {
+		if errors.Is(err, ErrPaymentDeclined) {
+			if err := s.UpdatePaymentStatus(paymentID, "failed"); err != nil {
+				return nil, err
+			}
+		}
+		return nil, err
+	}
+
+	if err := s.settlePaymentStatus(paymentID, paymentStatusForIntent(intent.Status)); err != nil {
+		return nil, err
+	}
+
+	return s.GetPaymentByID(paymentID)
+}
+
+// CapturePayment collects a payment the customer authorized with capture_later
+func (s *PaymentService) CapturePayment(paymentID string) (*models.Payment, error) {
+	payment, err := s.GetPaymentByID(paymentID)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found")
+	}
+	if payment.Status != "authorized" {
+		return nil, fmt.Errorf("payment is %s and cannot be captured", payment.Status)
+	}
+
+	provider, err := s.paymentProviderFor(paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	intent, err := provider.CapturePaymentIntent(payment.StripePaymentIntentID, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to capture payment: %w", err)
+	}
+
+	if err := s.settlePaymentStatus(paymentID, paymentStatusForIntent(intent.Status)); err != nil {
+		return nil, err
+	}
+
+	return s.GetPaymentByID(paymentID)
+}
+
+// CancelPayment voids a payment that was not made, at the provider first so that the customer can no
+// longer pay it. ErrPaymentAlreadyMade means the customer paid in the meantime.
+func (s *PaymentService) CancelPayment(paymentID string) error {
+	payment, err := s.GetPaymentByID(paymentID)
+	if err != nil {
+		return fmt.Errorf("payment not found")
+	}
+	switch payment.Status {
+	case "canceled":
+		return nil
+	case "pending", "failed", "authorized":
+	case "succeeded", "processing":
+		return ErrPaymentAlreadyMade
+	default:
+		return fmt.Errorf("payment is %s and cannot be canceled", payment.Status)
+	}
+
+	online, err := s.takenOnline(paymentID)
+	if err != nil {
+		return err
+	}
+	if online {
+		provider, err := s.paymentProviderFor(paymentID)
+		if err != nil {
+			return err
+		}
+		if _, err := provider.CancelPaymentIntent(payment.StripePaymentIntentID); err != nil {
+			if errors.Is(err, ErrIntentUnexpectedState) {
+				return ErrPaymentAlreadyMade
+			}
+			return fmt.Errorf("failed to cancel payment: %w", err)
+		}
+	}
+
+	// The webhook may have recorded the payment made while the provider was asked
+	result, err := s.db.Exec(`
+		UPDATE payments SET status = 'canceled', updated_at = $2
+		WHERE id = $1 AND status IN ('pending', 'failed', 'authorized')
+	`, paymentID, time.Now())
+	if err != nil {
+		return err
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return ErrPaymentAlreadyMade
+	}
+
+	return nil
 }
 
 func (s *PaymentService) GetPaymentHistory(userID string, limit, offset int, status string) ([]models.Payment, int, error) {
@@ -541,6 +989,7 @@ func (s *PaymentService) GetPaymentHistory(userID string, limit, offset int, sta
 		if err != nil {
 			return nil, 0, err
 		}
+		payment.SetCurrency()
 		payments = append(payments, payment)
 	}
 
@@ -550,3 +999,91 @@ func (s *PaymentService) GetPaymentHistory(userID string, limit, offset int, sta
 
 	return payments, totalCount, nil
 }
+
+// Helper methods
+
+// takenOnline reports whether a payment went through a provider rather than being taken offline
+func (s *PaymentService) takenOnline(paymentID string) (bool, error) {
+	var provider sql.NullString
+	if err := s.db.QueryRow("SELECT provider FROM payments WHERE id = $1", paymentID).Scan(&provider); err != nil {
+		return false, err
+	}
+
+	return provider.Valid, nil
+}
+
+// paymentProviderFor returns the provider that took a payment; it has to still be the active one
+func (s *PaymentService) paymentProviderFor(paymentID string) (PaymentProvider, error) {
+	var name sql.NullString
+	if err := s.db.QueryRow("SELECT provider FROM payments WHERE id = $1", paymentID).Scan(&name); err != nil {
+		return nil, fmt.Errorf("payment not found")
+	}
+	if !name.Valid {
+		return nil, fmt.Errorf("payment is taken offline; the company confirms it once paid")
+	}
+
+	provider := s.paymentProvider()
+	if provider == nil || provider.Name() != name.String {
+		return nil, fmt.Errorf("payment provider %s is not active", name.String)
+	}
+
+	return provider, nil
+}
+
+// providerCustomer returns the user's customer at the provider, creating it when asked; "" when there is none
+func (s *PaymentService) providerCustomer(provider PaymentProvider, userID string, create bool) (string, error) {
+	var customerID string
+	err := s.db.QueryRow(`
+		SELECT customer_id FROM payment_customers WHERE user_id = $1 AND provider = $2
+	`, userID, provider.Name()).Scan(&customerID)
+	if err == nil {
+		return customerID, nil
+	}
+	if err != sql.ErrNoRows {
+		return "", err
+	}
+	if !create {
+		return "", nil
+	}
+
+	var email string
+	var firstName, lastName sql.NullString
+	err = s.db.QueryRow("SELECT email, first_name, last_name FROM users WHERE id = $1", userID).Scan(&email, &firstName, &lastName)
+	if err != nil {
+		return "", fmt.Errorf("user not found")
+	}
+
+	customerID, err = provider.CreateCustomer(ProviderCustomerParams{
+		UserID: userID,
+		Email:  email,
+		Name:   strings.TrimSpace(firstName.String + " " + lastName.String),
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create payment customer: %w", err)
+	}
+
+	// A concurrent request may have stored a customer first; keep that one
+	err = s.db.QueryRow(`
+		INSERT INTO payment_customers (user_id, provider, customer_id)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, provider) DO UPDATE SET customer_id = payment_customers.customer_id
+		RETURNING customer_id
+	`, userID, provider.Name(), customerID).Scan(&customerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to save payment customer: %w", err)
+	}
+
+	return customerID, nil
+}
+
+// intentMetadata tags a provider payment with our payment ID alongside the request's metadata.
+// The tag is set last: webhooks find the payment by it, so the request cannot override it.
+func intentMetadata(paymentID string, metadata map[string]interface{}) map[string]string {
+	tagged := make(map[string]string, len(metadata)+1)
+	for key, value := range metadata {
+		tagged[key] = fmt.Sprint(value)
+	}
+	tagged["payment_id"] = paymentID
+
+	return tagged
+}
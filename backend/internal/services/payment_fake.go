@@ -0,0 +1,287 @@
+package services
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Payment methods the fake provider knows, named after Stripe's test cards
+const (
+	FakeCardVisa     = "pm_card_visa"
+	FakeCardDeclined = "pm_card_chargeDeclined"
+)
+
+// FakePaymentProvider takes payments in memory, so the payment flow runs without a network.
+// FakeCardVisa always pays and FakeCardDeclined is always declined; setup intents
+// succeed at once and save a test Visa to the customer. Like Stripe, a request repeated
+// with an idempotency key returns the first result.
+type FakePaymentProvider struct {
+	mu        sync.Mutex
+	sequence  int
+	intents   map[string]*fakeIntent
+	customers map[string][]SavedPaymentMethod
+	replays   map[string]interface{}
+}
+
+type fakeIntent struct {
+	ProviderIntent
+	customerID    string
+	manualCapture bool
+	refunded      int64
+}
+
+func NewFakePaymentProvider() *FakePaymentProvider {
+	return &FakePaymentProvider{
+		intents:   make(map[string]*fakeIntent),
+		customers: make(map[string][]SavedPaymentMethod),
+		replays:   make(map[string]interface{}),
+	}
+}
+
+func (p *FakePaymentProvider) Name() string {
+	return PaymentProviderFake
+}
+
+func (p *FakePaymentProvider) CreatePaymentIntent(params ProviderIntentParams) (*ProviderIntent, error) {
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if replay, ok := p.replays[params.IdempotencyKey].(ProviderIntent); ok {
+		return &replay, nil
+	}
+
+	id := p.nextID("pi")
+	intent := &fakeIntent{
+		ProviderIntent: ProviderIntent{
+			ID:           id,
+			ClientSecret: id + "_secret",
+			Status:       "requires_payment_method",
+			Amount:       params.Amount,
+			Currency:     strings.ToLower(params.Currency),
+		},
+		customerID:    params.CustomerID,
+		manualCapture: params.ManualCapture,
+	}
+	p.intents[id] = intent
+
+	result := intent.ProviderIntent
+	p.remember(params.IdempotencyKey, result)
+	return &result, nil
+}
+
+func (p *FakePaymentProvider) ConfirmPaymentIntent(intentID, paymentMethodID string) (*ProviderIntent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, fmt.Errorf("no such payment intent: %s", intentID)
+	}
+	if intent.Status != "requires_payment_method" && intent.Status != "requires_confirmation" {
+		return nil, fmt.Errorf("payment intent is %s and cannot be confirmed", intent.Status)
+	}
+
+	switch {
+	case paymentMethodID == FakeCardDeclined:
+		return nil, fmt.Errorf("%w: your card was declined", ErrPaymentDeclined)
+	case paymentMethodID != FakeCardVisa && !p.savedMethod(intent.customerID, paymentMethodID):
+		return nil, fmt.Errorf("no such payment method: %s", paymentMethodID)
+	}
+
+	if intent.manualCapture {
+		intent.Status = "requires_capture"
+	} else {
+		intent.Status = "succeeded"
+		intent.AmountReceived = intent.Amount
+	}
+
+	result := intent.ProviderIntent
+	return &result, nil
+}
+
+func (p *FakePaymentProvider) CapturePaymentIntent(intentID string, amount int64) (*ProviderIntent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, fmt.Errorf("no such payment intent: %s", intentID)
+	}
+	if intent.Status != "requires_capture" {
+		return nil, fmt.Errorf("payment intent is %s and cannot be captured", intent.Status)
+	}
+	if amount == 0 {
+		amount = intent.Amount
+	}
+	if amount < 0 || amount > intent.Amount {
+		return nil, fmt.Errorf("capture amount exceeds the authorized amount")
+	}
+
+	intent.Status = "succeeded"
+	intent.AmountReceived = amount
+
+	result := intent.ProviderIntent
+	return &result, nil
+}
+
+func (p *FakePaymentProvider) CancelPaymentIntent(intentID string) (*ProviderIntent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	intent, ok := p.intents[intentID]
+	if !ok {
+		return nil, fmt.Errorf("no such payment intent: %s", intentID)
+	}
+	switch intent.Status {
+	case "succeeded", "processing":
+		return nil, fmt.Errorf("%w: payment intent is %s and cannot be canceled", ErrIntentUnexpectedState, intent.Status)
+	}
+
+	intent.Status = "canceled"
+
+	result := intent.ProviderIntent
+	return &result, nil
+}
+
+func (p *FakePaymentProvider) Refund(params ProviderRefundParams) (*ProviderRefund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if replay, ok := p.replays[params.IdempotencyKey].(ProviderRefund); ok {
+		return &replay, nil
+	}
+
+	intent, ok := p.intents[params.IntentID]
+	if !ok {
+		return nil, fmt.Errorf("no such payment intent: %s", params.IntentID)
+	}
+	if intent.Status != "succeeded" {
+		return nil, fmt.Errorf("payment intent is %s and cannot be refunded", intent.Status)
+	}
+	if params.Amount <= 0 || intent.refunded+params.Amount > intent.AmountReceived {
+		return nil, fmt.Errorf("refund amount exceeds the amount paid")
+	}
+
+	intent.refunded += params.Amount
+	refund := ProviderRefund{ID: p.nextID("re"), Status: "succeeded", Amount: params.Amount}
+	p.remember(params.IdempotencyKey, refund)
+	return &refund, nil
+}
+
+func (p *FakePaymentProvider) CreateCustomer(params ProviderCustomerParams) (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	id := p.nextID("cus")
+	p.customers[id] = []SavedPaymentMethod{}
+	return id, nil
+}
+
+func (p *FakePaymentProvider) ListPaymentMethods(customerID string) ([]SavedPaymentMethod, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	saved, ok := p.customers[customerID]
+	if !ok {
+		return nil, fmt.Errorf("no such customer: %s", customerID)
+	}
+	return append([]SavedPaymentMethod{}, saved...), nil
+}
+
+func (p *FakePaymentProvider) CreateSetupIntent(customerID string) (*ProviderSetupIntent, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if _, ok := p.customers[customerID]; !ok {
+		return nil, fmt.Errorf("no such customer: %s", customerID)
+	}
+
+	p.customers[customerID] = append(p.customers[customerID], SavedPaymentMethod{
+		ID:       p.nextID("pm"),
+		Type:     "card",
+		Brand:    "visa",
+		Last4:    "4242",
+		ExpMonth: 12,
+		ExpYear:  time.Now().Year() + 3,
+	})
+
+	id := p.nextID("seti")
+	return &ProviderSetupIntent{ID: id, ClientSecret: id + "_secret", Status: "succeeded"}, nil
+}
+
+func (p *FakePaymentProvider) CreateSubscription(customerID, priceID, paymentMethodID string) (*ProviderSubscription, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	saved, ok := p.customers[customerID]
+	if !ok {
+		return nil, fmt.Errorf("no such customer: %s", customerID)
+	}
+	if priceID == "" {
+		return nil, fmt.Errorf("a price is required")
+	}
+
+	status := "active"
+	switch {
+	case paymentMethodID == FakeCardDeclined:
+		status = "incomplete"
+	case paymentMethodID == "" && len(saved) == 0:
+		status = "incomplete"
+	case paymentMethodID != "" && paymentMethodID != FakeCardVisa && !p.savedMethod(customerID, paymentMethodID):
+		return nil, fmt.Errorf("no such payment method: %s", paymentMethodID)
+	}
+
+	return &ProviderSubscription{
+		ID:               p.nextID("sub"),
+		Status:           status,
+		CurrentPeriodEnd: time.Now().AddDate(0, 1, 0),
+	}, nil
+}
+
+func (p *FakePaymentProvider) CreatePayout(params ProviderPayoutParams) (*ProviderPayout, error) {
+	if params.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if replay, ok := p.replays[params.IdempotencyKey].(ProviderPayout); ok {
+		return &replay, nil
+	}
+
+	payout := ProviderPayout{ID: p.nextID("tr"), Status: "paid"}
+	p.remember(params.IdempotencyKey, payout)
+	return &payout, nil
+}
+
+// Helper methods
+
+func (p *FakePaymentProvider) nextID(prefix string) string {
+	p.sequence++
+	return fmt.Sprintf("%s_fake_%d", prefix, p.sequence)
+}
+
+// remember keeps the result of a request made with an idempotency key
+func (p *FakePaymentProvider) remember(idempotencyKey string, result interface{}) {
+	if idempotencyKey != "" {
+		p.replays[idempotencyKey] = result
+	}
+}
+
+// savedMethod reports whether a customer saved the payment method; like Stripe, a card saved by
+// another customer cannot be used
+func (p *FakePaymentProvider) savedMethod(customerID, paymentMethodID string) bool {
+	for _, method := range p.customers[customerID] {
+		if method.ID == paymentMethodID {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,509 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// Ledger accounts; company accounts carry the company ID after their prefix
+const (
+	ledgerPlatformCash      = "platform_cash"      // asset: funds held at the payment provider
+	ledgerCommissionRevenue = "commission_revenue" // revenue: commission on released payments
+	ledgerProcessingFees    = "processing_fees"    // expense: provider fees the platform absorbs
+	ledgerEscrowPrefix      = "escrow:"            // liability: payments held until the service is completed
+	ledgerBalancePrefix     = "company_balance:"   // liability: released to the company, owed until paid out
+)
+
+// ledgerLine is one side of a posting, in minor units; debits are positive and credits negative
+type ledgerLine struct {
+	Account     string
+	AccountType string
+	CompanyID   *string
+	Amount      int64
+}
+
+// ledgerPosting is a transaction to post; its key makes posting the same business event twice a no-op
+type ledgerPosting struct {
+	Key         string
+	Kind        string
+	CompanyID   *string
+	PaymentID   *string
+	Currency    string
+	Description string
+	Lines       []ledgerLine
+}
+
+// ledgerPayment is a payment as the ledger sees it, in minor units
+type ledgerPayment struct {
+	ID         string
+	CompanyID  string
+	Amount     int64
+	Commission int64
+	Currency   string
+	Online     bool
+}
+
+// GetCompanyLedgerBalances returns what the platform holds for a company, per currency
+func (s *PaymentService) GetCompanyLedgerBalances(companyID string) ([]models.CompanyLedgerBalance, error) {
+	rows, err := s.db.Query(`
+		SELECT a.currency, a.code, COALESCE(-SUM(e.amount), 0)
+		FROM ledger_accounts a
+		LEFT JOIN ledger_entries e ON e.account_id = a.id
+		WHERE a.company_id = $1
+		GROUP BY a.currency, a.code
+		ORDER BY a.currency
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger balances: %w", err)
+	}
+	defer rows.Close()
+
+	balances := []models.CompanyLedgerBalance{}
+	byCurrency := make(map[string]int)
+	for rows.Next() {
+		var currency, code string
+		var amount int64
+		if err := rows.Scan(&currency, &code, &amount); err != nil {
+			return nil, err
+		}
+
+		i, ok := byCurrency[currency]
+		if !ok {
+			i = len(balances)
+			byCurrency[currency] = i
+			balances = append(balances, models.CompanyLedgerBalance{Currency: currency})
+		}
+		switch {
+		case strings.HasPrefix(code, ledgerEscrowPrefix):
+			balances[i].Escrow = models.NewMoney(amount, currency)
+		case strings.HasPrefix(code, ledgerBalancePrefix):
+			balances[i].Available = models.NewMoney(amount, currency)
+		}
+	}
+
+	return balances, nil
+}
+
+// GetCompanyLedger returns the latest ledger entries on a company's accounts
+func (s *PaymentService) GetCompanyLedger(companyID string, limit int) ([]models.LedgerEntry, error) {
+	rows, err := s.db.Query(`
+		SELECT t.id, t.kind, t.payment_id, a.code, a.account_type, e.amount, a.currency,
+			   COALESCE(t.description, ''), t.created_at
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE a.company_id = $1
+		ORDER BY t.created_at DESC, a.code
+		LIMIT $2
+	`, companyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get ledger: %w", err)
+	}
+	defer rows.Close()
+
+	entries := []models.LedgerEntry{}
+	for rows.Next() {
+		var entry models.LedgerEntry
+		var code, accountType string
+		var amount int64
+		if err := rows.Scan(
+			&entry.TransactionID, &entry.Kind, &entry.PaymentID, &code, &accountType,
+			&amount, &entry.Currency, &entry.Description, &entry.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		entry.Account, _, _ = strings.Cut(code, ":")
+		entry.Amount = models.NewMoney(normalBalance(accountType, amount), entry.Currency)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// GetLedgerReconciliation checks that every transaction balances and that the postings agree with the payments
+func (s *PaymentService) GetLedgerReconciliation() (*models.LedgerReconciliation, error) {
+	report := &models.LedgerReconciliation{
+		GeneratedAt:            time.Now(),
+		TrialBalance:           []models.LedgerAccountBalance{},
+		UnbalancedTransactions: []string{},
+		Discrepancies:          []models.LedgerDiscrepancy{},
+	}
+
+	rows, err := s.db.Query(`
+		SELECT a.code, a.account_type, a.currency, COALESCE(SUM(e.amount), 0)
+		FROM ledger_accounts a
+		LEFT JOIN ledger_entries e ON e.account_id = a.id
+		GROUP BY a.id, a.code, a.account_type, a.currency
+		ORDER BY a.currency, a.code
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get trial balance: %w", err)
+	}
+	defer rows.Close()
+
+	totals := make(map[string]int64)
+	for rows.Next() {
+		var balance models.LedgerAccountBalance
+		var amount int64
+		if err := rows.Scan(&balance.Account, &balance.AccountType, &balance.Currency, &amount); err != nil {
+			return nil, err
+		}
+		totals[balance.Currency] += amount
+		balance.Balance = models.NewMoney(normalBalance(balance.AccountType, amount), balance.Currency)
+		report.TrialBalance = append(report.TrialBalance, balance)
+	}
+	rows.Close()
+
+	unbalanced, err := queryLedgerIDs(s.db, `
+		SELECT transaction_id FROM ledger_entries
+		GROUP BY transaction_id
+		HAVING SUM(amount) <> 0
+	`)
+	if err != nil {
+		return nil, err
+	}
+	report.UnbalancedTransactions = unbalanced
+
+	report.Balanced = len(unbalanced) == 0
+	for _, total := range totals {
+		if total != 0 {
+			report.Balanced = false
+		}
+	}
+
+	checks := []struct {
+		issue string
+		query string
+	}{
+		{"captured payment has no ledger posting", `
+			SELECT p.id FROM payments p
+			WHERE p.provider IS NOT NULL AND p.company_id IS NOT NULL
+			  AND p.status IN ('succeeded', 'partially_refunded', 'refunded', 'disputed', 'dispute_lost')
+			  AND NOT EXISTS (SELECT 1 FROM ledger_transactions t WHERE t.payment_id = p.id AND t.kind = 'payment')`},
+		{"posted amount differs from the payment", `
+			SELECT p.id FROM payments p
+			JOIN ledger_transactions t ON t.payment_id = p.id AND t.kind = 'payment'
+			JOIN ledger_entries e ON e.transaction_id = t.id
+			JOIN ledger_accounts a ON a.id = e.account_id AND a.code = 'platform_cash'
+			GROUP BY p.id, p.amount, p.currency
+			HAVING SUM(e.amount) <> ROUND(p.amount * ` + minorUnitScaleSQL("p.currency") + `)`},
+		{"transferred payment is still held in escrow", `
+			SELECT p.id FROM payments p
+			JOIN ledger_transactions t ON t.payment_id = p.id
+			JOIN ledger_entries e ON e.transaction_id = t.id
+			JOIN ledger_accounts a ON a.id = e.account_id AND a.code = 'escrow:' || p.company_id::text
+			WHERE p.transferred_at IS NOT NULL
+			GROUP BY p.id
+			HAVING SUM(e.amount) <> 0`},
+		{"refunds differ from their ledger postings", `
+			SELECT p.id FROM payments p
+			WHERE p.provider IS NOT NULL AND p.company_id IS NOT NULL
+			  AND ROUND(COALESCE((SELECT SUM(r.amount) FROM refunds r
+								  WHERE r.payment_id = p.id AND r.status <> 'failed'), 0) * ` + minorUnitScaleSQL("p.currency") + `)
+			   <> COALESCE((SELECT -SUM(e.amount) FROM ledger_transactions t
+							JOIN ledger_entries e ON e.transaction_id = t.id
+							JOIN ledger_accounts a ON a.id = e.account_id AND a.code = 'platform_cash'
+							WHERE t.payment_id = p.id AND t.kind = 'refund'), 0)`},
+	}
+	for _, check := range checks {
+		paymentIDs, err := queryLedgerIDs(s.db, check.query)
+		if err != nil {
+			return nil, err
+		}
+		for _, paymentID := range paymentIDs {
+			report.Discrepancies = append(report.Discrepancies, models.LedgerDiscrepancy{PaymentID: paymentID, Issue: check.issue})
+		}
+	}
+
+	return report, nil
+}
+
+// RecordProcessorFee posts the fee the provider kept on a payment as a platform expense
+func (s *PaymentService) RecordProcessorFee(paymentID string, amount float64) error {
+	if amount <= 0 {
+		return fmt.Errorf("fee must be positive")
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	payment, err := loadLedgerPayment(tx, paymentID)
+	if err != nil {
+		return err
+	}
+	if !payment.Online {
+		return fmt.Errorf("payment was not taken through a payment provider")
+	}
+	fee := models.MoneyFromDecimal(amount, payment.Currency)
+
+	err = postLedgerTransaction(tx, &ledgerPosting{
+		Key:         "processor_fee:" + payment.ID,
+		Kind:        "processor_fee",
+		CompanyID:   &payment.CompanyID,
+		PaymentID:   &payment.ID,
+		Currency:    payment.Currency,
+		Description: "Payment provider fee",
+		Lines: []ledgerLine{
+			platformLedgerLine(ledgerProcessingFees, "expense", fee.Amount),
+			platformLedgerLine(ledgerPlatformCash, "asset", -fee.Amount),
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// Helper methods
+
+// postPaymentCaptured records money the provider collected, held in escrow for the company
+func postPaymentCaptured(tx *sql.Tx, paymentID string) error {
+	payment, err := loadLedgerPayment(tx, paymentID)
+	if err != nil || !payment.Online {
+		return err
+	}
+
+	return postLedgerTransaction(tx, &ledgerPosting{
+		Key:         "payment:" + payment.ID,
+		Kind:        "payment",
+		CompanyID:   &payment.CompanyID,
+		PaymentID:   &payment.ID,
+		Currency:    payment.Currency,
+		Description: "Customer payment",
+		Lines: []ledgerLine{
+			platformLedgerLine(ledgerPlatformCash, "asset", payment.Amount),
+			companyLedgerLine(ledgerEscrowPrefix, payment.CompanyID, -payment.Amount),
+		},
+	})
+}
+
+// postPaymentReleased releases what is left of a payment in escrow to the company, less the commission on it
+func postPaymentReleased(tx *sql.Tx, paymentID string) error {
+	payment, err := loadLedgerPayment(tx, paymentID)
+	if err != nil || !payment.Online {
+		return err
+	}
+
+	held, err := paymentEscrowHeld(tx, payment)
+	if err != nil || held <= 0 {
+		return err
+	}
+	commission := proportionOf(payment.Commission, held, payment.Amount)
+
+	return postLedgerTransaction(tx, &ledgerPosting{
+		Key:         "release:" + payment.ID,
+		Kind:        "release",
+		CompanyID:   &payment.CompanyID,
+		PaymentID:   &payment.ID,
+		Currency:    payment.Currency,
+		Description: "Service completed, payment released to the company",
+		Lines: []ledgerLine{
+			companyLedgerLine(ledgerEscrowPrefix, payment.CompanyID, held),
+			companyLedgerLine(ledgerBalancePrefix, payment.CompanyID, -(held - commission)),
+			platformLedgerLine(ledgerCommissionRevenue, "revenue", -commission),
+		},
+	})
+}
+
+// postPaymentReturned records money sent back to the customer, by refund or lost dispute. It comes out of
+// escrow first; once a payment is released, out of the company's balance and the commission in proportion.
+func postPaymentReturned(tx *sql.Tx, paymentID, key, kind string, amount int64, description string) error {
+	payment, err := loadLedgerPayment(tx, paymentID)
+	if err != nil || !payment.Online {
+		return err
+	}
+
+	held, err := paymentEscrowHeld(tx, payment)
+	if err != nil {
+		return err
+	}
+	fromEscrow := amount
+	if held < fromEscrow {
+		fromEscrow = held
+	}
+	if fromEscrow < 0 {
+		fromEscrow = 0
+	}
+	released := amount - fromEscrow
+	commission := proportionOf(payment.Commission, released, payment.Amount)
+
+	return postLedgerTransaction(tx, &ledgerPosting{
+		Key:         key,
+		Kind:        kind,
+		CompanyID:   &payment.CompanyID,
+		PaymentID:   &payment.ID,
+		Currency:    payment.Currency,
+		Description: description,
+		Lines: []ledgerLine{
+			companyLedgerLine(ledgerEscrowPrefix, payment.CompanyID, fromEscrow),
+			companyLedgerLine(ledgerBalancePrefix, payment.CompanyID, released-commission),
+			platformLedgerLine(ledgerCommissionRevenue, "revenue", commission),
+			platformLedgerLine(ledgerPlatformCash, "asset", -amount),
+		},
+	})
+}
+
+// postLedgerTransaction records a balanced transaction; a key posted before is skipped
+func postLedgerTransaction(tx *sql.Tx, posting *ledgerPosting) error {
+	var total int64
+	lines := make([]ledgerLine, 0, len(posting.Lines))
+	for _, line := range posting.Lines {
+		total += line.Amount
+		if line.Amount != 0 {
+			lines = append(lines, line)
+		}
+	}
+	if total != 0 {
+		return fmt.Errorf("ledger transaction %s does not balance", posting.Key)
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	var transactionID string
+	err := tx.QueryRow(`
+		INSERT INTO ledger_transactions (idempotency_key, kind, company_id, payment_id, currency, description)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (idempotency_key) DO NOTHING
+		RETURNING id
+	`, posting.Key, posting.Kind, posting.CompanyID, posting.PaymentID, posting.Currency,
+		posting.Description).Scan(&transactionID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to post ledger transaction: %w", err)
+	}
+
+	for _, line := range lines {
+		var accountID string
+		err := tx.QueryRow(`
+			INSERT INTO ledger_accounts (code, account_type, company_id, currency)
+			VALUES ($1, $2, $3, $4)
+			ON CONFLICT (code, currency) DO UPDATE SET code = EXCLUDED.code
+			RETURNING id
+		`, line.Account, line.AccountType, line.CompanyID, posting.Currency).Scan(&accountID)
+		if err != nil {
+			return fmt.Errorf("failed to open ledger account: %w", err)
+		}
+
+		_, err = tx.Exec(`
+			INSERT INTO ledger_entries (transaction_id, account_id, amount) VALUES ($1, $2, $3)
+		`, transactionID, accountID, line.Amount)
+		if err != nil {
+			return fmt.Errorf("failed to post ledger entry: %w", err)
+		}
+	}
+
+	return nil
+}
+
+func loadLedgerPayment(q rowQuerier, paymentID string) (*ledgerPayment, error) {
+	var payment ledgerPayment
+	var companyID, currency, provider sql.NullString
+	var amount, commission models.Money
+	err := q.QueryRow(`
+		SELECT id, company_id, amount, COALESCE(commission_amount, 0), currency, provider
+		FROM payments WHERE id = $1
+	`, paymentID).Scan(&payment.ID, &companyID, &amount, &commission, &currency, &provider)
+	if err != nil {
+		return nil, fmt.Errorf("payment not found")
+	}
+
+	payment.CompanyID = companyID.String
+	payment.Currency = strings.ToUpper(currency.String)
+	if payment.Currency == "" {
+		payment.Currency = models.DefaultCurrency
+	}
+	payment.Amount = amount.WithCurrency(payment.Currency).Amount
+	payment.Commission = commission.WithCurrency(payment.Currency).Amount
+	// Payments taken offline never pass through the platform
+	payment.Online = provider.Valid && companyID.Valid
+
+	return &payment, nil
+}
+
+// paymentEscrowHeld returns how much of a payment is still held in escrow
+func paymentEscrowHeld(q rowQuerier, payment *ledgerPayment) (int64, error) {
+	var held int64
+	err := q.QueryRow(`
+		SELECT COALESCE(-SUM(e.amount), 0)
+		FROM ledger_entries e
+		JOIN ledger_transactions t ON t.id = e.transaction_id
+		JOIN ledger_accounts a ON a.id = e.account_id
+		WHERE t.payment_id = $1 AND a.code = $2
+	`, payment.ID, ledgerEscrowPrefix+payment.CompanyID).Scan(&held)
+
+	return held, err
+}
+
+func platformLedgerLine(account, accountType string, amount int64) ledgerLine {
+	return ledgerLine{Account: account, AccountType: accountType, Amount: amount}
+}
+
+func companyLedgerLine(prefix, companyID string, amount int64) ledgerLine {
+	return ledgerLine{Account: prefix + companyID, AccountType: "liability", CompanyID: &companyID, Amount: amount}
+}
+
+// normalBalance signs a raw entry amount so that positive grows the account
+func normalBalance(accountType string, amount int64) int64 {
+	if accountType == "liability" || accountType == "revenue" {
+		return -amount
+	}
+	return amount
+}
+
+// minorUnitScaleSQL returns an SQL expression for how many minor units a major unit of the currency column has
+func minorUnitScaleSQL(column string) string {
+	exponents := models.CurrencyExponents()
+	currencies := make([]string, 0, len(exponents))
+	for currency := range exponents {
+		currencies = append(currencies, currency)
+	}
+	sort.Strings(currencies)
+
+	var scale strings.Builder
+	scale.WriteString("CASE UPPER(" + column + ")")
+	for _, currency := range currencies {
+		fmt.Fprintf(&scale, " WHEN '%s' THEN %d", currency, int64(math.Pow10(exponents[currency])))
+	}
+	scale.WriteString(" ELSE 100 END")
+	return scale.String()
+}
+
+// proportionOf returns part's share of amount out of total, rounded to the nearest minor unit
+func proportionOf(part, amount, total int64) int64 {
+	if total <= 0 || amount <= 0 {
+		return 0
+	}
+	return (2*part*amount + total) / (2 * total)
+}
+
+func queryLedgerIDs(q rowsQuerier, query string) ([]string, error) {
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reconcile ledger: %w", err)
+	}
+	defer rows.Close()
+
+	ids := []string{}
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	return ids, nil
+}
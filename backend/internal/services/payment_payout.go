@@ -0,0 +1,754 @@
+package services
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// payoutRefundHold is how long a released payment is held back from payouts while it can still be refunded
+const payoutRefundHold = 7 * 24 * time.Hour
+
+// payoutResendAfter is how old a payout the provider has not confirmed must be before it is sent again
+const payoutResendAfter = 10 * time.Minute
+
+// payoutCandidate is a released payment by what changed on it since it was last paid out, in minor units
+type payoutCandidate struct {
+	PaymentID     string
+	BookingID     *string
+	OrderID       *string
+	Description   string
+	Status        string
+	TransferredAt time.Time
+	Currency      string
+	Gross         int64
+	Commission    int64
+	Refunded      int64
+	Amount        int64
+}
+
+// StartPayoutCron pays companies out on their schedules
+func (s *PaymentService) StartPayoutCron() {
+	// Check for payouts due once an hour
+	_, err := s.cronScheduler.AddFunc("0 * * * *", s.ProcessScheduledPayouts)
+	if err != nil {
+		log.Printf("Error adding payout cron job: %v", err)
+		return
+	}
+
+	s.cronScheduler.Start()
+	log.Println("Payout cron scheduler started")
+}
+
+// ProcessScheduledPayouts pays every company whose payout is due what has been released to it
+func (s *PaymentService) ProcessScheduledPayouts() {
+	if err := s.loadPaymentSettings(); err != nil {
+		log.Printf("Error loading payment settings for payouts: %v", err)
+		return
+	}
+	if s.paymentProvider() == nil {
+		return
+	}
+
+	rows, err := s.db.Query(`
+		SELECT DISTINCT company_id FROM payments
+		WHERE transferred_at IS NOT NULL AND provider IS NOT NULL AND company_id IS NOT NULL
+	`)
+	if err != nil {
+		log.Printf("Error finding companies to pay out: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	var companyIDs []string
+	for rows.Next() {
+		var companyID string
+		if err := rows.Scan(&companyID); err != nil {
+			log.Printf("Error finding companies to pay out: %v", err)
+			return
+		}
+		companyIDs = append(companyIDs, companyID)
+	}
+	rows.Close()
+
+	s.resendPendingPayouts(time.Now())
+
+	for _, companyID := range companyIDs {
+		if err := s.payCompany(companyID, time.Now()); err != nil {
+			log.Printf("Error paying out company %s: %v", companyID, err)
+		}
+	}
+}
+
+// GetPayoutSettings returns a company's payout settings, the defaults until it changes them
+func (s *PaymentService) GetPayoutSettings(companyID string) (*models.CompanyPayoutSettings, error) {
+	return loadPayoutSettings(s.db, companyID, false)
+}
+
+// UpdatePayoutSettings changes how often and where a company is paid out
+func (s *PaymentService) UpdatePayoutSettings(companyID string, req *models.CompanyPayoutSettingsRequest) (*models.CompanyPayoutSettings, error) {
+	settings, err := s.GetPayoutSettings(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Schedule != nil {
+		if _, ok := payoutPeriodStart(*req.Schedule, time.Now()); !ok {
+			return nil, fmt.Errorf("schedule must be daily, weekly or monthly")
+		}
+		settings.Schedule = *req.Schedule
+	}
+	if req.MinimumPayout != nil {
+		if *req.MinimumPayout < 0 {
+			return nil, fmt.Errorf("minimum payout cannot be negative")
+		}
+		settings.MinimumPayout = models.MoneyFromDecimal(*req.MinimumPayout, "")
+	}
+	if req.Destination != nil {
+		settings.Destination = nil
+		if *req.Destination != "" {
+			settings.Destination = req.Destination
+		}
+	}
+	settings.UpdatedAt = time.Now()
+
+	_, err = s.db.Exec(`
+		INSERT INTO company_payout_settings (company_id, schedule, minimum_payout, destination, updated_at)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (company_id) DO UPDATE SET
+			schedule = EXCLUDED.schedule,
+			minimum_payout = EXCLUDED.minimum_payout,
+			destination = EXCLUDED.destination,
+			updated_at = EXCLUDED.updated_at
+	`, companyID, settings.Schedule, settings.MinimumPayout, settings.Destination, settings.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update payout settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// GetUpcomingPayouts returns when the company is paid next and how much, per currency
+func (s *PaymentService) GetUpcomingPayouts(companyID string) ([]models.UpcomingPayout, error) {
+	settings, err := s.GetPayoutSettings(companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	scheduledFor, err := s.nextPayoutAt(companyID, settings.Schedule, now)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates, err := queryPayoutCandidates(s.db, companyID)
+	if err != nil {
+		return nil, err
+	}
+
+	byCurrency := make(map[string]*models.UpcomingPayout)
+	upcoming := []models.UpcomingPayout{}
+	var currencies []string
+	for _, candidate := range candidates {
+		payout, ok := byCurrency[candidate.Currency]
+		if !ok {
+			payout = &models.UpcomingPayout{
+				Currency:      candidate.Currency,
+				ScheduledFor:  scheduledFor,
+				Amount:        models.NewMoney(0, candidate.Currency),
+				HeldAmount:    models.NewMoney(0, candidate.Currency),
+				MinimumPayout: settings.MinimumPayout.WithCurrency(candidate.Currency),
+			}
+			byCurrency[candidate.Currency] = payout
+			currencies = append(currencies, candidate.Currency)
+		}
+		if payoutHeld(&candidate, scheduledFor) {
+			payout.HeldAmount = payout.HeldAmount.Add(models.NewMoney(candidate.Amount, candidate.Currency))
+		} else {
+			payout.Amount = payout.Amount.Add(models.NewMoney(candidate.Amount, candidate.Currency))
+		}
+	}
+
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		upcoming = append(upcoming, *byCurrency[currency])
+	}
+
+	return upcoming, nil
+}
+
+// GetCompanyPayouts returns a company's latest payouts
+func (s *PaymentService) GetCompanyPayouts(companyID string, limit int) ([]models.CompanyPayout, error) {
+	rows, err := s.db.Query(payoutSelect+`
+		WHERE company_id = $1
+		ORDER BY created_at DESC
+		LIMIT $2
+	`, companyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payouts: %w", err)
+	}
+	defer rows.Close()
+
+	payouts := []models.CompanyPayout{}
+	for rows.Next() {
+		payout, err := scanPayout(rows)
+		if err != nil {
+			return nil, err
+		}
+		payouts = append(payouts, *payout)
+	}
+
+	return payouts, nil
+}
+
+// GetCompanyPayout returns one of a company's payouts with the payments it includes
+func (s *PaymentService) GetCompanyPayout(companyID, payoutID string) (*models.CompanyPayout, error) {
+	payout, err := scanPayout(s.db.QueryRow(payoutSelect+`
+		WHERE id = $1 AND company_id = $2
+	`, payoutID, companyID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("payout not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := s.db.Query(`
+		SELECT payment_id, booking_id, order_id, COALESCE(description, ''), gross_amount,
+			   commission_amount, refunded_amount, amount, created_at
+		FROM company_payout_items
+		WHERE payout_id = $1
+		ORDER BY created_at, payment_id
+	`, payoutID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout items: %w", err)
+	}
+	defer rows.Close()
+
+	payout.Items = []models.CompanyPayoutItem{}
+	for rows.Next() {
+		var item models.CompanyPayoutItem
+		err := rows.Scan(&item.PaymentID, &item.BookingID, &item.OrderID, &item.Description, &item.GrossAmount,
+			&item.CommissionAmount, &item.RefundedAmount, &item.Amount, &item.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		item.GrossAmount = item.GrossAmount.WithCurrency(payout.Currency)
+		item.CommissionAmount = item.CommissionAmount.WithCurrency(payout.Currency)
+		item.RefundedAmount = item.RefundedAmount.WithCurrency(payout.Currency)
+		item.Amount = item.Amount.WithCurrency(payout.Currency)
+		payout.Items = append(payout.Items, item)
+	}
+
+	return payout, nil
+}
+
+// GetPayoutStatement returns a payout's statement as CSV: a summary, then every booking and order it includes
+func (s *PaymentService) GetPayoutStatement(companyID, payoutID string) ([]byte, error) {
+	payout, err := s.GetCompanyPayout(companyID, payoutID)
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	summary := [][]string{
+		{"Payout", payout.ID},
+		{"Status", payout.Status},
+		{"Period", payout.PeriodStart.Format("2006-01-02") + " - " + payout.PeriodEnd.Format("2006-01-02")},
+		{"Currency", payout.Currency},
+		{"Total sales", payout.TotalSales.String()},
+		{"Commission", payout.CommissionDeducted.String()},
+		{"Refunds", payout.RefundsDeducted.String()},
+		{"Paid out", payout.Amount.String()},
+		{"Held for the next payout", payout.HeldAmount.String()},
+		{},
+		{"Payment", "Booking", "Order", "Description", "Gross", "Commission", "Refunded", "Paid out"},
+	}
+	for _, record := range summary {
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, item := range payout.Items {
+		record := []string{
+			item.PaymentID,
+			stringValue(item.BookingID),
+			stringValue(item.OrderID),
+			item.Description,
+			item.GrossAmount.String(),
+			item.CommissionAmount.String(),
+			item.RefundedAmount.String(),
+			item.Amount.String(),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, err
+		}
+	}
+
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Helper methods
+
+const payoutSelect = `
+	SELECT id, company_id, provider, stripe_transfer_id, amount, COALESCE(currency, 'USD'), status,
+		   description, failure_reason, period_start, period_end, total_sales, commission_deducted,
+		   COALESCE(refunds_deducted, 0), held_amount, processed_at, created_at
+	FROM company_payouts
+`
+
+func scanPayout(row interface{ Scan(...interface{}) error }) (*models.CompanyPayout, error) {
+	var payout models.CompanyPayout
+	err := row.Scan(&payout.ID, &payout.CompanyID, &payout.Provider, &payout.ProviderPayoutID, &payout.Amount,
+		&payout.Currency, &payout.Status, &payout.Description, &payout.FailureReason, &payout.PeriodStart,
+		&payout.PeriodEnd, &payout.TotalSales, &payout.CommissionDeducted, &payout.RefundsDeducted,
+		&payout.HeldAmount, &payout.ProcessedAt, &payout.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	payout.SetCurrency()
+	return &payout, nil
+}
+
+// payCompany pays a company whose payout is due everything released to it, per currency, except what is held
+// back. Payouts are recorded and posted to the ledger first, then sent through the provider; a payout
+// the provider fails is reversed, and its payments are paid with the next one. A payout the provider did not
+// answer for stays pending and is sent again.
+func (s *PaymentService) payCompany(companyID string, now time.Time) error {
+	provider := s.paymentProvider()
+	if provider == nil {
+		return nil
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The settings row lock keeps concurrent runs from paying the company twice
+	_, err = tx.Exec(`
+		INSERT INTO company_payout_settings (company_id) VALUES ($1)
+		ON CONFLICT (company_id) DO NOTHING
+	`, companyID)
+	if err != nil {
+		return err
+	}
+	settings, err := loadPayoutSettings(tx, companyID, true)
+	if err != nil {
+		return err
+	}
+
+	// A failed payout was reversed, so the company is paid again in the same period
+	periodStart, _ := payoutPeriodStart(settings.Schedule, now)
+	var paidThisPeriod bool
+	err = tx.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM company_payouts WHERE company_id = $1 AND created_at >= $2 AND status <> 'failed')
+	`, companyID, periodStart).Scan(&paidThisPeriod)
+	if err != nil || paidThisPeriod {
+		return err
+	}
+
+	candidates, err := queryPayoutCandidates(tx, companyID)
+	if err != nil {
+		return err
+	}
+
+	byCurrency := make(map[string][]payoutCandidate)
+	var currencies []string
+	for _, candidate := range candidates {
+		if _, ok := byCurrency[candidate.Currency]; !ok {
+			currencies = append(currencies, candidate.Currency)
+		}
+		byCurrency[candidate.Currency] = append(byCurrency[candidate.Currency], candidate)
+	}
+	sort.Strings(currencies)
+
+	var payouts []*models.CompanyPayout
+	for _, currency := range currencies {
+		payout, err := createPayout(tx, settings, currency, byCurrency[currency], now)
+		if err != nil {
+			return err
+		}
+		if payout != nil {
+			payouts = append(payouts, payout)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	for _, payout := range payouts {
+		if err := s.sendPayout(provider, settings, payout); err != nil {
+			log.Printf("Payout %s to company %s failed: %v", payout.ID, companyID, err)
+		}
+	}
+
+	return nil
+}
+
+// createPayout records a payout of the candidates not held back, if they reach the company's minimum
+func createPayout(tx *sql.Tx, settings *models.CompanyPayoutSettings, currency string, candidates []payoutCandidate, now time.Time) (*models.CompanyPayout, error) {
+	var included []payoutCandidate
+	var amount, held, gross, commission, refunded int64
+	periodStart := now
+	for _, candidate := range candidates {
+		if payoutHeld(&candidate, now) {
+			held += candidate.Amount
+			continue
+		}
+		included = append(included, candidate)
+		amount += candidate.Amount
+		gross += candidate.Gross
+		commission += candidate.Commission
+		refunded += candidate.Refunded
+		if candidate.TransferredAt.Before(periodStart) {
+			periodStart = candidate.TransferredAt
+		}
+	}
+	if amount <= 0 || amount < settings.MinimumPayout.WithCurrency(currency).Amount {
+		return nil, nil
+	}
+
+	// A payout's period runs on from the previous one's
+	var lastPeriodEnd *time.Time
+	err := tx.QueryRow(`
+		SELECT MAX(period_end) FROM company_payouts
+		WHERE company_id = $1 AND currency = $2 AND status <> 'failed'
+	`, settings.CompanyID, currency).Scan(&lastPeriodEnd)
+	if err != nil {
+		return nil, err
+	}
+	if lastPeriodEnd != nil {
+		periodStart = *lastPeriodEnd
+	}
+
+	description := fmt.Sprintf("Payout for %s - %s", periodStart.Format("2006-01-02"), now.Format("2006-01-02"))
+	payout := &models.CompanyPayout{
+		CompanyID:          settings.CompanyID,
+		Amount:             models.NewMoney(amount, currency),
+		Currency:           currency,
+		Status:             "pending",
+		Description:        &description,
+		PeriodStart:        periodStart,
+		PeriodEnd:          now,
+		TotalSales:         models.NewMoney(gross, currency),
+		CommissionDeducted: models.NewMoney(commission, currency),
+		RefundsDeducted:    models.NewMoney(refunded, currency),
+		HeldAmount:         models.NewMoney(held, currency),
+		CreatedAt:          now,
+	}
+
+	err = tx.QueryRow(`
+		INSERT INTO company_payouts (company_id, amount, currency, status, description, period_start, period_end,
+									 total_sales, commission_deducted, processing_fees, refunds_deducted,
+									 held_amount, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, 0, $10, $11, $12, $12)
+		RETURNING id
+	`, payout.CompanyID, payout.Amount, payout.Currency, payout.Status, payout.Description, payout.PeriodStart,
+		payout.PeriodEnd, payout.TotalSales, payout.CommissionDeducted, payout.RefundsDeducted, payout.HeldAmount,
+		payout.CreatedAt).Scan(&payout.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create payout: %w", err)
+	}
+
+	for _, item := range included {
+		_, err = tx.Exec(`
+			INSERT INTO company_payout_items (payout_id, payment_id, booking_id, order_id, description,
+											  gross_amount, commission_amount, refunded_amount, amount, created_at)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		`, payout.ID, item.PaymentID, item.BookingID, item.OrderID, item.Description,
+			models.NewMoney(item.Gross, currency), models.NewMoney(item.Commission, currency),
+			models.NewMoney(item.Refunded, currency), models.NewMoney(item.Amount, currency), now)
+		if err != nil {
+			return nil, fmt.Errorf("failed to record payout item: %w", err)
+		}
+	}
+
+	err = postLedgerTransaction(tx, &ledgerPosting{
+		Key:         "payout:" + payout.ID,
+		Kind:        "payout",
+		CompanyID:   &payout.CompanyID,
+		Currency:    currency,
+		Description: description,
+		Lines: []ledgerLine{
+			companyLedgerLine(ledgerBalancePrefix, payout.CompanyID, amount),
+			platformLedgerLine(ledgerPlatformCash, "asset", -amount),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return payout, nil
+}
+
+// sendPayout sends a recorded payout through the provider and records the outcome
+func (s *PaymentService) sendPayout(provider PaymentProvider, settings *models.CompanyPayoutSettings, payout *models.CompanyPayout) error {
+	sent, sendErr := provider.CreatePayout(ProviderPayoutParams{
+		Amount:      payout.Amount.Amount,
+		Currency:    payout.Currency,
+		Destination: stringValue(settings.Destination),
+		Description: stringValue(payout.Description),
+		Metadata:    map[string]string{"payout_id": payout.ID, "company_id": payout.CompanyID},
+		// A payout sent again, e.g. after a timeout, is not paid twice
+		IdempotencyKey: payout.ID,
+	})
+	if sendErr == nil && sent.Status == "failed" {
+		sendErr = fmt.Errorf("payout was rejected by the payment provider")
+	}
+
+	// Without an answer the payout may have been made; it stays pending and is sent again under the same key
+	if errors.Is(sendErr, ErrProviderUnreachable) {
+		_, err := s.db.Exec(`
+			UPDATE company_payouts SET provider = $2, failure_reason = $3, updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+		`, payout.ID, provider.Name(), sendErr.Error())
+		if err != nil {
+			return err
+		}
+		return sendErr
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if sendErr != nil {
+		_, err = tx.Exec(`
+			UPDATE company_payouts SET status = 'failed', provider = $2, failure_reason = $3, updated_at = NOW()
+			WHERE id = $1
+		`, payout.ID, provider.Name(), sendErr.Error())
+		if err != nil {
+			return err
+		}
+
+		// The company is owed the money again
+		amount := payout.Amount.Amount
+		err = postLedgerTransaction(tx, &ledgerPosting{
+			Key:         "payout_failed:" + payout.ID,
+			Kind:        "payout",
+			CompanyID:   &payout.CompanyID,
+			Currency:    payout.Currency,
+			Description: "Payout failed: " + sendErr.Error(),
+			Lines: []ledgerLine{
+				platformLedgerLine(ledgerPlatformCash, "asset", amount),
+				companyLedgerLine(ledgerBalancePrefix, payout.CompanyID, -amount),
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if err = tx.Commit(); err != nil {
+			return err
+		}
+		return sendErr
+	}
+
+	_, err = tx.Exec(`
+		UPDATE company_payouts
+		SET status = $2, provider = $3, stripe_transfer_id = $4, failure_reason = NULL,
+			processed_at = CASE WHEN $2 = 'paid' THEN NOW() END, updated_at = NOW()
+		WHERE id = $1
+	`, payout.ID, sent.Status, provider.Name(), sent.ID)
+	if err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// resendPendingPayouts sends again the payouts recorded but never confirmed by the provider, because the
+// request went unanswered or the run stopped before sending them. The payout ID keeps them from being paid twice.
+func (s *PaymentService) resendPendingPayouts(now time.Time) {
+	provider := s.paymentProvider()
+	if provider == nil {
+		return
+	}
+
+	rows, err := s.db.Query(payoutSelect+`
+		WHERE status = 'pending' AND stripe_transfer_id IS NULL AND created_at < $1
+		ORDER BY created_at
+	`, now.Add(-payoutResendAfter))
+	if err != nil {
+		log.Printf("Error finding pending payouts: %v", err)
+		return
+	}
+	defer rows.Close()
+
+	var payouts []*models.CompanyPayout
+	for rows.Next() {
+		payout, err := scanPayout(rows)
+		if err != nil {
+			log.Printf("Error finding pending payouts: %v", err)
+			return
+		}
+		payouts = append(payouts, payout)
+	}
+	rows.Close()
+
+	for _, payout := range payouts {
+		// A payout is only sent through the provider it was first sent through
+		if payout.Provider != nil && *payout.Provider != provider.Name() {
+			continue
+		}
+		settings, err := loadPayoutSettings(s.db, payout.CompanyID, false)
+		if err != nil {
+			log.Printf("Error loading payout settings of company %s: %v", payout.CompanyID, err)
+			continue
+		}
+		if err := s.sendPayout(provider, settings, payout); err != nil {
+			log.Printf("Payout %s to company %s failed: %v", payout.ID, payout.CompanyID, err)
+		}
+	}
+}
+
+// nextPayoutAt returns when the company's next payout runs: the next hourly run, unless it was paid this period
+func (s *PaymentService) nextPayoutAt(companyID, schedule string, now time.Time) (time.Time, error) {
+	periodStart, _ := payoutPeriodStart(schedule, now)
+	var paidThisPeriod bool
+	err := s.db.QueryRow(`
+		SELECT EXISTS (SELECT 1 FROM company_payouts WHERE company_id = $1 AND created_at >= $2 AND status <> 'failed')
+	`, companyID, periodStart).Scan(&paidThisPeriod)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if !paidThisPeriod {
+		return now.Truncate(time.Hour).Add(time.Hour), nil
+	}
+	switch schedule {
+	case "daily":
+		return periodStart.AddDate(0, 0, 1), nil
+	case "monthly":
+		return periodStart.AddDate(0, 1, 0), nil
+	default:
+		return periodStart.AddDate(0, 0, 7), nil
+	}
+}
+
+// payoutPeriodStart returns when the schedule's current period began: today, this Monday or the 1st, in UTC
+func payoutPeriodStart(schedule string, now time.Time) (time.Time, bool) {
+	year, month, day := now.UTC().Date()
+	today := time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+
+	switch schedule {
+	case "daily":
+		return today, true
+	case "weekly":
+		return today.AddDate(0, 0, -((int(today.Weekday()) + 6) % 7)), true
+	case "monthly":
+		return time.Date(year, month, 1, 0, 0, 0, 0, time.UTC), true
+	}
+	return today, false
+}
+
+// payoutHeld reports whether a candidate waits for a later payout: disputed payments wait for the dispute to
+// close, and payments wait out the refund hold after their release. Deductions are never held.
+func payoutHeld(candidate *payoutCandidate, at time.Time) bool {
+	if candidate.Amount <= 0 {
+		return false
+	}
+	return candidate.Status == "disputed" || at.Sub(candidate.TransferredAt) < payoutRefundHold
+}
+
+func loadPayoutSettings(q rowQuerier, companyID string, forUpdate bool) (*models.CompanyPayoutSettings, error) {
+	query := `
+		SELECT company_id, schedule, minimum_payout, destination, updated_at
+		FROM company_payout_settings WHERE company_id = $1
+	`
+	if forUpdate {
+		query += " FOR UPDATE"
+	}
+
+	settings := &models.CompanyPayoutSettings{}
+	err := q.QueryRow(query, companyID).Scan(&settings.CompanyID, &settings.Schedule, &settings.MinimumPayout,
+		&settings.Destination, &settings.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return &models.CompanyPayoutSettings{CompanyID: companyID, Schedule: "weekly", UpdatedAt: time.Now()}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout settings: %w", err)
+	}
+
+	return settings, nil
+}
+
+// queryPayoutCandidates returns the company's released payments with anything not paid out yet. What a payment
+// brought the company comes from the ledger; what earlier payouts included is subtracted.
+func queryPayoutCandidates(q rowsQuerier, companyID string) ([]payoutCandidate, error) {
+	rows, err := q.Query(`
+		SELECT p.id, p.booking_id, p.order_id, COALESCE(sv.name, p.description, ''), p.status, p.transferred_at,
+			   l.currency, l.gross, l.commission, l.refunded, l.net,
+			   COALESCE(paid.gross, 0), COALESCE(paid.commission, 0), COALESCE(paid.refunded, 0), COALESCE(paid.amount, 0)
+		FROM payments p
+		JOIN (
+			SELECT t.payment_id, t.currency,
+				   SUM(CASE WHEN a.code = $2 AND t.kind = 'payment' THEN e.amount ELSE 0 END) AS gross,
+				   -SUM(CASE WHEN a.code = $3 THEN e.amount ELSE 0 END) AS commission,
+				   -SUM(CASE WHEN a.code = $2 AND t.kind IN ('refund', 'dispute') THEN e.amount ELSE 0 END) AS refunded,
+				   -SUM(CASE WHEN a.code = $4 THEN e.amount ELSE 0 END) AS net
+			FROM ledger_transactions t
+			JOIN ledger_entries e ON e.transaction_id = t.id
+			JOIN ledger_accounts a ON a.id = e.account_id
+			WHERE t.company_id = $1 AND t.payment_id IS NOT NULL
+			GROUP BY t.payment_id, t.currency
+		) l ON l.payment_id = p.id
+		LEFT JOIN (
+			SELECT i.payment_id, SUM(i.gross_amount) AS gross, SUM(i.commission_amount) AS commission,
+				   SUM(i.refunded_amount) AS refunded, SUM(i.amount) AS amount
+			FROM company_payout_items i
+			JOIN company_payouts cp ON cp.id = i.payout_id
+			WHERE cp.company_id = $1 AND cp.status <> 'failed'
+			GROUP BY i.payment_id
+		) paid ON paid.payment_id = p.id
+		LEFT JOIN bookings b ON b.id = p.booking_id
+		LEFT JOIN services sv ON sv.id = b.service_id
+		WHERE p.company_id = $1 AND p.transferred_at IS NOT NULL
+		ORDER BY p.transferred_at
+	`, companyID, ledgerPlatformCash, ledgerCommissionRevenue, ledgerBalancePrefix+companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get payout candidates: %w", err)
+	}
+	defer rows.Close()
+
+	candidates := []payoutCandidate{}
+	for rows.Next() {
+		var candidate payoutCandidate
+		var paidGross, paidCommission, paidRefunded, paidAmount models.Money
+		err := rows.Scan(&candidate.PaymentID, &candidate.BookingID, &candidate.OrderID, &candidate.Description,
+			&candidate.Status, &candidate.TransferredAt, &candidate.Currency, &candidate.Gross, &candidate.Commission,
+			&candidate.Refunded, &candidate.Amount, &paidGross, &paidCommission, &paidRefunded, &paidAmount)
+		if err != nil {
+			return nil, err
+		}
+
+		candidate.Gross -= paidGross.WithCurrency(candidate.Currency).Amount
+		candidate.Commission -= paidCommission.WithCurrency(candidate.Currency).Amount
+		candidate.Refunded -= paidRefunded.WithCurrency(candidate.Currency).Amount
+		candidate.Amount -= paidAmount.WithCurrency(candidate.Currency).Amount
+		if candidate.Gross == 0 && candidate.Commission == 0 && candidate.Refunded == 0 && candidate.Amount == 0 {
+			continue
+		}
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates, nil
+}
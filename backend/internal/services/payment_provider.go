@@ -0,0 +1,159 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// ErrPaymentDeclined is returned when the card processor refuses a payment method
+var ErrPaymentDeclined = errors.New("payment was declined")
+
+// ErrIntentUnexpectedState is returned when a payment intent's status does not allow the request,
+// e.g. canceling an intent the customer already paid
+var ErrIntentUnexpectedState = errors.New("payment intent status does not allow this")
+
+// ErrProviderUnreachable is returned when a request may or may not have reached the card processor
+var ErrProviderUnreachable = errors.New("payment provider could not be reached")
+
+// Payment providers that payment_settings.payment_provider can select
+const (
+	PaymentProviderStripe = "stripe"
+	PaymentProviderFake   = "fake"
+)
+
+// PaymentProvider is the card processor behind PaymentService. Amounts are in minor units (cents);
+// intent statuses follow Stripe's: requires_payment_method, requires_confirmation, requires_action,
+// processing, requires_capture, canceled and succeeded.
+type PaymentProvider interface {
+	Name() string
+	CreatePaymentIntent(params ProviderIntentParams) (*ProviderIntent, error)
+	ConfirmPaymentIntent(intentID, paymentMethodID string) (*ProviderIntent, error)
+	// CapturePaymentIntent collects an authorized intent; an amount of 0 captures all of it
+	CapturePaymentIntent(intentID string, amount int64) (*ProviderIntent, error)
+	// CancelPaymentIntent voids an intent that was not paid, so that the customer can no longer pay it
+	CancelPaymentIntent(intentID string) (*ProviderIntent, error)
+	Refund(params ProviderRefundParams) (*ProviderRefund, error)
+	CreateCustomer(params ProviderCustomerParams) (string, error)
+	ListPaymentMethods(customerID string) ([]SavedPaymentMethod, error)
+	CreateSetupIntent(customerID string) (*ProviderSetupIntent, error)
+	CreateSubscription(customerID, priceID, paymentMethodID string) (*ProviderSubscription, error)
+	// CreatePayout sends money the platform holds for a company to the company's account
+	CreatePayout(params ProviderPayoutParams) (*ProviderPayout, error)
+}
+
+// ProviderIntentParams describes a payment to collect
+type ProviderIntentParams struct {
+	Amount        int64
+	Currency      string
+	CustomerID    string
+	Description   string
+	Metadata      map[string]string
+	ManualCapture bool
+	// IdempotencyKey makes a repeated request return the intent the first one created
+	IdempotencyKey string
+}
+
+// ProviderIntent is a payment as the provider sees it
+type ProviderIntent struct {
+	ID             string
+	ClientSecret   string
+	Status         string
+	Amount         int64
+	AmountReceived int64
+	Currency       string
+}
+
+// ProviderRefundParams describes money to send back on a payment
+type ProviderRefundParams struct {
+	IntentID       string
+	Amount         int64
+	Reason         string
+	IdempotencyKey string // Makes a repeated request return the refund the first one created
+}
+
+// ProviderRefund is money sent back on a payment
+type ProviderRefund struct {
+	ID     string
+	Status string // pending, succeeded, failed
+	Amount int64
+}
+
+// ProviderCustomerParams identifies a customer to the provider
+type ProviderCustomerParams struct {
+	UserID string
+	Email  string
+	Name   string
+}
+
+// ProviderSetupIntent saves a payment method for later payments
+type ProviderSetupIntent struct {
+	ID           string
+	ClientSecret string
+	Status       string
+}
+
+// ProviderSubscription is a recurring charge
+type ProviderSubscription struct {
+	ID               string
+	Status           string
+	CurrentPeriodEnd time.Time
+}
+
+// ProviderPayoutParams describes money to send to a company
+type ProviderPayoutParams struct {
+	Amount      int64
+	Currency    string
+	Destination string // The company's account with the provider
+	Description string
+	Metadata    map[string]string
+	// IdempotencyKey makes a repeated request return the payout the first one created
+	IdempotencyKey string
+}
+
+// ProviderPayout is money sent to a company
+type ProviderPayout struct {
+	ID     string
+	Status string // pending, paid, failed
+}
+
+// SavedPaymentMethod is a card a customer saved with the provider
+type SavedPaymentMethod struct {
+	ID       string `json:"id"`
+	Type     string `json:"type"`
+	Brand    string `json:"brand"`
+	Last4    string `json:"last4"`
+	ExpMonth int    `json:"exp_month"`
+	ExpYear  int    `json:"exp_year"`
+}
+
+// newPaymentProvider returns the provider the settings select, or nil when payments are taken offline
+func newPaymentProvider(name string, stripeEnabled bool, stripeSecretKey string) (PaymentProvider, error) {
+	switch name {
+	case "", PaymentProviderStripe:
+		if !stripeEnabled || stripeSecretKey == "" {
+			return nil, nil
+		}
+		return NewStripeProvider(stripeSecretKey), nil
+	case PaymentProviderFake:
+		return NewFakePaymentProvider(), nil
+	default:
+		return nil, fmt.Errorf("unknown payment provider: %s", name)
+	}
+}
+
+// paymentStatusForIntent maps a provider intent status onto payments.status
+func paymentStatusForIntent(status string) string {
+	switch status {
+	case "succeeded":
+		return "succeeded"
+	case "requires_capture":
+		return "authorized"
+	case "canceled":
+		return "canceled"
+	case "processing":
+		return "processing"
+	default:
+		return "pending"
+	}
+}
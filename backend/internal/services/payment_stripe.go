@@ -0,0 +1,349 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const stripeAPIBase = "https://api.stripe.com/v1"
+
+// stripeRetries is how many times a request with an idempotency key is sent again after a network error
+const stripeRetries = 2
+
+// StripeProvider takes payments through the Stripe REST API
+type StripeProvider struct {
+	secretKey string
+	baseURL   string
+	client    *http.Client
+}
+
+func NewStripeProvider(secretKey string) *StripeProvider {
+	return &StripeProvider{
+		secretKey: secretKey,
+		baseURL:   stripeAPIBase,
+		client:    &http.Client{Timeout: 30 * time.Second},
+	}
+}
+
+type stripeIntent struct {
+	ID             string `json:"id"`
+	ClientSecret   string `json:"client_secret"`
+	Status         string `json:"status"`
+	Amount         int64  `json:"amount"`
+	AmountReceived int64  `json:"amount_received"`
+	Currency       string `json:"currency"`
+}
+
+type stripeError struct {
+	Error struct {
+		Type        string `json:"type"`
+		Code        string `json:"code"`
+		DeclineCode string `json:"decline_code"`
+		Message     string `json:"message"`
+	} `json:"error"`
+}
+
+func (p *StripeProvider) Name() string {
+	return PaymentProviderStripe
+}
+
+func (p *StripeProvider) CreatePaymentIntent(params ProviderIntentParams) (*ProviderIntent, error) {
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	form.Set("payment_method_types[]", "card")
+	if params.CustomerID != "" {
+		form.Set("customer", params.CustomerID)
+	}
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	if params.ManualCapture {
+		form.Set("capture_method", "manual")
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var intent stripeIntent
+	if err := p.postIdempotent("/payment_intents", params.IdempotencyKey, form, &intent); err != nil {
+		return nil, err
+	}
+	return intent.provider(), nil
+}
+
+func (p *StripeProvider) ConfirmPaymentIntent(intentID, paymentMethodID string) (*ProviderIntent, error) {
+	form := url.Values{}
+	if paymentMethodID != "" {
+		form.Set("payment_method", paymentMethodID)
+	}
+
+	var intent stripeIntent
+	if err := p.post("/payment_intents/"+url.PathEscape(intentID)+"/confirm", form, &intent); err != nil {
+		return nil, err
+	}
+	return intent.provider(), nil
+}
+
+func (p *StripeProvider) CapturePaymentIntent(intentID string, amount int64) (*ProviderIntent, error) {
+	form := url.Values{}
+	if amount > 0 {
+		form.Set("amount_to_capture", strconv.FormatInt(amount, 10))
+	}
+
+	var intent stripeIntent
+	if err := p.post("/payment_intents/"+url.PathEscape(intentID)+"/capture", form, &intent); err != nil {
+		return nil, err
+	}
+	return intent.provider(), nil
+}
+
+func (p *StripeProvider) CancelPaymentIntent(intentID string) (*ProviderIntent, error) {
+	var intent stripeIntent
+	if err := p.post("/payment_intents/"+url.PathEscape(intentID)+"/cancel", url.Values{}, &intent); err != nil {
+		return nil, err
+	}
+	return intent.provider(), nil
+}
+
+func (p *StripeProvider) Refund(params ProviderRefundParams) (*ProviderRefund, error) {
+	form := url.Values{}
+	form.Set("payment_intent", params.IntentID)
+	form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	// Stripe only takes its own reason codes; ours travels as metadata
+	form.Set("reason", "requested_by_customer")
+	if params.Reason != "" {
+		form.Set("metadata[reason]", params.Reason)
+	}
+
+	var refund struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Amount int64  `json:"amount"`
+	}
+	if err := p.postIdempotent("/refunds", params.IdempotencyKey, form, &refund); err != nil {
+		return nil, err
+	}
+
+	status := refund.Status
+	if status != "succeeded" && status != "failed" {
+		status = "pending"
+	}
+	return &ProviderRefund{ID: refund.ID, Status: status, Amount: refund.Amount}, nil
+}
+
+func (p *StripeProvider) CreateCustomer(params ProviderCustomerParams) (string, error) {
+	form := url.Values{}
+	form.Set("email", params.Email)
+	if params.Name != "" {
+		form.Set("name", params.Name)
+	}
+	form.Set("metadata[user_id]", params.UserID)
+
+	var customer struct {
+		ID string `json:"id"`
+	}
+	if err := p.post("/customers", form, &customer); err != nil {
+		return "", err
+	}
+	return customer.ID, nil
+}
+
+func (p *StripeProvider) ListPaymentMethods(customerID string) ([]SavedPaymentMethod, error) {
+	query := url.Values{}
+	query.Set("customer", customerID)
+	query.Set("type", "card")
+
+	var list struct {
+		Data []struct {
+			ID   string `json:"id"`
+			Type string `json:"type"`
+			Card struct {
+				Brand    string `json:"brand"`
+				Last4    string `json:"last4"`
+				ExpMonth int    `json:"exp_month"`
+				ExpYear  int    `json:"exp_year"`
+			} `json:"card"`
+		} `json:"data"`
+	}
+	if err := p.request(http.MethodGet, "/payment_methods?"+query.Encode(), "", nil, &list); err != nil {
+		return nil, err
+	}
+
+	methods := []SavedPaymentMethod{}
+	for _, method := range list.Data {
+		methods = append(methods, SavedPaymentMethod{
+			ID:       method.ID,
+			Type:     method.Type,
+			Brand:    method.Card.Brand,
+			Last4:    method.Card.Last4,
+			ExpMonth: method.Card.ExpMonth,
+			ExpYear:  method.Card.ExpYear,
+		})
+	}
+	return methods, nil
+}
+
+func (p *StripeProvider) CreateSetupIntent(customerID string) (*ProviderSetupIntent, error) {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	form.Set("usage", "off_session")
+	form.Set("payment_method_types[]", "card")
+
+	var intent struct {
+		ID           string `json:"id"`
+		ClientSecret string `json:"client_secret"`
+		Status       string `json:"status"`
+	}
+	if err := p.post("/setup_intents", form, &intent); err != nil {
+		return nil, err
+	}
+	return &ProviderSetupIntent{ID: intent.ID, ClientSecret: intent.ClientSecret, Status: intent.Status}, nil
+}
+
+func (p *StripeProvider) CreateSubscription(customerID, priceID, paymentMethodID string) (*ProviderSubscription, error) {
+	form := url.Values{}
+	form.Set("customer", customerID)
+	form.Set("items[0][price]", priceID)
+	if paymentMethodID != "" {
+		form.Set("default_payment_method", paymentMethodID)
+	}
+
+	var subscription struct {
+		ID               string `json:"id"`
+		Status           string `json:"status"`
+		CurrentPeriodEnd int64  `json:"current_period_end"`
+	}
+	if err := p.post("/subscriptions", form, &subscription); err != nil {
+		return nil, err
+	}
+	return &ProviderSubscription{
+		ID:               subscription.ID,
+		Status:           subscription.Status,
+		CurrentPeriodEnd: time.Unix(subscription.CurrentPeriodEnd, 0),
+	}, nil
+}
+
+// CreatePayout transfers the amount to the company's connected account, from which Stripe pays it out
+func (p *StripeProvider) CreatePayout(params ProviderPayoutParams) (*ProviderPayout, error) {
+	if params.Destination == "" {
+		return nil, fmt.Errorf("company has no Stripe account to pay out to")
+	}
+
+	form := url.Values{}
+	form.Set("amount", strconv.FormatInt(params.Amount, 10))
+	form.Set("currency", strings.ToLower(params.Currency))
+	form.Set("destination", params.Destination)
+	if params.Description != "" {
+		form.Set("description", params.Description)
+	}
+	for key, value := range params.Metadata {
+		form.Set("metadata["+key+"]", value)
+	}
+
+	var transfer struct {
+		ID       string `json:"id"`
+		Reversed bool   `json:"reversed"`
+	}
+	if err := p.postIdempotent("/transfers", params.IdempotencyKey, form, &transfer); err != nil {
+		return nil, err
+	}
+
+	status := "paid"
+	if transfer.Reversed {
+		status = "failed"
+	}
+	return &ProviderPayout{ID: transfer.ID, Status: status}, nil
+}
+
+// Helper methods
+
+func (p *StripeProvider) post(path string, form url.Values, out interface{}) error {
+	return p.request(http.MethodPost, path, "", form, out)
+}
+
+// postIdempotent posts with an idempotency key, so that Stripe returns the first result when
+// the request is repeated. Requests that fail before a response arrives are sent again.
+func (p *StripeProvider) postIdempotent(path, idempotencyKey string, form url.Values, out interface{}) error {
+	var err error
+	for attempt := 0; attempt <= stripeRetries; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		err = p.request(http.MethodPost, path, idempotencyKey, form, out)
+		if !errors.Is(err, ErrProviderUnreachable) || idempotencyKey == "" {
+			return err
+		}
+	}
+	return err
+}
+
+// request calls the API and decodes the response into out. Card errors wrap ErrPaymentDeclined, requests
+// an intent's status does not allow wrap ErrIntentUnexpectedState, and errors that leave the outcome
+// unknown wrap ErrProviderUnreachable.
+func (p *StripeProvider) request(method, path, idempotencyKey string, form url.Values, out interface{}) error {
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+
+	req, err := http.NewRequest(method, p.baseURL+path, body)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(p.secretKey, "")
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrProviderUnreachable, err)
+	}
+
+	if resp.StatusCode >= 500 {
+		return fmt.Errorf("%w: status %d", ErrProviderUnreachable, resp.StatusCode)
+	}
+	if resp.StatusCode >= 300 {
+		var apiErr stripeError
+		if err := json.Unmarshal(data, &apiErr); err != nil || apiErr.Error.Message == "" {
+			return fmt.Errorf("stripe request failed with status %d", resp.StatusCode)
+		}
+		if apiErr.Error.Type == "card_error" {
+			return fmt.Errorf("%w: %s", ErrPaymentDeclined, apiErr.Error.Message)
+		}
+		if apiErr.Error.Code == "payment_intent_unexpected_state" {
+			return fmt.Errorf("%w: %s", ErrIntentUnexpectedState, apiErr.Error.Message)
+		}
+		return fmt.Errorf("stripe: %s", apiErr.Error.Message)
+	}
+
+	return json.Unmarshal(data, out)
+}
+
+func (i *stripeIntent) provider() *ProviderIntent {
+	return &ProviderIntent{
+		ID:             i.ID,
+		ClientSecret:   i.ClientSecret,
+		Status:         i.Status,
+		Amount:         i.Amount,
+		AmountReceived: i.AmountReceived,
+		Currency:       i.Currency,
+	}
+}
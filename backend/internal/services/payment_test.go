@@ -0,0 +1,210 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFakePaymentProviderFlow(t *testing.T) {
+	provider := NewFakePaymentProvider()
+
+	intent, err := provider.CreatePaymentIntent(ProviderIntentParams{
+		Amount:         5000,
+		Currency:       "USD",
+		ManualCapture:  true,
+		IdempotencyKey: "payment:1",
+	})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if status := paymentStatusForIntent(intent.Status); status != "pending" {
+		t.Fatalf("created intent maps to %s, want pending", status)
+	}
+
+	confirmed, err := provider.ConfirmPaymentIntent(intent.ID, FakeCardVisa)
+	if err != nil {
+		t.Fatalf("ConfirmPaymentIntent: %v", err)
+	}
+	if status := paymentStatusForIntent(confirmed.Status); status != "authorized" {
+		t.Fatalf("confirmed intent maps to %s, want authorized", status)
+	}
+
+	captured, err := provider.CapturePaymentIntent(intent.ID, 0)
+	if err != nil {
+		t.Fatalf("CapturePaymentIntent: %v", err)
+	}
+	if status := paymentStatusForIntent(captured.Status); status != "succeeded" {
+		t.Fatalf("captured intent maps to %s, want succeeded", status)
+	}
+	if captured.AmountReceived != 5000 {
+		t.Fatalf("captured %d, want 5000", captured.AmountReceived)
+	}
+
+	refund, err := provider.Refund(ProviderRefundParams{IntentID: intent.ID, Amount: 2000, IdempotencyKey: "refund:1"})
+	if err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if refund.Status != "succeeded" || refund.Amount != 2000 {
+		t.Fatalf("refund = %+v, want 2000 succeeded", refund)
+	}
+
+	// Repeated requests return the first result instead of acting twice
+	repeated, err := provider.Refund(ProviderRefundParams{IntentID: intent.ID, Amount: 2000, IdempotencyKey: "refund:1"})
+	if err != nil || repeated.ID != refund.ID {
+		t.Fatalf("repeated refund = %+v, %v; want %s", repeated, err, refund.ID)
+	}
+	again, err := provider.CreatePaymentIntent(ProviderIntentParams{Amount: 5000, Currency: "USD", IdempotencyKey: "payment:1"})
+	if err != nil || again.ID != intent.ID {
+		t.Fatalf("repeated intent = %+v, %v; want %s", again, err, intent.ID)
+	}
+
+	if _, err := provider.Refund(ProviderRefundParams{IntentID: intent.ID, Amount: 3001, IdempotencyKey: "refund:2"}); err == nil {
+		t.Fatal("refunding more than was paid succeeded")
+	}
+}
+
+func TestFakePaymentProviderDeclinedCard(t *testing.T) {
+	provider := NewFakePaymentProvider()
+
+	intent, err := provider.CreatePaymentIntent(ProviderIntentParams{Amount: 1000, Currency: "EUR"})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+
+	if _, err := provider.ConfirmPaymentIntent(intent.ID, FakeCardDeclined); !errors.Is(err, ErrPaymentDeclined) {
+		t.Fatalf("declined card returned %v, want ErrPaymentDeclined", err)
+	}
+
+	// The customer can pay with another card
+	confirmed, err := provider.ConfirmPaymentIntent(intent.ID, FakeCardVisa)
+	if err != nil {
+		t.Fatalf("ConfirmPaymentIntent: %v", err)
+	}
+	if confirmed.Status != "succeeded" {
+		t.Fatalf("confirmed intent is %s, want succeeded", confirmed.Status)
+	}
+}
+
+func TestFakePaymentProviderSavedMethodBelongsToCustomer(t *testing.T) {
+	provider := NewFakePaymentProvider()
+
+	owner, _ := provider.CreateCustomer(ProviderCustomerParams{UserID: "u1", Email: "owner@example.com"})
+	other, _ := provider.CreateCustomer(ProviderCustomerParams{UserID: "u2", Email: "other@example.com"})
+	if _, err := provider.CreateSetupIntent(owner); err != nil {
+		t.Fatalf("CreateSetupIntent: %v", err)
+	}
+	methods, err := provider.ListPaymentMethods(owner)
+	if err != nil || len(methods) != 1 {
+		t.Fatalf("ListPaymentMethods = %v, %v; want one card", methods, err)
+	}
+
+	stolen, err := provider.CreatePaymentIntent(ProviderIntentParams{Amount: 1000, Currency: "USD", CustomerID: other})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if _, err := provider.ConfirmPaymentIntent(stolen.ID, methods[0].ID); err == nil {
+		t.Fatal("another customer's card paid the intent")
+	}
+
+	own, err := provider.CreatePaymentIntent(ProviderIntentParams{Amount: 1000, Currency: "USD", CustomerID: owner})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if _, err := provider.ConfirmPaymentIntent(own.ID, methods[0].ID); err != nil {
+		t.Fatalf("ConfirmPaymentIntent with the customer's own card: %v", err)
+	}
+}
+
+func TestFakePaymentProviderCancel(t *testing.T) {
+	provider := NewFakePaymentProvider()
+
+	unpaid, err := provider.CreatePaymentIntent(ProviderIntentParams{Amount: 1000, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	canceled, err := provider.CancelPaymentIntent(unpaid.ID)
+	if err != nil {
+		t.Fatalf("CancelPaymentIntent: %v", err)
+	}
+	if status := paymentStatusForIntent(canceled.Status); status != "canceled" {
+		t.Fatalf("canceled intent maps to %s, want canceled", status)
+	}
+	if _, err := provider.ConfirmPaymentIntent(unpaid.ID, FakeCardVisa); err == nil {
+		t.Fatal("a canceled intent was paid")
+	}
+
+	paid, err := provider.CreatePaymentIntent(ProviderIntentParams{Amount: 1000, Currency: "USD"})
+	if err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if _, err := provider.ConfirmPaymentIntent(paid.ID, FakeCardVisa); err != nil {
+		t.Fatalf("ConfirmPaymentIntent: %v", err)
+	}
+	if _, err := provider.CancelPaymentIntent(paid.ID); !errors.Is(err, ErrIntentUnexpectedState) {
+		t.Fatalf("canceling a paid intent returned %v, want ErrIntentUnexpectedState", err)
+	}
+}
+
+func TestStripeProviderSendsIdempotencyKey(t *testing.T) {
+	var keys []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		keys = append(keys, r.Header.Get("Idempotency-Key"))
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/payment_intents":
+			w.Write([]byte(`{"id":"pi_1","status":"requires_payment_method","amount":5000,"currency":"usd"}`))
+		case "/refunds":
+			w.Write([]byte(`{"id":"re_1","status":"succeeded","amount":2000}`))
+		case "/transfers":
+			w.Write([]byte(`{"id":"tr_1","reversed":false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	provider := NewStripeProvider("sk_test")
+	provider.baseURL = server.URL
+
+	if _, err := provider.CreatePaymentIntent(ProviderIntentParams{Amount: 5000, Currency: "USD", IdempotencyKey: "payment:1"}); err != nil {
+		t.Fatalf("CreatePaymentIntent: %v", err)
+	}
+	if _, err := provider.Refund(ProviderRefundParams{IntentID: "pi_1", Amount: 2000, IdempotencyKey: "refund:1"}); err != nil {
+		t.Fatalf("Refund: %v", err)
+	}
+	if _, err := provider.CreatePayout(ProviderPayoutParams{Amount: 3000, Currency: "USD", Destination: "acct_1", IdempotencyKey: "payout-1"}); err != nil {
+		t.Fatalf("CreatePayout: %v", err)
+	}
+
+	want := []string{"payment:1", "refund:1", "payout-1"}
+	if len(keys) != len(want) {
+		t.Fatalf("sent keys %v, want %v", keys, want)
+	}
+	for i := range want {
+		if keys[i] != want[i] {
+			t.Fatalf("sent keys %v, want %v", keys, want)
+		}
+	}
+}
+
+func TestMinorUnitScaleSQL(t *testing.T) {
+	scale := minorUnitScaleSQL("p.currency")
+	for _, want := range []string{"CASE UPPER(p.currency)", "WHEN 'JPY' THEN 1 ", "WHEN 'KWD' THEN 1000 ", "ELSE 100 END"} {
+		if !strings.Contains(scale, want) {
+			t.Errorf("minorUnitScaleSQL() = %q, want it to contain %q", scale, want)
+		}
+	}
+}
+
+func TestIntentMetadataKeepsPaymentID(t *testing.T) {
+	metadata := intentMetadata("pay_1", map[string]interface{}{"payment_id": "pay_2", "booking_id": "b_1"})
+	if metadata["payment_id"] != "pay_1" {
+		t.Errorf("payment_id = %q, want pay_1", metadata["payment_id"])
+	}
+	if metadata["booking_id"] != "b_1" {
+		t.Errorf("booking_id = %q, want b_1", metadata["booking_id"])
+	}
+}
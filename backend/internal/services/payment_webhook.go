@@ -0,0 +1,486 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// ErrInvalidWebhook is returned for webhook requests that cannot be verified or read
+var ErrInvalidWebhook = errors.New("invalid webhook")
+
+// stripeWebhookTolerance is how far a webhook's signing time may be from now, guarding against replays
+const stripeWebhookTolerance = 5 * time.Minute
+
+type stripeEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		Object json.RawMessage `json:"object"`
+	} `json:"data"`
+}
+
+type stripeCharge struct {
+	ID             string `json:"id"`
+	PaymentIntent  string `json:"payment_intent"`
+	Amount         int64  `json:"amount"`
+	AmountRefunded int64  `json:"amount_refunded"`
+	Currency       string `json:"currency"`
+	Refunds        struct {
+		Data []struct {
+			ID     string `json:"id"`
+			Amount int64  `json:"amount"`
+			Status string `json:"status"`
+		} `json:"data"`
+	} `json:"refunds"`
+}
+
+type stripeDispute struct {
+	ID            string `json:"id"`
+	PaymentIntent string `json:"payment_intent"`
+	Amount        int64  `json:"amount"`
+	Currency      string `json:"currency"`
+	Reason        string `json:"reason"`
+	Status        string `json:"status"`
+}
+
+type stripeSubscription struct {
+	ID                string `json:"id"`
+	Status            string `json:"status"`
+	CurrentPeriodEnd  int64  `json:"current_period_end"`
+	CancelAtPeriodEnd bool   `json:"cancel_at_period_end"`
+}
+
+// stripeIntentEventStatus is the payment status each payment intent event moves a payment to
+var stripeIntentEventStatus = map[string]string{
+	"payment_intent.processing":                "processing",
+	"payment_intent.amount_capturable_updated": "authorized",
+	"payment_intent.succeeded":                 "succeeded",
+	"payment_intent.payment_failed":            "failed",
+	"payment_intent.canceled":                  "canceled",
+}
+
+// paymentIntentTransitions lists the statuses an intent event may move a payment to from each status.
+// Events can arrive out of order, so a payment never moves back, e.g. from succeeded to processing;
+// a failed payment can still be paid with another card.
+var paymentIntentTransitions = map[string][]string{
+	"pending":    {"processing", "authorized", "succeeded", "failed", "canceled"},
+	"processing": {"authorized", "succeeded", "failed", "canceled"},
+	"failed":     {"authorized", "succeeded", "canceled"},
+	"authorized": {"succeeded", "canceled"},
+}
+
+// HandleWebhook verifies and processes a Stripe event. Each event is processed once: redeliveries of a
+// processed event are acknowledged without effect, and an event that failed is processed again.
+func (s *PaymentService) HandleWebhook(payload []byte, sigHeader string) error {
+	if err := s.loadPaymentSettings(); err != nil {
+		return err
+	}
+	webhookSecret := s.currentWebhookSecret()
+	if webhookSecret == "" {
+		return fmt.Errorf("stripe webhook secret is not configured")
+	}
+	if err := verifyStripeSignature(payload, sigHeader, webhookSecret, time.Now()); err != nil {
+		return err
+	}
+
+	var event stripeEvent
+	if err := json.Unmarshal(payload, &event); err != nil || event.ID == "" || event.Type == "" {
+		return fmt.Errorf("%w: malformed event", ErrInvalidWebhook)
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		INSERT INTO payment_webhook_events (provider, event_id, event_type, payload)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (provider, event_id) DO NOTHING
+	`, PaymentProviderStripe, event.ID, event.Type, string(payload))
+	if err != nil {
+		return fmt.Errorf("failed to store webhook event: %w", err)
+	}
+
+	// The row lock makes concurrent deliveries of the event wait for this one
+	var processedAt *time.Time
+	err = tx.QueryRow(`
+		SELECT processed_at FROM payment_webhook_events
+		WHERE provider = $1 AND event_id = $2
+		FOR UPDATE
+	`, PaymentProviderStripe, event.ID).Scan(&processedAt)
+	if err != nil {
+		return err
+	}
+	if processedAt != nil {
+		return tx.Commit()
+	}
+
+	// The event's changes are made in tx, so they commit together with processed_at; the savepoint
+	// undoes them when processing fails and the failure is recorded for the redelivery
+	if _, err = tx.Exec("SAVEPOINT process_event"); err != nil {
+		return err
+	}
+	afterCommit, processErr := s.processStripeEvent(tx, &event)
+	if processErr != nil {
+		log.Printf("Failed to process Stripe event %s (%s): %v", event.ID, event.Type, processErr)
+		if _, err = tx.Exec("ROLLBACK TO SAVEPOINT process_event"); err != nil {
+			return err
+		}
+		_, err = tx.Exec(`
+			UPDATE payment_webhook_events SET attempts = attempts + 1, last_error = $3
+			WHERE provider = $1 AND event_id = $2
+		`, PaymentProviderStripe, event.ID, processErr.Error())
+	} else {
+		_, err = tx.Exec(`
+			UPDATE payment_webhook_events SET attempts = attempts + 1, last_error = NULL, processed_at = NOW()
+			WHERE provider = $1 AND event_id = $2
+		`, PaymentProviderStripe, event.ID)
+	}
+	if err != nil {
+		return err
+	}
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	if afterCommit != nil {
+		afterCommit()
+	}
+
+	return processErr
+}
+
+// Helper methods
+
+// processStripeEvent applies an event within tx; events of other types are acknowledged and ignored.
+// afterCommit, when set, is run once tx is committed.
+func (s *PaymentService) processStripeEvent(tx *sql.Tx, event *stripeEvent) (afterCommit func(), err error) {
+	switch {
+	case stripeIntentEventStatus[event.Type] != "":
+		var intent stripeIntent
+		if err := json.Unmarshal(event.Data.Object, &intent); err != nil {
+			return nil, err
+		}
+		return s.applyIntentEvent(tx, intent.ID, stripeIntentEventStatus[event.Type])
+
+	case event.Type == "charge.refunded":
+		var charge stripeCharge
+		if err := json.Unmarshal(event.Data.Object, &charge); err != nil {
+			return nil, err
+		}
+		return nil, applyChargeRefunded(tx, &charge)
+
+	case strings.HasPrefix(event.Type, "charge.dispute."):
+		var dispute stripeDispute
+		if err := json.Unmarshal(event.Data.Object, &dispute); err != nil {
+			return nil, err
+		}
+		return nil, applyDispute(tx, &dispute)
+
+	case strings.HasPrefix(event.Type, "customer.subscription."):
+		var subscription stripeSubscription
+		if err := json.Unmarshal(event.Data.Object, &subscription); err != nil {
+			return nil, err
+		}
+		return nil, applySubscription(tx, &subscription)
+	}
+
+	return nil, nil
+}
+
+// applyIntentEvent moves the payment of an intent that is still in flight to a later status;
+// refunds and disputes recorded on it are kept. A paid deposit confirms its booking and a paid order is marked paid.
+// afterCommit sends the notifications of a confirmed booking.
+func (s *PaymentService) applyIntentEvent(tx *sql.Tx, intentID, status string) (afterCommit func(), err error) {
+	var from []string
+	for current, next := range paymentIntentTransitions {
+		for _, candidate := range next {
+			if candidate == status {
+				from = append(from, current)
+			}
+		}
+	}
+
+	var paymentID string
+	var bookingID, orderID *string
+	err = tx.QueryRow(`
+		UPDATE payments SET status = $2, updated_at = NOW()
+		WHERE stripe_payment_intent_id = $1 AND status = ANY($3)
+		RETURNING id, booking_id, order_id
+	`, intentID, status, pq.Array(from)).Scan(&paymentID, &bookingID, &orderID)
+	if err == sql.ErrNoRows {
+		// Not one of ours, already settled or already past this status
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if status != "succeeded" {
+		return nil, nil
+	}
+
+	if err := postPaymentCaptured(tx, paymentID); err != nil {
+		return nil, err
+	}
+
+	if orderID != nil {
+		_, err = tx.Exec(`
+			UPDATE orders SET status = 'paid', payment_id = $2, updated_at = NOW()
+			WHERE id = $1 AND status = 'pending'
+		`, *orderID, paymentID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to mark order paid: %w", err)
+		}
+	}
+
+	if bookingID != nil && s.bookingService != nil {
+		var booking *models.Booking
+		booking, err = s.bookingService.confirmDepositPaid(tx, intentID, paymentID)
+		if err != nil {
+			return nil, err
+		}
+		if booking != nil {
+			return func() { go s.bookingService.sendStatusChangeNotifications(booking, "confirmed") }, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// applyChargeRefunded records the refunds of a charge, including ones issued at the provider directly
+func applyChargeRefunded(tx *sql.Tx, charge *stripeCharge) error {
+	var paymentID, paymentStatus string
+	var orderID *string
+	err := tx.QueryRow(`
+		SELECT id, order_id, status FROM payments WHERE stripe_payment_intent_id = $1 FOR UPDATE
+	`, charge.PaymentIntent).Scan(&paymentID, &orderID, &paymentStatus)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, refund := range charge.Refunds.Data {
+		status := refund.Status
+		if status != "succeeded" && status != "failed" {
+			status = "pending"
+		}
+
+		result, err := tx.Exec("UPDATE refunds SET status = $2 WHERE stripe_refund_id = $1", refund.ID, status)
+		if err != nil {
+			return err
+		}
+		if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+			_, err = tx.Exec(`
+				INSERT INTO refunds (id, payment_id, stripe_refund_id, amount, reason, status, created_at)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)
+			`, uuid.New().String(), paymentID, refund.ID, models.NewMoney(refund.Amount, charge.Currency),
+				"Refunded at the payment provider", status, time.Now())
+			if err != nil {
+				return fmt.Errorf("failed to record refund: %w", err)
+			}
+		}
+
+		// Refunds made through RefundPayment were posted under the same key
+		if status != "failed" {
+			err = postPaymentReturned(tx, paymentID, "refund:"+refund.ID, "refund", refund.Amount,
+				"Refunded at the payment provider")
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	// An open dispute keeps holding the payment back from payouts; once it is won, applyDispute
+	// sets the status from the refunds recorded meanwhile
+	if paymentStatus == "disputed" {
+		return nil
+	}
+
+	status := "partially_refunded"
+	if charge.AmountRefunded >= charge.Amount {
+		status = "refunded"
+	}
+	if _, err := tx.Exec("UPDATE payments SET status = $2, updated_at = NOW() WHERE id = $1", paymentID, status); err != nil {
+		return err
+	}
+
+	if status == "refunded" && orderID != nil {
+		if _, err := tx.Exec("UPDATE orders SET status = 'refunded', updated_at = NOW() WHERE id = $1", *orderID); err != nil {
+			return fmt.Errorf("failed to mark order refunded: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// applyDispute records a chargeback. The payment stays disputed while it is open; a won dispute
+// restores it and a lost one leaves it dispute_lost.
+func applyDispute(tx *sql.Tx, dispute *stripeDispute) error {
+	var paymentID, currency string
+	var amount models.Money
+	err := tx.QueryRow(`
+		SELECT id, amount, currency FROM payments WHERE stripe_payment_intent_id = $1 FOR UPDATE
+	`, dispute.PaymentIntent).Scan(&paymentID, &amount, &currency)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	amount = amount.WithCurrency(currency)
+
+	_, err = tx.Exec(`
+		INSERT INTO payment_disputes (payment_id, dispute_id, amount, reason, status)
+		VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (dispute_id) DO UPDATE
+		SET amount = EXCLUDED.amount, reason = EXCLUDED.reason, status = EXCLUDED.status, updated_at = NOW()
+	`, paymentID, dispute.ID, models.NewMoney(dispute.Amount, dispute.Currency), dispute.Reason, dispute.Status)
+	if err != nil {
+		return fmt.Errorf("failed to record dispute: %w", err)
+	}
+
+	status := "disputed"
+	switch dispute.Status {
+	case "won", "warning_closed":
+		var refunded models.Money
+		err = tx.QueryRow(`
+			SELECT COALESCE(SUM(amount), 0) FROM refunds WHERE payment_id = $1 AND status = 'succeeded'
+		`, paymentID).Scan(&refunded)
+		if err != nil {
+			return err
+		}
+		refunded = refunded.WithCurrency(currency)
+		switch {
+		case refunded.Amount >= amount.Amount:
+			status = "refunded"
+		case refunded.Amount > 0:
+			status = "partially_refunded"
+		default:
+			status = "succeeded"
+		}
+	case "lost":
+		status = "dispute_lost"
+		err = postPaymentReturned(tx, paymentID, "dispute:"+dispute.ID, "dispute", dispute.Amount,
+			"Dispute lost: "+dispute.Reason)
+		if err != nil {
+			return err
+		}
+	}
+
+	if _, err := tx.Exec("UPDATE payments SET status = $2, updated_at = NOW() WHERE id = $1", paymentID, status); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// applySubscription follows a company subscription through its lifecycle
+func applySubscription(tx *sql.Tx, subscription *stripeSubscription) error {
+	var periodEnd *time.Time
+	if subscription.CurrentPeriodEnd > 0 {
+		end := time.Unix(subscription.CurrentPeriodEnd, 0)
+		periodEnd = &end
+	}
+
+	var companyID string
+	err := tx.QueryRow(`
+		UPDATE payment_subscriptions
+		SET status = $3, current_period_end = $4, cancel_at_period_end = $5, updated_at = NOW()
+		WHERE provider = $1 AND subscription_id = $2
+		RETURNING company_id
+	`, PaymentProviderStripe, subscription.ID, subscription.Status, periodEnd,
+		subscription.CancelAtPeriodEnd).Scan(&companyID)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	return applyCompanySubscription(tx, companyID, subscription.Status, periodEnd)
+}
+
+// applyCompanySubscription mirrors a provider subscription status onto the company's plan access
+func applyCompanySubscription(q sqlExecer, companyID, status string, periodEnd *time.Time) error {
+	var err error
+	switch status {
+	case "active", "trialing":
+		_, err = q.Exec(`
+			UPDATE companies
+			SET subscription_status = 'active', subscription_expires_at = $2, trial_expired = false, updated_at = NOW()
+			WHERE id = $1
+		`, companyID, periodEnd)
+	case "past_due", "unpaid":
+		_, err = q.Exec(`
+			UPDATE companies SET subscription_status = 'suspended', updated_at = NOW() WHERE id = $1
+		`, companyID)
+	case "canceled", "incomplete_expired":
+		_, err = q.Exec(`
+			UPDATE companies SET subscription_status = 'canceled', updated_at = NOW() WHERE id = $1
+		`, companyID)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to update company subscription: %w", err)
+	}
+
+	return nil
+}
+
+// verifyStripeSignature checks the Stripe-Signature header: an HMAC-SHA256 of "timestamp.payload"
+// under the webhook secret, signed within the tolerance of now
+func verifyStripeSignature(payload []byte, header, secret string, now time.Time) error {
+	var timestamp string
+	var signatures []string
+	for _, part := range strings.Split(header, ",") {
+		key, value, ok := strings.Cut(strings.TrimSpace(part), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "t":
+			timestamp = value
+		case "v1":
+			signatures = append(signatures, value)
+		}
+	}
+
+	signedAt, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil || len(signatures) == 0 {
+		return fmt.Errorf("%w: malformed signature header", ErrInvalidWebhook)
+	}
+	age := now.Sub(time.Unix(signedAt, 0))
+	if age > stripeWebhookTolerance || age < -stripeWebhookTolerance {
+		return fmt.Errorf("%w: signature timestamp outside the tolerance", ErrInvalidWebhook)
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(payload)
+	expected := hex.EncodeToString(mac.Sum(nil))
+
+	for _, signature := range signatures {
+		if hmac.Equal([]byte(signature), []byte(expected)) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: signature mismatch", ErrInvalidWebhook)
+}
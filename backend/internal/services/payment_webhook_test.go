@@ -0,0 +1,139 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func stripeTestSignature(payload, secret string, signedAt time.Time) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(signedAt.Unix(), 10) + "." + payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifyStripeSignature(t *testing.T) {
+	const secret = "whsec_test"
+	const payload = `{"id":"evt_1","type":"payment_intent.succeeded"}`
+	now := time.Unix(1767225600, 0)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	valid := stripeTestSignature(payload, secret, now)
+
+	stale := now.Add(-stripeWebhookTolerance - time.Second)
+	future := now.Add(stripeWebhookTolerance + time.Second)
+
+	tests := []struct {
+		name    string
+		payload string
+		header  string
+		wantErr bool
+	}{
+		{
+			name:    "valid signature",
+			payload: payload,
+			header:  "t=" + timestamp + ",v1=" + valid,
+		},
+		{
+			name:    "valid signature with spaces and an older scheme",
+			payload: payload,
+			header:  "t=" + timestamp + ", v0=deadbeef, v1=" + valid,
+		},
+		{
+			name:    "signed at the edge of the tolerance",
+			payload: payload,
+			header: "t=" + strconv.FormatInt(now.Add(-stripeWebhookTolerance).Unix(), 10) +
+				",v1=" + stripeTestSignature(payload, secret, now.Add(-stripeWebhookTolerance)),
+		},
+		{
+			name:    "tampered payload",
+			payload: `{"id":"evt_1","type":"payment_intent.payment_failed"}`,
+			header:  "t=" + timestamp + ",v1=" + valid,
+			wantErr: true,
+		},
+		{
+			name:    "signed with another secret",
+			payload: payload,
+			header:  "t=" + timestamp + ",v1=" + stripeTestSignature(payload, "whsec_other", now),
+			wantErr: true,
+		},
+		{
+			name:    "stale timestamp",
+			payload: payload,
+			header:  "t=" + strconv.FormatInt(stale.Unix(), 10) + ",v1=" + stripeTestSignature(payload, secret, stale),
+			wantErr: true,
+		},
+		{
+			name:    "future timestamp",
+			payload: payload,
+			header:  "t=" + strconv.FormatInt(future.Unix(), 10) + ",v1=" + stripeTestSignature(payload, secret, future),
+			wantErr: true,
+		},
+		{
+			name:    "timestamp changed after signing",
+			payload: payload,
+			header:  "t=" + strconv.FormatInt(now.Unix()+1, 10) + ",v1=" + valid,
+			wantErr: true,
+		},
+		{
+			name:    "missing timestamp",
+			payload: payload,
+			header:  "v1=" + valid,
+			wantErr: true,
+		},
+		{
+			name:    "malformed timestamp",
+			payload: payload,
+			header:  "t=yesterday,v1=" + valid,
+			wantErr: true,
+		},
+		{
+			name:    "missing v1 signature",
+			payload: payload,
+			header:  "t=" + timestamp + ",v0=" + valid,
+			wantErr: true,
+		},
+		{
+			name:    "empty header",
+			payload: payload,
+			header:  "",
+			wantErr: true,
+		},
+		{
+			name:    "entries without values",
+			payload: payload,
+			header:  "t,v1",
+			wantErr: true,
+		},
+		{
+			name:    "one of several v1 signatures matches",
+			payload: payload,
+			header:  "t=" + timestamp + ",v1=" + stripeTestSignature(payload, "whsec_rolled", now) + ",v1=" + valid,
+		},
+		{
+			name:    "none of several v1 signatures matches",
+			payload: payload,
+			header: "t=" + timestamp + ",v1=" + stripeTestSignature(payload, "whsec_rolled", now) +
+				",v1=" + stripeTestSignature(payload, "whsec_other", now),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := verifyStripeSignature([]byte(tt.payload), tt.header, secret, now)
+			if tt.wantErr {
+				if !errors.Is(err, ErrInvalidWebhook) {
+					t.Fatalf("verifyStripeSignature() = %v, want ErrInvalidWebhook", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("verifyStripeSignature() = %v, want nil", err)
+			}
+		})
+	}
+}
@@ -0,0 +1,353 @@
+package services
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// maxRecurrenceIterations guards against rules that never produce a match
+const maxRecurrenceIterations = 5000
+
+// Forms of UNTIL
+const (
+	rruleUTCTime   = "20060102T150405Z"
+	rruleLocalTime = "20060102T150405"
+	rruleDate      = "20060102"
+)
+
+// RecurrenceRule is the supported subset of an iCalendar RRULE:
+// FREQ=DAILY|WEEKLY|MONTHLY, INTERVAL, BYDAY (MO..SU, monthly with ordinal like 2TU or -1FR),
+// BYMONTHDAY, COUNT and UNTIL.
+type RecurrenceRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []RecurrenceDay
+	ByMonthDay []int
+	Count      int
+	Until      *time.Time
+
+	untilLayout string // How UNTIL was written; a date or a time without Z is in the series' timezone
+}
+
+// RecurrenceDay is a BYDAY entry; Ordinal is 0 when the weekday applies to every week
+type RecurrenceDay struct {
+	Ordinal int
+	Weekday time.Weekday
+}
+
+var rruleWeekdays = map[string]time.Weekday{
+	"MO": time.Monday, "TU": time.Tuesday, "WE": time.Wednesday, "TH": time.Thursday,
+	"FR": time.Friday, "SA": time.Saturday, "SU": time.Sunday,
+}
+
+// ParseRecurrenceRule parses an RRULE string such as "FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;COUNT=10"
+func ParseRecurrenceRule(rule string) (*RecurrenceRule, error) {
+	rule = strings.TrimPrefix(strings.TrimSpace(rule), "RRULE:")
+	if rule == "" {
+		return nil, fmt.Errorf("recurrence rule is empty")
+	}
+
+	r := &RecurrenceRule{Interval: 1}
+	for _, part := range strings.Split(rule, ";") {
+		if part == "" {
+			continue
+		}
+		kv := strings.SplitN(part, "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("invalid rule part: %s", part)
+		}
+		key, value := strings.ToUpper(kv[0]), strings.ToUpper(kv[1])
+
+		switch key {
+		case "FREQ":
+			if value != "DAILY" && value != "WEEKLY" && value != "MONTHLY" {
+				return nil, fmt.Errorf("unsupported FREQ: %s", value)
+			}
+			r.Freq = value
+		case "INTERVAL":
+			interval, err := strconv.Atoi(value)
+			if err != nil || interval < 1 {
+				return nil, fmt.Errorf("invalid INTERVAL: %s", value)
+			}
+			r.Interval = interval
+		case "COUNT":
+			count, err := strconv.Atoi(value)
+			if err != nil || count < 1 {
+				return nil, fmt.Errorf("invalid COUNT: %s", value)
+			}
+			r.Count = count
+		case "UNTIL":
+			until, layout, err := parseRRuleTime(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid UNTIL: %s", value)
+			}
+			r.Until = &until
+			r.untilLayout = layout
+		case "BYDAY":
+			for _, day := range strings.Split(value, ",") {
+				parsed, err := parseRecurrenceDay(day)
+				if err != nil {
+					return nil, err
+				}
+				r.ByDay = append(r.ByDay, parsed)
+			}
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(value, ",") {
+				monthDay, err := strconv.Atoi(day)
+				if err != nil || monthDay == 0 || monthDay < -31 || monthDay > 31 {
+					return nil, fmt.Errorf("invalid BYMONTHDAY: %s", day)
+				}
+				r.ByMonthDay = append(r.ByMonthDay, monthDay)
+			}
+		case "WKST":
+			// Weeks always start on Monday
+		default:
+			return nil, fmt.Errorf("unsupported rule part: %s", key)
+		}
+	}
+
+	if r.Freq == "" {
+		return nil, fmt.Errorf("FREQ is required")
+	}
+	if r.Count > 0 && r.Until != nil {
+		return nil, fmt.Errorf("COUNT and UNTIL cannot be combined")
+	}
+	for _, day := range r.ByDay {
+		if day.Ordinal != 0 && r.Freq != "MONTHLY" {
+			return nil, fmt.Errorf("BYDAY ordinals are only supported with FREQ=MONTHLY")
+		}
+	}
+	if len(r.ByMonthDay) > 0 && r.Freq != "MONTHLY" {
+		return nil, fmt.Errorf("BYMONTHDAY is only supported with FREQ=MONTHLY")
+	}
+
+	return r, nil
+}
+
+// Occurrences expands the rule starting at dtstart and returns occurrences within [from, to].
+// Expansion happens in dtstart's location so wall-clock time is kept across DST changes.
+func (r *RecurrenceRule) Occurrences(dtstart, from, to time.Time) []time.Time {
+	var result []time.Time
+	emitted := 0
+	until := r.untilIn(dtstart.Location())
+
+	for period := 0; period < maxRecurrenceIterations; period++ {
+		candidates := r.periodCandidates(dtstart, period)
+		if len(candidates) == 0 && r.periodStart(dtstart, period).After(to) {
+			break
+		}
+
+		for _, candidate := range candidates {
+			if candidate.Before(dtstart) {
+				continue
+			}
+			if until != nil && candidate.After(*until) {
+				return result
+			}
+			if r.Count > 0 && emitted >= r.Count {
+				return result
+			}
+			if candidate.After(to) {
+				return result
+			}
+
+			emitted++
+			if !candidate.Before(from) {
+				result = append(result, candidate)
+			}
+		}
+	}
+
+	return result
+}
+
+// String renders the rule back into RRULE syntax
+func (r *RecurrenceRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if r.Interval > 1 {
+		parts = append(parts, fmt.Sprintf("INTERVAL=%d", r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, day := range r.ByDay {
+			code := ""
+			for k, v := range rruleWeekdays {
+				if v == day.Weekday {
+					code = k
+				}
+			}
+			if day.Ordinal != 0 {
+				code = strconv.Itoa(day.Ordinal) + code
+			}
+			days = append(days, code)
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	if len(r.ByMonthDay) > 0 {
+		var days []string
+		for _, day := range r.ByMonthDay {
+			days = append(days, strconv.Itoa(day))
+		}
+		parts = append(parts, "BYMONTHDAY="+strings.Join(days, ","))
+	}
+	if r.Count > 0 {
+		parts = append(parts, fmt.Sprintf("COUNT=%d", r.Count))
+	}
+	if r.Until != nil {
+		switch r.untilLayout {
+		case rruleDate, rruleLocalTime:
+			parts = append(parts, "UNTIL="+r.Until.Format(r.untilLayout))
+		default:
+			parts = append(parts, "UNTIL="+r.Until.UTC().Format(rruleUTCTime))
+		}
+	}
+	return strings.Join(parts, ";")
+}
+
+// untilIn returns the last time an occurrence may start at. UNTIL written as a date or a time without Z
+// is in the series' timezone, and a date includes the whole day.
+func (r *RecurrenceRule) untilIn(loc *time.Location) *time.Time {
+	if r.Until == nil {
+		return nil
+	}
+
+	until := *r.Until
+	switch r.untilLayout {
+	case rruleDate:
+		until = time.Date(until.Year(), until.Month(), until.Day()+1, 0, 0, 0, 0, loc).Add(-time.Nanosecond)
+	case rruleLocalTime:
+		until = time.Date(until.Year(), until.Month(), until.Day(), until.Hour(), until.Minute(), until.Second(), 0, loc)
+	}
+	return &until
+}
+
+// periodStart returns the first day of the n-th period of the rule
+func (r *RecurrenceRule) periodStart(dtstart time.Time, n int) time.Time {
+	day := inLocationDate(dtstart, dtstart.Location())
+	switch r.Freq {
+	case "WEEKLY":
+		offset := (int(day.Weekday()) + 6) % 7 // Monday-based weeks
+		return day.AddDate(0, 0, -offset+7*n*r.Interval)
+	case "MONTHLY":
+		return time.Date(day.Year(), day.Month()+time.Month(n*r.Interval), 1, 0, 0, 0, 0, day.Location())
+	default:
+		return day.AddDate(0, 0, n*r.Interval)
+	}
+}
+
+// periodCandidates returns the sorted occurrence times inside the n-th period
+func (r *RecurrenceRule) periodCandidates(dtstart time.Time, n int) []time.Time {
+	start := r.periodStart(dtstart, n)
+	atTime := func(day time.Time) time.Time {
+		return time.Date(day.Year(), day.Month(), day.Day(),
+			dtstart.Hour(), dtstart.Minute(), dtstart.Second(), 0, dtstart.Location())
+	}
+
+	var candidates []time.Time
+	switch r.Freq {
+	case "DAILY":
+		candidates = append(candidates, atTime(start))
+	case "WEEKLY":
+		days := r.ByDay
+		if len(days) == 0 {
+			days = []RecurrenceDay{{Weekday: dtstart.Weekday()}}
+		}
+		for _, day := range days {
+			offset := (int(day.Weekday) + 6) % 7
+			candidates = append(candidates, atTime(start.AddDate(0, 0, offset)))
+		}
+	case "MONTHLY":
+		// BYMONTHDAY and BYDAY each narrow down the days of the month, so a day has to match both (RFC 5545)
+		daysInMonth := start.AddDate(0, 1, -1).Day()
+		var byMonthDay, byDay map[int]bool
+		if len(r.ByMonthDay) > 0 {
+			byMonthDay = make(map[int]bool)
+			for _, monthDay := range r.ByMonthDay {
+				if monthDay < 0 {
+					monthDay = daysInMonth + monthDay + 1
+				}
+				byMonthDay[monthDay] = true
+			}
+		}
+		if len(r.ByDay) > 0 {
+			byDay = make(map[int]bool)
+			for _, day := range r.ByDay {
+				var matches []int
+				for monthDay := 1; monthDay <= daysInMonth; monthDay++ {
+					if start.AddDate(0, 0, monthDay-1).Weekday() == day.Weekday {
+						matches = append(matches, monthDay)
+					}
+				}
+				switch {
+				case day.Ordinal == 0:
+					for _, match := range matches {
+						byDay[match] = true
+					}
+				case day.Ordinal > 0 && day.Ordinal <= len(matches):
+					byDay[matches[day.Ordinal-1]] = true
+				case day.Ordinal < 0 && -day.Ordinal <= len(matches):
+					byDay[matches[len(matches)+day.Ordinal]] = true
+				}
+			}
+		}
+		if byMonthDay == nil && byDay == nil {
+			byMonthDay = map[int]bool{dtstart.Day(): true}
+		}
+
+		for monthDay := 1; monthDay <= daysInMonth; monthDay++ {
+			if (byMonthDay == nil || byMonthDay[monthDay]) && (byDay == nil || byDay[monthDay]) {
+				candidates = append(candidates, atTime(start.AddDate(0, 0, monthDay-1)))
+			}
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Before(candidates[j])
+	})
+
+	// A day listed twice, e.g. BYDAY=MO,MO, is one occurrence
+	unique := candidates[:0]
+	for _, candidate := range candidates {
+		if len(unique) == 0 || !unique[len(unique)-1].Equal(candidate) {
+			unique = append(unique, candidate)
+		}
+	}
+
+	return unique
+}
+
+func parseRecurrenceDay(value string) (RecurrenceDay, error) {
+	value = strings.TrimSpace(value)
+	if len(value) < 2 {
+		return RecurrenceDay{}, fmt.Errorf("invalid BYDAY: %s", value)
+	}
+
+	code := value[len(value)-2:]
+	weekday, ok := rruleWeekdays[code]
+	if !ok {
+		return RecurrenceDay{}, fmt.Errorf("invalid BYDAY: %s", value)
+	}
+
+	day := RecurrenceDay{Weekday: weekday}
+	if prefix := value[:len(value)-2]; prefix != "" {
+		ordinal, err := strconv.Atoi(prefix)
+		if err != nil || ordinal == 0 || ordinal < -5 || ordinal > 5 {
+			return RecurrenceDay{}, fmt.Errorf("invalid BYDAY: %s", value)
+		}
+		day.Ordinal = ordinal
+	}
+
+	return day, nil
+}
+
+func parseRRuleTime(value string) (time.Time, string, error) {
+	for _, layout := range []string{rruleUTCTime, rruleLocalTime, rruleDate} {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, layout, nil
+		}
+	}
+	return time.Time{}, "", fmt.Errorf("invalid time: %s", value)
+}
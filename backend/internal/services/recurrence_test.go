@@ -0,0 +1,109 @@
+package services
+
+import (
+	"testing"
+	"time"
+)
+
+func occurrenceDates(t *testing.T, rule string, dtstart, from, to time.Time) []string {
+	t.Helper()
+
+	r, err := ParseRecurrenceRule(rule)
+	if err != nil {
+		t.Fatalf("ParseRecurrenceRule(%q): %v", rule, err)
+	}
+
+	var dates []string
+	for _, occurrence := range r.Occurrences(dtstart, from, to) {
+		dates = append(dates, occurrence.Format("2006-01-02 15:04"))
+	}
+	return dates
+}
+
+func TestRecurrenceOccurrences(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("timezone data unavailable: %v", err)
+	}
+
+	dtstart := time.Date(2026, 1, 1, 18, 0, 0, 0, newYork)
+	from := dtstart
+	to := time.Date(2026, 12, 31, 23, 59, 0, 0, newYork)
+
+	tests := []struct {
+		name string
+		rule string
+		want []string
+	}{
+		{
+			name: "monthly BYDAY and BYMONTHDAY intersect",
+			rule: "FREQ=MONTHLY;BYDAY=FR;BYMONTHDAY=13",
+			want: []string{"2026-02-13 18:00", "2026-03-13 18:00", "2026-11-13 18:00"},
+		},
+		{
+			name: "monthly BYDAY narrowed to a week by BYMONTHDAY",
+			rule: "FREQ=MONTHLY;BYDAY=SA;BYMONTHDAY=7,8,9,10,11,12,13;COUNT=3",
+			want: []string{"2026-01-10 18:00", "2026-02-07 18:00", "2026-03-07 18:00"},
+		},
+		{
+			name: "monthly overlapping BYDAY entries occur once",
+			rule: "FREQ=MONTHLY;BYDAY=1MO,MO;COUNT=5",
+			want: []string{"2026-01-05 18:00", "2026-01-12 18:00", "2026-01-19 18:00", "2026-01-26 18:00", "2026-02-02 18:00"},
+		},
+		{
+			name: "monthly repeated BYMONTHDAY occurs once",
+			rule: "FREQ=MONTHLY;BYMONTHDAY=-1,31;COUNT=2",
+			want: []string{"2026-01-31 18:00", "2026-02-28 18:00"},
+		},
+		{
+			name: "weekly repeated BYDAY occurs once",
+			rule: "FREQ=WEEKLY;BYDAY=TH,TH;COUNT=2",
+			want: []string{"2026-01-01 18:00", "2026-01-08 18:00"},
+		},
+		{
+			name: "date-only UNTIL includes the whole day in the series timezone",
+			rule: "FREQ=DAILY;UNTIL=20260103",
+			want: []string{"2026-01-01 18:00", "2026-01-02 18:00", "2026-01-03 18:00"},
+		},
+		{
+			name: "local UNTIL is in the series timezone",
+			rule: "FREQ=DAILY;UNTIL=20260102T180000",
+			want: []string{"2026-01-01 18:00", "2026-01-02 18:00"},
+		},
+		{
+			name: "UTC UNTIL is an instant",
+			rule: "FREQ=DAILY;UNTIL=20260102T220000Z",
+			want: []string{"2026-01-01 18:00"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := occurrenceDates(t, tt.rule, dtstart, from, to)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Fatalf("got %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestRecurrenceRuleStringKeepsUntilForm(t *testing.T) {
+	for _, rule := range []string{
+		"FREQ=DAILY;UNTIL=20260103",
+		"FREQ=DAILY;UNTIL=20260103T090000",
+		"FREQ=WEEKLY;INTERVAL=2;BYDAY=TU;UNTIL=20260103T090000Z",
+	} {
+		r, err := ParseRecurrenceRule(rule)
+		if err != nil {
+			t.Fatalf("ParseRecurrenceRule(%q): %v", rule, err)
+		}
+		if got := r.String(); got != rule {
+			t.Errorf("String() = %q, want %q", got, rule)
+		}
+	}
+}
@@ -0,0 +1,624 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/google/uuid"
+)
+
+type ScheduleService struct {
+	db *sql.DB
+}
+
+func NewScheduleService(db *sql.DB) *ScheduleService {
+	return &ScheduleService{db: db}
+}
+
+var validWeekdays = map[string]bool{
+	"monday": true, "tuesday": true, "wednesday": true, "thursday": true,
+	"friday": true, "saturday": true, "sunday": true,
+}
+
+// GetEmployeeSchedule returns shifts, upcoming overrides, upcoming time-off and breaks of an employee
+func (s *ScheduleService) GetEmployeeSchedule(companyID, employeeID string) (*models.EmployeeSchedule, error) {
+	if err := s.verifyEmployeeCompany(companyID, employeeID); err != nil {
+		return nil, err
+	}
+
+	schedule := &models.EmployeeSchedule{
+		EmployeeID: employeeID,
+		Shifts:     []models.EmployeeShift{},
+		Overrides:  []models.EmployeeScheduleOverride{},
+		TimeOff:    []models.EmployeeTimeOff{},
+		Breaks:     []models.EmployeeBreak{},
+	}
+
+	shifts, err := s.getShifts(employeeID)
+	if err != nil {
+		return nil, err
+	}
+	schedule.Shifts = append(schedule.Shifts, shifts...)
+
+	overrideRows, err := s.db.Query(`
+		SELECT id, employee_id, company_id, override_date, is_day_off,
+			   to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS'), reason, created_at, updated_at
+		FROM employee_schedule_overrides
+		WHERE employee_id = $1 AND override_date >= CURRENT_DATE
+		ORDER BY override_date
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get schedule overrides: %w", err)
+	}
+	defer overrideRows.Close()
+
+	for overrideRows.Next() {
+		var o models.EmployeeScheduleOverride
+		if err := overrideRows.Scan(
+			&o.ID, &o.EmployeeID, &o.CompanyID, &o.OverrideDate, &o.IsDayOff,
+			&o.StartTime, &o.EndTime, &o.Reason, &o.CreatedAt, &o.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		schedule.Overrides = append(schedule.Overrides, o)
+	}
+
+	timeOffRows, err := s.db.Query(`
+		SELECT id, employee_id, company_id, type, starts_at, ends_at,
+			   reason, created_by, created_at, updated_at
+		FROM employee_time_off
+		WHERE employee_id = $1 AND ends_at >= NOW()
+		ORDER BY starts_at
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time-off: %w", err)
+	}
+	defer timeOffRows.Close()
+
+	for timeOffRows.Next() {
+		var t models.EmployeeTimeOff
+		if err := timeOffRows.Scan(
+			&t.ID, &t.EmployeeID, &t.CompanyID, &t.Type, &t.StartsAt, &t.EndsAt,
+			&t.Reason, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		schedule.TimeOff = append(schedule.TimeOff, t)
+	}
+
+	breakRows, err := s.db.Query(`
+		SELECT id, employee_id, company_id, day_of_week, break_date,
+			   to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS'), label, created_at
+		FROM employee_breaks
+		WHERE employee_id = $1 AND (break_date IS NULL OR break_date >= CURRENT_DATE)
+		ORDER BY break_date NULLS FIRST, day_of_week, start_time
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breaks: %w", err)
+	}
+	defer breakRows.Close()
+
+	for breakRows.Next() {
+		var b models.EmployeeBreak
+		if err := breakRows.Scan(
+			&b.ID, &b.EmployeeID, &b.CompanyID, &b.DayOfWeek, &b.BreakDate,
+			&b.StartTime, &b.EndTime, &b.Label, &b.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		schedule.Breaks = append(schedule.Breaks, b)
+	}
+
+	busyRows, err := s.db.Query(`
+		SELECT b.id, b.calendar_id, b.employee_id, b.starts_at, b.ends_at
+		FROM external_busy_blocks b
+		WHERE (b.employee_id = $1 OR (b.employee_id IS NULL AND b.company_id = $2))
+		  AND b.ends_at >= NOW() AND b.starts_at < NOW() + INTERVAL '30 days'
+		ORDER BY b.starts_at
+	`, employeeID, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get busy blocks: %w", err)
+	}
+	defer busyRows.Close()
+
+	if schedule.ExternalBusy, err = scanBusyBlocks(busyRows); err != nil {
+		return nil, err
+	}
+
+	return schedule, nil
+}
+
+// SetWeeklyShifts replaces all recurring shifts of an employee
+func (s *ScheduleService) SetWeeklyShifts(companyID, employeeID string, shifts []models.EmployeeShiftRequest) ([]models.EmployeeShift, error) {
+	if err := s.verifyEmployeeCompany(companyID, employeeID); err != nil {
+		return nil, err
+	}
+
+	// Validate all shifts before touching the database
+	for i, shift := range shifts {
+		day := strings.ToLower(shift.DayOfWeek)
+		if !validWeekdays[day] {
+			return nil, fmt.Errorf("invalid day_of_week: %s", shift.DayOfWeek)
+		}
+		start, end, err := parseClockRange(shift.StartTime, shift.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		shifts[i] = models.EmployeeShiftRequest{DayOfWeek: day, StartTime: start, EndTime: end}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec("DELETE FROM employee_shifts WHERE employee_id = $1", employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clear shifts: %w", err)
+	}
+
+	for _, shift := range shifts {
+		_, err = tx.Exec(`
+			INSERT INTO employee_shifts (
+				id, employee_id, company_id, day_of_week, start_time, end_time,
+				is_active, created_at, updated_at
+			) VALUES ($1, $2, $3, $4, $5, $6, true, NOW(), NOW())
+		`, uuid.New().String(), employeeID, companyID, shift.DayOfWeek, shift.StartTime, shift.EndTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create shift: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getShifts(employeeID)
+}
+
+// CreateOverride creates or replaces the schedule override for a date
+func (s *ScheduleService) CreateOverride(companyID, employeeID string, req *models.EmployeeScheduleOverrideRequest) (*models.EmployeeScheduleOverride, error) {
+	if err := s.verifyEmployeeCompany(companyID, employeeID); err != nil {
+		return nil, err
+	}
+
+	date, err := time.Parse("2006-01-02", req.Date)
+	if err != nil {
+		return nil, fmt.Errorf("invalid date format, use YYYY-MM-DD")
+	}
+
+	var startTime, endTime *string
+	if !req.IsDayOff {
+		if req.StartTime == nil || req.EndTime == nil {
+			return nil, fmt.Errorf("start_time and end_time are required unless is_day_off is set")
+		}
+		start, end, err := parseClockRange(*req.StartTime, *req.EndTime)
+		if err != nil {
+			return nil, err
+		}
+		startTime, endTime = &start, &end
+	}
+
+	var o models.EmployeeScheduleOverride
+	err = s.db.QueryRow(`
+		INSERT INTO employee_schedule_overrides (
+			id, employee_id, company_id, override_date, is_day_off,
+			start_time, end_time, reason, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		ON CONFLICT (employee_id, override_date) DO UPDATE SET
+			is_day_off = EXCLUDED.is_day_off,
+			start_time = EXCLUDED.start_time,
+			end_time = EXCLUDED.end_time,
+			reason = EXCLUDED.reason,
+			updated_at = NOW()
+		RETURNING id, employee_id, company_id, override_date, is_day_off,
+				  to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS'), reason, created_at, updated_at
+	`, uuid.New().String(), employeeID, companyID, date.Format("2006-01-02"), req.IsDayOff,
+		startTime, endTime, req.Reason).Scan(
+		&o.ID, &o.EmployeeID, &o.CompanyID, &o.OverrideDate, &o.IsDayOff,
+		&o.StartTime, &o.EndTime, &o.Reason, &o.CreatedAt, &o.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save schedule override: %w", err)
+	}
+
+	return &o, nil
+}
+
+// DeleteOverride removes a schedule override
+func (s *ScheduleService) DeleteOverride(companyID, employeeID, overrideID string) error {
+	return s.deleteScheduleEntry("employee_schedule_overrides", companyID, employeeID, overrideID)
+}
+
+// CreateTimeOff records a vacation, sick leave or other absence
+func (s *ScheduleService) CreateTimeOff(companyID, employeeID string, createdBy *string, req *models.EmployeeTimeOffRequest) (*models.EmployeeTimeOff, error) {
+	if err := s.verifyEmployeeCompany(companyID, employeeID); err != nil {
+		return nil, err
+	}
+
+	if !req.EndsAt.After(req.StartsAt) {
+		return nil, fmt.Errorf("ends_at must be after starts_at")
+	}
+
+	var t models.EmployeeTimeOff
+	err := s.db.QueryRow(`
+		INSERT INTO employee_time_off (
+			id, employee_id, company_id, type, starts_at, ends_at,
+			reason, created_by, created_at, updated_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW(), NOW())
+		RETURNING id, employee_id, company_id, type, starts_at, ends_at,
+				  reason, created_by, created_at, updated_at
+	`, uuid.New().String(), employeeID, companyID, req.Type, req.StartsAt, req.EndsAt,
+		req.Reason, createdBy).Scan(
+		&t.ID, &t.EmployeeID, &t.CompanyID, &t.Type, &t.StartsAt, &t.EndsAt,
+		&t.Reason, &t.CreatedBy, &t.CreatedAt, &t.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create time-off: %w", err)
+	}
+
+	return &t, nil
+}
+
+// DeleteTimeOff removes a time-off entry
+func (s *ScheduleService) DeleteTimeOff(companyID, employeeID, timeOffID string) error {
+	return s.deleteScheduleEntry("employee_time_off", companyID, employeeID, timeOffID)
+}
+
+// CreateBreak adds a recurring or one-off break
+func (s *ScheduleService) CreateBreak(companyID, employeeID string, req *models.EmployeeBreakRequest) (*models.EmployeeBreak, error) {
+	if err := s.verifyEmployeeCompany(companyID, employeeID); err != nil {
+		return nil, err
+	}
+
+	var dayOfWeek, breakDate *string
+	if req.DayOfWeek != nil && *req.DayOfWeek != "" {
+		day := strings.ToLower(*req.DayOfWeek)
+		if !validWeekdays[day] {
+			return nil, fmt.Errorf("invalid day_of_week: %s", *req.DayOfWeek)
+		}
+		dayOfWeek = &day
+	}
+	if req.Date != nil && *req.Date != "" {
+		if _, err := time.Parse("2006-01-02", *req.Date); err != nil {
+			return nil, fmt.Errorf("invalid date format, use YYYY-MM-DD")
+		}
+		breakDate = req.Date
+	}
+	if dayOfWeek == nil && breakDate == nil {
+		return nil, fmt.Errorf("either day_of_week or date is required")
+	}
+
+	start, end, err := parseClockRange(req.StartTime, req.EndTime)
+	if err != nil {
+		return nil, err
+	}
+
+	var b models.EmployeeBreak
+	err = s.db.QueryRow(`
+		INSERT INTO employee_breaks (
+			id, employee_id, company_id, day_of_week, break_date,
+			start_time, end_time, label, created_at
+		) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NOW())
+		RETURNING id, employee_id, company_id, day_of_week, break_date,
+				  to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS'), label, created_at
+	`, uuid.New().String(), employeeID, companyID, dayOfWeek, breakDate,
+		start, end, req.Label).Scan(
+		&b.ID, &b.EmployeeID, &b.CompanyID, &b.DayOfWeek, &b.BreakDate,
+		&b.StartTime, &b.EndTime, &b.Label, &b.CreatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create break: %w", err)
+	}
+
+	return &b, nil
+}
+
+// DeleteBreak removes a break
+func (s *ScheduleService) DeleteBreak(companyID, employeeID, breakID string) error {
+	return s.deleteScheduleEntry("employee_breaks", companyID, employeeID, breakID)
+}
+
+// GetWorkingWindows returns the intervals of the given calendar date, in the company's timezone,
+// in which the employee can take bookings.
+// Date overrides replace weekly shifts; breaks, time-off and imported busy time are cut out of the result.
+// Employees without any configured shifts are treated as available all day, minus breaks and time-off.
+func (s *ScheduleService) GetWorkingWindows(employeeID string, date time.Time) ([]models.WorkingWindow, error) {
+	return s.workingWindows(employeeID, date, employeeLocation(s.db, employeeID))
+}
+
+// IsEmployeeWorking checks that the whole interval [start, end) falls inside the employee's working windows
+func (s *ScheduleService) IsEmployeeWorking(employeeID string, start, end time.Time) (bool, error) {
+	loc := employeeLocation(s.db, employeeID)
+	windows, err := s.workingWindows(employeeID, start.In(loc), loc)
+	if err != nil {
+		return false, err
+	}
+
+	return windowsContain(windows, start, end), nil
+}
+
+// Helper methods
+
+func (s *ScheduleService) workingWindows(employeeID string, date time.Time, loc *time.Location) ([]models.WorkingWindow, error) {
+	dayStart := inLocationDate(date, loc)
+	dayEnd := dayStart.AddDate(0, 0, 1)
+	dayOfWeek := strings.ToLower(dayStart.Weekday().String())
+	dateStr := dayStart.Format("2006-01-02")
+
+	var windows []models.WorkingWindow
+
+	// 1. Date override takes precedence over the weekly template
+	var isDayOff bool
+	var overrideStart, overrideEnd sql.NullString
+	err := s.db.QueryRow(`
+		SELECT is_day_off, to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS')
+		FROM employee_schedule_overrides
+		WHERE employee_id = $1 AND override_date = $2
+	`, employeeID, dateStr).Scan(&isDayOff, &overrideStart, &overrideEnd)
+
+	switch {
+	case err == nil:
+		if isDayOff || !overrideStart.Valid || !overrideEnd.Valid {
+			return []models.WorkingWindow{}, nil
+		}
+		windows = append(windows, models.WorkingWindow{
+			Start: clockOnDate(dayStart, overrideStart.String),
+			End:   clockOnDate(dayStart, overrideEnd.String),
+		})
+	case err == sql.ErrNoRows:
+		// 2. Weekly shifts
+		var totalShifts int
+		err = s.db.QueryRow(`
+			SELECT COUNT(*) FROM employee_shifts WHERE employee_id = $1 AND is_active = true
+		`, employeeID).Scan(&totalShifts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to count shifts: %w", err)
+		}
+
+		if totalShifts == 0 {
+			windows = append(windows, models.WorkingWindow{Start: dayStart, End: dayEnd})
+		} else {
+			rows, err := s.db.Query(`
+				SELECT to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS')
+				FROM employee_shifts
+				WHERE employee_id = $1 AND day_of_week = $2 AND is_active = true
+				ORDER BY start_time
+			`, employeeID, dayOfWeek)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get shifts: %w", err)
+			}
+			defer rows.Close()
+
+			for rows.Next() {
+				var start, end string
+				if err := rows.Scan(&start, &end); err != nil {
+					return nil, err
+				}
+				windows = append(windows, models.WorkingWindow{
+					Start: clockOnDate(dayStart, start),
+					End:   clockOnDate(dayStart, end),
+				})
+			}
+		}
+	default:
+		return nil, fmt.Errorf("failed to get schedule override: %w", err)
+	}
+
+	if len(windows) == 0 {
+		return []models.WorkingWindow{}, nil
+	}
+
+	// 3. Cut out breaks
+	breakRows, err := s.db.Query(`
+		SELECT to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS')
+		FROM employee_breaks
+		WHERE employee_id = $1 AND (day_of_week = $2 OR break_date = $3)
+	`, employeeID, dayOfWeek, dateStr)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get breaks: %w", err)
+	}
+	defer breakRows.Close()
+
+	for breakRows.Next() {
+		var start, end string
+		if err := breakRows.Scan(&start, &end); err != nil {
+			return nil, err
+		}
+		windows = subtractWindow(windows, clockOnDate(dayStart, start), clockOnDate(dayStart, end))
+	}
+
+	// 4. Cut out time-off overlapping this day
+	timeOffRows, err := s.db.Query(`
+		SELECT starts_at, ends_at
+		FROM employee_time_off
+		WHERE employee_id = $1 AND starts_at < $3 AND ends_at > $2
+	`, employeeID, dayStart, dayEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get time-off: %w", err)
+	}
+	defer timeOffRows.Close()
+
+	for timeOffRows.Next() {
+		var start, end time.Time
+		if err := timeOffRows.Scan(&start, &end); err != nil {
+			return nil, err
+		}
+		windows = subtractWindow(windows, start.In(loc), end.In(loc))
+	}
+
+	// 5. Cut out events imported from the employee's and the company's external calendars
+	busyRows, err := s.db.Query(`
+		SELECT b.starts_at, b.ends_at
+		FROM external_busy_blocks b
+		JOIN employees e ON e.id = $1
+		WHERE (b.employee_id = e.id OR (b.employee_id IS NULL AND b.company_id = e.company_id))
+		  AND b.starts_at < $3 AND b.ends_at > $2
+	`, employeeID, dayStart, dayEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get busy blocks: %w", err)
+	}
+	defer busyRows.Close()
+
+	for busyRows.Next() {
+		var start, end time.Time
+		if err := busyRows.Scan(&start, &end); err != nil {
+			return nil, err
+		}
+		windows = subtractWindow(windows, start.In(loc), end.In(loc))
+	}
+
+	// 6. Cut out the sessions of courses the employee teaches
+	sessionRows, err := s.db.Query(`
+		SELECT cs.starts_at, cs.ends_at
+		FROM course_sessions cs
+		JOIN courses c ON c.id = cs.course_id
+		WHERE c.employee_id = $1 AND c.status <> 'cancelled' AND cs.status = 'scheduled'
+		  AND cs.starts_at < $3 AND cs.ends_at > $2
+	`, employeeID, dayStart, dayEnd)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get course sessions: %w", err)
+	}
+	defer sessionRows.Close()
+
+	for sessionRows.Next() {
+		var start, end time.Time
+		if err := sessionRows.Scan(&start, &end); err != nil {
+			return nil, err
+		}
+		windows = subtractWindow(windows, start.In(loc), end.In(loc))
+	}
+
+	sort.Slice(windows, func(i, j int) bool {
+		return windows[i].Start.Before(windows[j].Start)
+	})
+
+	return windows, nil
+}
+
+func (s *ScheduleService) getShifts(employeeID string) ([]models.EmployeeShift, error) {
+	rows, err := s.db.Query(`
+		SELECT id, employee_id, company_id, day_of_week, to_char(start_time, 'HH24:MI:SS'), to_char(end_time, 'HH24:MI:SS'),
+			   is_active, created_at, updated_at
+		FROM employee_shifts
+		WHERE employee_id = $1
+		ORDER BY CASE day_of_week
+			WHEN 'monday' THEN 1 WHEN 'tuesday' THEN 2 WHEN 'wednesday' THEN 3
+			WHEN 'thursday' THEN 4 WHEN 'friday' THEN 5 WHEN 'saturday' THEN 6
+			ELSE 7 END, start_time
+	`, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shifts: %w", err)
+	}
+	defer rows.Close()
+
+	shifts := []models.EmployeeShift{}
+	for rows.Next() {
+		var shift models.EmployeeShift
+		if err := rows.Scan(
+			&shift.ID, &shift.EmployeeID, &shift.CompanyID, &shift.DayOfWeek,
+			&shift.StartTime, &shift.EndTime, &shift.IsActive, &shift.CreatedAt, &shift.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		shifts = append(shifts, shift)
+	}
+
+	return shifts, nil
+}
+
+func (s *ScheduleService) verifyEmployeeCompany(companyID, employeeID string) error {
+	var employeeCompanyID string
+	err := s.db.QueryRow("SELECT company_id FROM employees WHERE id = $1", employeeID).Scan(&employeeCompanyID)
+	if err != nil {
+		return fmt.Errorf("employee not found")
+	}
+	if employeeCompanyID != companyID {
+		return fmt.Errorf("employee does not belong to company")
+	}
+	return nil
+}
+
+func (s *ScheduleService) deleteScheduleEntry(table, companyID, employeeID, entryID string) error {
+	if err := s.verifyEmployeeCompany(companyID, employeeID); err != nil {
+		return err
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE id = $1 AND employee_id = $2", table)
+	result, err := s.db.Exec(query, entryID, employeeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("schedule entry not found")
+	}
+
+	return nil
+}
+
+// parseClockRange normalizes HH:MM or HH:MM:SS values and validates that end is after start
+func parseClockRange(startTime, endTime string) (string, string, error) {
+	start, err := parseClock(startTime)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid start_time: %s", startTime)
+	}
+	end, err := parseClock(endTime)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid end_time: %s", endTime)
+	}
+	if !end.After(start) {
+		return "", "", fmt.Errorf("end_time must be after start_time")
+	}
+	return start.Format("15:04:05"), end.Format("15:04:05"), nil
+}
+
+func parseClock(value string) (time.Time, error) {
+	if t, err := time.Parse("15:04:05", value); err == nil {
+		return t, nil
+	}
+	return time.Parse("15:04", value)
+}
+
+// clockOnDate combines a TIME column value with the given day
+func clockOnDate(day time.Time, clock string) time.Time {
+	t, err := parseClock(clock)
+	if err != nil {
+		return day
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), t.Hour(), t.Minute(), t.Second(), 0, day.Location())
+}
+
+// subtractWindow removes [start, end) from every window
+func subtractWindow(windows []models.WorkingWindow, start, end time.Time) []models.WorkingWindow {
+	var result []models.WorkingWindow
+	for _, w := range windows {
+		if !start.Before(w.End) || !end.After(w.Start) {
+			result = append(result, w)
+			continue
+		}
+		if start.After(w.Start) {
+			result = append(result, models.WorkingWindow{Start: w.Start, End: start})
+		}
+		if end.Before(w.End) {
+			result = append(result, models.WorkingWindow{Start: end, End: w.End})
+		}
+	}
+	return result
+}
+
+// windowsContain reports whether [start, end) lies entirely inside one of the windows
+func windowsContain(windows []models.WorkingWindow, start, end time.Time) bool {
+	for _, w := range windows {
+		if !start.Before(w.Start) && !end.After(w.End) {
+			return true
+		}
+	}
+	return false
+}
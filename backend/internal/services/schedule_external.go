@@ -0,0 +1,419 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// externalCalendarHorizonDays is how far ahead imported events are expanded into busy blocks
+const externalCalendarHorizonDays = 180
+
+// maxExternalCalendarBytes caps the size of an imported .ics file
+const maxExternalCalendarBytes = 5 << 20
+
+// externalCalendarClient downloads calendar URLs. Every connection, redirects included, is checked
+// so that a calendar URL cannot reach the server's own network.
+var externalCalendarClient = &http.Client{
+	Timeout: 20 * time.Second,
+	Transport: &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout: 10 * time.Second,
+			Control: rejectInternalAddress,
+		}).DialContext,
+		TLSHandshakeTimeout: 10 * time.Second,
+	},
+	CheckRedirect: func(req *http.Request, via []*http.Request) error {
+		if len(via) >= 5 {
+			return fmt.Errorf("too many redirects")
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != "https" {
+			return fmt.Errorf("calendar url redirects to an unsupported scheme")
+		}
+		return nil
+	},
+}
+
+// GetExternalCalendars returns the imported calendars of an employee, or the company-wide ones when employeeID is nil
+func (s *ScheduleService) GetExternalCalendars(companyID string, employeeID *string) ([]models.ExternalCalendar, error) {
+	if employeeID != nil {
+		if err := s.verifyEmployeeCompany(companyID, *employeeID); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := s.db.Query(`
+		SELECT id, company_id, employee_id, name, source_type, url,
+			   event_count, last_synced_at, last_error, created_at, updated_at
+		FROM external_calendars
+		WHERE company_id = $1 AND employee_id IS NOT DISTINCT FROM $2::uuid
+		ORDER BY created_at
+	`, companyID, employeeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get external calendars: %w", err)
+	}
+	defer rows.Close()
+
+	calendars := []models.ExternalCalendar{}
+	for rows.Next() {
+		var calendar models.ExternalCalendar
+		if err := rows.Scan(
+			&calendar.ID, &calendar.CompanyID, &calendar.EmployeeID, &calendar.Name, &calendar.SourceType,
+			&calendar.URL, &calendar.EventCount, &calendar.LastSyncedAt,
+			&calendar.LastError, &calendar.CreatedAt, &calendar.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		calendars = append(calendars, calendar)
+	}
+
+	return calendars, nil
+}
+
+// CreateExternalCalendar imports an ICS calendar from a URL or uploaded content.
+// The calendar is parsed before it is saved so broken sources are rejected up front.
+func (s *ScheduleService) CreateExternalCalendar(companyID string, employeeID *string, req *models.ExternalCalendarRequest) (*models.ExternalCalendar, error) {
+	if employeeID != nil {
+		if err := s.verifyEmployeeCompany(companyID, *employeeID); err != nil {
+			return nil, err
+		}
+	}
+
+	var sourceType string
+	var calendarURL, icsContent *string
+	switch {
+	case req.URL != "" && req.ICSContent != "":
+		return nil, fmt.Errorf("provide either url or an uploaded file, not both")
+	case req.URL != "":
+		normalized, err := normalizeCalendarURL(req.URL)
+		if err != nil {
+			return nil, err
+		}
+		sourceType, calendarURL = "url", &normalized
+	case req.ICSContent != "":
+		if len(req.ICSContent) > maxExternalCalendarBytes {
+			return nil, fmt.Errorf("calendar file is too large")
+		}
+		sourceType, icsContent = "upload", &req.ICSContent
+	default:
+		return nil, fmt.Errorf("url or an uploaded file is required")
+	}
+
+	content, err := loadExternalCalendarContent(sourceType, calendarURL, icsContent)
+	if err != nil {
+		return nil, err
+	}
+	from, to := externalCalendarRange()
+	busy, err := parseICSBusyTimes(content, companyLocation(s.db, companyID), from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var calendarID string
+	err = tx.QueryRow(`
+		INSERT INTO external_calendars (company_id, employee_id, name, source_type, url, ics_content)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`, companyID, employeeID, req.Name, sourceType, calendarURL, icsContent).Scan(&calendarID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create external calendar: %w", err)
+	}
+
+	if err = s.replaceBusyBlocks(tx, calendarID, companyID, employeeID, busy); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getExternalCalendar(companyID, employeeID, calendarID)
+}
+
+// DeleteExternalCalendar removes an imported calendar together with its busy blocks
+func (s *ScheduleService) DeleteExternalCalendar(companyID string, employeeID *string, calendarID string) error {
+	result, err := s.db.Exec(`
+		DELETE FROM external_calendars
+		WHERE id = $1 AND company_id = $2 AND employee_id IS NOT DISTINCT FROM $3::uuid
+	`, calendarID, companyID, employeeID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("external calendar not found")
+	}
+
+	return nil
+}
+
+// SyncExternalCalendar fetches and re-imports a calendar right away
+func (s *ScheduleService) SyncExternalCalendar(companyID string, employeeID *string, calendarID string) (*models.ExternalCalendar, error) {
+	if _, err := s.getExternalCalendar(companyID, employeeID, calendarID); err != nil {
+		return nil, err
+	}
+
+	if err := s.syncExternalCalendar(calendarID); err != nil {
+		return nil, err
+	}
+
+	return s.getExternalCalendar(companyID, employeeID, calendarID)
+}
+
+// RefreshExternalCalendars re-imports every calendar. URL calendars pick up remote changes
+// and uploaded ones are expanded further as the import horizon moves forward.
+// A source that fails keeps its previous busy blocks and records the error.
+func (s *ScheduleService) RefreshExternalCalendars() {
+	rows, err := s.db.Query("SELECT id FROM external_calendars")
+	if err != nil {
+		log.Printf("Error getting external calendars: %v", err)
+		return
+	}
+
+	var calendarIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err == nil {
+			calendarIDs = append(calendarIDs, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range calendarIDs {
+		if err := s.syncExternalCalendar(id); err != nil {
+			log.Printf("Error refreshing external calendar %s: %v", id, err)
+		}
+	}
+}
+
+// GetCompanyBusyBlocks returns company-wide imported busy blocks overlapping [start, end)
+func (s *ScheduleService) GetCompanyBusyBlocks(companyID string, start, end time.Time) ([]models.ExternalBusyBlock, error) {
+	rows, err := s.db.Query(`
+		SELECT id, calendar_id, employee_id, starts_at, ends_at
+		FROM external_busy_blocks
+		WHERE company_id = $1 AND employee_id IS NULL AND starts_at < $3 AND ends_at > $2
+		ORDER BY starts_at
+	`, companyID, start, end)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get busy blocks: %w", err)
+	}
+	defer rows.Close()
+
+	return scanBusyBlocks(rows)
+}
+
+// IsCompanyBusy reports whether a company-wide imported event overlaps [start, end)
+func (s *ScheduleService) IsCompanyBusy(companyID string, start, end time.Time) (bool, error) {
+	blocks, err := s.GetCompanyBusyBlocks(companyID, start, end)
+	if err != nil {
+		return false, err
+	}
+
+	return len(blocks) > 0, nil
+}
+
+// Helper methods
+
+func (s *ScheduleService) getExternalCalendar(companyID string, employeeID *string, calendarID string) (*models.ExternalCalendar, error) {
+	var calendar models.ExternalCalendar
+	err := s.db.QueryRow(`
+		SELECT id, company_id, employee_id, name, source_type, url,
+			   event_count, last_synced_at, last_error, created_at, updated_at
+		FROM external_calendars
+		WHERE id = $1 AND company_id = $2 AND employee_id IS NOT DISTINCT FROM $3::uuid
+	`, calendarID, companyID, employeeID).Scan(
+		&calendar.ID, &calendar.CompanyID, &calendar.EmployeeID, &calendar.Name, &calendar.SourceType,
+		&calendar.URL, &calendar.EventCount, &calendar.LastSyncedAt,
+		&calendar.LastError, &calendar.CreatedAt, &calendar.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("external calendar not found")
+	}
+
+	return &calendar, nil
+}
+
+// syncExternalCalendar loads, parses and stores the busy blocks of a calendar
+func (s *ScheduleService) syncExternalCalendar(calendarID string) error {
+	var companyID, sourceType string
+	var employeeID, calendarURL, icsContent *string
+	err := s.db.QueryRow(`
+		SELECT company_id, employee_id, source_type, url, ics_content
+		FROM external_calendars WHERE id = $1
+	`, calendarID).Scan(&companyID, &employeeID, &sourceType, &calendarURL, &icsContent)
+	if err != nil {
+		return fmt.Errorf("external calendar not found")
+	}
+
+	content, err := loadExternalCalendarContent(sourceType, calendarURL, icsContent)
+	if err != nil {
+		s.recordExternalCalendarError(calendarID, err)
+		return err
+	}
+	from, to := externalCalendarRange()
+	busy, err := parseICSBusyTimes(content, companyLocation(s.db, companyID), from, to)
+	if err != nil {
+		s.recordExternalCalendarError(calendarID, err)
+		return err
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err = s.replaceBusyBlocks(tx, calendarID, companyID, employeeID, busy); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// replaceBusyBlocks swaps the stored busy blocks of a calendar for a fresh import
+func (s *ScheduleService) replaceBusyBlocks(tx *sql.Tx, calendarID, companyID string, employeeID *string, busy []icsBusyTime) error {
+	if _, err := tx.Exec("DELETE FROM external_busy_blocks WHERE calendar_id = $1", calendarID); err != nil {
+		return fmt.Errorf("failed to clear busy blocks: %w", err)
+	}
+
+	for _, block := range busy {
+		_, err := tx.Exec(`
+			INSERT INTO external_busy_blocks (calendar_id, company_id, employee_id, uid, starts_at, ends_at)
+			VALUES ($1, $2, $3, $4, $5, $6)
+		`, calendarID, companyID, employeeID, block.UID, block.Start.UTC(), block.End.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to save busy block: %w", err)
+		}
+	}
+
+	_, err := tx.Exec(`
+		UPDATE external_calendars
+		SET event_count = $2, last_synced_at = NOW(), last_error = NULL, updated_at = NOW()
+		WHERE id = $1
+	`, calendarID, len(busy))
+	return err
+}
+
+func (s *ScheduleService) recordExternalCalendarError(calendarID string, syncErr error) {
+	_, err := s.db.Exec(`
+		UPDATE external_calendars SET last_error = $2, updated_at = NOW() WHERE id = $1
+	`, calendarID, syncErr.Error())
+	if err != nil {
+		log.Printf("Error recording external calendar error: %v", err)
+	}
+}
+
+// loadExternalCalendarContent returns the stored upload or downloads the calendar URL
+func loadExternalCalendarContent(sourceType string, calendarURL, icsContent *string) ([]byte, error) {
+	if sourceType == "upload" {
+		if icsContent == nil {
+			return nil, fmt.Errorf("uploaded calendar has no content")
+		}
+		return []byte(*icsContent), nil
+	}
+	if calendarURL == nil {
+		return nil, fmt.Errorf("calendar has no url")
+	}
+
+	resp, err := externalCalendarClient.Get(*calendarURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download calendar: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download calendar: status %d", resp.StatusCode)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, maxExternalCalendarBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to download calendar: %w", err)
+	}
+	if len(content) > maxExternalCalendarBytes {
+		return nil, fmt.Errorf("calendar file is too large")
+	}
+
+	return content, nil
+}
+
+// normalizeCalendarURL accepts http(s) and webcal subscription links
+func normalizeCalendarURL(rawURL string) (string, error) {
+	parsed, err := url.Parse(strings.TrimSpace(rawURL))
+	if err != nil || parsed.Host == "" {
+		return "", fmt.Errorf("invalid calendar url")
+	}
+
+	switch strings.ToLower(parsed.Scheme) {
+	case "http", "https":
+	case "webcal", "webcals":
+		parsed.Scheme = "https"
+	default:
+		return "", fmt.Errorf("calendar url must use http, https or webcal")
+	}
+
+	ips, err := net.LookupIP(parsed.Hostname())
+	if err != nil || len(ips) == 0 {
+		return "", fmt.Errorf("calendar url host cannot be resolved")
+	}
+	for _, ip := range ips {
+		if isInternalIP(ip) {
+			return "", fmt.Errorf("calendar url must not point to a private address")
+		}
+	}
+
+	return parsed.String(), nil
+}
+
+// rejectInternalAddress is a dialer Control that refuses connections to the server's own network
+func rejectInternalAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || isInternalIP(ip) {
+		return fmt.Errorf("calendar url must not point to a private address")
+	}
+	return nil
+}
+
+// isInternalIP reports loopback, private, link-local, unspecified and multicast addresses
+func isInternalIP(ip net.IP) bool {
+	return ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified()
+}
+
+// externalCalendarRange is the window imported events are expanded into
+func externalCalendarRange() (time.Time, time.Time) {
+	now := time.Now()
+	return now.AddDate(0, 0, -1), now.AddDate(0, 0, externalCalendarHorizonDays)
+}
+
+func scanBusyBlocks(rows *sql.Rows) ([]models.ExternalBusyBlock, error) {
+	blocks := []models.ExternalBusyBlock{}
+	for rows.Next() {
+		var block models.ExternalBusyBlock
+		if err := rows.Scan(&block.ID, &block.CalendarID, &block.EmployeeID, &block.StartsAt, &block.EndsAt); err != nil {
+			return nil, err
+		}
+		blocks = append(blocks, block)
+	}
+
+	return blocks, nil
+}
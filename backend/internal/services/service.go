@@ -266,6 +266,8 @@ func (s *ServiceService) GetPublicServices(filters map[string]interface{}) ([]*m
 		services = append(services, service)
 	}
 
+	s.attachPriceRanges(services)
+
 	return services, totalCount, nil
 }
 
@@ -277,8 +279,8 @@ func (s *ServiceService) CreateService(service *models.Service) (*models.Service
 
 	// Calculate discount price if on sale
 	if service.IsOnSale && service.DiscountPercentage != nil && service.OriginalPrice != nil {
-		discountAmount := (*service.OriginalPrice) * (float64(*service.DiscountPercentage) / 100.0)
-		service.Price = (*service.OriginalPrice) - discountAmount
+		discountAmount := service.OriginalPrice.Percent(float64(*service.DiscountPercentage))
+		service.Price = service.OriginalPrice.Sub(discountAmount)
 	}
 
 	query := `
@@ -326,8 +328,8 @@ func (s *ServiceService) UpdateService(service *models.Service) (*models.Service
 
 	// Calculate discount price if on sale
 	if service.IsOnSale && service.DiscountPercentage != nil && service.OriginalPrice != nil {
-		discountAmount := (*service.OriginalPrice) * (float64(*service.DiscountPercentage) / 100.0)
-		service.Price = (*service.OriginalPrice) - discountAmount
+		discountAmount := service.OriginalPrice.Percent(float64(*service.DiscountPercentage))
+		service.Price = service.OriginalPrice.Sub(discountAmount)
 	}
 
 	query := `
@@ -850,6 +852,8 @@ func (s *ServiceService) GetCompanyPublicServices(companyID string, limit, offse
 		services = append(services, service)
 	}
 
+	s.attachPriceRanges(services)
+
 	return services, total, nil
 }
 
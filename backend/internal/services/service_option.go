@@ -0,0 +1,245 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+	"github.com/lib/pq"
+)
+
+const serviceOptionColumns = `
+	id, company_id, service_id, name, description, price, extra_minutes,
+	is_active, sort_order, created_at, updated_at`
+
+// GetServiceOptions returns the options of a service; activeOnly limits them to those customers can choose
+func (s *ServiceService) GetServiceOptions(serviceID string, activeOnly bool) ([]models.ServiceOption, error) {
+	return queryServiceOptions(s.db, `
+		WHERE service_id = $1 AND (is_active OR NOT $2)
+		ORDER BY sort_order, name
+	`, serviceID, activeOnly)
+}
+
+// GetCompanyServiceOptions returns every option of a company service
+func (s *ServiceService) GetCompanyServiceOptions(companyID, serviceID string) ([]models.ServiceOption, error) {
+	if err := s.verifyServiceCompany(companyID, serviceID); err != nil {
+		return nil, err
+	}
+
+	return s.GetServiceOptions(serviceID, false)
+}
+
+// CreateServiceOption adds an option to a company service
+func (s *ServiceService) CreateServiceOption(companyID, serviceID string, req *models.ServiceOptionRequest) (*models.ServiceOption, error) {
+	if err := s.verifyServiceCompany(companyID, serviceID); err != nil {
+		return nil, err
+	}
+	if err := validateServiceOption(req); err != nil {
+		return nil, err
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	var optionID string
+	err := s.db.QueryRow(`
+		INSERT INTO service_options (company_id, service_id, name, description, price,
+									 extra_minutes, is_active, sort_order)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, companyID, serviceID, req.Name, req.Description, models.MoneyFromDecimal(req.Price, ""),
+		req.ExtraMinutes, isActive, req.SortOrder).Scan(&optionID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create service option: %w", err)
+	}
+
+	return s.getServiceOption(companyID, optionID)
+}
+
+// UpdateServiceOption changes an option; bookings already made keep the price and minutes they were sold with
+func (s *ServiceService) UpdateServiceOption(companyID, optionID string, req *models.ServiceOptionRequest) (*models.ServiceOption, error) {
+	existing, err := s.getServiceOption(companyID, optionID)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateServiceOption(req); err != nil {
+		return nil, err
+	}
+
+	isActive := existing.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	_, err = s.db.Exec(`
+		UPDATE service_options
+		SET name = $2, description = $3, price = $4, extra_minutes = $5,
+			is_active = $6, sort_order = $7, updated_at = NOW()
+		WHERE id = $1
+	`, optionID, req.Name, req.Description, models.MoneyFromDecimal(req.Price, ""), req.ExtraMinutes, isActive, req.SortOrder)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update service option: %w", err)
+	}
+
+	return s.getServiceOption(companyID, optionID)
+}
+
+// DeleteServiceOption removes an option; past bookings keep their copy of it
+func (s *ServiceService) DeleteServiceOption(companyID, optionID string) error {
+	result, err := s.db.Exec("DELETE FROM service_options WHERE id = $1 AND company_id = $2", optionID, companyID)
+	if err != nil {
+		return err
+	}
+
+	rowsAffected, _ := result.RowsAffected()
+	if rowsAffected == 0 {
+		return fmt.Errorf("service option not found")
+	}
+
+	return nil
+}
+
+// Helper methods
+
+func (s *ServiceService) getServiceOption(companyID, optionID string) (*models.ServiceOption, error) {
+	options, err := queryServiceOptions(s.db, `WHERE id = $1 AND company_id = $2`, optionID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("service option not found")
+	}
+
+	return &options[0], nil
+}
+
+func validateServiceOption(req *models.ServiceOptionRequest) error {
+	if req.Price < 0 {
+		return fmt.Errorf("price cannot be negative")
+	}
+	if req.ExtraMinutes < 0 {
+		return fmt.Errorf("extra_minutes cannot be negative")
+	}
+
+	return nil
+}
+
+func queryServiceOptions(q rowsQuerier, where string, args ...interface{}) ([]models.ServiceOption, error) {
+	rows, err := q.Query(`SELECT `+serviceOptionColumns+` FROM service_options `+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get service options: %w", err)
+	}
+	defer rows.Close()
+
+	options := []models.ServiceOption{}
+	for rows.Next() {
+		var option models.ServiceOption
+		if err := rows.Scan(
+			&option.ID, &option.CompanyID, &option.ServiceID, &option.Name, &option.Description,
+			&option.Price, &option.ExtraMinutes, &option.IsActive, &option.SortOrder,
+			&option.CreatedAt, &option.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+
+	return options, nil
+}
+
+// selectedServiceOptions loads the options chosen for a booking; each must be an active option of the service
+func selectedServiceOptions(q rowsQuerier, serviceID string, optionIDs []string) ([]models.ServiceOption, error) {
+	if len(optionIDs) == 0 {
+		return nil, nil
+	}
+
+	seen := make(map[string]bool)
+	for _, id := range optionIDs {
+		if seen[id] {
+			return nil, fmt.Errorf("option %s is selected more than once", id)
+		}
+		seen[id] = true
+	}
+
+	options, err := queryServiceOptions(q, `
+		WHERE service_id = $1 AND is_active = true AND id::text = ANY($2)
+		ORDER BY sort_order, name
+	`, serviceID, pq.Array(optionIDs))
+	if err != nil {
+		return nil, err
+	}
+	if len(options) != len(optionIDs) {
+		return nil, fmt.Errorf("one or more options are not available for this service")
+	}
+
+	return options, nil
+}
+
+// sumServiceOptions returns the price and minutes the options add to a booking
+func sumServiceOptions(options []models.ServiceOption) (models.Money, int) {
+	var price models.Money
+	var minutes int
+	for _, option := range options {
+		price = price.Add(option.Price)
+		minutes += option.ExtraMinutes
+	}
+
+	return price, minutes
+}
+
+// saveBookingOptions copies the chosen options onto a booking
+func saveBookingOptions(tx *sql.Tx, bookingID string, options []models.ServiceOption) ([]models.BookingOption, error) {
+	saved := []models.BookingOption{}
+	for _, option := range options {
+		optionID := option.ID
+		bookingOption := models.BookingOption{
+			BookingID:    bookingID,
+			OptionID:     &optionID,
+			Name:         option.Name,
+			Price:        option.Price,
+			ExtraMinutes: option.ExtraMinutes,
+		}
+
+		err := tx.QueryRow(`
+			INSERT INTO booking_options (booking_id, option_id, name, price, extra_minutes)
+			VALUES ($1, $2, $3, $4, $5)
+			RETURNING id, created_at
+		`, bookingID, optionID, option.Name, option.Price, option.ExtraMinutes).Scan(&bookingOption.ID, &bookingOption.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save booking option: %w", err)
+		}
+		saved = append(saved, bookingOption)
+	}
+
+	return saved, nil
+}
+
+// getBookingOptions returns the options sold with a booking
+func getBookingOptions(q rowsQuerier, bookingID string) ([]models.BookingOption, error) {
+	rows, err := q.Query(`
+		SELECT id, booking_id, option_id, name, price, extra_minutes, created_at
+		FROM booking_options
+		WHERE booking_id = $1
+		ORDER BY created_at, name
+	`, bookingID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get booking options: %w", err)
+	}
+	defer rows.Close()
+
+	options := []models.BookingOption{}
+	for rows.Next() {
+		var option models.BookingOption
+		if err := rows.Scan(
+			&option.ID, &option.BookingID, &option.OptionID, &option.Name,
+			&option.Price, &option.ExtraMinutes, &option.CreatedAt,
+		); err != nil {
+			return nil, err
+		}
+		options = append(options, option)
+	}
+
+	return options, nil
+}
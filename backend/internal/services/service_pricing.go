@@ -0,0 +1,264 @@
+package services
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// breedSizeClasses are the size classes a breed or price rule can use, smallest first
+var breedSizeClasses = []string{"toy", "small", "medium", "large", "giant"}
+
+// petPricingProfile is what a price rule matches a pet on
+type petPricingProfile struct {
+	PetTypeID string
+	SizeClass string
+	Weight    float64
+}
+
+// GetServicePriceRules returns the price matrix of a company service
+func (s *ServiceService) GetServicePriceRules(companyID, serviceID string) ([]models.ServicePriceRule, error) {
+	if err := s.verifyServiceCompany(companyID, serviceID); err != nil {
+		return nil, err
+	}
+
+	return loadServicePriceRules(s.db, serviceID)
+}
+
+// SetServicePriceRules replaces the price matrix of a company service
+func (s *ServiceService) SetServicePriceRules(companyID, serviceID string, rules []models.ServicePriceRule) ([]models.ServicePriceRule, error) {
+	if err := s.verifyServiceCompany(companyID, serviceID); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range rules {
+		if err := validateSizeClass(rule.SizeClass); err != nil {
+			return nil, fmt.Errorf("rule %d: %w", i+1, err)
+		}
+		if rule.Price == nil && rule.Duration == nil {
+			return nil, fmt.Errorf("rule %d: price or duration is required", i+1)
+		}
+		if rule.Price != nil && rule.Price.IsNegative() {
+			return nil, fmt.Errorf("rule %d: price cannot be negative", i+1)
+		}
+		if rule.Duration != nil && *rule.Duration <= 0 {
+			return nil, fmt.Errorf("rule %d: duration must be positive", i+1)
+		}
+		if (rule.MinWeight != nil && *rule.MinWeight < 0) || (rule.MaxWeight != nil && *rule.MaxWeight <= 0) {
+			return nil, fmt.Errorf("rule %d: invalid weight band", i+1)
+		}
+		if rule.MinWeight != nil && rule.MaxWeight != nil && *rule.MinWeight >= *rule.MaxWeight {
+			return nil, fmt.Errorf("rule %d: min_weight must be below max_weight", i+1)
+		}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec("DELETE FROM service_price_rules WHERE service_id = $1", serviceID); err != nil {
+		return nil, fmt.Errorf("failed to clear price rules: %w", err)
+	}
+
+	for i, rule := range rules {
+		_, err := tx.Exec(`
+			INSERT INTO service_price_rules (service_id, company_id, pet_type_id, size_class,
+											 min_weight, max_weight, price, duration, sort_order)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		`, serviceID, companyID, rule.PetTypeID, rule.SizeClass, rule.MinWeight, rule.MaxWeight, rule.Price, rule.Duration, i)
+		if err != nil {
+			return nil, fmt.Errorf("failed to save price rule: %w", err)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return loadServicePriceRules(s.db, serviceID)
+}
+
+// Helper methods
+
+func (s *ServiceService) verifyServiceCompany(companyID, serviceID string) error {
+	var serviceCompanyID string
+	err := s.db.QueryRow("SELECT company_id FROM services WHERE id = $1", serviceID).Scan(&serviceCompanyID)
+	if err != nil || serviceCompanyID != companyID {
+		return fmt.Errorf("service not found")
+	}
+
+	return nil
+}
+
+// attachPriceRanges sets the price and duration spread of services that have a price matrix
+func (s *ServiceService) attachPriceRanges(services []*models.Service) {
+	for _, service := range services {
+		rules, err := loadServicePriceRules(s.db, service.ID)
+		if err != nil || len(rules) == 0 {
+			continue
+		}
+		service.PriceRange = servicePriceRange(service.Price, service.Duration, rules)
+	}
+}
+
+// servicePriceRange spans the base price and duration, which apply to pets no rule matches, and every rule
+func servicePriceRange(price models.Money, duration int, rules []models.ServicePriceRule) *models.ServicePriceRange {
+	priceRange := &models.ServicePriceRange{
+		MinPrice: price, MaxPrice: price,
+		MinDuration: duration, MaxDuration: duration,
+	}
+
+	for _, rule := range rules {
+		if rule.Price != nil {
+			rulePrice := rule.Price.WithCurrency(price.Currency)
+			if rulePrice.Amount < priceRange.MinPrice.Amount {
+				priceRange.MinPrice = rulePrice
+			}
+			if rulePrice.Amount > priceRange.MaxPrice.Amount {
+				priceRange.MaxPrice = rulePrice
+			}
+		}
+		if rule.Duration != nil {
+			if *rule.Duration < priceRange.MinDuration {
+				priceRange.MinDuration = *rule.Duration
+			}
+			if *rule.Duration > priceRange.MaxDuration {
+				priceRange.MaxDuration = *rule.Duration
+			}
+		}
+	}
+
+	return priceRange
+}
+
+// petServicePricing returns the price and duration of a service for a pet under the service's price matrix,
+// falling back to the given base price and duration when no rule matches
+func petServicePricing(q dbQuerier, serviceID, petID string, price models.Money, duration int) (models.Money, int, error) {
+	if petID == "" {
+		return price, duration, nil
+	}
+
+	rules, err := loadServicePriceRules(q, serviceID)
+	if err != nil || len(rules) == 0 {
+		return price, duration, err
+	}
+
+	profile, err := loadPetPricingProfile(q, petID)
+	if err != nil {
+		return models.Money{}, 0, err
+	}
+
+	if rule := matchPriceRule(rules, profile); rule != nil {
+		if rule.Price != nil {
+			price = rule.Price.WithCurrency(price.Currency)
+		}
+		if rule.Duration != nil {
+			duration = *rule.Duration
+		}
+	}
+
+	return price, duration, nil
+}
+
+func loadServicePriceRules(q rowsQuerier, serviceID string) ([]models.ServicePriceRule, error) {
+	rows, err := q.Query(`
+		SELECT id, service_id, pet_type_id, size_class, min_weight, max_weight,
+			   price, duration, created_at, updated_at
+		FROM service_price_rules
+		WHERE service_id = $1
+		ORDER BY sort_order, created_at
+	`, serviceID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get price rules: %w", err)
+	}
+	defer rows.Close()
+
+	rules := []models.ServicePriceRule{}
+	for rows.Next() {
+		var rule models.ServicePriceRule
+		if err := rows.Scan(
+			&rule.ID, &rule.ServiceID, &rule.PetTypeID, &rule.SizeClass, &rule.MinWeight,
+			&rule.MaxWeight, &rule.Price, &rule.Duration, &rule.CreatedAt, &rule.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func loadPetPricingProfile(q rowQuerier, petID string) (*petPricingProfile, error) {
+	var petTypeID, sizeClass sql.NullString
+	var weight sql.NullFloat64
+	err := q.QueryRow(`
+		SELECT p.pet_type_id, b.size_class, p.weight
+		FROM pets p
+		LEFT JOIN breeds b ON b.id = p.breed_id
+		WHERE p.id = $1
+	`, petID).Scan(&petTypeID, &sizeClass, &weight)
+	if err != nil {
+		return nil, fmt.Errorf("pet not found")
+	}
+
+	return &petPricingProfile{
+		PetTypeID: petTypeID.String,
+		SizeClass: sizeClass.String,
+		Weight:    weight.Float64,
+	}, nil
+}
+
+// matchPriceRule picks the rule matching the pet on the most criteria; earlier rules win ties.
+// Criteria the pet has no data for (unknown size, no weight) never match.
+func matchPriceRule(rules []models.ServicePriceRule, pet *petPricingProfile) *models.ServicePriceRule {
+	var best *models.ServicePriceRule
+	bestScore := -1
+
+	for i := range rules {
+		rule := &rules[i]
+		score := 0
+
+		if rule.PetTypeID != nil {
+			if *rule.PetTypeID != pet.PetTypeID {
+				continue
+			}
+			score++
+		}
+		if rule.SizeClass != nil {
+			if *rule.SizeClass != pet.SizeClass {
+				continue
+			}
+			score++
+		}
+		if rule.MinWeight != nil || rule.MaxWeight != nil {
+			if pet.Weight <= 0 ||
+				(rule.MinWeight != nil && pet.Weight < *rule.MinWeight) ||
+				(rule.MaxWeight != nil && pet.Weight >= *rule.MaxWeight) {
+				continue
+			}
+			score++
+		}
+
+		if score > bestScore {
+			best, bestScore = rule, score
+		}
+	}
+
+	return best
+}
+
+func validateSizeClass(sizeClass *string) error {
+	if sizeClass == nil {
+		return nil
+	}
+	for _, class := range breedSizeClasses {
+		if *sizeClass == class {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("invalid size class: %s", *sizeClass)
+}
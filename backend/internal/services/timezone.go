@@ -0,0 +1,70 @@
+package services
+
+import (
+	"database/sql"
+	"time"
+)
+
+// DefaultCompanyTimezone is used for companies that have not configured a timezone
+const DefaultCompanyTimezone = "UTC"
+
+// rowQuerier is implemented by both *sql.DB and *sql.Tx
+type rowQuerier interface {
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// loadLocation resolves an IANA timezone name, falling back to UTC for empty or unknown names
+func loadLocation(name string) *time.Location {
+	if name == "" {
+		return time.UTC
+	}
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+// companyLocation returns the configured timezone of a company
+func companyLocation(q rowQuerier, companyID string) *time.Location {
+	var timezone sql.NullString
+	if err := q.QueryRow("SELECT timezone FROM companies WHERE id = $1", companyID).Scan(&timezone); err != nil {
+		return time.UTC
+	}
+	return loadLocation(timezone.String)
+}
+
+// serviceLocation returns the timezone of the company that provides a service
+func serviceLocation(q rowQuerier, serviceID string) *time.Location {
+	var timezone sql.NullString
+	err := q.QueryRow(`
+		SELECT c.timezone
+		FROM services s
+		JOIN companies c ON s.company_id = c.id
+		WHERE s.id = $1
+	`, serviceID).Scan(&timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loadLocation(timezone.String)
+}
+
+// employeeLocation returns the timezone of the company an employee works for
+func employeeLocation(q rowQuerier, employeeID string) *time.Location {
+	var timezone sql.NullString
+	err := q.QueryRow(`
+		SELECT c.timezone
+		FROM employees e
+		JOIN companies c ON e.company_id = c.id
+		WHERE e.id = $1
+	`, employeeID).Scan(&timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loadLocation(timezone.String)
+}
+
+// inLocationDate reinterprets the calendar date of t as midnight in loc
+func inLocationDate(t time.Time, loc *time.Location) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, loc)
+}
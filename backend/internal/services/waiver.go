@@ -0,0 +1,430 @@
+package services
+
+import (
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/TahyrOrazdurdyyev/zootel/backend/internal/models"
+)
+
+// ErrWaiverNotSigned is returned when a service requires a waiver the customer has not signed in its current version
+var ErrWaiverNotSigned = errors.New("please sign the waiver for this service before booking")
+
+const waiverSignatureColumns = `
+	ws.id, ws.waiver_id, w.title, ws.version_id, wv.version, ws.company_id, ws.user_id,
+	TRIM(CONCAT(u.first_name, ' ', u.last_name)), COALESCE(u.email, ''),
+	ws.signature_name, ws.document_hash, ws.ip_address, ws.user_agent, ws.signed_at`
+
+const waiverSignatureJoins = `
+	JOIN waivers w ON w.id = ws.waiver_id
+	JOIN waiver_versions wv ON wv.id = ws.version_id
+	JOIN users u ON u.id = ws.user_id
+`
+
+type WaiverService struct {
+	db *sql.DB
+}
+
+func NewWaiverService(db *sql.DB) *WaiverService {
+	return &WaiverService{db: db}
+}
+
+// GetCompanyWaivers returns every waiver of the company with its current text
+func (s *WaiverService) GetCompanyWaivers(companyID string) ([]models.Waiver, error) {
+	rows, err := s.db.Query(`
+		SELECT id FROM waivers WHERE company_id = $1 ORDER BY title
+	`, companyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waivers: %w", err)
+	}
+	defer rows.Close()
+
+	var waiverIDs []string
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			return nil, err
+		}
+		waiverIDs = append(waiverIDs, id)
+	}
+
+	waivers := []models.Waiver{}
+	for _, id := range waiverIDs {
+		waiver, err := s.getWaiver(companyID, id)
+		if err != nil {
+			return nil, err
+		}
+		waivers = append(waivers, *waiver)
+	}
+
+	return waivers, nil
+}
+
+// GetServiceWaiver returns the active waiver customers sign before booking a service, nil when none is required
+func (s *WaiverService) GetServiceWaiver(serviceID string) (*models.Waiver, error) {
+	var companyID string
+	var waiverID sql.NullString
+	err := s.db.QueryRow(`
+		SELECT s.company_id, w.id
+		FROM services s
+		LEFT JOIN waivers w ON w.id = s.waiver_id AND w.is_active = true
+		WHERE s.id = $1
+	`, serviceID).Scan(&companyID, &waiverID)
+	if err != nil {
+		return nil, fmt.Errorf("service not found")
+	}
+	if !waiverID.Valid {
+		return nil, nil
+	}
+
+	return s.getWaiver(companyID, waiverID.String)
+}
+
+// CreateWaiver creates a waiver with its first version
+func (s *WaiverService) CreateWaiver(companyID string, req *models.WaiverRequest) (*models.Waiver, error) {
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, fmt.Errorf("waiver text is required")
+	}
+
+	isActive := true
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	var waiverID string
+	err = tx.QueryRow(`
+		INSERT INTO waivers (company_id, title, is_active)
+		VALUES ($1, $2, $3)
+		RETURNING id
+	`, companyID, req.Title, isActive).Scan(&waiverID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create waiver: %w", err)
+	}
+
+	if err := publishWaiverVersion(tx, waiverID, body); err != nil {
+		return nil, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getWaiver(companyID, waiverID)
+}
+
+// UpdateWaiver changes a waiver. A changed text is published as a new version,
+// which customers have to sign again before their next booking.
+func (s *WaiverService) UpdateWaiver(companyID, waiverID string, req *models.WaiverRequest) (*models.Waiver, error) {
+	existing, err := s.getWaiver(companyID, waiverID)
+	if err != nil {
+		return nil, err
+	}
+	body := strings.TrimSpace(req.Body)
+	if body == "" {
+		return nil, fmt.Errorf("waiver text is required")
+	}
+
+	isActive := existing.IsActive
+	if req.IsActive != nil {
+		isActive = *req.IsActive
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`
+		UPDATE waivers SET title = $2, is_active = $3, updated_at = NOW() WHERE id = $1
+	`, waiverID, req.Title, isActive)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update waiver: %w", err)
+	}
+
+	if existing.CurrentVersion == nil || existing.CurrentVersion.DocumentHash != waiverDocumentHash(body) {
+		if err := publishWaiverVersion(tx, waiverID, body); err != nil {
+			return nil, err
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+
+	return s.getWaiver(companyID, waiverID)
+}
+
+// SetServiceWaiver sets the waiver customers must sign before booking a service; nil removes the requirement
+func (s *WaiverService) SetServiceWaiver(companyID, serviceID string, waiverID *string) error {
+	if waiverID != nil {
+		if _, err := s.getWaiver(companyID, *waiverID); err != nil {
+			return err
+		}
+	}
+
+	result, err := s.db.Exec(`
+		UPDATE services SET waiver_id = $3, updated_at = NOW() WHERE id = $1 AND company_id = $2
+	`, serviceID, companyID, waiverID)
+	if err != nil {
+		return fmt.Errorf("failed to set service waiver: %w", err)
+	}
+	if rowsAffected, _ := result.RowsAffected(); rowsAffected == 0 {
+		return fmt.Errorf("service not found")
+	}
+
+	return nil
+}
+
+// SignWaiver records a customer's typed signature of the current version of an active waiver,
+// with the hash of the text they signed and where they signed from
+func (s *WaiverService) SignWaiver(userID, waiverID string, req *models.WaiverSignRequest, ipAddress, userAgent string) (*models.WaiverSignature, error) {
+	signatureName := strings.TrimSpace(req.SignatureName)
+	if signatureName == "" {
+		return nil, fmt.Errorf("signature_name is required")
+	}
+	if !req.Agreed {
+		return nil, fmt.Errorf("the waiver must be agreed to")
+	}
+
+	var companyID string
+	var isActive bool
+	var currentVersionID, documentHash string
+	err := s.db.QueryRow(`
+		SELECT w.company_id, w.is_active, wv.id, wv.document_hash
+		FROM waivers w
+		JOIN waiver_versions wv ON wv.waiver_id = w.id
+		WHERE w.id = $1
+		ORDER BY wv.version DESC
+		LIMIT 1
+	`, waiverID).Scan(&companyID, &isActive, &currentVersionID, &documentHash)
+	if err != nil || !isActive {
+		return nil, fmt.Errorf("waiver not found")
+	}
+	if req.VersionID != currentVersionID {
+		return nil, fmt.Errorf("the waiver has been updated, please review and sign the current version")
+	}
+
+	var signatureID string
+	err = s.db.QueryRow(`
+		INSERT INTO waiver_signatures (waiver_id, version_id, company_id, user_id, signature_name,
+									   document_hash, ip_address, user_agent)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`, waiverID, currentVersionID, companyID, userID, signatureName, documentHash,
+		optionalString(ipAddress), optionalString(userAgent)).Scan(&signatureID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign waiver: %w", err)
+	}
+
+	signatures, err := s.querySignatures(`WHERE ws.id = $1`, signatureID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &signatures[0], nil
+}
+
+// GetUserSignatures returns the waivers a customer has signed
+func (s *WaiverService) GetUserSignatures(userID string) ([]models.WaiverSignature, error) {
+	return s.querySignatures(`
+		WHERE ws.user_id = $1
+		ORDER BY ws.signed_at DESC
+	`, userID)
+}
+
+// GetCompanySignatures returns the signatures collected by the company, filtered by customer when userID is set
+func (s *WaiverService) GetCompanySignatures(companyID, userID string) ([]models.WaiverSignature, error) {
+	return s.querySignatures(`
+		WHERE ws.company_id = $1 AND ($2 = '' OR ws.user_id::text = $2)
+		ORDER BY ws.signed_at DESC
+	`, companyID, userID)
+}
+
+// GetSignedWaiver returns a signature with the text as signed, checked against its hash
+func (s *WaiverService) GetSignedWaiver(companyID, signatureID string) (*models.SignedWaiverRecord, error) {
+	signatures, err := s.querySignatures(`WHERE ws.id = $1 AND ws.company_id = $2`, signatureID, companyID)
+	if err != nil {
+		return nil, err
+	}
+	if len(signatures) == 0 {
+		return nil, fmt.Errorf("waiver signature not found")
+	}
+	signature := signatures[0]
+
+	var body string
+	err = s.db.QueryRow("SELECT body FROM waiver_versions WHERE id = $1", signature.VersionID).Scan(&body)
+	if err != nil {
+		return nil, err
+	}
+
+	record := &models.SignedWaiverRecord{
+		Signature: signature,
+		Body:      body,
+		Verified:  waiverDocumentHash(body) == signature.DocumentHash,
+	}
+	record.Document = renderSignedWaiver(record)
+
+	return record, nil
+}
+
+// Helper methods
+
+func (s *WaiverService) getWaiver(companyID, waiverID string) (*models.Waiver, error) {
+	var waiver models.Waiver
+	err := s.db.QueryRow(`
+		SELECT id, company_id, title, is_active, created_at, updated_at
+		FROM waivers WHERE id = $1 AND company_id = $2
+	`, waiverID, companyID).Scan(
+		&waiver.ID, &waiver.CompanyID, &waiver.Title, &waiver.IsActive, &waiver.CreatedAt, &waiver.UpdatedAt,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("waiver not found")
+	}
+
+	var version models.WaiverVersion
+	err = s.db.QueryRow(`
+		SELECT id, waiver_id, version, body, document_hash, created_at
+		FROM waiver_versions WHERE waiver_id = $1
+		ORDER BY version DESC
+		LIMIT 1
+	`, waiverID).Scan(
+		&version.ID, &version.WaiverID, &version.Version, &version.Body, &version.DocumentHash, &version.CreatedAt,
+	)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+	if err == nil {
+		waiver.CurrentVersion = &version
+	}
+
+	return &waiver, nil
+}
+
+func (s *WaiverService) querySignatures(where string, args ...interface{}) ([]models.WaiverSignature, error) {
+	rows, err := s.db.Query("SELECT "+waiverSignatureColumns+" FROM waiver_signatures ws "+waiverSignatureJoins+where, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get waiver signatures: %w", err)
+	}
+	defer rows.Close()
+
+	signatures := []models.WaiverSignature{}
+	for rows.Next() {
+		var signature models.WaiverSignature
+		if err := rows.Scan(
+			&signature.ID, &signature.WaiverID, &signature.WaiverTitle, &signature.VersionID, &signature.Version,
+			&signature.CompanyID, &signature.UserID, &signature.CustomerName, &signature.CustomerEmail,
+			&signature.SignatureName, &signature.DocumentHash, &signature.IPAddress, &signature.UserAgent,
+			&signature.SignedAt,
+		); err != nil {
+			return nil, err
+		}
+		signatures = append(signatures, signature)
+	}
+
+	return signatures, nil
+}
+
+// publishWaiverVersion adds the next version of a waiver's text
+func publishWaiverVersion(tx *sql.Tx, waiverID, body string) error {
+	_, err := tx.Exec(`
+		INSERT INTO waiver_versions (waiver_id, version, body, document_hash)
+		SELECT $1, COALESCE(MAX(version), 0) + 1, $2, $3 FROM waiver_versions WHERE waiver_id = $1
+	`, waiverID, body, waiverDocumentHash(body))
+	if err != nil {
+		return fmt.Errorf("failed to publish waiver version: %w", err)
+	}
+
+	return nil
+}
+
+// serviceWaiverSignature returns the customer's signature of the current version of the waiver a service requires.
+// It is nil when the service requires none, or when enforce is off and the customer has not signed.
+func serviceWaiverSignature(q rowQuerier, serviceID, userID string, enforce bool) (*string, error) {
+	var versionID sql.NullString
+	err := q.QueryRow(`
+		SELECT (SELECT wv.id FROM waiver_versions wv WHERE wv.waiver_id = w.id ORDER BY wv.version DESC LIMIT 1)
+		FROM services s
+		JOIN waivers w ON w.id = s.waiver_id AND w.is_active = true
+		WHERE s.id = $1
+	`, serviceID).Scan(&versionID)
+	if err == sql.ErrNoRows || (err == nil && !versionID.Valid) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var signatureID string
+	err = q.QueryRow(`
+		SELECT id FROM waiver_signatures
+		WHERE version_id = $1 AND user_id = $2
+		ORDER BY signed_at DESC
+		LIMIT 1
+	`, versionID.String, userID).Scan(&signatureID)
+	if err == sql.ErrNoRows {
+		if enforce {
+			return nil, ErrWaiverNotSigned
+		}
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &signatureID, nil
+}
+
+// waiverDocumentHash fingerprints a waiver text so a signature can be matched to exactly what was shown
+func waiverDocumentHash(body string) string {
+	sum := sha256.Sum256([]byte(body))
+	return hex.EncodeToString(sum[:])
+}
+
+// renderSignedWaiver lays out a signed waiver as a printable document
+func renderSignedWaiver(record *models.SignedWaiverRecord) string {
+	signature := record.Signature
+	verification := "Text matches the signed document hash"
+	if !record.Verified {
+		verification = "WARNING: text does not match the signed document hash"
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s (version %d)\n\n", signature.WaiverTitle, signature.Version)
+	b.WriteString(record.Body)
+	b.WriteString("\n\n----------------------------------------\n")
+	fmt.Fprintf(&b, "Signed by:      %s\n", signature.SignatureName)
+	fmt.Fprintf(&b, "Customer:       %s <%s>\n", signature.CustomerName, signature.CustomerEmail)
+	fmt.Fprintf(&b, "Signed at:      %s\n", signature.SignedAt.UTC().Format(time.RFC3339))
+	if signature.IPAddress != nil {
+		fmt.Fprintf(&b, "IP address:     %s\n", *signature.IPAddress)
+	}
+	if signature.UserAgent != nil {
+		fmt.Fprintf(&b, "Device:         %s\n", *signature.UserAgent)
+	}
+	fmt.Fprintf(&b, "Document hash:  SHA-256 %s\n", signature.DocumentHash)
+	fmt.Fprintf(&b, "Signature ID:   %s\n", signature.ID)
+	fmt.Fprintf(&b, "Verification:   %s\n", verification)
+
+	return b.String()
+}
+
+// optionalString stores an empty value as NULL
+func optionalString(value string) *string {
+	if value == "" {
+		return nil
+	}
+	return &value
+}
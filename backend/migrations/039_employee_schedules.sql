@@ -0,0 +1,86 @@
+-- Migration: Employee Schedules
+-- Description: Adds recurring shifts, date overrides, time-off and breaks for employees
+
+-- Recurring weekly shifts (an employee may have several shifts per day)
+CREATE TABLE IF NOT EXISTS employee_shifts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    day_of_week VARCHAR(10) NOT NULL,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_shift_day CHECK (day_of_week IN ('monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday', 'sunday')),
+    CONSTRAINT valid_shift_times CHECK (end_time > start_time)
+);
+
+-- One-off overrides of the weekly template for a specific date
+CREATE TABLE IF NOT EXISTS employee_schedule_overrides (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    override_date DATE NOT NULL,
+    is_day_off BOOLEAN DEFAULT FALSE,
+    start_time TIME,
+    end_time TIME,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT unique_override_per_day UNIQUE(employee_id, override_date),
+    CONSTRAINT valid_override_times CHECK (
+        is_day_off = TRUE OR (start_time IS NOT NULL AND end_time IS NOT NULL AND end_time > start_time)
+    )
+);
+
+-- Vacations, sick days and other absences
+CREATE TABLE IF NOT EXISTS employee_time_off (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL DEFAULT 'vacation',
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_time_off_type CHECK (type IN ('vacation', 'sick', 'personal', 'other')),
+    CONSTRAINT valid_time_off_range CHECK (ends_at > starts_at)
+);
+
+-- Breaks, either recurring on a weekday or on a single date
+CREATE TABLE IF NOT EXISTS employee_breaks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    employee_id UUID NOT NULL REFERENCES employees(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    day_of_week VARCHAR(10),
+    break_date DATE,
+    start_time TIME NOT NULL,
+    end_time TIME NOT NULL,
+    label VARCHAR(100),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_break_day CHECK (day_of_week IS NULL OR day_of_week IN ('monday', 'tuesday', 'wednesday', 'thursday', 'friday', 'saturday', 'sunday')),
+    CONSTRAINT break_day_or_date CHECK (day_of_week IS NOT NULL OR break_date IS NOT NULL),
+    CONSTRAINT valid_break_times CHECK (end_time > start_time)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_employee_shifts_employee ON employee_shifts(employee_id, day_of_week);
+CREATE INDEX IF NOT EXISTS idx_employee_shifts_company ON employee_shifts(company_id);
+CREATE INDEX IF NOT EXISTS idx_employee_overrides_employee_date ON employee_schedule_overrides(employee_id, override_date);
+CREATE INDEX IF NOT EXISTS idx_employee_time_off_employee_range ON employee_time_off(employee_id, starts_at, ends_at);
+CREATE INDEX IF NOT EXISTS idx_employee_breaks_employee ON employee_breaks(employee_id);
+
+-- Permission for managing schedules without full employee management access
+INSERT INTO employee_permissions (id, name, description, category) VALUES
+('manage_schedules', 'Manage Schedules', 'Manage employee shifts, time-off and breaks', 'employees')
+ON CONFLICT (id) DO UPDATE SET
+    name = EXCLUDED.name,
+    description = EXCLUDED.description,
+    category = EXCLUDED.category;
@@ -0,0 +1,18 @@
+-- Migration: Company Timezone
+-- Description: Adds an IANA timezone to companies and stores booking times as UTC instants
+
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS timezone VARCHAR(64) NOT NULL DEFAULT 'UTC';
+
+-- Existing naive booking timestamps were written as UTC
+DO $$
+BEGIN
+    IF EXISTS (
+        SELECT 1 FROM information_schema.columns
+        WHERE table_name = 'bookings' AND column_name = 'date_time'
+          AND data_type = 'timestamp without time zone'
+    ) THEN
+        ALTER TABLE bookings
+            ALTER COLUMN date_time TYPE TIMESTAMP WITH TIME ZONE
+            USING date_time AT TIME ZONE 'UTC';
+    END IF;
+END $$;
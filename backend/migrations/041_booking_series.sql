@@ -0,0 +1,50 @@
+-- Migration: Booking Series
+-- Description: Recurring bookings generated from RRULE-style rules with per-occurrence exceptions
+
+CREATE TABLE IF NOT EXISTS booking_series (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    pet_id UUID REFERENCES pets(id) ON DELETE SET NULL,
+    employee_id UUID REFERENCES employees(id) ON DELETE SET NULL,
+    rrule TEXT NOT NULL,
+    start_date_time TIMESTAMP WITH TIME ZONE NOT NULL,
+    timezone VARCHAR(64) NOT NULL DEFAULT 'UTC',
+    notes TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    generated_until TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_series_status CHECK (status IN ('active', 'cancelled', 'completed'))
+);
+
+CREATE TABLE IF NOT EXISTS booking_series_exceptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    series_id UUID NOT NULL REFERENCES booking_series(id) ON DELETE CASCADE,
+    occurrence_date DATE NOT NULL,
+    type VARCHAR(10) NOT NULL,
+    new_date_time TIMESTAMP WITH TIME ZONE,
+    reason TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT unique_series_exception UNIQUE(series_id, occurrence_date),
+    CONSTRAINT valid_exception_type CHECK (type IN ('skip', 'move')),
+    CONSTRAINT move_requires_time CHECK (type = 'skip' OR new_date_time IS NOT NULL)
+);
+
+-- Generated bookings keep a link to their series and original occurrence date
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS series_id UUID REFERENCES booking_series(id) ON DELETE SET NULL;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS occurrence_date DATE;
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_booking_series_user ON booking_series(user_id);
+CREATE INDEX IF NOT EXISTS idx_booking_series_company ON booking_series(company_id);
+CREATE INDEX IF NOT EXISTS idx_booking_series_active ON booking_series(status, generated_until);
+CREATE INDEX IF NOT EXISTS idx_bookings_series_id ON bookings(series_id);
+
+-- At most one live booking per series occurrence
+CREATE UNIQUE INDEX IF NOT EXISTS idx_bookings_series_occurrence
+    ON bookings(series_id, occurrence_date)
+    WHERE series_id IS NOT NULL AND status NOT IN ('cancelled', 'rejected');
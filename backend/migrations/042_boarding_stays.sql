@@ -0,0 +1,56 @@
+-- Migration: Boarding Stays
+-- Description: Multi-night boarding reservations with kennel inventory, nightly rates and company holidays
+
+CREATE TABLE IF NOT EXISTS kennels (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    description TEXT,
+    size VARCHAR(20) NOT NULL DEFAULT 'medium',
+    max_pet_weight DECIMAL(6,2),
+    allowed_pet_types TEXT[] DEFAULT '{}',
+    capacity INTEGER NOT NULL DEFAULT 1,
+    is_active BOOLEAN DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_kennel_size CHECK (size IN ('small', 'medium', 'large', 'xlarge')),
+    CONSTRAINT positive_kennel_capacity CHECK (capacity > 0),
+    CONSTRAINT unique_company_kennel_name UNIQUE(company_id, name)
+);
+
+-- Nightly pricing and check-in/out times of a boarding service
+CREATE TABLE IF NOT EXISTS boarding_rates (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL UNIQUE REFERENCES services(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    nightly_rate DECIMAL(10,2) NOT NULL,
+    weekend_rate DECIMAL(10,2),
+    holiday_rate DECIMAL(10,2),
+    weekend_days TEXT[] DEFAULT '{friday,saturday}',
+    check_in_time TIME NOT NULL DEFAULT '14:00',
+    check_out_time TIME NOT NULL DEFAULT '11:00',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS company_holidays (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    holiday_date DATE NOT NULL,
+    name VARCHAR(100) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT unique_company_holiday UNIQUE(company_id, holiday_date)
+);
+
+-- Stays are bookings with a date range and an assigned kennel
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS check_in_date DATE;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS check_out_date DATE;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS kennel_id UUID REFERENCES kennels(id) ON DELETE SET NULL;
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_kennels_company ON kennels(company_id, is_active);
+CREATE INDEX IF NOT EXISTS idx_company_holidays_company_date ON company_holidays(company_id, holiday_date);
+CREATE INDEX IF NOT EXISTS idx_bookings_kennel_stay ON bookings(kennel_id, check_in_date, check_out_date)
+    WHERE kennel_id IS NOT NULL;
@@ -0,0 +1,29 @@
+-- Migration: Booking Waitlist
+-- Description: Waitlist per service and time window with time-limited holds offered on cancellation
+
+CREATE TABLE IF NOT EXISTS booking_waitlist (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    pet_id UUID REFERENCES pets(id) ON DELETE SET NULL,
+    employee_id UUID REFERENCES employees(id) ON DELETE SET NULL,
+    window_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    window_end TIMESTAMP WITH TIME ZONE NOT NULL,
+    notes TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'waiting',
+    hold_date_time TIMESTAMP WITH TIME ZONE,
+    hold_expires_at TIMESTAMP WITH TIME ZONE,
+    booking_id UUID REFERENCES bookings(id) ON DELETE SET NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_waitlist_status CHECK (status IN ('waiting', 'offered', 'booked', 'declined', 'expired', 'cancelled')),
+    CONSTRAINT valid_waitlist_window CHECK (window_end > window_start)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_booking_waitlist_queue ON booking_waitlist(service_id, status, created_at);
+CREATE INDEX IF NOT EXISTS idx_booking_waitlist_user ON booking_waitlist(user_id, status);
+CREATE INDEX IF NOT EXISTS idx_booking_waitlist_holds ON booking_waitlist(status, hold_expires_at)
+    WHERE status = 'offered';
@@ -0,0 +1,45 @@
+-- Migration: Cancellation Policies
+-- Description: Structured cancellation policies with fee tiers and a record of fees charged and refunds issued
+
+CREATE TABLE IF NOT EXISTS cancellation_policies (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    free_cancel_hours INTEGER NOT NULL DEFAULT 24 CHECK (free_cancel_hours >= 0),
+    fee_tiers JSONB NOT NULL DEFAULT '[]',
+    no_show_fee_percentage DECIMAL(5,2) NOT NULL DEFAULT 100 CHECK (no_show_fee_percentage BETWEEN 0 AND 100),
+    apply_to_reschedule BOOLEAN NOT NULL DEFAULT false,
+    is_default BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (company_id, name)
+);
+
+CREATE INDEX IF NOT EXISTS idx_cancellation_policies_company ON cancellation_policies(company_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cancellation_policies_default
+    ON cancellation_policies(company_id) WHERE is_default = true;
+
+ALTER TABLE services ADD COLUMN IF NOT EXISTS cancellation_policy_id UUID
+    REFERENCES cancellation_policies(id) ON DELETE SET NULL;
+
+CREATE TABLE IF NOT EXISTS booking_cancellations (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    policy_id UUID REFERENCES cancellation_policies(id) ON DELETE SET NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('cancel', 'reschedule', 'no_show')),
+    initiated_by VARCHAR(20) NOT NULL CHECK (initiated_by IN ('customer', 'company')),
+    hours_before DECIMAL(10,2) NOT NULL,
+    fee_percentage DECIMAL(5,2) NOT NULL DEFAULT 0,
+    fee_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    amount_paid DECIMAL(10,2) NOT NULL DEFAULT 0,
+    refund_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    charge_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    currency VARCHAR(3) NOT NULL DEFAULT 'usd',
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    refund_id UUID,
+    charge_payment_intent_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_booking_cancellations_booking ON booking_cancellations(booking_id);
@@ -0,0 +1,13 @@
+-- Migration: Booking Deposits
+-- Description: Services can require a fixed or percentage deposit; unpaid bookings hold the slot in pending_payment until the deadline
+
+ALTER TABLE services ADD COLUMN IF NOT EXISTS deposit_type VARCHAR(20) NOT NULL DEFAULT 'none';
+ALTER TABLE services ADD COLUMN IF NOT EXISTS deposit_value DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE services ADD COLUMN IF NOT EXISTS deposit_hold_minutes INTEGER NOT NULL DEFAULT 15;
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS deposit_amount DECIMAL(10,2);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS deposit_payment_intent_id VARCHAR(255);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS payment_expires_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_bookings_pending_payment ON bookings(payment_expires_at)
+    WHERE status = 'pending_payment';
@@ -0,0 +1,14 @@
+-- Migration: Calendar Feeds
+-- Description: Secret-token iCalendar feed URLs for company, employee and customer bookings
+
+CREATE TABLE IF NOT EXISTS calendar_feeds (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    owner_type VARCHAR(20) NOT NULL,
+    owner_id UUID NOT NULL,
+    token VARCHAR(64) NOT NULL UNIQUE,
+    last_accessed_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    CONSTRAINT valid_calendar_feed_owner CHECK (owner_type IN ('company', 'employee', 'user')),
+    UNIQUE (owner_type, owner_id)
+);
@@ -0,0 +1,39 @@
+-- Migration: External Calendars
+-- Description: Imported ICS calendars of employees and companies whose events block availability
+
+CREATE TABLE IF NOT EXISTS external_calendars (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    employee_id UUID REFERENCES employees(id) ON DELETE CASCADE, -- NULL blocks the whole company
+    name VARCHAR(255) NOT NULL,
+    source_type VARCHAR(20) NOT NULL,
+    url TEXT,
+    ics_content TEXT, -- Uploaded file, re-expanded on every refresh
+    event_count INTEGER NOT NULL DEFAULT 0,
+    last_synced_at TIMESTAMP WITH TIME ZONE,
+    last_error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_external_calendar_source CHECK (source_type IN ('url', 'upload'))
+);
+
+CREATE TABLE IF NOT EXISTS external_busy_blocks (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    calendar_id UUID NOT NULL REFERENCES external_calendars(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    employee_id UUID REFERENCES employees(id) ON DELETE CASCADE,
+    uid TEXT,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_external_busy_block CHECK (ends_at > starts_at)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_external_calendars_company ON external_calendars(company_id, employee_id);
+CREATE INDEX IF NOT EXISTS idx_external_busy_blocks_calendar ON external_busy_blocks(calendar_id);
+CREATE INDEX IF NOT EXISTS idx_external_busy_blocks_employee ON external_busy_blocks(employee_id, starts_at, ends_at);
+CREATE INDEX IF NOT EXISTS idx_external_busy_blocks_company ON external_busy_blocks(company_id, starts_at, ends_at)
+    WHERE employee_id IS NULL;
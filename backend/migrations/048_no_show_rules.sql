@@ -0,0 +1,12 @@
+-- Migration: No-Show Rules
+-- Description: Company rules for customers who repeatedly miss their bookings
+
+-- Rules are off while the thresholds are 0
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS no_show_deposit_after INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS no_show_deposit_type VARCHAR(20) NOT NULL DEFAULT 'percentage';
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS no_show_deposit_value DECIMAL(10,2) NOT NULL DEFAULT 100;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS no_show_block_after INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS no_show_window_days INTEGER NOT NULL DEFAULT 0; -- 0 counts every no-show
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_bookings_customer_status ON bookings(company_id, user_id, status);
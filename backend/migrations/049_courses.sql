@@ -0,0 +1,75 @@
+-- Migration: Courses
+-- Description: Group classes and multi-session training courses with capacity, enrollment and attendance
+
+CREATE TABLE IF NOT EXISTS courses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    service_id UUID REFERENCES services(id) ON DELETE SET NULL, -- Category, description and cancellation policy
+    employee_id UUID REFERENCES employees(id) ON DELETE SET NULL, -- Trainer
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    capacity INTEGER NOT NULL CHECK (capacity > 0),
+    price DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (price >= 0), -- Price of the whole course
+    currency VARCHAR(3) NOT NULL DEFAULT 'usd',
+    status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    enrollment_closes_at TIMESTAMP WITH TIME ZONE, -- Defaults to the start of the first session
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_course_status CHECK (status IN ('draft', 'published', 'completed', 'cancelled'))
+);
+
+CREATE TABLE IF NOT EXISTS course_sessions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    location TEXT,
+    status VARCHAR(20) NOT NULL DEFAULT 'scheduled',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_course_session_status CHECK (status IN ('scheduled', 'cancelled')),
+    CONSTRAINT valid_course_session_time CHECK (ends_at > starts_at)
+);
+
+CREATE TABLE IF NOT EXISTS course_enrollments (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    course_id UUID NOT NULL REFERENCES courses(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pet_id UUID NOT NULL REFERENCES pets(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL DEFAULT 'enrolled',
+    price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    payment_intent_id VARCHAR(255),
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    payment_expires_at TIMESTAMP WITH TIME ZONE,
+    fee_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    refund_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    cancelled_by VARCHAR(20),
+    cancelled_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_course_enrollment_status CHECK (status IN ('pending_payment', 'enrolled', 'completed', 'cancelled'))
+);
+
+CREATE TABLE IF NOT EXISTS course_attendance (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    session_id UUID NOT NULL REFERENCES course_sessions(id) ON DELETE CASCADE,
+    enrollment_id UUID NOT NULL REFERENCES course_enrollments(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    notes TEXT,
+    marked_by UUID,
+    marked_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_course_attendance_status CHECK (status IN ('present', 'absent', 'excused')),
+    UNIQUE (session_id, enrollment_id)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_courses_company ON courses(company_id, status);
+CREATE INDEX IF NOT EXISTS idx_course_sessions_course ON course_sessions(course_id, starts_at);
+CREATE INDEX IF NOT EXISTS idx_course_enrollments_course ON course_enrollments(course_id, status);
+CREATE INDEX IF NOT EXISTS idx_course_enrollments_user ON course_enrollments(user_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_course_enrollments_active_pet ON course_enrollments(course_id, pet_id)
+    WHERE status IN ('pending_payment', 'enrolled');
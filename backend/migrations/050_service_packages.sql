@@ -0,0 +1,60 @@
+-- Migration: Service packages
+-- Description: Prepaid session bundles ("10 walks") with per-customer balances, expiry and a credit/debit ledger
+
+CREATE TABLE IF NOT EXISTS service_packages (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE, -- Bookings of this service redeem sessions
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    session_count INTEGER NOT NULL CHECK (session_count > 0),
+    price DECIMAL(10,2) NOT NULL CHECK (price >= 0),
+    currency VARCHAR(3) NOT NULL DEFAULT 'usd',
+    validity_days INTEGER CHECK (validity_days > 0), -- NULL means the sessions never expire
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS package_purchases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    package_id UUID NOT NULL REFERENCES service_packages(id) ON DELETE RESTRICT,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    pet_id UUID REFERENCES pets(id) ON DELETE SET NULL, -- NULL means any pet of the customer
+    sessions_total INTEGER NOT NULL,
+    sessions_remaining INTEGER NOT NULL CHECK (sessions_remaining >= 0),
+    price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending_payment',
+    payment_intent_id VARCHAR(255),
+    payment_id UUID REFERENCES payments(id) ON DELETE SET NULL,
+    expires_at TIMESTAMP WITH TIME ZONE, -- Set when the package is paid
+    activated_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_package_purchase_status CHECK (status IN ('pending_payment', 'active', 'exhausted', 'expired', 'cancelled'))
+);
+
+CREATE TABLE IF NOT EXISTS package_ledger_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    purchase_id UUID NOT NULL REFERENCES package_purchases(id) ON DELETE CASCADE,
+    booking_id UUID REFERENCES bookings(id) ON DELETE SET NULL,
+    entry_type VARCHAR(20) NOT NULL,
+    sessions INTEGER NOT NULL, -- Positive for credits, negative for debits
+    balance_after INTEGER NOT NULL,
+    note TEXT,
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_package_entry_type CHECK (entry_type IN ('purchase', 'redemption', 'adjustment', 'expiry'))
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_service_packages_company ON service_packages(company_id, is_active);
+CREATE INDEX IF NOT EXISTS idx_package_purchases_user ON package_purchases(user_id, company_id, status);
+CREATE INDEX IF NOT EXISTS idx_package_purchases_company ON package_purchases(company_id, status);
+CREATE INDEX IF NOT EXISTS idx_package_ledger_purchase ON package_ledger_entries(purchase_id, created_at);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_package_ledger_booking_redemption ON package_ledger_entries(booking_id)
+    WHERE entry_type = 'redemption';
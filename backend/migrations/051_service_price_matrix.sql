@@ -0,0 +1,26 @@
+-- Migration: Service price matrix
+-- Description: Breed size classes and per-service price/duration rules by pet type, size class and weight band
+
+ALTER TABLE breeds ADD COLUMN IF NOT EXISTS size_class VARCHAR(20); -- toy, small, medium, large, giant
+
+CREATE TABLE IF NOT EXISTS service_price_rules (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    pet_type_id UUID REFERENCES pet_types(id) ON DELETE CASCADE, -- NULL matches any pet type
+    size_class VARCHAR(20), -- NULL matches any size
+    min_weight DECIMAL(6,2), -- Inclusive, kg
+    max_weight DECIMAL(6,2), -- Exclusive, kg
+    price DECIMAL(10,2) CHECK (price >= 0), -- NULL keeps the service price
+    duration INTEGER CHECK (duration > 0), -- NULL keeps the service duration, minutes
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT price_rule_has_effect CHECK (price IS NOT NULL OR duration IS NOT NULL),
+    CONSTRAINT valid_price_rule_size_class CHECK (size_class IS NULL OR size_class IN ('toy', 'small', 'medium', 'large', 'giant')),
+    CONSTRAINT valid_price_rule_weight_band CHECK (min_weight IS NULL OR max_weight IS NULL OR min_weight < max_weight)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_service_price_rules_service ON service_price_rules(service_id, sort_order);
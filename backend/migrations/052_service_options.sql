@@ -0,0 +1,35 @@
+-- Migration: Service options
+-- Description: Add-ons such as nail trim or teeth brushing with their own price and extra minutes, chosen per booking
+
+CREATE TABLE IF NOT EXISTS service_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    price DECIMAL(10,2) NOT NULL DEFAULT 0 CHECK (price >= 0),
+    extra_minutes INTEGER NOT NULL DEFAULT 0 CHECK (extra_minutes >= 0),
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Options as sold; name, price and minutes are copied so later edits do not change past bookings
+CREATE TABLE IF NOT EXISTS booking_options (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    option_id UUID REFERENCES service_options(id) ON DELETE SET NULL,
+    name VARCHAR(255) NOT NULL,
+    price DECIMAL(10,2) NOT NULL DEFAULT 0,
+    extra_minutes INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Part of bookings.price that comes from options
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS options_price DECIMAL(10,2) NOT NULL DEFAULT 0;
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_service_options_service ON service_options(service_id, is_active);
+CREATE INDEX IF NOT EXISTS idx_booking_options_booking ON booking_options(booking_id);
+CREATE INDEX IF NOT EXISTS idx_booking_options_option ON booking_options(option_id);
@@ -0,0 +1,22 @@
+-- Migration: Booking check-in and check-out
+-- Description: QR check-in/out timestamps on bookings and the belongings and notes handed over at each step
+
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS checked_in_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS checked_out_at TIMESTAMP WITH TIME ZONE;
+
+CREATE TABLE IF NOT EXISTS booking_handovers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    stage VARCHAR(20) NOT NULL,
+    employee_id UUID REFERENCES employees(id) ON DELETE SET NULL, -- Staff member who scanned the code
+    belongings TEXT[] NOT NULL DEFAULT '{}', -- Leash, food, medication... left with or returned to the owner
+    notes TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_handover_stage CHECK (stage IN ('check_in', 'check_out')),
+    CONSTRAINT unique_booking_handover_stage UNIQUE (booking_id, stage)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_booking_handovers_booking ON booking_handovers(booking_id);
+CREATE INDEX IF NOT EXISTS idx_bookings_checked_in ON bookings(company_id, checked_in_at) WHERE checked_out_at IS NULL;
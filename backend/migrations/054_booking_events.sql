@@ -0,0 +1,41 @@
+-- Migration: Booking events
+-- Description: Append-only change history of bookings with the user, employee, AI agent or system behind each change
+
+CREATE TABLE IF NOT EXISTS booking_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    event_type VARCHAR(30) NOT NULL,
+    actor_type VARCHAR(20) NOT NULL DEFAULT 'system',
+    actor_id VARCHAR(255), -- User or employee ID, or the AI agent key
+    changes JSONB NOT NULL DEFAULT '{}', -- Field name to {"from": ..., "to": ...}
+    note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_booking_event_type CHECK (event_type IN (
+        'created', 'rescheduled', 'reassigned', 'status_changed', 'price_changed', 'updated', 'checked_in', 'checked_out'
+    )),
+    CONSTRAINT valid_booking_event_actor CHECK (actor_type IN ('user', 'employee', 'ai_agent', 'system'))
+);
+
+-- Events are never edited; they only go away with their booking
+CREATE OR REPLACE FUNCTION prevent_booking_event_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Deletes cascading from the booking run one trigger level deeper
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION 'booking_events is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_prevent_booking_event_changes ON booking_events;
+CREATE TRIGGER trigger_prevent_booking_event_changes
+    BEFORE UPDATE OR DELETE ON booking_events
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_booking_event_changes();
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_booking_events_booking ON booking_events(booking_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_booking_events_company ON booking_events(company_id, created_at);
@@ -0,0 +1,18 @@
+-- Migration: Mobile service routes
+-- Description: Customer addresses for walking, sitting and pet taxi appointments and travel time settings for route planning
+
+-- Where the employee meets the pet; required by mobile businesses
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS service_address TEXT;
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS service_latitude DECIMAL(10, 8);
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS service_longitude DECIMAL(11, 8);
+
+ALTER TABLE booking_series ADD COLUMN IF NOT EXISTS service_address TEXT;
+ALTER TABLE booking_series ADD COLUMN IF NOT EXISTS service_latitude DECIMAL(10, 8);
+ALTER TABLE booking_series ADD COLUMN IF NOT EXISTS service_longitude DECIMAL(11, 8);
+
+-- Travel time between jobs is the straight-line distance at this average speed plus a fixed allowance
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS travel_speed_kmh DECIMAL(5, 2) NOT NULL DEFAULT 25 CHECK (travel_speed_kmh > 0);
+ALTER TABLE companies ADD COLUMN IF NOT EXISTS travel_buffer_minutes INTEGER NOT NULL DEFAULT 5 CHECK (travel_buffer_minutes >= 0);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_bookings_employee_date_time ON bookings(employee_id, date_time) WHERE employee_id IS NOT NULL;
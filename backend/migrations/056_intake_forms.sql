@@ -0,0 +1,44 @@
+-- Migration: Intake forms
+-- Description: Company-defined questionnaires (symptoms, feeding instructions) attached to services, answered per booking
+
+CREATE TABLE IF NOT EXISTS intake_forms (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    fields JSONB NOT NULL DEFAULT '[]', -- [{key, label, type, required, options}]
+    completion VARCHAR(20) NOT NULL DEFAULT 'before_visit', -- at_booking forms are answered in the booking request
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_intake_form_completion CHECK (completion IN ('at_booking', 'before_visit'))
+);
+
+CREATE TABLE IF NOT EXISTS service_intake_forms (
+    service_id UUID NOT NULL REFERENCES services(id) ON DELETE CASCADE,
+    form_id UUID NOT NULL REFERENCES intake_forms(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    PRIMARY KEY (service_id, form_id)
+);
+
+-- Answers as given; the form name and fields are copied so later edits do not change past bookings
+CREATE TABLE IF NOT EXISTS booking_intake_responses (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    booking_id UUID NOT NULL REFERENCES bookings(id) ON DELETE CASCADE,
+    form_id UUID REFERENCES intake_forms(id) ON DELETE SET NULL,
+    form_name VARCHAR(255) NOT NULL,
+    fields JSONB NOT NULL DEFAULT '[]',
+    answers JSONB NOT NULL DEFAULT '{}',
+    submitted_by UUID, -- User or employee who filled the form in
+    submitted_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_intake_forms_company ON intake_forms(company_id, is_active);
+CREATE INDEX IF NOT EXISTS idx_service_intake_forms_form ON service_intake_forms(form_id);
+CREATE INDEX IF NOT EXISTS idx_booking_intake_responses_booking ON booking_intake_responses(booking_id);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_booking_intake_responses_form ON booking_intake_responses(booking_id, form_id)
+    WHERE form_id IS NOT NULL;
@@ -0,0 +1,72 @@
+-- Migration: Waivers
+-- Description: Versioned liability waivers per company, typed e-signatures with IP and document hash, required per service
+
+CREATE TABLE IF NOT EXISTS waivers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    title VARCHAR(255) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Each change of the text is a new version; customers sign a version, not the waiver
+CREATE TABLE IF NOT EXISTS waiver_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    waiver_id UUID NOT NULL REFERENCES waivers(id) ON DELETE CASCADE,
+    version INTEGER NOT NULL,
+    body TEXT NOT NULL,
+    document_hash VARCHAR(64) NOT NULL, -- SHA-256 of the body, hex
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    UNIQUE(waiver_id, version)
+);
+
+CREATE TABLE IF NOT EXISTS waiver_signatures (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    waiver_id UUID NOT NULL REFERENCES waivers(id) ON DELETE CASCADE,
+    version_id UUID NOT NULL REFERENCES waiver_versions(id) ON DELETE CASCADE,
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    signature_name VARCHAR(255) NOT NULL, -- Typed signature
+    document_hash VARCHAR(64) NOT NULL, -- Hash of the text as it was signed
+    ip_address VARCHAR(45),
+    user_agent TEXT,
+    signed_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Services requiring a signed waiver before booking
+ALTER TABLE services ADD COLUMN IF NOT EXISTS waiver_id UUID REFERENCES waivers(id) ON DELETE SET NULL;
+
+-- Signature a booking was made under
+ALTER TABLE bookings ADD COLUMN IF NOT EXISTS waiver_signature_id UUID REFERENCES waiver_signatures(id) ON DELETE SET NULL;
+
+-- Published versions and signatures are never edited; they only go away with their company, waiver or user
+CREATE OR REPLACE FUNCTION prevent_signed_waiver_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    -- Deletes cascading from a parent row run one trigger level deeper
+    IF TG_OP = 'DELETE' AND pg_trigger_depth() > 1 THEN
+        RETURN OLD;
+    END IF;
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_prevent_waiver_version_changes ON waiver_versions;
+CREATE TRIGGER trigger_prevent_waiver_version_changes
+    BEFORE UPDATE OR DELETE ON waiver_versions
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_signed_waiver_changes();
+
+DROP TRIGGER IF EXISTS trigger_prevent_waiver_signature_changes ON waiver_signatures;
+CREATE TRIGGER trigger_prevent_waiver_signature_changes
+    BEFORE UPDATE OR DELETE ON waiver_signatures
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_signed_waiver_changes();
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_waivers_company ON waivers(company_id, is_active);
+CREATE INDEX IF NOT EXISTS idx_waiver_signatures_user ON waiver_signatures(user_id, version_id);
+CREATE INDEX IF NOT EXISTS idx_waiver_signatures_company ON waiver_signatures(company_id, signed_at);
+CREATE INDEX IF NOT EXISTS idx_services_waiver ON services(waiver_id) WHERE waiver_id IS NOT NULL;
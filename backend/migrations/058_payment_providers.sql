@@ -0,0 +1,21 @@
+-- Migration: Payment providers
+-- Description: Select the card processor in payment settings, remember which one took each payment, and each user's customer at it
+
+ALTER TABLE payment_settings ADD COLUMN IF NOT EXISTS payment_provider VARCHAR(20) NOT NULL DEFAULT 'stripe'
+    CHECK (payment_provider IN ('stripe', 'fake'));
+
+-- NULL for payments taken offline
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider VARCHAR(20);
+
+CREATE TABLE IF NOT EXISTS payment_customers (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    provider VARCHAR(20) NOT NULL,
+    customer_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    UNIQUE(user_id, provider)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_payments_stripe_payment_intent_id ON payments(stripe_payment_intent_id);
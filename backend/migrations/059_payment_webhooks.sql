@@ -0,0 +1,49 @@
+-- Migration: Payment webhooks
+-- Description: Idempotent store of provider webhook events, payment disputes and provider subscriptions of companies
+
+-- Each event is processed once; failed events keep their error and are retried on redelivery
+CREATE TABLE IF NOT EXISTS payment_webhook_events (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    provider VARCHAR(20) NOT NULL,
+    event_id VARCHAR(255) NOT NULL,
+    event_type VARCHAR(100) NOT NULL,
+    payload JSONB NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    last_error TEXT,
+    processed_at TIMESTAMP WITH TIME ZONE,
+    received_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    UNIQUE(provider, event_id)
+);
+
+CREATE TABLE IF NOT EXISTS payment_disputes (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    dispute_id VARCHAR(255) NOT NULL UNIQUE,
+    amount DECIMAL(10,2) NOT NULL,
+    reason VARCHAR(100),
+    status VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS payment_subscriptions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    company_id UUID NOT NULL REFERENCES companies(id) ON DELETE CASCADE,
+    user_id UUID REFERENCES users(id) ON DELETE SET NULL,
+    provider VARCHAR(20) NOT NULL,
+    subscription_id VARCHAR(255) NOT NULL,
+    price_id VARCHAR(255) NOT NULL,
+    status VARCHAR(30) NOT NULL,
+    current_period_end TIMESTAMP WITH TIME ZONE,
+    cancel_at_period_end BOOLEAN NOT NULL DEFAULT false,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    UNIQUE(provider, subscription_id)
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_payment_webhook_events_unprocessed ON payment_webhook_events(received_at) WHERE processed_at IS NULL;
+CREATE INDEX IF NOT EXISTS idx_payment_disputes_payment_id ON payment_disputes(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payment_subscriptions_company_id ON payment_subscriptions(company_id);
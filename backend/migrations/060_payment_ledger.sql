@@ -0,0 +1,82 @@
+-- Migration: Payment ledger
+-- Description: Double-entry ledger of customer payments through platform escrow to company balances, commission, refunds and fees
+
+CREATE TABLE IF NOT EXISTS ledger_accounts (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    code VARCHAR(100) NOT NULL, -- platform_cash, commission_revenue, processing_fees, escrow:<company>, company_balance:<company>
+    account_type VARCHAR(20) NOT NULL,
+    company_id UUID REFERENCES companies(id) ON DELETE RESTRICT,
+    currency VARCHAR(3) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_ledger_account_type CHECK (account_type IN ('asset', 'liability', 'revenue', 'expense')),
+    UNIQUE(code, currency)
+);
+
+CREATE TABLE IF NOT EXISTS ledger_transactions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    idempotency_key VARCHAR(255) NOT NULL UNIQUE, -- Each business event posts once
+    kind VARCHAR(30) NOT NULL,
+    company_id UUID REFERENCES companies(id) ON DELETE RESTRICT,
+    payment_id UUID REFERENCES payments(id) ON DELETE RESTRICT,
+    currency VARCHAR(3) NOT NULL,
+    description TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_ledger_transaction_kind CHECK (kind IN ('payment', 'release', 'refund', 'dispute', 'processor_fee', 'payout'))
+);
+
+CREATE TABLE IF NOT EXISTS ledger_entries (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transaction_id UUID NOT NULL REFERENCES ledger_transactions(id) ON DELETE RESTRICT,
+    account_id UUID NOT NULL REFERENCES ledger_accounts(id) ON DELETE RESTRICT,
+    amount BIGINT NOT NULL, -- Minor units; debits positive, credits negative
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT nonzero_ledger_entry CHECK (amount <> 0)
+);
+
+-- Every transaction balances: checked at commit, once all its entries are in
+CREATE OR REPLACE FUNCTION check_ledger_transaction_balanced()
+RETURNS TRIGGER AS $$
+BEGIN
+    IF (SELECT SUM(amount) FROM ledger_entries WHERE transaction_id = NEW.transaction_id) <> 0 THEN
+        RAISE EXCEPTION 'ledger transaction % does not balance', NEW.transaction_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_ledger_entries_balanced ON ledger_entries;
+CREATE CONSTRAINT TRIGGER trigger_ledger_entries_balanced
+    AFTER INSERT ON ledger_entries
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+    EXECUTE FUNCTION check_ledger_transaction_balanced();
+
+-- Posted transactions are corrected by posting new ones, never edited
+CREATE OR REPLACE FUNCTION prevent_ledger_changes()
+RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION '% is append-only', TG_TABLE_NAME;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trigger_prevent_ledger_transaction_changes ON ledger_transactions;
+CREATE TRIGGER trigger_prevent_ledger_transaction_changes
+    BEFORE UPDATE OR DELETE ON ledger_transactions
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+DROP TRIGGER IF EXISTS trigger_prevent_ledger_entry_changes ON ledger_entries;
+CREATE TRIGGER trigger_prevent_ledger_entry_changes
+    BEFORE UPDATE OR DELETE ON ledger_entries
+    FOR EACH ROW
+    EXECUTE FUNCTION prevent_ledger_changes();
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_ledger_accounts_company ON ledger_accounts(company_id) WHERE company_id IS NOT NULL;
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_payment ON ledger_transactions(payment_id, kind);
+CREATE INDEX IF NOT EXISTS idx_ledger_transactions_company ON ledger_transactions(company_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_transaction ON ledger_entries(transaction_id);
+CREATE INDEX IF NOT EXISTS idx_ledger_entries_account ON ledger_entries(account_id);
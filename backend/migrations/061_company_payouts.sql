@@ -0,0 +1,40 @@
+-- Migration: Scheduled company payouts
+-- Description: Per-company payout schedules, payouts through the payment provider and the payments each payout includes
+
+CREATE TABLE IF NOT EXISTS company_payout_settings (
+    company_id UUID PRIMARY KEY REFERENCES companies(id) ON DELETE CASCADE,
+    schedule VARCHAR(20) NOT NULL DEFAULT 'weekly',
+    minimum_payout DECIMAL(10,2) NOT NULL DEFAULT 0,
+    destination VARCHAR(255), -- The company's account with the payment provider, e.g. a Stripe connected account
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+
+    CONSTRAINT valid_payout_schedule CHECK (schedule IN ('daily', 'weekly', 'monthly')),
+    CONSTRAINT valid_minimum_payout CHECK (minimum_payout >= 0)
+);
+
+-- company_payouts.stripe_transfer_id holds the payout ID of whichever provider sent it
+ALTER TABLE company_payouts ADD COLUMN IF NOT EXISTS provider VARCHAR(20);
+ALTER TABLE company_payouts ADD COLUMN IF NOT EXISTS held_amount DECIMAL(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE company_payouts ADD COLUMN IF NOT EXISTS updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW();
+
+CREATE TABLE IF NOT EXISTS company_payout_items (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    payout_id UUID NOT NULL REFERENCES company_payouts(id) ON DELETE CASCADE,
+    payment_id UUID NOT NULL REFERENCES payments(id) ON DELETE RESTRICT,
+    booking_id UUID REFERENCES bookings(id) ON DELETE SET NULL,
+    order_id UUID REFERENCES orders(id) ON DELETE SET NULL,
+    description TEXT,
+    -- What changed on the payment since it was last paid out; negative for refunds after a payout
+    gross_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    commission_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    refunded_amount DECIMAL(10,2) NOT NULL DEFAULT 0,
+    amount DECIMAL(10,2) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_company_payouts_created_at ON company_payouts(company_id, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_company_payout_items_payout_id ON company_payout_items(payout_id);
+CREATE INDEX IF NOT EXISTS idx_company_payout_items_payment_id ON company_payout_items(payment_id);
+CREATE INDEX IF NOT EXISTS idx_payments_transferred ON payments(company_id, transferred_at) WHERE transferred_at IS NOT NULL;
@@ -0,0 +1,94 @@
+-- Migration: Money columns with three decimals
+-- Description: Widens the amounts stored for payments, refunds, disputes, payouts, deposits, options, packages,
+-- courses and cancellations to NUMERIC(15,3), so that currencies with three-digit minor units (KWD, BHD, ...)
+-- are stored exactly and large zero-decimal amounts (JPY, KRW, ...) fit
+
+-- The reporting views read payments.amount and have to be recreated around the change
+DROP VIEW IF EXISTS payment_summary;
+DROP VIEW IF EXISTS company_revenue_summary;
+
+ALTER TABLE payments
+    ALTER COLUMN amount TYPE NUMERIC(15,3),
+    ALTER COLUMN commission_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN tax_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN total_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN platform_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN company_amount TYPE NUMERIC(15,3);
+
+ALTER TABLE payment_refunds ALTER COLUMN amount TYPE NUMERIC(15,3);
+ALTER TABLE payment_disputes ALTER COLUMN amount TYPE NUMERIC(15,3);
+
+ALTER TABLE company_payouts
+    ALTER COLUMN amount TYPE NUMERIC(15,3),
+    ALTER COLUMN total_sales TYPE NUMERIC(15,3),
+    ALTER COLUMN commission_deducted TYPE NUMERIC(15,3),
+    ALTER COLUMN processing_fees TYPE NUMERIC(15,3),
+    ALTER COLUMN refunds_deducted TYPE NUMERIC(15,3),
+    ALTER COLUMN held_amount TYPE NUMERIC(15,3);
+
+ALTER TABLE company_payout_items
+    ALTER COLUMN gross_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN commission_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN refunded_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN amount TYPE NUMERIC(15,3);
+
+ALTER TABLE company_payout_settings ALTER COLUMN minimum_payout TYPE NUMERIC(15,3);
+
+ALTER TABLE booking_cancellations
+    ALTER COLUMN fee_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN amount_paid TYPE NUMERIC(15,3),
+    ALTER COLUMN refund_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN charge_amount TYPE NUMERIC(15,3);
+
+ALTER TABLE services ALTER COLUMN deposit_value TYPE NUMERIC(15,3);
+ALTER TABLE bookings
+    ALTER COLUMN deposit_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN options_price TYPE NUMERIC(15,3);
+
+ALTER TABLE courses ALTER COLUMN price TYPE NUMERIC(15,3);
+ALTER TABLE course_enrollments
+    ALTER COLUMN price TYPE NUMERIC(15,3),
+    ALTER COLUMN fee_amount TYPE NUMERIC(15,3),
+    ALTER COLUMN refund_amount TYPE NUMERIC(15,3);
+
+ALTER TABLE service_packages ALTER COLUMN price TYPE NUMERIC(15,3);
+ALTER TABLE package_purchases ALTER COLUMN price TYPE NUMERIC(15,3);
+
+ALTER TABLE service_price_rules ALTER COLUMN price TYPE NUMERIC(15,3);
+ALTER TABLE service_options ALTER COLUMN price TYPE NUMERIC(15,3);
+ALTER TABLE booking_options ALTER COLUMN price TYPE NUMERIC(15,3);
+
+CREATE OR REPLACE VIEW payment_summary AS
+SELECT
+    p.id,
+    p.company_id,
+    c.name as company_name,
+    p.user_id,
+    u.email as user_email,
+    p.amount,
+    p.commission_amount,
+    p.total_amount,
+    p.status,
+    p.payment_type,
+    p.created_at,
+    p.processed_at,
+    pm.name as payment_method_name,
+    pm.type as payment_method_type
+FROM payments p
+LEFT JOIN companies c ON p.company_id = c.id
+LEFT JOIN users u ON p.user_id = u.id
+LEFT JOIN payment_methods pm ON p.payment_method_id = pm.id;
+
+CREATE OR REPLACE VIEW company_revenue_summary AS
+SELECT
+    c.id as company_id,
+    c.name as company_name,
+    COUNT(p.id) as total_transactions,
+    SUM(CASE WHEN p.status = 'succeeded' THEN p.amount ELSE 0 END) as total_revenue,
+    SUM(CASE WHEN p.status = 'succeeded' THEN p.commission_amount ELSE 0 END) as total_commission,
+    SUM(CASE WHEN p.status = 'succeeded' THEN (p.amount - p.commission_amount) ELSE 0 END) as net_revenue,
+    AVG(CASE WHEN p.status = 'succeeded' THEN p.amount ELSE NULL END) as avg_transaction_amount,
+    MAX(p.created_at) as last_transaction_date
+FROM companies c
+LEFT JOIN payments p ON c.id = p.company_id
+GROUP BY c.id, c.name;